@@ -96,8 +96,18 @@ var _ = Describe("bbl", func() {
 			Entry("Up", "up", "--aws-access-key-id", []string{"up", "--help"}),
 			Entry("Destroy", "destroy", "--no-confirm", []string{"help", "destroy"}),
 			Entry("Destroy", "destroy", "--no-confirm", []string{"destroy", "--help"}),
-			Entry("Rotate", "rotate", "Rotates SSH key", []string{"help", "rotate"}),
-			Entry("Rotate", "rotate", "Rotates SSH key", []string{"rotate", "--help"}),
+			Entry("Rotate", "rotate", "Rotates the SSH key for the jumpbox user", []string{"help", "rotate"}),
+			Entry("Rotate", "rotate", "Rotates the SSH key for the jumpbox user", []string{"rotate", "--help"}),
+			Entry("Upgrade", "upgrade", "--dry-run", []string{"help", "upgrade"}),
+			Entry("Upgrade", "upgrade", "--dry-run", []string{"upgrade", "--help"}),
+			Entry("SCP", "scp", "--recursive", []string{"help", "scp"}),
+			Entry("SCP", "scp", "--recursive", []string{"scp", "--help"}),
+			Entry("Tunnel", "tunnel", "--local-port", []string{"help", "tunnel"}),
+			Entry("Tunnel", "tunnel", "--local-port", []string{"tunnel", "--help"}),
+			Entry("Env Info", "env-info", "terraform outputs", []string{"help", "env-info"}),
+			Entry("Env Info", "env-info", "terraform outputs", []string{"env-info", "--help"}),
+			Entry("Config", "config", "bbl config validate", []string{"help", "config"}),
+			Entry("Config", "config", "bbl config validate", []string{"config", "--help"}),
 			Entry("Version", "version", "Prints version", []string{"help", "version"}),
 			Entry("Version", "version", "Prints version", []string{"version", "--help"}),
 			Entry("Jumpbox Address", "jumpbox-address", "Prints BOSH jumpbox address", []string{"help", "jumpbox-address"}),
@@ -14,6 +14,28 @@ type usage interface {
 	PrintCommandUsage(command, message string)
 }
 
+// jsonCommands print a JSON document when bbl is run with --json, so the
+// global flag is passed on to them.
+var jsonCommands = map[string]bool{
+	"version":           true,
+	"lbs":               true,
+	"outputs":           true,
+	"env-info":          true,
+	"print-env":         true,
+	"status":            true,
+	"env-id":            true,
+	"jumpbox-address":   true,
+	"director-address":  true,
+	"director-username": true,
+	"director-password": true,
+	"director-ca-cert":  true,
+	"ssh-key":           true,
+	"director-ssh-key":  true,
+	"drift":             true,
+	"latest-error":      true,
+	"state history":     true,
+}
+
 type App struct {
 	commands      CommandSet
 	configuration Configuration
@@ -72,13 +94,21 @@ func (a App) execute() error {
 			return err
 		}
 
-		return versionCommand.Execute([]string{}, storage.State{})
+		versionFlags := []string{}
+		if a.configuration.Global.JSON {
+			versionFlags = append(versionFlags, commands.JSONFlag)
+		}
+		return versionCommand.Execute(versionFlags, storage.State{})
 	}
 
 	if (a.configuration.Command == "plan" || a.configuration.Command == "up") && a.configuration.Global.Name != "" {
 		a.configuration.SubcommandFlags = append(a.configuration.SubcommandFlags, "--name", a.configuration.Global.Name)
 	}
 
+	if a.configuration.Global.JSON && jsonCommands[a.jsonCommandName()] && !a.configuration.SubcommandFlags.ContainsAny(commands.JSONFlag) {
+		a.configuration.SubcommandFlags = append(a.configuration.SubcommandFlags, commands.JSONFlag)
+	}
+
 	err = command.CheckFastFails(a.configuration.SubcommandFlags, a.configuration.State)
 	if err != nil {
 		return err
@@ -86,3 +116,12 @@ func (a App) execute() error {
 
 	return command.Execute(a.configuration.SubcommandFlags, a.configuration.State)
 }
+
+// jsonCommandName includes the subcommand of bbl state, since only some of
+// those subcommands print a JSON document.
+func (a App) jsonCommandName() string {
+	if a.configuration.Command == "state" && len(a.configuration.SubcommandFlags) > 0 {
+		return fmt.Sprintf("state %s", a.configuration.SubcommandFlags[0])
+	}
+	return a.configuration.Command
+}
@@ -109,6 +109,88 @@ var _ = Describe("App", func() {
 			)
 		})
 
+		Context("when json is passed as a global flag", func() {
+			DescribeTable("propagates json to the subcommand flags of query commands", func(command string) {
+				commandFake := &fakes.Command{}
+
+				app = application.New(
+					application.CommandSet{
+						command: commandFake,
+					},
+					application.Configuration{
+						Command:         command,
+						SubcommandFlags: []string{"--shell-type", "posix"},
+						Global: application.GlobalConfiguration{
+							JSON: true,
+						},
+					},
+					usage)
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(commandFake.ExecuteCall.Receives.SubcommandFlags).To(Equal([]string{"--shell-type", "posix", "--json"}))
+			},
+				Entry("when command is print-env", "print-env"),
+				Entry("when command is lbs", "lbs"),
+				Entry("when command is env-info", "env-info"),
+				Entry("when command is director-address", "director-address"),
+				Entry("when command is ssh-key", "ssh-key"),
+				Entry("when command is director-ssh-key", "director-ssh-key"),
+				Entry("when command is drift", "drift"),
+				Entry("when command is latest-error", "latest-error"),
+			)
+
+			DescribeTable("propagates json to the bbl state subcommands that print a document", func(subcommand string, expected []string) {
+				stateCmd := &fakes.Command{}
+
+				app = application.New(
+					application.CommandSet{
+						"state": stateCmd,
+					},
+					application.Configuration{
+						Command:         "state",
+						SubcommandFlags: []string{subcommand},
+						Global: application.GlobalConfiguration{
+							JSON: true,
+						},
+					},
+					usage)
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(stateCmd.ExecuteCall.Receives.SubcommandFlags).To(Equal(expected))
+			},
+				Entry("when the subcommand is history", "history", []string{"history", "--json"}),
+				Entry("when the subcommand is rollback", "rollback", []string{"rollback"}),
+			)
+
+			It("does not propagate json to other commands", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "some",
+					Global: application.GlobalConfiguration{
+						JSON: true,
+					},
+				})
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(someCmd.ExecuteCall.Receives.SubcommandFlags).To(BeEmpty())
+			})
+
+			It("prints the version as json", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "--version",
+					Global: application.GlobalConfiguration{
+						JSON: true,
+					},
+				})
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(versionCmd.ExecuteCall.Receives.SubcommandFlags).To(Equal([]string{"--json"}))
+			})
+		})
+
 		Context("when subcommand flags contains help", func() {
 			DescribeTable("prints command specific usage when help subcommand flag is provided", func(helpFlag string) {
 				someCmd.UsageCall.Returns.Usage = "some usage message"
@@ -8,6 +8,8 @@ type GlobalConfiguration struct {
 	Name                 string
 	TerraformBinary      bool
 	DisableTfAutoApprove bool
+	RemoteStateVersion   string
+	JSON                 bool
 }
 
 type StringSlice []string
@@ -29,5 +31,7 @@ type Configuration struct {
 	SubcommandFlags      StringSlice
 	State                storage.State
 	ShowCommandHelp      bool
+	CommandLocksState    bool
 	CommandModifiesState bool
+	CommandReachesIAAS   bool
 }
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// LogFile appends to the file at path, which is rotated to path.1 once it
+// grows past maxSize, keeping backups of the rotated files. Nothing is logged
+// while the directory of path does not exist, so that logging never creates
+// a state directory.
+type LogFile struct {
+	mutex   sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+func NewLogFile(path string, maxSize int64, backups int) *LogFile {
+	return &LogFile{
+		path:    path,
+		maxSize: maxSize,
+		backups: backups,
+	}
+}
+
+func (f *LogFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		err := f.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		err := f.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *LogFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close() //nolint:errcheck
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *LogFile) rotate() error {
+	err := f.file.Close()
+	if err != nil {
+		return err
+	}
+	f.file = nil
+
+	for i := f.backups - 1; i > 0; i-- {
+		err = os.Rename(f.backup(i), f.backup(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if f.backups > 0 {
+		err = os.Rename(f.path, f.backup(1))
+	} else {
+		err = os.Remove(f.path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+func (f *LogFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+func (f *LogFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
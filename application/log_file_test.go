@@ -0,0 +1,84 @@
+package application_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/application"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogFile", func() {
+	var (
+		dir     string
+		path    string
+		logFile *application.LogFile
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "bbl.log")
+
+		logFile = application.NewLogFile(path, 10, 2)
+	})
+
+	AfterEach(func() {
+		logFile.Close()   //nolint:errcheck
+		os.RemoveAll(dir) //nolint:errcheck
+	})
+
+	It("appends to the file", func() {
+		Expect(os.WriteFile(path, []byte("old\n"), 0600)).To(Succeed())
+
+		_, err := logFile.Write([]byte("new\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("old\nnew\n"))
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("rotates the file once it grows past the maximum size", func() {
+		for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+			_, err := logFile.Write([]byte(line))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(os.ReadFile(path)).To(Equal([]byte("fourth\n")))
+		Expect(os.ReadFile(path + ".1")).To(Equal([]byte("third\n")))
+		Expect(os.ReadFile(path + ".2")).To(Equal([]byte("second\n")))
+		Expect(path + ".3").NotTo(BeAnExistingFile())
+	})
+
+	Context("when there are no backups", func() {
+		It("starts the file again", func() {
+			logFile = application.NewLogFile(path, 10, 0)
+
+			for _, line := range []string{"first\n", "second\n"} {
+				_, err := logFile.Write([]byte(line))
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(os.ReadFile(path)).To(Equal([]byte("second\n")))
+			Expect(path + ".1").NotTo(BeAnExistingFile())
+		})
+	})
+
+	Context("when the directory does not exist", func() {
+		It("returns an error without creating it", func() {
+			logFile = application.NewLogFile(filepath.Join(dir, "missing", "bbl.log"), 10, 2)
+
+			_, err := logFile.Write([]byte("first\n"))
+			Expect(err).To(HaveOccurred())
+			Expect(filepath.Join(dir, "missing")).NotTo(BeADirectory())
+		})
+	})
+})
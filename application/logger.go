@@ -1,16 +1,74 @@
 package application
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"time"
 )
 
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+func (l Level) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if name == levelName {
+			return Level(level), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q, use debug, info, warn or error", name)
+}
+
+// logOptions are shared by the loggers that WithWriter returns, so that the
+// stdout and stderr loggers log at the same level, in the same format, to
+// the same log file, with the same phase and redacting the same secrets.
+type logOptions struct {
+	mutex    sync.Mutex
+	level    Level
+	json     bool
+	sink     io.Writer
+	phase    string
+	redactor redactor
+}
+
+type redactor interface {
+	Redact(string) string
+}
+
+// record is a line of the log, as it is written with --log-format json.
+type record struct {
+	Time    string `json:"time"`
+	Level   Level  `json:"level"`
+	Phase   string `json:"phase,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Message string `json:"msg"`
+}
+
 type Logger struct {
 	newline   bool
 	writer    io.Writer
 	reader    io.Reader
 	noConfirm bool
+	options   *logOptions
 }
 
 func NewLogger(writer io.Writer, reader io.Reader) *Logger {
@@ -19,9 +77,54 @@ func NewLogger(writer io.Writer, reader io.Reader) *Logger {
 		writer:    writer,
 		reader:    reader,
 		noConfirm: false,
+		options: &logOptions{
+			level: LevelInfo,
+		},
 	}
 }
 
+// WithWriter returns a logger that writes to writer and shares the level,
+// format, log file and phase of l.
+func (l *Logger) WithWriter(writer io.Writer) *Logger {
+	return &Logger{
+		newline:   true,
+		writer:    writer,
+		reader:    l.reader,
+		noConfirm: l.noConfirm,
+		options:   l.options,
+	}
+}
+
+func (l *Logger) SetLevel(level Level) {
+	l.options.level = level
+}
+
+// UseJSON writes the log as JSON lines. Printf and Println are the output
+// of commands rather than the log, so they are written as they are.
+func (l *Logger) UseJSON() {
+	l.options.json = true
+}
+
+// TeeTo also writes the log to sink, at every level.
+func (l *Logger) TeeTo(sink io.Writer) {
+	l.options.sink = sink
+}
+
+// RedactWith redacts the secrets that redactor knows about from the log.
+// Printf and Println are left alone, since commands such as
+// director-password print secrets on purpose.
+func (l *Logger) RedactWith(redactor redactor) {
+	l.options.redactor = redactor
+}
+
+// SetPhase tags the log with the phase that bbl up or bbl destroy is
+// running, such as jumpbox, until it is set to "".
+func (l *Logger) SetPhase(phase string) {
+	l.options.mutex.Lock()
+	defer l.options.mutex.Unlock()
+	l.options.phase = phase
+}
+
 func (l *Logger) clear() {
 	if l.newline {
 		return
@@ -31,13 +134,83 @@ func (l *Logger) clear() {
 	l.newline = true
 }
 
+func (l *Logger) log(level Level, source, message string) {
+	l.options.mutex.Lock()
+	defer l.options.mutex.Unlock()
+
+	if l.options.redactor != nil {
+		message = l.options.redactor.Redact(message)
+	}
+
+	r := record{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Level:   level,
+		Phase:   l.options.phase,
+		Source:  source,
+		Message: message,
+	}
+
+	if level >= l.options.level {
+		if l.options.json {
+			l.writer.Write(r.json()) //nolint:errcheck
+		} else {
+			l.clear()
+			fmt.Fprintln(l.writer, r.text()) //nolint:errcheck
+		}
+	}
+
+	if l.options.sink != nil {
+		if l.options.json {
+			l.options.sink.Write(r.json()) //nolint:errcheck
+		} else {
+			fmt.Fprintf(l.options.sink, "%s %-5s %s\n", r.Time, r.Level, r.tagged()) //nolint:errcheck
+		}
+	}
+}
+
+func (r record) json() []byte {
+	// A record of strings always marshals.
+	line, _ := json.Marshal(r)
+	return append(line, '\n')
+}
+
+// text is how r is printed, with the step, warning and error prefixes
+// that bbl has always printed.
+func (r record) text() string {
+	if r.Source == "" {
+		switch r.Level {
+		case LevelInfo:
+			return fmt.Sprintf("step: %s", r.Message)
+		case LevelWarn:
+			return fmt.Sprintf("warning: %s", r.Message)
+		case LevelError:
+			return fmt.Sprintf("error: %s", r.Message)
+		}
+	}
+	return r.tagged()
+}
+
+// tagged is the message tagged with the phase that produced it, or with
+// its source outside of a phase.
+func (r record) tagged() string {
+	switch {
+	case r.Source != "" && r.Phase != "":
+		return fmt.Sprintf("[%s] %s", r.Phase, r.Message)
+	case r.Source != "":
+		return fmt.Sprintf("[%s] %s", r.Source, r.Message)
+	}
+	return r.Message
+}
+
 func (l *Logger) Step(message string, a ...interface{}) {
-	l.clear()
-	fmt.Fprintf(l.writer, "step: %s\n", fmt.Sprintf(message, a...)) //nolint:errcheck
-	l.newline = true
+	l.log(LevelInfo, "", fmt.Sprintf(message, a...))
 }
 
 func (l *Logger) Dot() {
+	if l.options.json || l.options.level > LevelInfo {
+		return
+	}
+
 	l.writer.Write([]byte("\u2022")) //nolint:errcheck
 	l.newline = false
 }
@@ -53,13 +226,51 @@ func (l *Logger) Println(message string) {
 }
 
 func (l *Logger) Debugf(message string, a ...interface{}) {
-	l.clear()
-	fmt.Fprintf(l.writer, "%s\n", message) //nolint:errcheck
+	l.log(LevelDebug, "", fmt.Sprintf(message, a...))
 }
 
 func (l *Logger) Debugln(message string) {
-	l.clear()
-	fmt.Fprintf(l.writer, "%s\n", message) //nolint:errcheck
+	l.log(LevelDebug, "", message)
+}
+
+func (l *Logger) Warnf(message string, a ...interface{}) {
+	l.log(LevelWarn, "", fmt.Sprintf(message, a...))
+}
+
+func (l *Logger) Errorf(message string, a ...interface{}) {
+	l.log(LevelError, "", fmt.Sprintf(message, a...))
+}
+
+// Writer returns a writer for the output of a subprocess such as terraform
+// or bosh, which logs each line at level, tagged with source and the phase.
+func (l *Logger) Writer(source string, level Level) io.Writer {
+	return &logWriter{logger: l, source: source, level: level}
+}
+
+type logWriter struct {
+	mutex  sync.Mutex
+	logger *Logger
+	source string
+	level  Level
+	buffer []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buffer = append(w.buffer, p...)
+	for {
+		end := bytes.IndexByte(w.buffer, '\n')
+		if end < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.buffer[:end]), "\r")
+		w.buffer = w.buffer[end+1:]
+		w.logger.log(w.level, w.source, line)
+	}
+
+	return len(p), nil
 }
 
 func (l *Logger) NoConfirm() {
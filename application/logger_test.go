@@ -2,6 +2,7 @@ package application_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 
@@ -96,6 +97,168 @@ var _ = Describe("Logger", func() {
 		)
 	})
 
+	Describe("levels", func() {
+		It("does not print messages below the level", func() {
+			logger.Debugf("some %s", "detail")
+			logger.Step("creating key")
+			logger.SetLevel(application.LevelWarn)
+			logger.Step("generating template")
+			logger.Warnf("some %s", "warning")
+			logger.Errorf("some error")
+
+			Expect(writer.String()).To(Equal(`step: creating key
+warning: some warning
+error: some error
+`))
+		})
+
+		It("prints debug messages at the debug level", func() {
+			logger.SetLevel(application.LevelDebug)
+			logger.Debugf("some %s", "detail")
+			logger.Debugln("some more detail")
+
+			Expect(writer.String()).To(Equal("some detail\nsome more detail\n"))
+		})
+
+		It("does not print dots above the info level", func() {
+			logger.SetLevel(application.LevelWarn)
+			logger.Dot()
+
+			Expect(writer.String()).To(Equal(""))
+		})
+
+		It("is shared with the loggers from WithWriter", func() {
+			otherWriter := bytes.NewBuffer([]byte{})
+			otherLogger := logger.WithWriter(otherWriter)
+
+			logger.SetLevel(application.LevelError)
+			otherLogger.Step("creating key")
+
+			Expect(otherWriter.String()).To(Equal(""))
+		})
+	})
+
+	Describe("ParseLevel", func() {
+		It("parses the name of a level", func() {
+			level, err := application.ParseLevel("warn")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(level).To(Equal(application.LevelWarn))
+		})
+
+		Context("when the level is unknown", func() {
+			It("returns an error", func() {
+				_, err := application.ParseLevel("verbose")
+				Expect(err).To(MatchError(`unknown log level "verbose", use debug, info, warn or error`))
+			})
+		})
+	})
+
+	Describe("UseJSON", func() {
+		BeforeEach(func() {
+			logger.UseJSON()
+		})
+
+		It("prints each message as a JSON line", func() {
+			logger.Step("creating key")
+			logger.Dot()
+
+			var line map[string]string
+			Expect(json.Unmarshal(writer.Bytes(), &line)).To(Succeed())
+			Expect(line).To(HaveKeyWithValue("level", "info"))
+			Expect(line).To(HaveKeyWithValue("msg", "creating key"))
+			Expect(line).To(HaveKey("time"))
+		})
+
+		It("prints Println as it is", func() {
+			logger.Println("some-output")
+
+			Expect(writer.String()).To(Equal("some-output\n"))
+		})
+	})
+
+	Describe("TeeTo", func() {
+		var sink *bytes.Buffer
+
+		BeforeEach(func() {
+			sink = bytes.NewBuffer([]byte{})
+			logger.TeeTo(sink)
+		})
+
+		It("writes messages at every level to the sink", func() {
+			logger.Debugf("some detail")
+			logger.Step("creating key")
+			logger.Println("some-output")
+
+			Expect(writer.String()).To(Equal("step: creating key\nsome-output\n"))
+			Expect(sink.String()).To(MatchRegexp(`^\S+ debug some detail\n\S+ info  creating key\n$`))
+		})
+	})
+
+	Describe("RedactWith", func() {
+		var sink *bytes.Buffer
+
+		BeforeEach(func() {
+			redactor := application.NewRedactor()
+			redactor.Add("some-password")
+			logger.RedactWith(redactor)
+
+			sink = bytes.NewBuffer([]byte{})
+			logger.TeeTo(sink)
+		})
+
+		It("redacts the secrets from the log", func() {
+			logger.Step("logging in with some-password")
+			fmt.Fprintln(logger.Writer("bosh", application.LevelInfo), "-v password=some-password") //nolint:errcheck
+
+			Expect(writer.String()).To(Equal("step: logging in with <redacted>\n[bosh] -v password=<redacted>\n"))
+			Expect(sink.String()).NotTo(ContainSubstring("some-password"))
+		})
+
+		It("does not redact the output of commands", func() {
+			logger.Println("some-password")
+
+			Expect(writer.String()).To(Equal("some-password\n"))
+		})
+	})
+
+	Describe("Writer", func() {
+		It("logs each line with its source", func() {
+			w := logger.Writer("bosh", application.LevelInfo)
+			fmt.Fprint(w, "Deploying:\n  Creating")  //nolint:errcheck
+			fmt.Fprint(w, " instance\r\nFinished\n") //nolint:errcheck
+
+			Expect(writer.String()).To(Equal("[bosh] Deploying:\n[bosh]   Creating instance\n[bosh] Finished\n"))
+		})
+
+		It("tags the lines with the phase", func() {
+			logger.SetPhase("jumpbox")
+			fmt.Fprintln(logger.Writer("bosh", application.LevelInfo), "Deploying") //nolint:errcheck
+			logger.SetPhase("")
+			fmt.Fprintln(logger.Writer("bosh", application.LevelInfo), "Done") //nolint:errcheck
+
+			Expect(writer.String()).To(Equal("[jumpbox] Deploying\n[bosh] Done\n"))
+		})
+
+		It("logs both the source and the phase as JSON", func() {
+			logger.UseJSON()
+			logger.SetPhase("terraform")
+			fmt.Fprintln(logger.Writer("terraform", application.LevelWarn), "Apply complete!") //nolint:errcheck
+
+			var line map[string]string
+			Expect(json.Unmarshal(writer.Bytes(), &line)).To(Succeed())
+			Expect(line).To(HaveKeyWithValue("level", "warn"))
+			Expect(line).To(HaveKeyWithValue("phase", "terraform"))
+			Expect(line).To(HaveKeyWithValue("source", "terraform"))
+			Expect(line).To(HaveKeyWithValue("msg", "Apply complete!"))
+		})
+
+		It("does not print lines below the level", func() {
+			fmt.Fprintln(logger.Writer("terraform", application.LevelDebug), "Refreshing state...") //nolint:errcheck
+
+			Expect(writer.String()).To(Equal(""))
+		})
+	})
+
 	Describe("mixing steps, dots and printlns", func() {
 		It("prints out a coherent set of lines", func() {
 			logger.Step("creating key")
@@ -0,0 +1,187 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"gopkg.in/yaml.v2"
+)
+
+const redacted = "<redacted>"
+
+// minSecretLength keeps short values, which would match all over the
+// output, from being redacted.
+const minSecretLength = 4
+
+// publicVariables are the vars-store keys of certificates and ssh keys that
+// are not secret.
+var publicVariables = map[string]bool{
+	"ca":                     true,
+	"certificate":            true,
+	"public_key":             true,
+	"public_key_fingerprint": true,
+}
+
+// Redactor replaces every secret value it knows about with <redacted>.
+type Redactor struct {
+	mutex    sync.RWMutex
+	secrets  map[string]bool
+	replacer *strings.Replacer
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{
+		secrets:  map[string]bool{},
+		replacer: strings.NewReplacer(),
+	}
+}
+
+// Add makes the redactor replace secrets. Each line of a multi-line secret,
+// such as a private key, is redacted on its own since output is logged line
+// by line.
+func (r *Redactor) Add(secrets ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, secret := range secrets {
+		for _, line := range strings.Split(secret, "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) < minSecretLength || strings.HasPrefix(line, "-----") {
+				continue
+			}
+			r.secrets[line] = true
+		}
+	}
+
+	// The longest secrets come first so that a secret is not partly
+	// replaced by another that it contains.
+	secretList := []string{}
+	for secret := range r.secrets {
+		secretList = append(secretList, secret)
+	}
+	sort.Slice(secretList, func(i, j int) bool {
+		if len(secretList[i]) != len(secretList[j]) {
+			return len(secretList[i]) > len(secretList[j])
+		}
+		return secretList[i] < secretList[j]
+	})
+
+	oldnew := []string{}
+	for _, secret := range secretList {
+		oldnew = append(oldnew, secret, redacted)
+	}
+	r.replacer = strings.NewReplacer(oldnew...)
+}
+
+// AddState adds the IaaS credentials, the director credentials and the
+// vars-store values in state. A director password kept in a secret store is
+// only a reference, so the caller adds the resolved password.
+func (r *Redactor) AddState(state storage.State) {
+	r.Add(
+		state.AWS.AccessKeyID,
+		state.AWS.SecretAccessKey,
+		state.AWS.SessionToken,
+		state.Azure.ClientSecret,
+		state.VSphere.VCenterPassword,
+		state.OpenStack.Password,
+		state.CloudStack.ApiKey,
+		state.CloudStack.SecretAccessKey,
+		state.CloudStack.PrivateKey,
+		state.BOSH.DirectorPassword,
+		state.BOSH.DirectorSSLPrivateKey,
+		state.GCP.AccessToken,
+	)
+	r.addServiceAccountKey(state.GCP.ServiceAccountKey)
+	r.AddVarsStore(state.BOSH.Variables)
+	r.AddVarsStore(state.Jumpbox.Variables)
+}
+
+// addServiceAccountKey adds the private key of a GCP service account key,
+// rather than each line of its JSON, most of which are not secret.
+func (r *Redactor) addServiceAccountKey(serviceAccountKey string) {
+	if serviceAccountKey == "" {
+		return
+	}
+
+	var key struct {
+		PrivateKeyID string `json:"private_key_id"`
+		PrivateKey   string `json:"private_key"`
+	}
+	err := json.Unmarshal([]byte(serviceAccountKey), &key)
+	if err != nil {
+		return
+	}
+	r.Add(key.PrivateKeyID, key.PrivateKey)
+}
+
+// AddVarsStore adds the passwords, private keys and other secret values
+// of a bosh vars store.
+func (r *Redactor) AddVarsStore(contents string) {
+	var variables interface{}
+	err := yaml.Unmarshal([]byte(contents), &variables)
+	if err != nil {
+		return
+	}
+
+	r.addVariables(variables)
+}
+
+func (r *Redactor) addVariables(value interface{}) {
+	switch value := value.(type) {
+	case string:
+		r.Add(value)
+	case map[interface{}]interface{}:
+		for key, nested := range value {
+			if !publicVariables[fmt.Sprint(key)] {
+				r.addVariables(nested)
+			}
+		}
+	case []interface{}:
+		for _, nested := range value {
+			r.addVariables(nested)
+		}
+	}
+}
+
+func (r *Redactor) Redact(s string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.replacer.Replace(s)
+}
+
+// Writer redacts what is written to w. Each write is redacted on its own,
+// so w should be written to a line or more at a time, as subprocesses do.
+// A writer for a buffer can be read back with String, redacted.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	writer := redactingWriter{redactor: r, writer: w}
+	if _, ok := w.(fmt.Stringer); ok {
+		return redactingBuffer{writer}
+	}
+	return writer
+}
+
+type redactingWriter struct {
+	redactor *Redactor
+	writer   io.Writer
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	_, err := io.WriteString(w.writer, w.redactor.Redact(string(p)))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type redactingBuffer struct {
+	redactingWriter
+}
+
+func (b redactingBuffer) String() string {
+	return b.redactor.Redact(b.writer.(fmt.Stringer).String())
+}
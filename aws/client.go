@@ -46,7 +46,7 @@ type Client struct {
 
 func NewClient(creds storage.AWS, logger logger) Client {
 	config := awslib.NewConfig().
-		WithCredentials(credentials.NewStaticCredentials(creds.AccessKeyID, creds.SecretAccessKey, "")).
+		WithCredentials(credentials.NewStaticCredentials(creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken)).
 		WithRegion(creds.Region)
 	awsSession := session.Must(session.NewSession(config))
 
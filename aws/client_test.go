@@ -37,6 +37,22 @@ var _ = Describe("Client", func() {
 			Expect(ec2Client.Config.Credentials).To(Equal(credentials.NewStaticCredentials("some-access-key-id", "some-secret-access-key", "")))
 			Expect(ec2Client.Config.Region).To(Equal(awslib.String("some-region")))
 		})
+
+		It("uses the session token of short-lived credentials", func() {
+			client := aws.NewClient(
+				storage.AWS{
+					AccessKeyID:     "some-access-key-id",
+					SecretAccessKey: "some-secret-access-key",
+					SessionToken:    "some-session-token",
+					Region:          "some-region",
+				},
+				&fakes.Logger{},
+			)
+
+			ec2Client, ok := client.GetEC2Client().(*awsec2.EC2)
+			Expect(ok).To(BeTrue())
+			Expect(ec2Client.Config.Credentials).To(Equal(credentials.NewStaticCredentials("some-access-key-id", "some-secret-access-key", "some-session-token")))
+		})
 	})
 
 	Describe("RetrieveDNS", func() {
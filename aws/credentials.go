@@ -0,0 +1,60 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type commandRunner func(name string, args ...string) ([]byte, error)
+
+// ProfileCredentials resolves the credentials of a profile in the AWS shared
+// config with the AWS CLI, which handles SSO, assumed roles and
+// credential_process the same way the user's other tools do.
+type ProfileCredentials struct {
+	run commandRunner
+}
+
+func NewProfileCredentials() ProfileCredentials {
+	return ProfileCredentials{run: runCommand}
+}
+
+func runCommand(name string, args ...string) ([]byte, error) {
+	stderr := bytes.NewBuffer([]byte{})
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// Resolve sets the access key, secret and session token of the profile.
+func (p ProfileCredentials) Resolve(creds storage.AWS) (storage.AWS, error) {
+	output, err := p.run("aws", "configure", "export-credentials", "--profile", creds.Profile, "--format", "process")
+	if err != nil {
+		return storage.AWS{}, fmt.Errorf("export credentials of profile %s, which needs the AWS CLI v2: %s", creds.Profile, err)
+	}
+
+	var exported struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		SessionToken    string `json:"SessionToken"`
+	}
+	err = json.Unmarshal(output, &exported)
+	if err != nil {
+		return storage.AWS{}, fmt.Errorf("parse credentials of profile %s: %s", creds.Profile, err)
+	}
+
+	creds.AccessKeyID = exported.AccessKeyID
+	creds.SecretAccessKey = exported.SecretAccessKey
+	creds.SessionToken = exported.SessionToken
+
+	return creds, nil
+}
@@ -0,0 +1,65 @@
+package aws_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProfileCredentials", func() {
+	var (
+		command []string
+		output  []byte
+		runErr  error
+
+		profileCredentials aws.ProfileCredentials
+	)
+
+	BeforeEach(func() {
+		output = []byte(`{"Version": 1, "AccessKeyId": "some-access-key-id", "SecretAccessKey": "some-secret-access-key", "SessionToken": "some-session-token", "Expiration": "2026-10-18T12:00:00+00:00"}`)
+		runErr = nil
+
+		profileCredentials = aws.NewProfileCredentialsWithRunner(func(name string, args ...string) ([]byte, error) {
+			command = append([]string{name}, args...)
+			return output, runErr
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("exports the credentials of the profile with the AWS CLI", func() {
+			creds, err := profileCredentials.Resolve(storage.AWS{Profile: "some-profile", Region: "some-region"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(command).To(Equal([]string{"aws", "configure", "export-credentials", "--profile", "some-profile", "--format", "process"}))
+			Expect(creds).To(Equal(storage.AWS{
+				AccessKeyID:     "some-access-key-id",
+				SecretAccessKey: "some-secret-access-key",
+				SessionToken:    "some-session-token",
+				Profile:         "some-profile",
+				Region:          "some-region",
+			}))
+		})
+
+		Context("when the AWS CLI fails", func() {
+			It("returns an error", func() {
+				runErr = errors.New("The SSO session associated with this profile has expired")
+
+				_, err := profileCredentials.Resolve(storage.AWS{Profile: "some-profile"})
+				Expect(err).To(MatchError("export credentials of profile some-profile, which needs the AWS CLI v2: The SSO session associated with this profile has expired"))
+			})
+		})
+
+		Context("when the output is not JSON", func() {
+			It("returns an error", func() {
+				output = []byte("%%%")
+
+				_, err := profileCredentials.Resolve(storage.AWS{Profile: "some-profile"})
+				Expect(err).To(MatchError(ContainSubstring("parse credentials of profile some-profile:")))
+			})
+		})
+	})
+})
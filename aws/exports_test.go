@@ -21,3 +21,7 @@ func (c Client) GetEC2Client() EC2Client {
 func (c Client) GetRoute53Client() Route53Client {
 	return c.route53Client
 }
+
+func NewProfileCredentialsWithRunner(run func(name string, args ...string) ([]byte, error)) ProfileCredentials {
+	return ProfileCredentials{run: run}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"log"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"                             //nolint:staticcheck
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"     //nolint:staticcheck
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources" //nolint:staticcheck
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"     //nolint:staticcheck
@@ -13,7 +12,7 @@ import (
 
 func NewClient(azureConfig storage.Azure) (Client, error) {
 
-	credential, err := azidentity.NewClientSecretCredential(azureConfig.TenantID, azureConfig.ClientID, azureConfig.ClientSecret, nil)
+	credential, err := NewCredential(azureConfig)
 	if err != nil {
 		log.Fatal(err)
 	}
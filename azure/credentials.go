@@ -0,0 +1,68 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity" //nolint:staticcheck
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const managementScope = "https://management.azure.com/.default"
+
+// NewCredential returns the credential that bbl authenticates with: that of
+// the Azure CLI login, of the managed identity of the VM that bbl runs on, or
+// of the service principal.
+func NewCredential(azureConfig storage.Azure) (azcore.TokenCredential, error) {
+	switch azureConfig.CredentialSource {
+	case "cli":
+		credential, err := azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: azureConfig.TenantID})
+		if err != nil {
+			return nil, err
+		}
+		return credential, nil
+	case "managed-identity":
+		options := &azidentity.ManagedIdentityCredentialOptions{}
+		if azureConfig.ClientID != "" {
+			options.ID = azidentity.ClientID(azureConfig.ClientID)
+		}
+		credential, err := azidentity.NewManagedIdentityCredential(options)
+		if err != nil {
+			return nil, err
+		}
+		return credential, nil
+	}
+
+	credential, err := azidentity.NewClientSecretCredential(azureConfig.TenantID, azureConfig.ClientID, azureConfig.ClientSecret, nil)
+	if err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// IdentityCredentials checks that the Azure CLI login or the managed identity
+// that the credential source selects can get a token, before terraform and
+// bosh are run with it.
+type IdentityCredentials struct {
+	newCredential func(storage.Azure) (azcore.TokenCredential, error)
+}
+
+func NewIdentityCredentials() IdentityCredentials {
+	return IdentityCredentials{newCredential: NewCredential}
+}
+
+func (i IdentityCredentials) Resolve(azureConfig storage.Azure) (storage.Azure, error) {
+	credential, err := i.newCredential(azureConfig)
+	if err != nil {
+		return storage.Azure{}, fmt.Errorf("create %s credential: %s", azureConfig.CredentialSource, err)
+	}
+
+	_, err = credential.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{managementScope}})
+	if err != nil {
+		return storage.Azure{}, fmt.Errorf("get token with %s credential: %s", azureConfig.CredentialSource, err)
+	}
+
+	return azureConfig, nil
+}
@@ -0,0 +1,76 @@
+package azure_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/cloudfoundry/bosh-bootloader/azure"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type tokenCredential struct {
+	scopes []string
+	err    error
+}
+
+func (t *tokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	t.scopes = options.Scopes
+	return azcore.AccessToken{Token: "some-token"}, t.err
+}
+
+var _ = Describe("IdentityCredentials", func() {
+	var (
+		credential  *tokenCredential
+		receives    storage.Azure
+		credentials azure.IdentityCredentials
+	)
+
+	BeforeEach(func() {
+		credential = &tokenCredential{}
+		credentials = azure.NewIdentityCredentialsWithFactory(func(azureConfig storage.Azure) (azcore.TokenCredential, error) {
+			receives = azureConfig
+			return credential, nil
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("gets a token for the Azure Resource Manager", func() {
+			azureConfig := storage.Azure{CredentialSource: "cli", TenantID: "some-tenant-id", SubscriptionID: "some-subscription-id"}
+
+			resolved, err := credentials.Resolve(azureConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(receives).To(Equal(azureConfig))
+			Expect(credential.scopes).To(Equal([]string{"https://management.azure.com/.default"}))
+			Expect(resolved).To(Equal(azureConfig))
+		})
+
+		Context("when there is no token", func() {
+			It("returns an error", func() {
+				credential.err = errors.New("Please run 'az login' to setup account.")
+
+				_, err := credentials.Resolve(storage.Azure{CredentialSource: "cli"})
+				Expect(err).To(MatchError("get token with cli credential: Please run 'az login' to setup account."))
+			})
+		})
+	})
+
+	Describe("NewCredential", func() {
+		It("creates a credential for each source", func() {
+			for _, source := range []string{"", "cli", "managed-identity"} {
+				credential, err := azure.NewCredential(storage.Azure{
+					CredentialSource: source,
+					TenantID:         "some-tenant-id",
+					ClientID:         "some-client-id",
+					ClientSecret:     "some-client-secret",
+				})
+				Expect(err).NotTo(HaveOccurred(), source)
+				Expect(credential).NotTo(BeNil(), source)
+			}
+		})
+	})
+})
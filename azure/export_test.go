@@ -1,5 +1,10 @@
 package azure
 
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
 func NewClientWithInjectedVMsClient(azureVMsClient AzureVMsClient) Client {
 	return Client{
 		azureVMsClient: azureVMsClient,
@@ -11,3 +16,7 @@ func NewClientWithInjectedGroupsClient(azureGroupsClient AzureGroupsClient) Clie
 		azureGroupsClient: azureGroupsClient,
 	}
 }
+
+func NewIdentityCredentialsWithFactory(newCredential func(storage.Azure) (azcore.TokenCredential, error)) IdentityCredentials {
+	return IdentityCredentials{newCredential: newCredential}
+}
@@ -0,0 +1,147 @@
+package backends
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mholt/archiver"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// archiveStateDir tars the state dir in the layout that extractStateDir
+// expects, leaving out the local state lock, bbl.log and the local history
+// of snapshots.
+func archiveStateDir(dir string) ([]byte, error) {
+	var tarball bytes.Buffer
+	err := writeArchive(&tarball, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return tarball.Bytes(), nil
+}
+
+// streamBackup tars a backup into a pipe as the upload reads it, so that it
+// is never held in memory, and seals it when config.EncryptionKey is set.
+func streamBackup(config Config, dir string) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		sealed, err := storage.NewEncryptor(config.EncryptionKey).EncryptStream(writer)
+		if err == nil {
+			err = writeArchive(sealed, dir)
+		}
+		if err == nil {
+			err = sealed.Close()
+		}
+		writer.CloseWithError(err) //nolint:errcheck
+	}()
+	return reader
+}
+
+// extractBackup opens a backup written by streamBackup into dir.
+func extractBackup(config Config, backup io.Reader, dir string) error {
+	opened, err := storage.NewEncryptor(config.EncryptionKey).DecryptStream(backup)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt backup: %s", err)
+	}
+	return extractStateDir(opened, dir)
+}
+
+func writeArchive(w io.Writer, dir string) error {
+	_, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("reading state dir: %s", err)
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err // not tested
+		}
+		if rel == "." {
+			return nil
+		}
+		if !archived(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return archiveFile(tarWriter, path, filepath.ToSlash(rel), info)
+	})
+	if err == nil {
+		err = tarWriter.Close()
+	}
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to tar state dir: %s", err)
+	}
+
+	return nil
+}
+
+func archived(rel string) bool {
+	if rel == storage.LOCK_FILE || strings.HasPrefix(rel, storage.LOG_FILE) {
+		return false
+	}
+	return rel != filepath.FromSlash(storage.HISTORY_DIR)
+}
+
+func archiveFile(tarWriter *tar.Writer, path, name string, info os.FileInfo) error {
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		link, err = os.Readlink(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	err = tarWriter.WriteHeader(header)
+	if err != nil || !info.Mode().IsRegular() {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
+
+func extractStateDir(tarball io.Reader, dir string) error {
+	err := archiver.TarGz.Read(tarball, dir)
+	if err != nil {
+		return fmt.Errorf("unable to untar state dir: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+const (
+	azureStorageAPIVersion = "2021-08-06"
+	azureStorageScope      = "https://storage.azure.com/.default"
+)
+
+// azureBlockSize is how much of a backup goes into each block.
+const azureBlockSize = 4 * 1024 * 1024
+
+// azureHTTPClient bounds every blob request, so that an unreachable storage
+// account fails the command instead of hanging it while it holds the lock.
+var azureHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+
+// azureBlobBackend keeps the state tarball as a block blob named after the
+// env. config.Endpoint is the storage account URL and config.Bucket the
+// container. Requests are authorised with config.AzureSASToken when it is
+// set, and with a token for the service principal otherwise.
+type azureBlobBackend struct{}
+
+func (a azureBlobBackend) GetState(config Config, name string) (string, error) {
+	request, err := a.request(config, http.MethodGet, name, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := azureHTTPClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("downloading remote state from Azure: %s", err)
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading remote state from Azure: %s", azureError(response))
+	}
+
+	err = extractStateDir(response.Body, config.Dest)
+	if err != nil {
+		return "", err
+	}
+
+	return response.Header.Get("ETag"), nil
+}
+
+func (a azureBlobBackend) PutState(config Config, name string) error {
+	tarball, err := archiveStateDir(config.Dest)
+	if err != nil {
+		return err
+	}
+
+	request, err := a.request(config, http.MethodPut, name, nil, tarball)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("x-ms-blob-type", "BlockBlob")
+	request.Header.Set("Content-Type", "application/gzip")
+	if config.Version == "" {
+		request.Header.Set("If-None-Match", "*")
+	} else {
+		request.Header.Set("If-Match", config.Version)
+	}
+
+	response, err := azureHTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("uploading remote state to Azure: %s", err)
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if isConflict(response.StatusCode) {
+		return ErrStateConflict
+	}
+	if response.StatusCode != http.StatusCreated {
+		return fmt.Errorf("uploading remote state to Azure: %s", azureError(response))
+	}
+
+	return nil
+}
+
+// PutBackup streams the backup in blocks and then commits the list of them,
+// since a single Put Blob needs the length of the whole backup up front.
+func (a azureBlobBackend) PutBackup(config Config, name, dir string) error {
+	backup := streamBackup(config, dir)
+	defer backup.Close() //nolint:errcheck
+
+	var (
+		blockIDs []string
+		block    = make([]byte, azureBlockSize)
+	)
+	for {
+		n, readErr := io.ReadFull(backup, block)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return fmt.Errorf("uploading backup to Azure: %s", readErr)
+		}
+		if n == 0 {
+			break
+		}
+
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", len(blockIDs))))
+		err := a.putBackupPart(config, name, url.Values{"comp": {"block"}, "blockid": {blockID}}, block[:n])
+		if err != nil {
+			return err
+		}
+		blockIDs = append(blockIDs, blockID)
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	var blockList bytes.Buffer
+	blockList.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+	for _, blockID := range blockIDs {
+		fmt.Fprintf(&blockList, "<Latest>%s</Latest>", blockID)
+	}
+	blockList.WriteString("</BlockList>")
+
+	return a.putBackupPart(config, name, url.Values{"comp": {"blocklist"}}, blockList.Bytes())
+}
+
+func (a azureBlobBackend) putBackupPart(config Config, name string, query url.Values, body []byte) error {
+	request, err := a.request(config, http.MethodPut, name, query, body)
+	if err != nil {
+		return err
+	}
+
+	response, err := azureHTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("uploading backup to Azure: %s", err)
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusCreated {
+		return fmt.Errorf("uploading backup to Azure: %s", azureError(response))
+	}
+
+	return nil
+}
+
+func (a azureBlobBackend) GetBackup(config Config, name, dir string) error {
+	request, err := a.request(config, http.MethodGet, name, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := azureHTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("downloading backup from Azure: %s", err)
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode == http.StatusNotFound {
+		return ErrBackupNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading backup from Azure: %s", azureError(response))
+	}
+
+	return extractBackup(config, response.Body, dir)
+}
+
+func (a azureBlobBackend) request(config Config, method, name string, query url.Values, body []byte) (*http.Request, error) {
+	blob, err := url.Parse(fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(config.Endpoint, "/"), config.Bucket, name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure storage account %q: %s", config.Endpoint, err)
+	}
+	if config.AzureSASToken != "" {
+		blob.RawQuery = strings.TrimPrefix(config.AzureSASToken, "?")
+	}
+	if len(query) > 0 {
+		if blob.RawQuery != "" {
+			blob.RawQuery += "&"
+		}
+		blob.RawQuery += query.Encode()
+	}
+
+	request, err := http.NewRequest(method, blob.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("x-ms-version", azureStorageAPIVersion)
+
+	if config.AzureSASToken == "" {
+		token, err := a.token(config)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return request, nil
+}
+
+func (a azureBlobBackend) token(config Config) (string, error) {
+	credential, err := azidentity.NewClientSecretCredential(config.AzureTenantID, config.AzureClientID, config.AzureClientSecret, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not create Azure credential: %s", err)
+	}
+
+	token, err := credential.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{azureStorageScope}})
+	if err != nil {
+		return "", fmt.Errorf("could not get an Azure storage token: %s", err)
+	}
+
+	return token.Token, nil
+}
+
+// azureError describes a failed blob request by its status and the error
+// code the storage service sets in x-ms-error-code.
+func azureError(response *http.Response) string {
+	io.Copy(io.Discard, response.Body) //nolint:errcheck
+
+	code := response.Header.Get("x-ms-error-code")
+	if code == "" {
+		return response.Status
+	}
+	return fmt.Sprintf("%s (%s)", response.Status, code)
+}
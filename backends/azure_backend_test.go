@@ -0,0 +1,235 @@
+package backends_test
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeBlobService serves block blobs from memory the way Azurite does,
+// honouring the If-Match and If-None-Match conditions on uploads and
+// committing staged blocks with Put Block List.
+type fakeBlobService struct {
+	mutex  sync.Mutex
+	blobs  map[string][]byte
+	blocks map[string][]byte
+	query  string
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.query = r.URL.RawQuery
+
+	switch r.URL.Query().Get("comp") {
+	case "block":
+		f.blocks[r.URL.Path+"#"+r.URL.Query().Get("blockid")], _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		return
+	case "blocklist":
+		var blockList struct {
+			Latest []string `xml:"Latest"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if xml.Unmarshal(body, &blockList) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var blob []byte
+		for _, blockID := range blockList.Latest {
+			blob = append(blob, f.blocks[r.URL.Path+"#"+blockID]...)
+		}
+		f.blobs[r.URL.Path] = blob
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	blob, exists := f.blobs[r.URL.Path]
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(blob))
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(blob) //nolint:errcheck
+	case http.MethodPut:
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if (r.Header.Get("If-None-Match") == "*" && exists) ||
+			(r.Header.Get("If-Match") != "" && (!exists || r.Header.Get("If-Match") != etag)) {
+			w.Header().Set("x-ms-error-code", "ConditionNotMet")
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.blobs[r.URL.Path], _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+var _ = Describe("azure blob backend", func() {
+	var (
+		backend  backends.Backend
+		service  *fakeBlobService
+		server   *httptest.Server
+		config   backends.Config
+		stateDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		backend, err = backends.NewProvider().Client("azure")
+		Expect(err).NotTo(HaveOccurred())
+
+		service = &fakeBlobService{blobs: map[string][]byte{}, blocks: map[string][]byte{}}
+		server = httptest.NewServer(service)
+
+		stateDir = GinkgoT().TempDir()
+		err = os.WriteFile(filepath.Join(stateDir, "bbl-state.json"), []byte("some-state"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.WriteFile(filepath.Join(stateDir, "bbl-state.lock"), []byte("some-lock"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		config = backends.Config{
+			Endpoint:      server.URL + "/devstoreaccount1",
+			Bucket:        "some-container",
+			AzureSASToken: "?sv=some-version&sig=some-signature",
+			Dest:          stateDir,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("round trips the state dir without its lock", func() {
+		err := backend.PutState(config, "some-env")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service.blobs).To(HaveKey("/devstoreaccount1/some-container/some-env"))
+		Expect(service.query).To(Equal("sv=some-version&sig=some-signature"))
+
+		downloadDir := GinkgoT().TempDir()
+		config.Dest = downloadDir
+		version, err := backend.GetState(config, "some-env")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version).NotTo(BeEmpty())
+
+		contents, err := os.ReadFile(filepath.Join(downloadDir, "bbl-state.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-state"))
+		Expect(filepath.Join(downloadDir, "bbl-state.lock")).NotTo(BeAnExistingFile())
+	})
+
+	Context("when there is no remote state", func() {
+		It("returns an empty version", func() {
+			version, err := backend.GetState(config, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(BeEmpty())
+		})
+	})
+
+	Context("when the remote state changed after it was downloaded", func() {
+		var version string
+
+		BeforeEach(func() {
+			err := backend.PutState(config, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+
+			downloadConfig := config
+			downloadConfig.Dest = GinkgoT().TempDir()
+			version, err = backend.GetState(downloadConfig, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.WriteFile(filepath.Join(stateDir, "bbl-state.json"), []byte("some-other-state"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+			config.Version = version
+			err = backend.PutState(config, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("refuses to overwrite it", func() {
+			err := backend.PutState(config, "some-env")
+			Expect(err).To(Equal(backends.ErrStateConflict))
+		})
+
+		It("refuses to create it again", func() {
+			config.Version = ""
+			err := backend.PutState(config, "some-env")
+			Expect(err).To(Equal(backends.ErrStateConflict))
+		})
+	})
+
+	It("round trips a backup", func() {
+		err := backend.PutBackup(config, "some-env-backups/some-backup.tgz", stateDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service.blobs).To(HaveKey("/devstoreaccount1/some-container/some-env-backups/some-backup.tgz"))
+
+		downloadDir := GinkgoT().TempDir()
+		err = backend.GetBackup(config, "some-env-backups/some-backup.tgz", downloadDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(downloadDir, "bbl-state.json")).To(BeAnExistingFile())
+	})
+
+	It("streams a backup larger than a block", func() {
+		backupDir := GinkgoT().TempDir()
+		artifact := make([]byte, 5*1024*1024)
+		_, err := rand.Read(artifact)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.WriteFile(filepath.Join(backupDir, "some-artifact"), artifact, 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		config.EncryptionKey = "some-encryption-key"
+		err = backend.PutBackup(config, "some-env-backups/some-backup.tgz", backupDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(service.blocks)).To(BeNumerically(">", 1))
+		Expect(string(service.blobs["/devstoreaccount1/some-container/some-env-backups/some-backup.tgz"])).To(HavePrefix("bbl-encrypted-stream:v1\n"))
+
+		downloadDir := GinkgoT().TempDir()
+		err = backend.GetBackup(config, "some-env-backups/some-backup.tgz", downloadDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(filepath.Join(downloadDir, "some-artifact"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contents).To(Equal(artifact))
+	})
+
+	Context("when the backup does not exist", func() {
+		It("returns ErrBackupNotFound", func() {
+			err := backend.GetBackup(config, "some-env-backups/some-backup.tgz", GinkgoT().TempDir())
+			Expect(err).To(Equal(backends.ErrBackupNotFound))
+		})
+	})
+
+	Context("when the storage service fails", func() {
+		BeforeEach(func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("x-ms-error-code", "AuthenticationFailed")
+				w.WriteHeader(http.StatusForbidden)
+			})
+		})
+
+		It("returns the error code", func() {
+			_, err := backend.GetState(config, "some-env")
+			Expect(err).To(MatchError("downloading remote state from Azure: 403 Forbidden (AuthenticationFailed)"))
+		})
+	})
+})
@@ -1,24 +1,36 @@
 package backends
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"os"
-
-	"github.com/araddon/gou"
-	"github.com/cloudfoundry/bbl-state-resource/storage"
-	"github.com/lytics/cloudstorage"
-	"github.com/lytics/cloudstorage/awss3"
-	"github.com/mholt/archiver"
 )
 
+var ErrStateConflict = errors.New("the remote state was changed by another bbl run after it was downloaded, re-run the command against the latest remote state")
+
 type Config struct {
 	AWSAccessKeyID       string
 	AWSSecretAccessKey   string
 	GCPServiceAccountKey string
+	AzureTenantID        string
+	AzureClientID        string
+	AzureClientSecret    string
+	AzureSASToken        string
 	Bucket               string
 	Region               string
 	Dest                 string
+
+	// Endpoint is the URL of an S3-compatible object store such as MinIO or
+	// Ceph RGW, or of an Azure storage account. It is empty for AWS S3.
+	Endpoint string
+
+	// EncryptionKey is the --state-encryption-key passphrase, which seals
+	// backups when it is set.
+	EncryptionKey string
+
+	// Version is the ETag or generation of the remote state that was
+	// downloaded into Dest. PutState refuses to overwrite any other version,
+	// and when it is empty PutState only creates the remote state.
+	Version string
 }
 
 type Provider interface {
@@ -31,89 +43,36 @@ func NewProvider() Provider {
 
 type provider struct{}
 
-func (p provider) Client(iaas string) (Backend, error) {
-	switch iaas {
-	case "aws":
+// Client returns the backend for a kind of remote state storage: "s3",
+// "gcs", "azure" or "file".
+func (p provider) Client(kind string) (Backend, error) {
+	switch kind {
+	case "s3":
 		return cloudStorageBackend{}, nil
-	case "gcp":
+	case "gcs":
 		return gcsStateBackend{}, nil
+	case "azure":
+		return azureBlobBackend{}, nil
+	case "file":
+		return fileBackend{}, nil
 	default:
-		return nil, fmt.Errorf("remote state storage is unsupported for %s environments", iaas)
+		return nil, fmt.Errorf("remote state storage %q is unsupported", kind)
 	}
 }
 
 type Backend interface {
-	GetState(Config, string) error
+	// GetState untars the remote state into config.Dest and returns its
+	// version, or an empty version when there is no remote state yet.
+	GetState(Config, string) (string, error)
+	// PutState tars config.Dest and uploads it, provided the remote state is
+	// still at config.Version.
+	PutState(Config, string) error
+	// PutBackup tars a directory and streams it under the given name, next
+	// to the remote state, sealed with config.EncryptionKey when it is set.
+	PutBackup(config Config, name, dir string) error
+	// GetBackup untars the backup with the given name into a directory.
+	GetBackup(config Config, name, dir string) error
 }
 
-type cloudStorageBackend struct{}
-
-func (c cloudStorageBackend) GetState(config Config, name string) error {
-	awsAuthSettings := make(gou.JsonHelper)
-	awsAuthSettings[awss3.ConfKeyAccessKey] = config.AWSAccessKeyID
-	awsAuthSettings[awss3.ConfKeyAccessSecret] = config.AWSSecretAccessKey
-
-	csConfig := cloudstorage.Config{
-		Type:       awss3.StoreType,
-		AuthMethod: awss3.AuthAccessKey,
-		Bucket:     config.Bucket,
-		Settings:   awsAuthSettings,
-		Region:     config.Region,
-	}
-
-	store, err := cloudstorage.NewStore(&csConfig)
-	if err != nil {
-		return err
-	}
-
-	tarball, err := store.Get(context.Background(), name)
-	if err != nil {
-		return err
-	}
-
-	stateTar, err := tarball.Open(cloudstorage.ReadOnly)
-	if err != nil {
-		return err
-	}
-
-	err = archiver.TarGz.Read(stateTar, config.Dest)
-	if err != nil {
-		return fmt.Errorf("unable to untar state dir: %s", err)
-	}
-
-	return nil
-}
-
-type gcsStateBackend struct{}
-
-func (g gcsStateBackend) GetState(config Config, name string) error {
-	key, err := g.getGCPServiceAccountKey(config.GCPServiceAccountKey)
-	if err != nil {
-		return fmt.Errorf("could not read GCP service account key: %s", err)
-	}
-
-	gcsClient, err := storage.NewStorageClient(key, name, config.Bucket)
-	if err != nil {
-		return fmt.Errorf("could not create GCS client: %s", err)
-	}
-
-	_, err = gcsClient.Download(config.Dest)
-	if err != nil {
-		return fmt.Errorf("downloading remote state from GCS: %s", err)
-	}
-
-	return nil
-}
-
-func (g gcsStateBackend) getGCPServiceAccountKey(key string) (string, error) {
-	if _, err := os.Stat(key); err != nil {
-		return key, nil
-	}
-
-	keyBytes, err := os.ReadFile(key)
-	if err != nil {
-		return "", fmt.Errorf("Reading key: %v", err) //nolint:staticcheck
-	}
-
-	return string(keyBytes), nil
-}
+// ErrBackupNotFound is returned by GetBackup when there is no such backup.
+var ErrBackupNotFound = errors.New("the backup does not exist in the remote state storage")
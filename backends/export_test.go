@@ -0,0 +1,16 @@
+package backends
+
+import (
+	"context"
+
+	"golang.org/x/oauth2/jwt"
+	"google.golang.org/api/option"
+)
+
+func SetGCSClientOptions(f func(context.Context, *jwt.Config) []option.ClientOption) {
+	gcsClientOptions = f
+}
+
+func ResetGCSClientOptions() {
+	gcsClientOptions = gcsClientOptionsFunc
+}
@@ -0,0 +1,145 @@
+package backends
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// fileBackend keeps the state tarball in a directory, typically an NFS share.
+// config.Bucket is the directory and the tarball is named after the env.
+type fileBackend struct{}
+
+func (f fileBackend) GetState(config Config, name string) (string, error) {
+	tarball, err := os.ReadFile(f.path(config, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading remote state: %s", err)
+	}
+
+	err = extractStateDir(bytes.NewReader(tarball), config.Dest)
+	if err != nil {
+		return "", err
+	}
+
+	return checksum(tarball), nil
+}
+
+func (f fileBackend) PutState(config Config, name string) error {
+	tarball, err := archiveStateDir(config.Dest)
+	if err != nil {
+		return err
+	}
+
+	path := f.path(config, name)
+
+	// O_EXCL is honoured by NFSv3 and later, so the lock keeps two bbl runs
+	// from checking the version and replacing the tarball at the same time.
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return ErrStateConflict
+	}
+	if err != nil {
+		return fmt.Errorf("locking remote state: %s", err)
+	}
+	defer os.Remove(lock.Name()) //nolint:errcheck
+	defer lock.Close()           //nolint:errcheck
+
+	current, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if config.Version != "" {
+			return ErrStateConflict
+		}
+	case err != nil:
+		return fmt.Errorf("reading remote state: %s", err)
+	case checksum(current) != config.Version:
+		return ErrStateConflict
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("writing remote state: %s", err)
+	}
+	defer os.Remove(temp.Name()) //nolint:errcheck
+
+	_, err = temp.Write(tarball)
+	if err != nil {
+		temp.Close() //nolint:errcheck
+		return fmt.Errorf("writing remote state: %s", err)
+	}
+
+	err = temp.Close()
+	if err != nil {
+		return fmt.Errorf("writing remote state: %s", err)
+	}
+
+	err = os.Rename(temp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("writing remote state: %s", err)
+	}
+
+	return nil
+}
+
+func (f fileBackend) PutBackup(config Config, name, dir string) error {
+	backup := streamBackup(config, dir)
+	defer backup.Close() //nolint:errcheck
+
+	path := filepath.Join(config.Bucket, name)
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("writing backup: %s", err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("writing backup: %s", err)
+	}
+	defer os.Remove(temp.Name()) //nolint:errcheck
+
+	_, err = io.Copy(temp, backup)
+	if err != nil {
+		temp.Close() //nolint:errcheck
+		return fmt.Errorf("writing backup: %s", err)
+	}
+
+	err = temp.Close()
+	if err != nil {
+		return fmt.Errorf("writing backup: %s", err)
+	}
+
+	err = os.Rename(temp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("writing backup: %s", err)
+	}
+
+	return nil
+}
+
+func (f fileBackend) GetBackup(config Config, name, dir string) error {
+	backup, err := os.Open(filepath.Join(config.Bucket, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrBackupNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("reading backup: %s", err)
+	}
+	defer backup.Close() //nolint:errcheck
+
+	return extractBackup(config, backup, dir)
+}
+
+func (f fileBackend) path(config Config, name string) string {
+	return filepath.Join(config.Bucket, fmt.Sprintf("%s.tgz", name))
+}
+
+func checksum(contents []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(contents))
+}
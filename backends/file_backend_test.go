@@ -0,0 +1,173 @@
+package backends_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("file backend", func() {
+	var (
+		backend   backends.Backend
+		remoteDir string
+		stateDir  string
+	)
+
+	BeforeEach(func() {
+		var err error
+		backend, err = backends.NewProvider().Client("file")
+		Expect(err).NotTo(HaveOccurred())
+
+		remoteDir = GinkgoT().TempDir()
+		stateDir = GinkgoT().TempDir()
+
+		err = os.WriteFile(filepath.Join(stateDir, "bbl-state.json"), []byte("some-state"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.WriteFile(filepath.Join(stateDir, "bbl-state.lock"), []byte("some-lock"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.WriteFile(filepath.Join(stateDir, "bbl.log"), []byte("some-log"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.MkdirAll(filepath.Join(stateDir, "vars"), 0700)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.WriteFile(filepath.Join(stateDir, "vars", "terraform.tfstate"), []byte("some-tfstate"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.MkdirAll(filepath.Join(stateDir, ".bbl", "history", "some-snapshot"), 0700)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.WriteFile(filepath.Join(stateDir, ".bbl", "some-file"), []byte("some-contents"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("round trips the state dir without its lock, log or history", func() {
+		err := backend.PutState(backends.Config{Bucket: remoteDir, Dest: stateDir}, "some-env")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(remoteDir, "some-env.tgz")).To(BeAnExistingFile())
+
+		downloadDir := GinkgoT().TempDir()
+		version, err := backend.GetState(backends.Config{Bucket: remoteDir, Dest: downloadDir}, "some-env")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version).NotTo(BeEmpty())
+
+		contents, err := os.ReadFile(filepath.Join(downloadDir, "bbl-state.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-state"))
+		Expect(filepath.Join(downloadDir, "bbl-state.lock")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(downloadDir, "bbl.log")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(downloadDir, ".bbl", "history")).NotTo(BeADirectory())
+
+		contents, err = os.ReadFile(filepath.Join(downloadDir, "vars", "terraform.tfstate"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-tfstate"))
+		Expect(filepath.Join(downloadDir, ".bbl", "some-file")).To(BeAnExistingFile())
+	})
+
+	Context("when there is no remote state", func() {
+		It("returns an empty version", func() {
+			version, err := backend.GetState(backends.Config{Bucket: remoteDir, Dest: stateDir}, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(BeEmpty())
+		})
+	})
+
+	Context("when the remote state changed after it was downloaded", func() {
+		var version string
+
+		BeforeEach(func() {
+			err := backend.PutState(backends.Config{Bucket: remoteDir, Dest: stateDir}, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+
+			version, err = backend.GetState(backends.Config{Bucket: remoteDir, Dest: GinkgoT().TempDir()}, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.WriteFile(filepath.Join(stateDir, "bbl-state.json"), []byte("some-other-state"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+			err = backend.PutState(backends.Config{Bucket: remoteDir, Dest: stateDir, Version: version}, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("refuses to overwrite it", func() {
+			err := backend.PutState(backends.Config{Bucket: remoteDir, Dest: stateDir, Version: version}, "some-env")
+			Expect(err).To(Equal(backends.ErrStateConflict))
+		})
+
+		It("refuses to create it again", func() {
+			err := backend.PutState(backends.Config{Bucket: remoteDir, Dest: stateDir}, "some-env")
+			Expect(err).To(Equal(backends.ErrStateConflict))
+		})
+	})
+
+	Describe("backups", func() {
+		var backupDir string
+
+		BeforeEach(func() {
+			backupDir = GinkgoT().TempDir()
+			err := os.WriteFile(filepath.Join(backupDir, "metadata"), []byte("some-metadata"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("round trips a backup", func() {
+			err := backend.PutBackup(backends.Config{Bucket: remoteDir}, "some-env-backups/some-backup.tgz", backupDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Join(remoteDir, "some-env-backups", "some-backup.tgz")).To(BeAnExistingFile())
+
+			downloadDir := GinkgoT().TempDir()
+			err = backend.GetBackup(backends.Config{Bucket: remoteDir}, "some-env-backups/some-backup.tgz", downloadDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(filepath.Join(downloadDir, "metadata"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-metadata"))
+		})
+
+		Context("when there is a state encryption key", func() {
+			var config backends.Config
+
+			BeforeEach(func() {
+				config = backends.Config{Bucket: remoteDir, EncryptionKey: "some-encryption-key"}
+			})
+
+			It("seals the backup and opens it again", func() {
+				err := backend.PutBackup(config, "some-env-backups/some-backup.tgz", backupDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				sealed, err := os.ReadFile(filepath.Join(remoteDir, "some-env-backups", "some-backup.tgz"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(sealed)).To(HavePrefix("bbl-encrypted-stream:v1\n"))
+
+				downloadDir := GinkgoT().TempDir()
+				err = backend.GetBackup(config, "some-env-backups/some-backup.tgz", downloadDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(filepath.Join(downloadDir, "metadata"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("some-metadata"))
+			})
+
+			It("refuses to open the backup without the key", func() {
+				err := backend.PutBackup(config, "some-env-backups/some-backup.tgz", backupDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = backend.GetBackup(backends.Config{Bucket: remoteDir}, "some-env-backups/some-backup.tgz", GinkgoT().TempDir())
+				Expect(err).To(MatchError(ContainSubstring("unable to decrypt backup")))
+			})
+		})
+
+		Context("when the backup dir does not exist", func() {
+			It("returns an error without writing a backup", func() {
+				err := backend.PutBackup(backends.Config{Bucket: remoteDir}, "some-env-backups/some-backup.tgz", filepath.Join(backupDir, "missing"))
+				Expect(err).To(MatchError(ContainSubstring("reading state dir")))
+				Expect(filepath.Join(remoteDir, "some-env-backups", "some-backup.tgz")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		Context("when the backup does not exist", func() {
+			It("returns ErrBackupNotFound", func() {
+				err := backend.GetBackup(backends.Config{Bucket: remoteDir}, "some-env-backups/some-backup.tgz", GinkgoT().TempDir())
+				Expect(err).To(Equal(backends.ErrBackupNotFound))
+			})
+		})
+	})
+})
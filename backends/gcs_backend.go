@@ -0,0 +1,193 @@
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	gcs "cloud.google.com/go/storage"
+	oauthgoogle "golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/jwt"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+var gcsClientOptions = gcsClientOptionsFunc
+
+func gcsClientOptionsFunc(ctx context.Context, jwtConfig *jwt.Config) []option.ClientOption {
+	return []option.ClientOption{option.WithTokenSource(jwtConfig.TokenSource(ctx))}
+}
+
+type gcsStateBackend struct{}
+
+func (g gcsStateBackend) GetState(config Config, name string) (string, error) {
+	ctx := context.Background()
+
+	bucket, projectID, err := g.bucket(ctx, config)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = bucket.Attrs(ctx)
+	if err == gcs.ErrBucketNotExist {
+		err = bucket.Create(ctx, projectID, nil)
+		if err != nil {
+			return "", fmt.Errorf("creating GCS bucket: %s", err)
+		}
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("getting GCS bucket: %s", err)
+	}
+
+	object := bucket.Object(name)
+	attrs, err := object.Attrs(ctx)
+	if err == gcs.ErrObjectNotExist {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("downloading remote state from GCS: %s", err)
+	}
+
+	reader, err := object.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return "", fmt.Errorf("downloading remote state from GCS: %s", err)
+	}
+	defer reader.Close() //nolint:errcheck
+
+	err = extractStateDir(reader, config.Dest)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatInt(attrs.Generation, 10), nil
+}
+
+func (g gcsStateBackend) PutState(config Config, name string) error {
+	ctx := context.Background()
+
+	bucket, _, err := g.bucket(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	conditions := gcs.Conditions{DoesNotExist: true}
+	if config.Version != "" {
+		generation, err := strconv.ParseInt(config.Version, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid GCS generation %q: %s", config.Version, err)
+		}
+		conditions = gcs.Conditions{GenerationMatch: generation}
+	}
+
+	tarball, err := archiveStateDir(config.Dest)
+	if err != nil {
+		return err
+	}
+
+	writer := bucket.Object(name).If(conditions).NewWriter(ctx)
+	_, err = writer.Write(tarball)
+	if err != nil {
+		return fmt.Errorf("uploading remote state to GCS: %s", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && isConflict(gerr.Code) {
+			return ErrStateConflict
+		}
+		return fmt.Errorf("uploading remote state to GCS: %s", err)
+	}
+
+	return nil
+}
+
+func (g gcsStateBackend) PutBackup(config Config, name, dir string) error {
+	// Cancelling the context aborts an upload that could not be finished.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bucket, _, err := g.bucket(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	backup := streamBackup(config, dir)
+	defer backup.Close() //nolint:errcheck
+
+	writer := bucket.Object(name).NewWriter(ctx)
+	_, err = io.Copy(writer, backup)
+	if err != nil {
+		return fmt.Errorf("uploading backup to GCS: %s", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("uploading backup to GCS: %s", err)
+	}
+
+	return nil
+}
+
+func (g gcsStateBackend) GetBackup(config Config, name, dir string) error {
+	ctx := context.Background()
+
+	bucket, _, err := g.bucket(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	reader, err := bucket.Object(name).NewReader(ctx)
+	if err == gcs.ErrObjectNotExist {
+		return ErrBackupNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("downloading backup from GCS: %s", err)
+	}
+	defer reader.Close() //nolint:errcheck
+
+	return extractBackup(config, reader, dir)
+}
+
+func (g gcsStateBackend) bucket(ctx context.Context, config Config) (*gcs.BucketHandle, string, error) {
+	key, err := g.getGCPServiceAccountKey(config.GCPServiceAccountKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read GCP service account key: %s", err)
+	}
+
+	jwtConfig, err := oauthgoogle.JWTConfigFromJSON([]byte(key), gcs.ScopeReadWrite)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create GCS client: %s", err)
+	}
+
+	client, err := gcs.NewClient(ctx, gcsClientOptions(ctx, jwtConfig)...)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create GCS client: %s", err)
+	}
+
+	var serviceAccount struct {
+		ProjectID string `json:"project_id"`
+	}
+	err = json.Unmarshal([]byte(key), &serviceAccount)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read GCP service account key: %s", err)
+	}
+
+	return client.Bucket(config.Bucket).UserProject(serviceAccount.ProjectID), serviceAccount.ProjectID, nil
+}
+
+func (g gcsStateBackend) getGCPServiceAccountKey(key string) (string, error) {
+	if _, err := os.Stat(key); err != nil {
+		return key, nil
+	}
+
+	keyBytes, err := os.ReadFile(key)
+	if err != nil {
+		return "", fmt.Errorf("Reading key: %v", err) //nolint:staticcheck
+	}
+
+	return string(keyBytes), nil
+}
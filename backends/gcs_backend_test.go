@@ -0,0 +1,120 @@
+package backends_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+	"golang.org/x/oauth2/jwt"
+	"google.golang.org/api/option"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeGCS counts the uploads of a single object, honouring
+// ifGenerationMatch the way the GCS JSON API does.
+type fakeGCS struct {
+	mutex      sync.Mutex
+	generation int64
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r.Method != http.MethodPost || r.URL.Path != "/b/some-bucket/o" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	io.Copy(io.Discard, r.Body) //nolint:errcheck
+
+	if match := r.URL.Query().Get("ifGenerationMatch"); match != "" {
+		generation, _ := strconv.ParseInt(match, 10, 64)
+		if generation != f.generation {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprint(w, `{"error": {"code": 412, "message": "conditionNotMet"}}`) //nolint:errcheck
+			return
+		}
+	}
+
+	f.generation++
+	fmt.Fprintf(w, `{"bucket": "some-bucket", "name": "some-env", "generation": "%d"}`, f.generation) //nolint:errcheck
+}
+
+var _ = Describe("gcs backend", func() {
+	var (
+		backend backends.Backend
+		service *fakeGCS
+		server  *httptest.Server
+		config  backends.Config
+	)
+
+	BeforeEach(func() {
+		var err error
+		backend, err = backends.NewProvider().Client("gcs")
+		Expect(err).NotTo(HaveOccurred())
+
+		service = &fakeGCS{}
+		server = httptest.NewServer(service)
+		backends.SetGCSClientOptions(func(context.Context, *jwt.Config) []option.ClientOption {
+			return []option.ClientOption{option.WithEndpoint(server.URL + "/"), option.WithHTTPClient(server.Client())}
+		})
+
+		stateDir := GinkgoT().TempDir()
+		err = os.WriteFile(filepath.Join(stateDir, "bbl-state.json"), []byte("some-state"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		config = backends.Config{
+			Bucket:               "some-bucket",
+			GCPServiceAccountKey: `{"type": "service_account", "project_id": "some-project", "client_email": "some-email", "private_key": "some-key"}`,
+			Dest:                 stateDir,
+		}
+	})
+
+	AfterEach(func() {
+		backends.ResetGCSClientOptions()
+		server.Close()
+	})
+
+	Describe("PutState", func() {
+		It("only creates the remote state when no version was downloaded", func() {
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+			Expect(service.generation).To(Equal(int64(1)))
+
+			err := backend.PutState(config, "some-env")
+			Expect(err).To(Equal(backends.ErrStateConflict))
+		})
+
+		It("overwrites the generation that was downloaded", func() {
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+
+			config.Version = "1"
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+			Expect(service.generation).To(Equal(int64(2)))
+		})
+
+		It("refuses to overwrite a newer remote state", func() {
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+			config.Version = "1"
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+
+			err := backend.PutState(config, "some-env")
+			Expect(err).To(Equal(backends.ErrStateConflict))
+		})
+
+		It("returns an error when the version is not a generation", func() {
+			config.Version = "some-etag"
+
+			err := backend.PutState(config, "some-env")
+			Expect(err).To(MatchError(ContainSubstring(`invalid GCS generation "some-etag"`)))
+		})
+	})
+})
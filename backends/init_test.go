@@ -0,0 +1,13 @@
+package backends_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestBackends(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "backends")
+}
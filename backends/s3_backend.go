@@ -0,0 +1,134 @@
+package backends
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+type cloudStorageBackend struct{}
+
+func (c cloudStorageBackend) GetState(config Config, name string) (string, error) {
+	client, err := c.client(config)
+	if err != nil {
+		return "", err
+	}
+
+	object, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(config.Bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return "", nil
+		}
+		return "", err
+	}
+	defer object.Body.Close() //nolint:errcheck
+
+	err = extractStateDir(object.Body, config.Dest)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(object.ETag), nil
+}
+
+func (c cloudStorageBackend) PutState(config Config, name string) error {
+	client, err := c.client(config)
+	if err != nil {
+		return err
+	}
+
+	tarball, err := archiveStateDir(config.Dest)
+	if err != nil {
+		return err
+	}
+
+	request, _ := client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(config.Bucket),
+		Key:    aws.String(name),
+		Body:   bytes.NewReader(tarball),
+	})
+	if config.Version == "" {
+		request.HTTPRequest.Header.Set("If-None-Match", "*")
+	} else {
+		request.HTTPRequest.Header.Set("If-Match", config.Version)
+	}
+
+	err = request.Send()
+	if err != nil {
+		if rerr, ok := err.(awserr.RequestFailure); ok && isConflict(rerr.StatusCode()) {
+			return ErrStateConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (c cloudStorageBackend) PutBackup(config Config, name, dir string) error {
+	client, err := c.client(config)
+	if err != nil {
+		return err
+	}
+
+	backup := streamBackup(config, dir)
+	defer backup.Close() //nolint:errcheck
+
+	// The uploader sends a stream in parts, which PutObject cannot.
+	_, err = s3manager.NewUploaderWithClient(client).Upload(&s3manager.UploadInput{
+		Bucket: aws.String(config.Bucket),
+		Key:    aws.String(name),
+		Body:   backup,
+	})
+	return err
+}
+
+func (c cloudStorageBackend) GetBackup(config Config, name, dir string) error {
+	client, err := c.client(config)
+	if err != nil {
+		return err
+	}
+
+	object, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(config.Bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return ErrBackupNotFound
+		}
+		return err
+	}
+	defer object.Body.Close() //nolint:errcheck
+
+	return extractBackup(config, object.Body, dir)
+}
+
+func (c cloudStorageBackend) client(config Config) (*s3.S3, error) {
+	awsConfig := aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials(config.AWSAccessKeyID, config.AWSSecretAccessKey, "")).
+		WithRegion(config.Region)
+	if config.Endpoint != "" {
+		// S3-compatible stores rarely serve virtual-hosted buckets.
+		awsConfig = awsConfig.WithEndpoint(config.Endpoint).WithS3ForcePathStyle(true)
+	}
+
+	awsSession, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.New(awsSession), nil
+}
+
+func isConflict(statusCode int) bool {
+	return statusCode == http.StatusPreconditionFailed || statusCode == http.StatusConflict
+}
@@ -0,0 +1,140 @@
+package backends_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeS3 serves path-style objects from memory the way MinIO does,
+// honouring the If-Match and If-None-Match conditions on uploads.
+type fakeS3 struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	object, exists := f.objects[r.URL.Path]
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(object))
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>") //nolint:errcheck
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(object) //nolint:errcheck
+	case http.MethodPut:
+		if (r.Header.Get("If-None-Match") == "*" && exists) ||
+			(r.Header.Get("If-Match") != "" && (!exists || r.Header.Get("If-Match") != etag)) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprint(w, "<Error><Code>PreconditionFailed</Code></Error>") //nolint:errcheck
+			return
+		}
+		f.objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha256.Sum256(f.objects[r.URL.Path])))
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+var _ = Describe("s3 backend", func() {
+	var (
+		backend  backends.Backend
+		service  *fakeS3
+		server   *httptest.Server
+		config   backends.Config
+		stateDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		backend, err = backends.NewProvider().Client("s3")
+		Expect(err).NotTo(HaveOccurred())
+
+		service = &fakeS3{objects: map[string][]byte{}}
+		server = httptest.NewServer(service)
+
+		stateDir = GinkgoT().TempDir()
+		err = os.WriteFile(filepath.Join(stateDir, "bbl-state.json"), []byte("some-state"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		config = backends.Config{
+			Endpoint:           server.URL,
+			Bucket:             "some-bucket",
+			Region:             "us-east-1",
+			AWSAccessKeyID:     "some-access-key-id",
+			AWSSecretAccessKey: "some-secret-access-key",
+			Dest:               stateDir,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("round trips the state dir and returns its etag", func() {
+		err := backend.PutState(config, "some-env")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service.objects).To(HaveKey("/some-bucket/some-env"))
+
+		config.Dest = GinkgoT().TempDir()
+		version, err := backend.GetState(config, "some-env")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version).To(Equal(fmt.Sprintf(`"%x"`, sha256.Sum256(service.objects["/some-bucket/some-env"]))))
+
+		contents, err := os.ReadFile(filepath.Join(config.Dest, "bbl-state.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-state"))
+	})
+
+	Context("when there is no remote state", func() {
+		It("returns an empty version", func() {
+			version, err := backend.GetState(config, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(BeEmpty())
+		})
+	})
+
+	Describe("conditional writes", func() {
+		It("only creates the remote state when no version was downloaded", func() {
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+
+			err := backend.PutState(config, "some-env")
+			Expect(err).To(Equal(backends.ErrStateConflict))
+		})
+
+		It("overwrites the version that was downloaded", func() {
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+			version, err := backend.GetState(config, "some-env")
+			Expect(err).NotTo(HaveOccurred())
+
+			config.Version = version
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+		})
+
+		It("refuses to overwrite a newer remote state", func() {
+			Expect(backend.PutState(config, "some-env")).To(Succeed())
+
+			config.Version = `"some-older-etag"`
+			err := backend.PutState(config, "some-env")
+			Expect(err).To(Equal(backends.ErrStateConflict))
+		})
+	})
+})
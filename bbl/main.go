@@ -3,24 +3,32 @@ package main
 import (
 	"bytes"
 	"crypto/rand"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/aws"
 	"github.com/cloudfoundry/bosh-bootloader/azure"
 	"github.com/cloudfoundry/bosh-bootloader/backends"
+	"github.com/cloudfoundry/bosh-bootloader/bbr"
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/certs"
 	"github.com/cloudfoundry/bosh-bootloader/cloudconfig"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/config"
 	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/health"
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/renderers"
 	"github.com/cloudfoundry/bosh-bootloader/runtimeconfig"
+	"github.com/cloudfoundry/bosh-bootloader/secrets"
 	"github.com/cloudfoundry/bosh-bootloader/ssh"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
@@ -52,35 +60,126 @@ func main() {
 	log.SetFlags(0)
 
 	logger := application.NewLogger(os.Stdout, os.Stdin)
-	stderrLogger := application.NewLogger(os.Stderr, os.Stdin)
-	stateBootstrap := storage.NewStateBootstrap(stderrLogger, Version)
+	stderrLogger := logger.WithWriter(os.Stderr)
+	fileLogger := logger.WithWriter(io.Discard)
 	envRendererFactory := renderers.NewFactory(helpers.NewEnvGetter())
 
 	globals, remainingArgs, err := config.ParseArgs(os.Args)
 	if err != nil {
-		log.Fatalf("\n\n%s\n", err)
+		log.Fatal(errorMessage(err, globals.JSON))
 	}
 	if globals.NoConfirm {
 		logger.NoConfirm()
+		stderrLogger.NoConfirm()
 	}
 
+	err = configureLogger(logger, globals)
+	if err != nil {
+		log.Fatal(errorMessage(err, globals.JSON))
+	}
+	redactor := application.NewRedactor()
+	logger.RedactWith(redactor)
+
+	// bbl plan --diff renders into a copy of the state dir and keeps stdout for the diff.
+	diffLogger := logger
+	previewsPlan := config.PreviewsPlan(remainingArgs)
+	removeScratchDir := func() {}
+	if previewsPlan {
+		scratchDir, err := storage.CopyStateDir(globals.StateDir)
+		if err != nil {
+			log.Fatal(errorMessage(err, globals.JSON))
+		}
+		globals.StateDir = scratchDir
+		removeScratchDir = func() {
+			os.RemoveAll(scratchDir) //nolint:errcheck
+		}
+		logger = stderrLogger
+	}
+
+	stateEncryptionKey, err := config.GetStateEncryptionKey(globals.StateEncryptionKey)
+	if err != nil {
+		log.Fatal(errorMessage(err, globals.JSON))
+	}
+	stateEncryptor := storage.NewEncryptor(stateEncryptionKey)
+	stateBootstrap := storage.NewStateBootstrap(stderrLogger, Version, stateEncryptor)
+
 	// File IO
 	fs := afero.NewOsFs()
-	afs := &afero.Afero{Fs: fs}
+	afs := storage.NewAtomicFs(&afero.Afero{Fs: fs}, globals.StateDir)
 
 	// bbl Configuration
 	garbageCollector := storage.NewGarbageCollector(afs)
-	stateStore := storage.NewStore(globals.StateDir, afs, garbageCollector)
+	secretStore, err := secrets.NewStore(globals.SecretStore, globals.StateDir, afs, stateEncryptor)
+	if err != nil {
+		log.Fatal(errorMessage(err, globals.JSON))
+	}
+	secretResolver := secrets.NewResolver(secretStore, afs)
+	stateStore := storage.NewStore(globals.StateDir, afs, garbageCollector, stateEncryptor, secretResolver)
+	stateStore.RedactWith(redactor)
 	patchDetector := storage.NewPatchDetector(globals.StateDir, logger)
 	stateMigrator := storage.NewMigrator(stateStore, afs)
 	stateMerger := config.NewMerger(afs)
 	storageProvider := backends.NewProvider()
 	stateDownloader := config.NewDownloader(storageProvider)
-	newConfig := config.NewConfig(stateBootstrap, stateMigrator, stateMerger, stateDownloader, stderrLogger, afs)
+	stateUploader := config.NewUploader(storageProvider)
+	stateLocker := storage.NewLocker(globals.StateDir, afs)
+	stateHistory := storage.NewHistory(globals.StateDir, afs)
+	stateBundler := storage.NewBundler(globals.StateDir, Version, afs, stateEncryptor)
+	newConfig := config.NewConfig(stateBootstrap, stateMigrator, stateMerger, stateDownloader, stateLocker, stateBundler, stderrLogger, afs)
 
 	appConfig, err := newConfig.Bootstrap(globals, remainingArgs, len(os.Args))
 	if err != nil {
-		log.Fatalf("\n\n%s\n", err)
+		removeScratchDir()
+		fileLogger.Errorf("%s", err)
+		log.Fatal(errorMessage(err, globals.JSON))
+	}
+
+	// Mutating commands hold the state lock from bootstrap onwards, so release it on every exit path.
+	fatal := func(err error) {
+		stateLocker.Unlock() //nolint:errcheck
+		removeScratchDir()
+		fileLogger.Errorf("%s", err)
+		log.Fatal(redactedErrorMessage(redactor, err, globals.JSON))
+	}
+
+	if appConfig.CommandReachesIAAS {
+		credentialResolver := config.NewCredentialResolver(aws.NewProfileCredentials(), gcp.NewDefaultCredentials(), azure.NewIdentityCredentials())
+		appConfig.State, err = credentialResolver.Resolve(appConfig.State)
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	redactor.AddState(appConfig.State)
+	directorPassword, err := secretResolver.Resolve(appConfig.State.BOSH.DirectorPassword)
+	if err == nil {
+		redactor.Add(directorPassword)
+	}
+
+	if appConfig.CommandReachesIAAS {
+		err = secretResolver.Validate(appConfig.State)
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	// Commands that do not hold the state lock read a decrypted copy of vars/,
+	// so the files at rest are only ever decrypted under the lock.
+	varsDir := filepath.Join(appConfig.Global.StateDir, "vars")
+	if !appConfig.CommandLocksState {
+		var copyDir string
+		stateStore, copyDir, err = stateStore.UnsealVarsCopy()
+		if err != nil {
+			fatal(err)
+		}
+		if copyDir != "" {
+			varsDir = copyDir
+			removeStateDirCopy := removeScratchDir
+			removeScratchDir = func() {
+				os.RemoveAll(copyDir) //nolint:errcheck
+				removeStateDirCopy()
+			}
+		}
 	}
 
 	// Utilities
@@ -89,38 +188,42 @@ func main() {
 	certificateValidator := certs.NewValidator()
 	lbArgsHandler := commands.NewLBArgsHandler(certificateValidator)
 	sshCLI := ssh.NewCLI(os.Stdin, os.Stdout, os.Stderr)
+	scpCLI := ssh.NewSCPCLI(os.Stdin, os.Stdout, os.Stderr)
 	pathFinder := helpers.NewPathFinder()
 
 	// Terraform
 	terraformOutputBuffer := bytes.NewBuffer([]byte{})
 	dotTerraformDir := filepath.Join(appConfig.Global.StateDir, "terraform", ".terraform")
-	bufferingCLI := terraform.NewCLI(terraformOutputBuffer, terraformOutputBuffer, dotTerraformDir, globals.TerraformBinary, globals.DisableTfAutoApprove)
+	redactedOutputBuffer := redactor.Writer(terraformOutputBuffer)
+	bufferingCLI := terraform.NewCLI(redactedOutputBuffer, redactedOutputBuffer, dotTerraformDir, globals.TerraformBinary, globals.DisableTfAutoApprove)
 
-	var (
-		terraformCLI terraform.CLI
-		out          io.Writer
-	)
+	terraformCLI := bufferingCLI
+	out := logger.Writer("terraform", application.LevelDebug)
 	if appConfig.Global.Debug {
-		errBuffer := io.MultiWriter(os.Stderr, terraformOutputBuffer)
-		terraformCLI = terraform.NewCLI(errBuffer, terraformOutputBuffer, dotTerraformDir, globals.TerraformBinary, globals.DisableTfAutoApprove)
-		out = os.Stdout
-	} else {
-		terraformCLI = bufferingCLI
-		out = io.Discard
+		errBuffer := io.MultiWriter(stderrLogger.Writer("terraform", application.LevelDebug), redactedOutputBuffer)
+		terraformCLI = terraform.NewCLI(errBuffer, redactedOutputBuffer, dotTerraformDir, globals.TerraformBinary, globals.DisableTfAutoApprove)
+		// terraform asks for approval without ending the line, so it is
+		// not logged line by line.
+		if globals.DisableTfAutoApprove {
+			out = redactor.Writer(os.Stdout)
+		}
 	}
 	terraformExecutor := terraform.NewExecutor(terraformCLI, bufferingCLI, stateStore, afs, appConfig.Global.Debug, out)
+	terraformExecutor.RedactWith(redactor)
 
 	// BOSH
 	boshPath, err := config.GetBOSHPath()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
-	boshCommand := bosh.NewCLI(os.Stderr, boshPath)
+	boshCommand := bosh.NewCLI(stderrLogger.Writer("bosh", application.LevelInfo), boshPath)
 	boshExecutor := bosh.NewExecutor(boshCommand, afs)
+	boshExecutor.Stdout = logger.Writer("bosh", application.LevelInfo)
+	boshExecutor.Stderr = stderrLogger.Writer("bosh", application.LevelInfo)
 	sshKeyGetter := bosh.NewSSHKeyGetter(stateStore, afs)
 	allProxyGetter := bosh.NewAllProxyGetter(sshKeyGetter, afs)
 	credhubGetter := bosh.NewCredhubGetter(stateStore, afs)
-	boshCLIProvider := bosh.NewCLIProvider(allProxyGetter, boshPath)
+	boshCLIProvider := bosh.NewCLIProvider(allProxyGetter, secretResolver, boshPath)
 	boshManager := bosh.NewManager(boshExecutor, logger, stateStore, sshKeyGetter, afs, boshCLIProvider)
 
 	configUpdater := bosh.NewConfigUpdater(boshCLIProvider)
@@ -137,7 +240,7 @@ func main() {
 		awsClient aws.Client
 	)
 	// IF we could push this whole block down out of main somehow
-	if appConfig.CommandModifiesState {
+	if appConfig.CommandReachesIAAS {
 		switch appConfig.State.IAAS {
 		case "aws":
 			awsClient = aws.NewClient(appConfig.State.AWS, logger)
@@ -145,16 +248,15 @@ func main() {
 			networkDeletionValidator = awsClient
 			networkClient = awsClient
 
-			sessionToken := ""
-			leftovers, err = awsleftovers.NewLeftovers(logger, appConfig.State.AWS.AccessKeyID, appConfig.State.AWS.SecretAccessKey, sessionToken, appConfig.State.AWS.Region)
+			leftovers, err = awsleftovers.NewLeftovers(logger, appConfig.State.AWS.AccessKeyID, appConfig.State.AWS.SecretAccessKey, appConfig.State.AWS.SessionToken, appConfig.State.AWS.Region)
 			if err != nil {
-				log.Fatalf("\n\n%s\n", err)
+				fatal(err)
 			}
 
 		case "gcp":
 			gcpClient, err := gcp.NewClient(appConfig.State.GCP, "")
 			if err != nil {
-				log.Fatalf("\n\n%s\n", err)
+				fatal(err)
 			}
 
 			networkDeletionValidator = gcpClient
@@ -163,33 +265,39 @@ func main() {
 			gcpZonerHack := config.NewGCPZonerHack(gcpClient)
 			stateWithZones, err := gcpZonerHack.SetZones(appConfig.State)
 			if err != nil {
-				log.Fatalf("\n\n%s\n", err)
+				fatal(err)
 			}
 			appConfig.State = stateWithZones
 
-			leftovers, err = gcpleftovers.NewLeftovers(logger, appConfig.State.GCP.ServiceAccountKeyPath)
-			if err != nil {
-				log.Fatalf("\n\n%s\n", err)
+			// leftovers only takes a service account key.
+			if appConfig.State.GCP.ServiceAccountKeyPath != "" {
+				leftovers, err = gcpleftovers.NewLeftovers(logger, appConfig.State.GCP.ServiceAccountKeyPath)
+				if err != nil {
+					fatal(err)
+				}
 			}
 
 		case "azure":
 			azureClient, err := azure.NewClient(appConfig.State.Azure)
 			if err != nil {
-				log.Fatalf("\n\n%s\n", err)
+				fatal(err)
 			}
 
 			networkDeletionValidator = azureClient
 			networkClient = azureClient
 
-			leftovers, err = azureleftovers.NewLeftovers(logger, appConfig.State.Azure.ClientID, appConfig.State.Azure.ClientSecret, appConfig.State.Azure.SubscriptionID, appConfig.State.Azure.TenantID)
-			if err != nil {
-				log.Fatalf("\n\n%s\n", err)
+			// leftovers only takes a client secret.
+			if appConfig.State.Azure.ClientSecret != "" {
+				leftovers, err = azureleftovers.NewLeftovers(logger, appConfig.State.Azure.ClientID, appConfig.State.Azure.ClientSecret, appConfig.State.Azure.SubscriptionID, appConfig.State.Azure.TenantID)
+				if err != nil {
+					fatal(err)
+				}
 			}
 		case "vsphere":
 			vSphereLogger := application.NewLogger(os.Stdout, os.Stdin)
 			leftovers, err = vsphereleftovers.NewLeftovers(vSphereLogger, appConfig.State.VSphere.VCenterIP, appConfig.State.VSphere.VCenterUser, appConfig.State.VSphere.VCenterPassword, appConfig.State.VSphere.VCenterDC)
 			if err != nil {
-				log.Fatalf("\n\n%s\n", err)
+				fatal(err)
 			}
 		}
 	}
@@ -264,40 +372,177 @@ func main() {
 	if appConfig.State.IAAS != "" {
 		envIDManager = helpers.NewEnvIDManager(envIDGenerator, networkClient)
 	}
-	plan := commands.NewPlan(boshManager, cloudConfigManager, runtimeConfigManager, stateStore, patchDetector, envIDManager, terraformManager, lbArgsHandler, stderrLogger, Version)
-	up := commands.NewUp(plan, boshManager, cloudConfigManager, runtimeConfigManager, stateStore, terraformManager)
+	plan := commands.NewPlan(boshManager, cloudConfigManager, runtimeConfigManager, stateStore, patchDetector, envIDManager, terraformManager, lbArgsHandler, storage.NewDiffer(afs, globals.StateDir), stderrLogger, diffLogger, Version)
+	up := commands.NewUp(plan, boshManager, cloudConfigManager, runtimeConfigManager, stateStore, terraformManager, storage.NewFingerprinter(afs, globals.StateDir), logger)
 	usage := commands.NewUsage(logger)
 
 	commandSet := application.CommandSet{}
 	commandSet["help"] = usage
 	commandSet["version"] = commands.NewVersion(Version, logger)
 	commandSet["outputs"] = commands.NewOutputs(logger, terraformManager, stateValidator)
+	commandSet["env-info"] = commands.NewEnvInfo(logger, stateValidator, terraformManager, secretResolver)
 	commandSet["up"] = up
 	commandSet["plan"] = plan
 	sshKeyDeleter := bosh.NewSSHKeyDeleter(stateStore, afs)
-	commandSet["rotate"] = commands.NewRotate(stateValidator, sshKeyDeleter, up)
+	credentialRotator := bosh.NewCredentialRotator(stateStore, afs)
+	commandSet["rotate"] = commands.NewRotate(stateValidator, sshKeyDeleter, credentialRotator, stateStore, up, logger)
+	commandSet["upgrade"] = commands.NewUpgrade(stateValidator, boshManager, stateStore, up, logger)
 	commandSet["destroy"] = commands.NewDestroy(plan, logger, boshManager, stateStore, stateValidator, terraformManager, networkDeletionValidator)
 	commandSet["down"] = commandSet["destroy"]
 	commandSet["cleanup-leftovers"] = commands.NewCleanupLeftovers(leftovers)
 	commandSet["leftovers"] = commandSet["cleanup-leftovers"]
 	commandSet["lbs"] = commands.NewLBs(lbsCmd, stateValidator)
-	commandSet["jumpbox-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.JumpboxAddressPropertyName)
-	commandSet["director-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.DirectorAddressPropertyName)
-	commandSet["director-username"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.DirectorUsernamePropertyName)
-	commandSet["director-password"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.DirectorPasswordPropertyName)
-	commandSet["director-ca-cert"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.DirectorCACertPropertyName)
+	commandSet["jumpbox-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, secretResolver, commands.JumpboxAddressPropertyName)
+	commandSet["director-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, secretResolver, commands.DirectorAddressPropertyName)
+	commandSet["director-username"] = commands.NewStateQuery(logger, stateValidator, terraformManager, secretResolver, commands.DirectorUsernamePropertyName)
+	commandSet["director-password"] = commands.NewStateQuery(logger, stateValidator, terraformManager, secretResolver, commands.DirectorPasswordPropertyName)
+	commandSet["director-ca-cert"] = commands.NewStateQuery(logger, stateValidator, terraformManager, secretResolver, commands.DirectorCACertPropertyName)
 	commandSet["ssh-key"] = commands.NewSSHKey(logger, stateValidator, sshKeyGetter)
 	commandSet["validate"] = commands.NewValidate(plan, stateStore, terraformManager)
 	commandSet["director-ssh-key"] = commands.NewDirectorSSHKey(logger, stateValidator, sshKeyGetter)
-	commandSet["env-id"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.EnvIDPropertyName)
+	commandSet["env-id"] = commands.NewStateQuery(logger, stateValidator, terraformManager, secretResolver, commands.EnvIDPropertyName)
 	commandSet["latest-error"] = commands.NewLatestError(logger, stateValidator)
-	commandSet["print-env"] = commands.NewPrintEnv(logger, stderrLogger, stateValidator, allProxyGetter, credhubGetter, terraformManager, afs, envRendererFactory)
+	commandSet["drift"] = commands.NewDrift(logger, stateValidator, terraformManager)
+	commandSet["status"] = commands.NewStatus(logger, stateValidator, health.NewChecker(allProxyGetter, credhubGetter, afs, 30*time.Second), terraformManager)
+	bbrCLI := bbr.NewCLI(os.Stdout, os.Stderr)
+	backupStore := config.NewBackupStore(storageProvider, globals, stateEncryptionKey)
+	commandSet["backup-director"] = commands.NewBackupDirector(logger, stateValidator, bbrCLI, allProxyGetter, sshKeyGetter, afs, backupStore)
+	commandSet["restore-director"] = commands.NewRestoreDirector(logger, stateValidator, bbrCLI, allProxyGetter, sshKeyGetter, afs, backupStore)
+	commandSet["print-env"] = commands.NewPrintEnv(logger, stderrLogger, stateValidator, allProxyGetter, credhubGetter, secretResolver, terraformManager, afs, envRendererFactory)
 	commandSet["ssh"] = commands.NewSSH(logger, sshCLI, sshKeyGetter, pathFinder, afs, ssh.RandomPort{})
+	commandSet["scp"] = commands.NewSCP(logger, sshCLI, scpCLI, sshKeyGetter, pathFinder, afs, ssh.RandomPort{})
+	commandSet["tunnel"] = commands.NewTunnel(logger, sshCLI, sshKeyGetter, afs)
+	commandSet["config"] = commands.NewConfig(logger, config.NewFileValidator(afs, stateMerger, globals))
+	commandSet["state"] = commands.NewState(commands.StateSubcommands{
+		"encrypt":     commands.NewStateEncrypt(logger, stateValidator, stateStore),
+		"decrypt":     commands.NewStateDecrypt(logger, stateValidator, stateStore),
+		"unlock":      commands.NewStateUnlock(logger, stateLocker),
+		"history":     commands.NewStateHistory(logger, stateHistory),
+		"rollback":    commands.NewStateRollback(logger, stateHistory),
+		"migrate":     commands.NewStateMigrate(logger, stateMigrator),
+		"accept-edit": commands.NewStateAcceptEdit(logger, stateValidator, stateStore),
+		"export":      commands.NewStateExport(logger, stateValidator, stateBundler),
+		"import":      commands.NewStateImport(logger, plan),
+	})
 
 	app := application.New(commandSet, appConfig, usage)
 
+	if appConfig.CommandModifiesState {
+		err = stateHistory.Snapshot(config.CommandName(remainingArgs))
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	stopSealOnSignal := func() {}
+	if appConfig.CommandLocksState {
+		err = stateStore.UnsealVarsDir()
+		if err != nil {
+			fatal(err)
+		}
+
+		// vars/ stays decrypted while the command runs, so an interrupted
+		// bbl seals it again rather than leave the secrets in plaintext.
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		stopSealOnSignal = func() { signal.Stop(signals) }
+		go func() {
+			received := <-signals
+			err := stateStore.SealVarsDir()
+			if err != nil {
+				fileLogger.Errorf("%s", err)
+				log.Print(redactedErrorMessage(redactor, err, globals.JSON))
+			}
+			stateLocker.Unlock() //nolint:errcheck
+			removeScratchDir()
+			os.Exit(128 + int(received.(syscall.Signal)))
+		}()
+	}
+	for _, varsStore := range []string{"jumpbox-vars-store.yml", "director-vars-store.yml"} {
+		contents, err := afs.ReadFile(filepath.Join(varsDir, varsStore))
+		if err == nil {
+			redactor.AddVarsStore(string(contents))
+		}
+	}
+
 	err = app.Run()
+
+	stopSealOnSignal()
+	sealErr := stateStore.SealVarsDir()
+
+	// A failed command can still have changed the state, terraform may have
+	// applied part of the templates for example, so it is uploaded either way.
+	var uploadErr error
+	if appConfig.CommandModifiesState && globals.StateBucket != "" && !previewsPlan && sealErr == nil {
+		uploadErr = stateUploader.UploadState(globals, appConfig.Global.RemoteStateVersion)
+		if uploadErr != nil && err != nil {
+			fileLogger.Errorf("%s", uploadErr)
+			log.Print(redactedErrorMessage(redactor, uploadErr, globals.JSON))
+		}
+	}
+
+	var exitCodeErr commands.ExitCodeError
+	if errors.As(err, &exitCodeErr) && sealErr == nil {
+		stateLocker.Unlock() //nolint:errcheck
+		removeScratchDir()
+		fileLogger.Errorf("%s", err)
+		log.Print(redactedErrorMessage(redactor, err, globals.JSON))
+		os.Exit(exitCodeErr.Code)
+	}
 	if err != nil {
-		log.Fatalf("\n\n%s\n", err)
+		fatal(err)
+	}
+	if sealErr != nil {
+		fatal(sealErr)
+	}
+	if uploadErr != nil {
+		fatal(uploadErr)
 	}
+
+	err = stateLocker.Unlock()
+	removeScratchDir()
+	if err != nil {
+		log.Fatal(errorMessage(err, globals.JSON))
+	}
+}
+
+// configureLogger sets the level and format of the log from the global
+// flags, and tees it into bbl.log in the state dir with --log-file.
+func configureLogger(logger *application.Logger, globals config.GlobalFlags) error {
+	level, err := application.ParseLevel(globals.LogLevel)
+	if err != nil {
+		return err
+	}
+	if globals.Debug {
+		level = application.LevelDebug
+	}
+	logger.SetLevel(level)
+
+	switch globals.LogFormat {
+	case "text":
+	case "json":
+		logger.UseJSON()
+	default:
+		return fmt.Errorf("unknown log format %q, use text or json", globals.LogFormat)
+	}
+
+	if globals.LogFile {
+		logger.TeeTo(application.NewLogFile(filepath.Join(globals.StateDir, storage.LOG_FILE), 10<<20, 3))
+	}
+	return nil
+}
+
+// errorMessage is how bbl prints err before exiting, as a JSON document when
+// it is run with --json.
+func errorMessage(err error, asJSON bool) string {
+	if asJSON {
+		return commands.JSONError(err)
+	}
+	return fmt.Sprintf("\n\n%s\n", err)
+}
+
+// redactedErrorMessage redacts err before errorMessage encodes it, since a
+// secret no longer matches once JSON has escaped it.
+func redactedErrorMessage(redactor *application.Redactor, err error, asJSON bool) string {
+	return errorMessage(errors.New(redactor.Redact(err.Error())), asJSON)
 }
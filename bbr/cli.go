@@ -0,0 +1,36 @@
+package bbr
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CLI runs BOSH Backup and Restore, which has to be on the PATH.
+type CLI struct {
+	out io.Writer
+	err io.Writer
+}
+
+func NewCLI(out, err io.Writer) CLI {
+	return CLI{
+		out: out,
+		err: err,
+	}
+}
+
+// Run executes bbr in workingDirectory, adding env to bbl's environment.
+func (c CLI) Run(env []string, workingDirectory string, args []string) error {
+	fmt.Fprintf(c.out, "running:\nbbr %s\n", strings.Join(args, " ")) //nolint:errcheck
+
+	command := exec.Command("bbr", args...)
+	command.Dir = workingDirectory
+	command.Env = append(os.Environ(), env...)
+
+	command.Stdout = c.out
+	command.Stderr = c.err
+
+	return command.Run()
+}
@@ -8,9 +8,14 @@ import (
 
 type CLIProvider struct {
 	allProxyGetter allProxyGetter
+	secretResolver secretResolver
 	boshCLIPath    string
 }
 
+type secretResolver interface {
+	Resolve(value string) (string, error)
+}
+
 type allProxyGetter interface {
 	GeneratePrivateKey() (string, error)
 	BoshAllProxy(string, string) string
@@ -20,14 +25,20 @@ type AuthenticatedCLIRunner interface {
 	Run(stdout io.Writer, workingDirectory string, args []string) error
 }
 
-func NewCLIProvider(allProxyGetter allProxyGetter, boshCLIPath string) CLIProvider {
+func NewCLIProvider(allProxyGetter allProxyGetter, secretResolver secretResolver, boshCLIPath string) CLIProvider {
 	return CLIProvider{
 		allProxyGetter: allProxyGetter,
+		secretResolver: secretResolver,
 		boshCLIPath:    boshCLIPath,
 	}
 }
 
 func (c CLIProvider) AuthenticatedCLI(jumpbox storage.Jumpbox, stderr io.Writer, directorAddress, directorUsername, directorPassword, directorCACert string) (AuthenticatedCLIRunner, error) {
+	directorPassword, err := c.secretResolver.Resolve(directorPassword)
+	if err != nil {
+		return AuthenticatedCLI{}, err
+	}
+
 	privateKey, err := c.allProxyGetter.GeneratePrivateKey()
 	if err != nil {
 		return AuthenticatedCLI{}, err
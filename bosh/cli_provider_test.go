@@ -13,13 +13,15 @@ import (
 var _ = Describe("Client Provider", func() {
 	var (
 		allProxyGetter *fakes.AllProxyGetter
+		secretResolver *fakes.SecretResolver
 		cliProvider    bosh.CLIProvider
 	)
 
 	BeforeEach(func() {
 		allProxyGetter = &fakes.AllProxyGetter{}
+		secretResolver = &fakes.SecretResolver{}
 
-		cliProvider = bosh.NewCLIProvider(allProxyGetter, "some-path-to-bosh")
+		cliProvider = bosh.NewCLIProvider(allProxyGetter, secretResolver, "some-path-to-bosh")
 	})
 
 	Describe("AuthenticatedCLI", func() {
@@ -40,6 +42,25 @@ var _ = Describe("Client Provider", func() {
 			Expect(allProxyGetter.BoshAllProxyCall.Receives.JumpboxURL).To(Equal("jumpbox@some-jumpbox:22"))
 		})
 
+		Context("when the director password is kept in a secret store", func() {
+			It("authenticates with the resolved password", func() {
+				secretResolver.ResolveCall.Stub = func(string) (string, error) { return "some-resolved-password", nil }
+
+				cliRunner, err := cliProvider.AuthenticatedCLI(storage.Jumpbox{URL: "some-jumpbox:22"}, nil, "some-address", "some-username", "secret:director-password", "some-fake-ca")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(secretResolver.ResolveCall.Receives.Value).To(Equal("secret:director-password"))
+				Expect(cliRunner.(bosh.AuthenticatedCLI).GlobalArgs).To(ContainElement("some-resolved-password"))
+			})
+
+			It("returns an error when the password cannot be resolved", func() {
+				secretResolver.ResolveCall.Stub = func(string) (string, error) { return "", errors.New("vault is sealed") }
+
+				_, err := cliProvider.AuthenticatedCLI(storage.Jumpbox{URL: "some-jumpbox:22"}, nil, "some-address", "some-username", "secret:director-password", "some-fake-ca")
+				Expect(err).To(MatchError("vault is sealed"))
+			})
+		})
+
 		Context("when it can not get the correct key", func() {
 			It("Errors", func() {
 				allProxyGetter.GeneratePrivateKeyCall.Returns.Error = errors.New("fruit")
@@ -0,0 +1,336 @@
+package bosh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	"gopkg.in/yaml.v2"
+)
+
+// directorCredentials are regenerated by bosh create-env once they are
+// deleted from the director vars store.
+var directorCredentials = []string{
+	"admin_password",
+	"uaa_admin_client_secret",
+	"credhub_admin_client_secret",
+}
+
+// rotatedCAs are the director CAs that deployments may trust, with the
+// certificates that they sign.
+var rotatedCAs = []struct {
+	name   string
+	leaves []string
+}{
+	{"default_ca", []string{"director_ssl", "mbus_bootstrap_ssl", "uaa_ssl", "uaa_service_provider_ssl"}},
+	{"credhub_ca", []string{"credhub_tls"}},
+}
+
+// jumpboxCertificates are only trusted by bbl itself, so they are
+// regenerated at once.
+var jumpboxCertificates = []string{"default_ca", "mbus_bootstrap_ssl"}
+
+const (
+	newCASuffix  = "_new"
+	caValidity   = 365 * 24 * time.Hour
+	caKeyBits    = 2048
+	caSerialBits = 128
+)
+
+type vars map[string]interface{}
+
+type CredentialRotator struct {
+	stateStore stateStore
+	fs         deleterFs
+}
+
+func NewCredentialRotator(stateStore stateStore, fs deleterFs) CredentialRotator {
+	return CredentialRotator{
+		stateStore: stateStore,
+		fs:         fs,
+	}
+}
+
+// RotateDirectorCredentials deletes the director passwords and client
+// secrets so that the next bosh create-env generates new ones.
+func (c CredentialRotator) RotateDirectorCredentials() error {
+	return c.edit("director-vars-store.yml", func(v vars) error {
+		for _, name := range directorCredentials {
+			delete(v, name)
+		}
+		return nil
+	})
+}
+
+// RotateCertificates moves the CA rotation on from stage and returns the
+// stage it reached. The director CAs are rotated in three stages, each
+// followed by a bosh create-env, so that deployments can pick up the new
+// CAs before the director stops presenting certificates from the old ones:
+// the new CAs are trusted next to the old ones, then they sign the director
+// certificates, then the old CAs are dropped.
+func (c CredentialRotator) RotateCertificates(stage string) (string, error) {
+	switch stage {
+	case "":
+		err := c.edit("jumpbox-vars-store.yml", func(v vars) error {
+			for _, name := range jumpboxCertificates {
+				delete(v, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return stage, err
+		}
+
+		err = c.edit("director-vars-store.yml", trustNewCAs)
+		if err != nil {
+			return stage, err
+		}
+		return storage.CARotationNewCATrusted, nil
+	case storage.CARotationNewCATrusted:
+		err := c.edit("director-vars-store.yml", signWithNewCAs)
+		if err != nil {
+			return stage, err
+		}
+		return storage.CARotationNewCASigning, nil
+	case storage.CARotationNewCASigning:
+		err := c.edit("director-vars-store.yml", dropOldCAs)
+		if err != nil {
+			return stage, err
+		}
+		return "", nil
+	default:
+		return stage, fmt.Errorf("Unknown CA rotation stage %q", stage) //nolint:staticcheck
+	}
+}
+
+func (c CredentialRotator) edit(file string, change func(vars) error) error {
+	varsDir, err := c.stateStore.GetVarsDir()
+	if err != nil {
+		return fmt.Errorf("Get vars directory: %s", err) //nolint:staticcheck
+	}
+
+	varsStore := filepath.Join(varsDir, file)
+	contents, err := c.fs.ReadFile(varsStore)
+	if err != nil {
+		return fmt.Errorf("Read %s file: %s", file, err) //nolint:staticcheck
+	}
+
+	v := vars{}
+	err = yaml.Unmarshal(contents, &v)
+	if err != nil {
+		return fmt.Errorf("Parse %s file: %s", file, err) //nolint:staticcheck
+	}
+
+	err = change(v)
+	if err != nil {
+		return fmt.Errorf("Rotate %s: %s", file, err) //nolint:staticcheck
+	}
+
+	newContents, err := yaml.Marshal(v)
+	if err != nil {
+		return err // not tested
+	}
+	if string(newContents) == string(contents) {
+		return nil
+	}
+
+	err = c.fs.WriteFile(varsStore, newContents, storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write %s file: %s", file, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+// trustNewCAs generates the new CAs and adds them to the trusted
+// certificates, leaving the old CAs to sign. A new CA left by an earlier
+// attempt at this stage is trusted again rather than replaced.
+func trustNewCAs(v vars) error {
+	for _, ca := range rotatedCAs {
+		entry, ok := v.entry(ca.name)
+		if !ok {
+			continue
+		}
+
+		oldCA, err := firstCertificate(entry, ca.name)
+		if err != nil {
+			return err
+		}
+
+		var newCA string
+		if next, ok := v.entry(ca.name + newCASuffix); ok {
+			newCA, err = firstCertificate(next, ca.name+newCASuffix)
+			if err != nil {
+				return err
+			}
+		} else {
+			var newKey string
+			newCA, newKey, err = generateCA(oldCA)
+			if err != nil {
+				return fmt.Errorf("generate %s: %s", ca.name, err)
+			}
+
+			v[ca.name+newCASuffix] = map[interface{}]interface{}{
+				"ca":          newCA,
+				"certificate": newCA,
+				"private_key": newKey,
+			}
+		}
+
+		trusted := bundle(oldCA, newCA)
+		entry["certificate"] = trusted
+		entry["ca"] = trusted
+		v.setCA(ca.leaves, trusted)
+	}
+	return nil
+}
+
+// signWithNewCAs makes the new CAs sign and deletes the certificates signed
+// by the old ones so that bosh create-env issues them again. After an
+// earlier attempt at this stage the new CAs already sign, and only the
+// certificates are deleted again.
+func signWithNewCAs(v vars) error {
+	for _, ca := range rotatedCAs {
+		entry, ok := v.entry(ca.name)
+		if !ok {
+			continue
+		}
+
+		next, ok := v.entry(ca.name + newCASuffix)
+		if !ok && isBundle(entry) {
+			for _, leaf := range ca.leaves {
+				delete(v, leaf)
+			}
+			continue
+		}
+		if !ok {
+			return fmt.Errorf("%s has no new CA to sign with", ca.name)
+		}
+
+		oldCA, err := firstCertificate(entry, ca.name)
+		if err != nil {
+			return err
+		}
+		newCA, err := firstCertificate(next, ca.name+newCASuffix)
+		if err != nil {
+			return err
+		}
+
+		trusted := bundle(newCA, oldCA)
+		entry["certificate"] = trusted
+		entry["ca"] = trusted
+		entry["private_key"] = next["private_key"]
+		delete(v, ca.name+newCASuffix)
+
+		for _, leaf := range ca.leaves {
+			delete(v, leaf)
+		}
+	}
+	return nil
+}
+
+// dropOldCAs stops trusting the old CAs.
+func dropOldCAs(v vars) error {
+	for _, ca := range rotatedCAs {
+		entry, ok := v.entry(ca.name)
+		if !ok {
+			continue
+		}
+
+		newCA, err := firstCertificate(entry, ca.name)
+		if err != nil {
+			return err
+		}
+
+		entry["certificate"] = newCA
+		entry["ca"] = newCA
+		v.setCA(ca.leaves, newCA)
+	}
+	return nil
+}
+
+func (v vars) entry(name string) (map[interface{}]interface{}, bool) {
+	entry, ok := v[name].(map[interface{}]interface{})
+	return entry, ok
+}
+
+func (v vars) setCA(names []string, ca string) {
+	for _, name := range names {
+		if entry, ok := v.entry(name); ok {
+			entry["ca"] = ca
+		}
+	}
+}
+
+// firstCertificate returns the signing certificate of a CA, which comes
+// first when it is a bundle.
+func firstCertificate(entry map[interface{}]interface{}, name string) (string, error) {
+	certificate, _ := entry["certificate"].(string)
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil {
+		return "", fmt.Errorf("%s has no certificate", name)
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// isBundle is true when a CA trusts more than its own certificate, which is
+// the case between the first and last stage of a rotation.
+func isBundle(entry map[interface{}]interface{}) bool {
+	certificate, _ := entry["certificate"].(string)
+	return strings.Count(certificate, "-----BEGIN CERTIFICATE-----") > 1
+}
+
+func bundle(certificates ...string) string {
+	var pems []string
+	for _, certificate := range certificates {
+		pems = append(pems, strings.TrimSpace(certificate))
+	}
+	return strings.Join(pems, "\n") + "\n"
+}
+
+// generateCA creates a CA with the same subject as the one it replaces.
+func generateCA(oldCA string) (string, string, error) {
+	block, _ := pem.Decode([]byte(oldCA))
+	old, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, caKeyBits)
+	if err != nil {
+		return "", "", err // not tested
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), caSerialBits))
+	if err != nil {
+		return "", "", err // not tested
+	}
+
+	now := time.Now().UTC()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               old.Subject,
+		NotBefore:             now,
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err // not tested
+	}
+
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(certificate), string(privateKey), nil
+}
@@ -0,0 +1,349 @@
+package bosh_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("CredentialRotator", func() {
+	var (
+		credentialRotator bosh.CredentialRotator
+		stateStore        *fakes.StateStore
+		fileIO            *fakes.FileIO
+		files             map[string]string
+
+		directorVarsStore string
+		jumpboxVarsStore  string
+	)
+
+	readVars := func(path string) map[string]map[string]string {
+		var vars map[string]interface{}
+		Expect(yaml.Unmarshal([]byte(files[path]), &vars)).To(Succeed())
+
+		entries := map[string]map[string]string{}
+		for name, value := range vars {
+			entry, ok := value.(map[interface{}]interface{})
+			if !ok {
+				continue
+			}
+			entries[name] = map[string]string{}
+			for key, field := range entry {
+				entries[name][key.(string)] = field.(string)
+			}
+		}
+		return entries
+	}
+
+	certificates := func(bundle string) []*x509.Certificate {
+		var parsed []*x509.Certificate
+		rest := []byte(bundle)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				return parsed
+			}
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			parsed = append(parsed, certificate)
+		}
+	}
+
+	BeforeEach(func() {
+		stateStore = &fakes.StateStore{}
+		stateStore.GetVarsDirCall.Returns.Directory = "some-vars-dir"
+
+		directorVarsStore = filepath.Join("some-vars-dir", "director-vars-store.yml")
+		jumpboxVarsStore = filepath.Join("some-vars-dir", "jumpbox-vars-store.yml")
+
+		defaultCA, defaultCAKey := generateTestCA("ca")
+		credhubCA, credhubCAKey := generateTestCA("CredHub CA")
+
+		directorVars, err := yaml.Marshal(map[string]interface{}{
+			"admin_password":              "some-admin-password",
+			"uaa_admin_client_secret":     "some-uaa-secret",
+			"credhub_admin_client_secret": "some-credhub-secret",
+			"nats_password":               "some-nats-password",
+			"default_ca": map[string]string{
+				"ca":          defaultCA,
+				"certificate": defaultCA,
+				"private_key": defaultCAKey,
+			},
+			"director_ssl": map[string]string{
+				"ca":          defaultCA,
+				"certificate": "some-director-certificate",
+				"private_key": "some-director-key",
+			},
+			"uaa_ssl": map[string]string{
+				"ca":          defaultCA,
+				"certificate": "some-uaa-certificate",
+				"private_key": "some-uaa-key",
+			},
+			"credhub_ca": map[string]string{
+				"ca":          credhubCA,
+				"certificate": credhubCA,
+				"private_key": credhubCAKey,
+			},
+			"credhub_tls": map[string]string{
+				"ca":          credhubCA,
+				"certificate": "some-credhub-certificate",
+				"private_key": "some-credhub-key",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		files = map[string]string{
+			directorVarsStore: string(directorVars),
+			jumpboxVarsStore:  "jumpbox_ssh:\n  private_key: some-ssh-key\ndefault_ca:\n  certificate: some-jumpbox-ca\nmbus_bootstrap_ssl:\n  certificate: some-mbus-certificate\n",
+		}
+
+		fileIO = &fakes.FileIO{}
+		fileIO.ReadFileCall.Fake = func(path string) ([]byte, error) {
+			contents, ok := files[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(contents), nil
+		}
+
+		credentialRotator = bosh.NewCredentialRotator(stateStore, fileIO)
+	})
+
+	// apply writes what the rotator wrote to the fake files.
+	apply := func() {
+		for _, write := range fileIO.WriteFileCall.Receives {
+			files[write.Filename] = string(write.Contents)
+		}
+		fileIO.WriteFileCall.Receives = nil
+	}
+
+	Describe("RotateDirectorCredentials", func() {
+		It("deletes the director credentials from the director vars store", func() {
+			err := credentialRotator.RotateDirectorCredentials()
+			Expect(err).NotTo(HaveOccurred())
+			apply()
+
+			var vars map[string]interface{}
+			Expect(yaml.Unmarshal([]byte(files[directorVarsStore]), &vars)).To(Succeed())
+			Expect(vars).NotTo(HaveKey("admin_password"))
+			Expect(vars).NotTo(HaveKey("uaa_admin_client_secret"))
+			Expect(vars).NotTo(HaveKey("credhub_admin_client_secret"))
+			Expect(vars).To(HaveKeyWithValue("nats_password", "some-nats-password"))
+			Expect(vars).To(HaveKey("default_ca"))
+		})
+
+		Context("when the director vars store does not exist", func() {
+			BeforeEach(func() {
+				delete(files, directorVarsStore)
+			})
+
+			It("returns an error", func() {
+				err := credentialRotator.RotateDirectorCredentials()
+				Expect(err).To(MatchError(ContainSubstring("Read director-vars-store.yml file")))
+			})
+		})
+
+		Context("when the vars dir cannot be found", func() {
+			BeforeEach(func() {
+				stateStore.GetVarsDirCall.Returns.Error = errors.New("banana")
+			})
+
+			It("returns an error", func() {
+				err := credentialRotator.RotateDirectorCredentials()
+				Expect(err).To(MatchError("Get vars directory: banana"))
+			})
+		})
+
+		Context("when writing the vars store fails", func() {
+			BeforeEach(func() {
+				fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("cherry")}}
+			})
+
+			It("returns an error", func() {
+				err := credentialRotator.RotateDirectorCredentials()
+				Expect(err).To(MatchError("Write director-vars-store.yml file: cherry"))
+			})
+		})
+	})
+
+	Describe("RotateCertificates", func() {
+		var (
+			oldDefaultCA string
+			oldCredhubCA string
+		)
+
+		BeforeEach(func() {
+			vars := readVars(directorVarsStore)
+			oldDefaultCA = vars["default_ca"]["certificate"]
+			oldCredhubCA = vars["credhub_ca"]["certificate"]
+		})
+
+		It("goes through the transitional CA stages", func() {
+			By("trusting new CAs next to the old ones", func() {
+				stage, err := credentialRotator.RotateCertificates("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stage).To(Equal(storage.CARotationNewCATrusted))
+				apply()
+
+				jumpboxVars := readVars(jumpboxVarsStore)
+				Expect(jumpboxVars).To(HaveKey("jumpbox_ssh"))
+				Expect(jumpboxVars).NotTo(HaveKey("default_ca"))
+				Expect(jumpboxVars).NotTo(HaveKey("mbus_bootstrap_ssl"))
+
+				vars := readVars(directorVarsStore)
+				newCA := vars["default_ca_new"]["certificate"]
+				Expect(newCA).NotTo(Equal(oldDefaultCA))
+				Expect(certificates(newCA)[0].Subject.CommonName).To(Equal("ca"))
+				Expect(certificates(newCA)[0].IsCA).To(BeTrue())
+
+				trusted := oldDefaultCA + newCA
+				Expect(vars["default_ca"]["certificate"]).To(Equal(trusted))
+				Expect(vars["default_ca"]["ca"]).To(Equal(trusted))
+				Expect(vars["director_ssl"]["ca"]).To(Equal(trusted))
+				Expect(vars["director_ssl"]["certificate"]).To(Equal("some-director-certificate"))
+				Expect(vars["uaa_ssl"]["ca"]).To(Equal(trusted))
+
+				Expect(vars["credhub_tls"]["ca"]).To(Equal(oldCredhubCA + vars["credhub_ca_new"]["certificate"]))
+			})
+
+			var newDefaultCA, newDefaultCAKey string
+			By("signing with the new CAs", func() {
+				vars := readVars(directorVarsStore)
+				newDefaultCA = vars["default_ca_new"]["certificate"]
+				newDefaultCAKey = vars["default_ca_new"]["private_key"]
+
+				stage, err := credentialRotator.RotateCertificates(storage.CARotationNewCATrusted)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stage).To(Equal(storage.CARotationNewCASigning))
+				apply()
+
+				vars = readVars(directorVarsStore)
+				Expect(vars).NotTo(HaveKey("default_ca_new"))
+				Expect(vars).NotTo(HaveKey("credhub_ca_new"))
+				Expect(vars["default_ca"]["certificate"]).To(Equal(newDefaultCA + oldDefaultCA))
+				Expect(vars["default_ca"]["private_key"]).To(Equal(newDefaultCAKey))
+				Expect(vars).NotTo(HaveKey("director_ssl"))
+				Expect(vars).NotTo(HaveKey("uaa_ssl"))
+				Expect(vars).NotTo(HaveKey("credhub_tls"))
+			})
+
+			By("dropping the old CAs", func() {
+				files[directorVarsStore] = strings.Replace(files[directorVarsStore], "default_ca:", "director_ssl:\n  ca: some-bundle\n  certificate: some-new-director-certificate\ndefault_ca:", 1)
+
+				stage, err := credentialRotator.RotateCertificates(storage.CARotationNewCASigning)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stage).To(Equal(""))
+				apply()
+
+				vars := readVars(directorVarsStore)
+				Expect(vars["default_ca"]["certificate"]).To(Equal(newDefaultCA))
+				Expect(vars["default_ca"]["ca"]).To(Equal(newDefaultCA))
+				Expect(vars["default_ca"]["private_key"]).To(Equal(newDefaultCAKey))
+				Expect(vars["director_ssl"]["ca"]).To(Equal(newDefaultCA))
+				Expect(vars["director_ssl"]["certificate"]).To(Equal("some-new-director-certificate"))
+			})
+		})
+
+		Context("when a stage is retried after bbl up failed", func() {
+			It("ends up where the first attempt did", func() {
+				By("trusting the same new CAs again", func() {
+					_, err := credentialRotator.RotateCertificates("")
+					Expect(err).NotTo(HaveOccurred())
+					apply()
+					first := readVars(directorVarsStore)
+
+					stage, err := credentialRotator.RotateCertificates("")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stage).To(Equal(storage.CARotationNewCATrusted))
+					apply()
+
+					vars := readVars(directorVarsStore)
+					Expect(vars["default_ca_new"]).To(Equal(first["default_ca_new"]))
+					Expect(vars["default_ca"]["certificate"]).To(Equal(oldDefaultCA + first["default_ca_new"]["certificate"]))
+				})
+
+				By("deleting the certificates the new CAs sign again", func() {
+					newDefaultCAKey := readVars(directorVarsStore)["default_ca_new"]["private_key"]
+
+					_, err := credentialRotator.RotateCertificates(storage.CARotationNewCATrusted)
+					Expect(err).NotTo(HaveOccurred())
+					apply()
+					files[directorVarsStore] = strings.Replace(files[directorVarsStore], "default_ca:", "director_ssl:\n  ca: some-bundle\n  certificate: some-half-deployed-certificate\ndefault_ca:", 1)
+
+					stage, err := credentialRotator.RotateCertificates(storage.CARotationNewCATrusted)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stage).To(Equal(storage.CARotationNewCASigning))
+					apply()
+
+					vars := readVars(directorVarsStore)
+					Expect(vars).NotTo(HaveKey("director_ssl"))
+					Expect(vars["default_ca"]["private_key"]).To(Equal(newDefaultCAKey))
+				})
+			})
+		})
+
+		Context("when the new CA is missing when signing", func() {
+			It("returns an error", func() {
+				_, err := credentialRotator.RotateCertificates(storage.CARotationNewCATrusted)
+				Expect(err).To(MatchError("Rotate director-vars-store.yml: default_ca has no new CA to sign with"))
+			})
+		})
+
+		Context("when a CA has no certificate", func() {
+			BeforeEach(func() {
+				files[directorVarsStore] = "default_ca:\n  certificate: not-a-certificate\n"
+			})
+
+			It("returns an error", func() {
+				_, err := credentialRotator.RotateCertificates("")
+				Expect(err).To(MatchError("Rotate director-vars-store.yml: default_ca has no certificate"))
+			})
+		})
+
+		Context("when the stage is unknown", func() {
+			It("returns an error", func() {
+				stage, err := credentialRotator.RotateCertificates("some-stage")
+				Expect(err).To(MatchError(`Unknown CA rotation stage "some-stage"`))
+				Expect(stage).To(Equal("some-stage"))
+			})
+		})
+	})
+})
+
+func generateTestCA(commonName string) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(certificate), string(privateKey)
+}
@@ -0,0 +1,226 @@
+package bosh
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// DeploymentUpgrade lists how the jumpbox-deployment and bosh-deployment
+// embedded in bbl differ from the copies in the state dir.
+type DeploymentUpgrade struct {
+	Jumpbox  []DeploymentChange
+	Director []DeploymentChange
+}
+
+// DeploymentChange is a file of a deployment that bbl upgrade would add or
+// replace, with the releases and stemcells it moves to another version.
+type DeploymentChange struct {
+	Path     string
+	Added    bool
+	Versions []VersionChange
+}
+
+// VersionChange is a release or stemcell version that bbl upgrade would
+// change. From is empty for a release or stemcell that is new.
+type VersionChange struct {
+	Name string
+	From string
+	To   string
+}
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+func (d DeploymentUpgrade) IsEmpty() bool {
+	return len(d.Jumpbox) == 0 && len(d.Director) == 0
+}
+
+// PreviewUpgrade compares the deployments in the state dir with the ones
+// embedded in bbl.
+func (m *Manager) PreviewUpgrade() (DeploymentUpgrade, error) {
+	jumpboxDeploymentDir, err := m.stateStore.GetJumpboxDeploymentDir()
+	if err != nil {
+		return DeploymentUpgrade{}, err
+	}
+
+	directorDeploymentDir, err := m.stateStore.GetDirectorDeploymentDir()
+	if err != nil {
+		return DeploymentUpgrade{}, err
+	}
+
+	var upgrade DeploymentUpgrade
+	upgrade.Jumpbox, err = m.executor.DiffDeployment(jumpboxDeploymentRepo, jumpboxDeploymentDir)
+	if err != nil {
+		return DeploymentUpgrade{}, fmt.Errorf("Compare %s: %s", jumpboxDeploymentRepo, err) //nolint:staticcheck
+	}
+
+	upgrade.Director, err = m.executor.DiffDeployment(boshDeploymentRepo, directorDeploymentDir)
+	if err != nil {
+		return DeploymentUpgrade{}, fmt.Errorf("Compare %s: %s", boshDeploymentRepo, err) //nolint:staticcheck
+	}
+
+	return upgrade, nil
+}
+
+// Upgrade replaces the deployments in the state dir with the ones embedded
+// in bbl. Files that bbl no longer embeds are left in place.
+func (m *Manager) Upgrade() error {
+	jumpboxDeploymentDir, err := m.stateStore.GetJumpboxDeploymentDir()
+	if err != nil {
+		return err
+	}
+
+	directorDeploymentDir, err := m.stateStore.GetDirectorDeploymentDir()
+	if err != nil {
+		return err
+	}
+
+	err = m.executor.WriteDeployment(jumpboxDeploymentRepo, jumpboxDeploymentDir)
+	if err != nil {
+		return fmt.Errorf("Write %s: %s", jumpboxDeploymentRepo, err) //nolint:staticcheck
+	}
+
+	err = m.executor.WriteDeployment(boshDeploymentRepo, directorDeploymentDir)
+	if err != nil {
+		return fmt.Errorf("Write %s: %s", boshDeploymentRepo, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+// WriteDeployment writes the embedded repo into deploymentDir.
+func (e Executor) WriteDeployment(repo, deploymentDir string) error {
+	for _, f := range e.getSetupFiles(repo, deploymentDir) {
+		err := e.FS.MkdirAll(filepath.Dir(f.dest), storage.StateMode)
+		if err != nil {
+			return err
+		}
+		err = e.FS.WriteFile(f.dest, f.contents, storage.StateMode)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiffDeployment lists the files of the embedded repo that are missing from
+// deploymentDir or differ from the copies there.
+func (e Executor) DiffDeployment(repo, deploymentDir string) ([]DeploymentChange, error) {
+	var changes []DeploymentChange
+	for _, f := range e.getSetupFiles(repo, deploymentDir) {
+		relativePath, err := filepath.Rel(deploymentDir, f.dest)
+		if err != nil {
+			return nil, err // not tested
+		}
+
+		current, err := e.FS.ReadFile(f.dest)
+		if os.IsNotExist(err) {
+			changes = append(changes, DeploymentChange{
+				Path:     filepath.ToSlash(relativePath),
+				Added:    true,
+				Versions: versionChanges(nil, f.contents),
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(current, f.contents) {
+			continue
+		}
+
+		changes = append(changes, DeploymentChange{
+			Path:     filepath.ToSlash(relativePath),
+			Versions: versionChanges(current, f.contents),
+		})
+	}
+	return changes, nil
+}
+
+func (e Executor) hasDeployment(deploymentDir, manifest string) bool {
+	_, err := e.FS.Stat(filepath.Join(deploymentDir, manifest))
+	return err == nil
+}
+
+func versionChanges(current, embedded []byte) []VersionChange {
+	from := versions(current)
+	to := versions(embedded)
+
+	var changes []VersionChange
+	for name, version := range to {
+		if from[name] != version {
+			changes = append(changes, VersionChange{Name: name, From: from[name], To: version})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// versions finds the releases and stemcells of a manifest or ops file, which
+// are the entries with a url and a sha1.
+func versions(contents []byte) map[string]string {
+	found := map[string]string{}
+
+	var document interface{}
+	if yaml.Unmarshal(contents, &document) != nil {
+		return found
+	}
+
+	collectVersions(document, found)
+	return found
+}
+
+func collectVersions(node interface{}, found map[string]string) {
+	switch n := node.(type) {
+	case []interface{}:
+		for _, item := range n {
+			collectVersions(item, found)
+		}
+	case map[interface{}]interface{}:
+		artifactURL, _ := n["url"].(string)
+		if _, ok := n["sha1"]; ok && artifactURL != "" {
+			name, version := artifactVersion(n, artifactURL)
+			if version != "" {
+				found[name] = version
+			}
+			return
+		}
+
+		for _, value := range n {
+			collectVersions(value, found)
+		}
+	}
+}
+
+// artifactVersion names a release by its name and version. Stemcells only
+// have a url, which carries the version either as a v parameter or in the
+// file name.
+func artifactVersion(artifact map[interface{}]interface{}, artifactURL string) (string, string) {
+	name, _ := artifact["name"].(string)
+	if name == "" {
+		name = "stemcell"
+	}
+
+	if version, ok := artifact["version"]; ok && version != nil {
+		return name, fmt.Sprint(version)
+	}
+
+	parsed, err := url.Parse(artifactURL)
+	if err != nil {
+		return name, ""
+	}
+	if version := parsed.Query().Get("v"); version != "" {
+		return name, version
+	}
+	return name, versionPattern.FindString(path.Base(parsed.Path))
+}
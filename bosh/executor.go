@@ -21,6 +21,7 @@ type executorFs interface {
 	fileio.FileReader
 	fileio.FileWriter
 	fileio.Stater
+	fileio.AllMkdirer
 }
 
 type Executor struct {
@@ -28,6 +29,8 @@ type Executor struct {
 	FS              executorFs
 	EmbedData       embed.FS
 	EmbedDataPrefix string
+	Stdout          io.Writer
+	Stderr          io.Writer
 }
 
 type DirInput struct {
@@ -61,6 +64,8 @@ func NewExecutor(cmd cli, fs executorFs) Executor {
 		FS:              fs,
 		EmbedData:       content,
 		EmbedDataPrefix: "deployments/",
+		Stdout:          os.Stdout,
+		Stderr:          os.Stderr,
 	}
 }
 func extractNestedFiles(fs embed.FS, fileList []setupFile, path string, trimPrefix string, destPath string, source_entries ...fs.DirEntry) []setupFile {
@@ -105,12 +110,9 @@ func (e Executor) PlanJumpbox(input DirInput, deploymentDir, iaas string) error
 }
 
 func (e Executor) PlanJumpboxWithState(input DirInput, deploymentDir, iaas string, state storage.State) error {
-	setupFiles := e.getSetupFiles(jumpboxDeploymentRepo, deploymentDir)
-
-	for _, f := range setupFiles {
-		// ignore error if dir already exists
-		os.MkdirAll(filepath.Dir(f.dest), os.ModePerm) //nolint:errcheck
-		err := e.FS.WriteFile(f.dest, f.contents, storage.StateMode)
+	// Once jumpbox-deployment is in the state dir only bbl upgrade replaces it.
+	if !e.hasDeployment(deploymentDir, "jumpbox.yml") {
+		err := e.WriteDeployment(jumpboxDeploymentRepo, deploymentDir)
 		if err != nil {
 			return fmt.Errorf("jumpbox write setup file: %s", err) //not tested
 		}
@@ -138,6 +140,15 @@ func (e Executor) PlanJumpboxWithState(input DirInput, deploymentDir, iaas strin
 		}
 	}
 
+	if name, ops := jumpboxCredentialsOps(iaas, state); name != "" {
+		credentialsOpsPath := filepath.Join(deploymentDir, name)
+		sharedArgs = append(sharedArgs, "-o", credentialsOpsPath)
+		err := e.FS.WriteFile(credentialsOpsPath, []byte(ops), os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("jumpbox write credentials ops file: %s", err) //not tested
+		}
+	}
+
 	jumpboxState := filepath.Join(input.VarsDir, "jumpbox-state.json")
 
 	boshArgs := append([]string{filepath.Join(deploymentDir, "jumpbox.yml"), "--state", jumpboxState}, sharedArgs...)
@@ -153,6 +164,11 @@ func (e Executor) PlanJumpboxWithState(input DirInput, deploymentDir, iaas strin
 				"-v", `role_arn="${BBL_AWS_ASSUME_ROLE}"`,
 			)
 		}
+		if state.AWS.Profile != "" {
+			boshArgs = append(boshArgs,
+				"-v", `session_token="${BBL_AWS_SESSION_TOKEN}"`,
+			)
+		}
 	case "azure":
 		boshArgs = append(boshArgs,
 			"-v", `subscription_id="${BBL_AZURE_SUBSCRIPTION_ID}"`,
@@ -161,8 +177,12 @@ func (e Executor) PlanJumpboxWithState(input DirInput, deploymentDir, iaas strin
 			"-v", `tenant_id="${BBL_AZURE_TENANT_ID}"`,
 		)
 	case "gcp":
+		if state.GCP.CredentialSource == "" {
+			boshArgs = append(boshArgs,
+				"--var-file", `gcp_credentials_json="${BBL_GCP_SERVICE_ACCOUNT_KEY_PATH}"`,
+			)
+		}
 		boshArgs = append(boshArgs,
-			"--var-file", `gcp_credentials_json="${BBL_GCP_SERVICE_ACCOUNT_KEY_PATH}"`,
 			"-v", `project_id="${BBL_GCP_PROJECT_ID}"`,
 			"-v", `zone="${BBL_GCP_ZONE}"`,
 		)
@@ -202,8 +222,22 @@ func (e Executor) PlanJumpboxWithState(input DirInput, deploymentDir, iaas strin
 	return nil
 }
 
-func (e Executor) getDirectorSetupFiles(stateDir, deploymentDir, iaas string) []setupFile {
-	files := e.getSetupFiles(boshDeploymentRepo, deploymentDir)
+// jumpboxCredentialsOps returns the name and contents of the ops file that
+// points the jumpbox CPI at credentials from a credential source, if any.
+func jumpboxCredentialsOps(iaas string, state storage.State) (string, string) {
+	switch {
+	case iaas == "aws" && state.AWS.Profile != "":
+		return "aws-session-token.yml", AWSSessionTokenOps
+	case iaas == "gcp" && state.GCP.CredentialSource != "":
+		return "gcp-application-default-credentials.yml", GCPApplicationDefaultJumpboxOps
+	case iaas == "azure" && state.Azure.CredentialSource == "managed-identity":
+		return "azure-managed-identity.yml", AzureManagedIdentityJumpboxOps
+	}
+	return "", ""
+}
+
+func (e Executor) getDirectorSetupFiles(stateDir, iaas string, state storage.State) []setupFile {
+	var files []setupFile
 
 	statePath := filepath.Join(stateDir, "bbl-ops-files", iaas)
 	assetPath := filepath.Join(boshDeploymentRepo, iaas)
@@ -222,9 +256,32 @@ func (e Executor) getDirectorSetupFiles(stateDir, deploymentDir, iaas string) []
 		})
 	}
 
+	if name, ops := directorCredentialsOps(iaas, state); name != "" {
+		files = append(files, setupFile{
+			source:   filepath.Join(assetPath, name),
+			dest:     filepath.Join(statePath, name),
+			contents: []byte(ops),
+		})
+	}
+
 	return files
 }
 
+// directorCredentialsOps returns the name and contents of the ops file that
+// points the director CPI at credentials from a credential source, if any.
+// The director itself uses the identity of its VM.
+func directorCredentialsOps(iaas string, state storage.State) (string, string) {
+	switch {
+	case iaas == "aws" && state.AWS.Profile != "":
+		return "aws-session-token.yml", AWSSessionTokenOps
+	case iaas == "gcp" && state.GCP.CredentialSource != "":
+		return "gcp-application-default-credentials.yml", GCPApplicationDefaultDirectorOps
+	case iaas == "azure" && state.Azure.CredentialSource == "managed-identity":
+		return "azure-managed-identity.yml", AzureManagedIdentityDirectorOps
+	}
+	return "", ""
+}
+
 func (e Executor) getDirectorOpsFiles(stateDir, deploymentDir, iaas string, state storage.State) []string {
 	files := []string{
 		filepath.Join(deploymentDir, iaas, "cpi.yml"),
@@ -244,6 +301,12 @@ func (e Executor) getDirectorOpsFiles(stateDir, deploymentDir, iaas string, stat
 	} else if iaas == "vsphere" {
 		files = append(files, filepath.Join(deploymentDir, "vsphere", "resource-pool.yml"))
 	}
+	if name, _ := directorCredentialsOps(iaas, state); name != "" {
+		files = append(files, filepath.Join(stateDir, "bbl-ops-files", iaas, name))
+	}
+	if state.DirectorBackups {
+		files = append(files, filepath.Join(deploymentDir, "bbr.yml"))
+	}
 	return files
 }
 
@@ -252,12 +315,17 @@ func (e Executor) PlanDirector(input DirInput, deploymentDir, iaas string) error
 }
 
 func (e Executor) PlanDirectorWithState(input DirInput, deploymentDir, iaas string, state storage.State) error {
-	setupFiles := e.getDirectorSetupFiles(input.StateDir, deploymentDir, iaas)
+	// Once bosh-deployment is in the state dir only bbl upgrade replaces it.
+	if !e.hasDeployment(deploymentDir, "bosh.yml") {
+		if err := e.WriteDeployment(boshDeploymentRepo, deploymentDir); err != nil {
+			return fmt.Errorf("director write setup file: %s", err) //not tested
+		}
+	}
+
+	setupFiles := e.getDirectorSetupFiles(input.StateDir, iaas, state)
 
 	for _, f := range setupFiles {
-		if f.source != "" {
-			os.MkdirAll(filepath.Dir(f.dest), storage.StateMode) //nolint:errcheck
-		}
+		os.MkdirAll(filepath.Dir(f.dest), storage.StateMode) //nolint:errcheck
 		if err := e.FS.WriteFile(f.dest, f.contents, storage.StateMode); err != nil {
 			return fmt.Errorf("director write setup file: %s", err) //not tested
 		}
@@ -287,6 +355,11 @@ func (e Executor) PlanDirectorWithState(input DirInput, deploymentDir, iaas stri
 				"-v", `role_arn="${BBL_AWS_ASSUME_ROLE}"`,
 			)
 		}
+		if state.AWS.Profile != "" {
+			boshArgs = append(boshArgs,
+				"-v", `session_token="${BBL_AWS_SESSION_TOKEN}"`,
+			)
+		}
 	case "azure":
 		boshArgs = append(boshArgs,
 			"-v", `subscription_id="${BBL_AZURE_SUBSCRIPTION_ID}"`,
@@ -295,8 +368,12 @@ func (e Executor) PlanDirectorWithState(input DirInput, deploymentDir, iaas stri
 			"-v", `tenant_id="${BBL_AZURE_TENANT_ID}"`,
 		)
 	case "gcp":
+		if state.GCP.CredentialSource == "" {
+			boshArgs = append(boshArgs,
+				"--var-file", `gcp_credentials_json="${BBL_GCP_SERVICE_ACCOUNT_KEY_PATH}"`,
+			)
+		}
 		boshArgs = append(boshArgs,
-			"--var-file", `gcp_credentials_json="${BBL_GCP_SERVICE_ACCOUNT_KEY_PATH}"`,
 			"-v", `project_id="${BBL_GCP_PROJECT_ID}"`,
 			"-v", `zone="${BBL_GCP_ZONE}"`,
 		)
@@ -356,12 +433,23 @@ func (e Executor) WriteDeploymentVars(input DirInput, deploymentVars string) err
 	return nil
 }
 
+// The Azure CPI can use a client secret or a managed identity, but not the
+// login of the Azure CLI, so a create-env script bbl wrote cannot run with it.
+var errAzureCLICredentials = errors.New("The Azure CPI cannot use Azure CLI credentials. Pass --azure-client-secret or use --azure-credential-source managed-identity to create the jumpbox and director.") //nolint:staticcheck
+
+func usesAzureCLICredentials(state storage.State) bool {
+	return state.IAAS == "azure" && state.Azure.CredentialSource == "cli"
+}
+
 func (e Executor) CreateEnv(input DirInput, state storage.State) (string, error) {
 	os.Setenv("BBL_STATE_DIR", input.StateDir) //nolint:errcheck
 	createEnvScript := filepath.Join(input.StateDir, fmt.Sprintf("create-%s-override.sh", input.Deployment))
 	_, err := e.FS.Stat(createEnvScript)
 	if err != nil {
 		createEnvScript = strings.Replace(createEnvScript, "-override", "", -1) //nolint:staticcheck
+		if usesAzureCLICredentials(state) {
+			return "", errAzureCLICredentials
+		}
 	}
 
 	os.Setenv("BBL_IAAS", state.IAAS) //nolint:errcheck
@@ -369,6 +457,7 @@ func (e Executor) CreateEnv(input DirInput, state storage.State) (string, error)
 	case "aws":
 		os.Setenv("BBL_AWS_ACCESS_KEY_ID", state.AWS.AccessKeyID)         //nolint:errcheck
 		os.Setenv("BBL_AWS_SECRET_ACCESS_KEY", state.AWS.SecretAccessKey) //nolint:errcheck
+		os.Setenv("BBL_AWS_SESSION_TOKEN", state.AWS.SessionToken)        //nolint:errcheck
 	case "azure":
 		os.Setenv("BBL_AZURE_CLIENT_ID", state.Azure.ClientID)             //nolint:errcheck
 		os.Setenv("BBL_AZURE_CLIENT_SECRET", state.Azure.ClientSecret)     //nolint:errcheck
@@ -390,8 +479,8 @@ func (e Executor) CreateEnv(input DirInput, state storage.State) (string, error)
 	}
 
 	cmd := exec.Command(createEnvScript)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = e.Stdout
+	cmd.Stderr = e.Stderr
 
 	err = cmd.Run()
 	if err != nil {
@@ -419,12 +508,16 @@ func (e Executor) DeleteEnv(input DirInput, state storage.State) error {
 	_, err = e.FS.Stat(deleteEnvScript)
 	if err != nil {
 		deleteEnvScript = strings.Replace(deleteEnvScript, "-override", "", -1) //nolint:staticcheck
+		if usesAzureCLICredentials(state) {
+			return errAzureCLICredentials
+		}
 	}
 
 	switch state.IAAS {
 	case "aws":
 		os.Setenv("BBL_AWS_ACCESS_KEY_ID", state.AWS.AccessKeyID)         //nolint:errcheck
 		os.Setenv("BBL_AWS_SECRET_ACCESS_KEY", state.AWS.SecretAccessKey) //nolint:errcheck
+		os.Setenv("BBL_AWS_SESSION_TOKEN", state.AWS.SessionToken)        //nolint:errcheck
 	case "azure":
 		os.Setenv("BBL_AZURE_CLIENT_ID", state.Azure.ClientID)             //nolint:errcheck
 		os.Setenv("BBL_AZURE_CLIENT_SECRET", state.Azure.ClientSecret)     //nolint:errcheck
@@ -443,8 +536,8 @@ func (e Executor) DeleteEnv(input DirInput, state storage.State) error {
 	}
 
 	cmd := exec.Command(deleteEnvScript)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = e.Stdout
+	cmd.Stderr = e.Stderr
 
 	err = cmd.Run()
 	if err != nil {
@@ -1,6 +1,7 @@
 package bosh_test
 
 import (
+	"bytes"
 	"embed"
 	"errors"
 	"fmt"
@@ -173,6 +174,41 @@ var _ = Describe("Executor", func() {
 					Expect(string(shellScript)).To(Equal(expectedScript))
 				})
 			})
+			Context("when a profile is set", func() {
+				It("writes create-env and delete-env scripts with the session token ops file and variable", func() {
+					state := storage.State{
+						AWS: storage.AWS{
+							Profile: "some-profile",
+						},
+					}
+					err := executor.PlanJumpboxWithState(dirInput, deploymentDir, "aws", state)
+					Expect(err).NotTo(HaveOccurred())
+
+					expectedArgs := []string{
+						fmt.Sprintf("%s/jumpbox.yml", relativeDeploymentDir),
+						"--state", fmt.Sprintf("%s/jumpbox-state.json", relativeVarsDir),
+						"--vars-store", fmt.Sprintf("%s/jumpbox-vars-store.yml", relativeVarsDir),
+						"--vars-file", fmt.Sprintf("%s/jumpbox-vars-file.yml", relativeVarsDir),
+						"-o", fmt.Sprintf("%s/aws/cpi.yml", relativeDeploymentDir),
+						"-o", fmt.Sprintf("%s/aws-session-token.yml", relativeDeploymentDir),
+						"-v", `access_key_id="${BBL_AWS_ACCESS_KEY_ID}"`,
+						"-v", `secret_access_key="${BBL_AWS_SECRET_ACCESS_KEY}"`,
+						"-v", `session_token="${BBL_AWS_SESSION_TOKEN}"`,
+					}
+
+					opsFile, err := fs.ReadFile(fmt.Sprintf("%s/aws-session-token.yml", deploymentDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(ContainSubstring("/cloud_provider/properties/aws/session_token?"))
+
+					shellScript, err := fs.ReadFile(fmt.Sprintf("%s/create-jumpbox.sh", stateDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(shellScript)).To(Equal(formatScript("create-env", stateDir, expectedArgs)))
+
+					shellScript, err = fs.ReadFile(fmt.Sprintf("%s/delete-jumpbox.sh", stateDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(shellScript)).To(Equal(formatScript("delete-env", stateDir, expectedArgs)))
+				})
+			})
 		})
 
 		Context("on azure", func() {
@@ -250,6 +286,37 @@ var _ = Describe("Executor", func() {
 					Expect(string(shellScript)).To(Equal(expectedScript))
 				})
 			})
+
+			Context("when application default credentials are used", func() {
+				It("removes the service account key from the create-env args", func() {
+					state := storage.State{
+						GCP: storage.GCP{
+							CredentialSource: "application-default",
+						},
+					}
+					err := executor.PlanJumpboxWithState(dirInput, deploymentDir, "gcp", state)
+					Expect(err).NotTo(HaveOccurred())
+
+					expectedArgs := []string{
+						fmt.Sprintf("%s/jumpbox.yml", relativeDeploymentDir),
+						"--state", fmt.Sprintf("%s/jumpbox-state.json", relativeVarsDir),
+						"--vars-store", fmt.Sprintf("%s/jumpbox-vars-store.yml", relativeVarsDir),
+						"--vars-file", fmt.Sprintf("%s/jumpbox-vars-file.yml", relativeVarsDir),
+						"-o", fmt.Sprintf("%s/gcp/cpi.yml", relativeDeploymentDir),
+						"-o", fmt.Sprintf("%s/gcp-application-default-credentials.yml", relativeDeploymentDir),
+						"-v", `project_id="${BBL_GCP_PROJECT_ID}"`,
+						"-v", `zone="${BBL_GCP_ZONE}"`,
+					}
+
+					opsFile, err := fs.ReadFile(fmt.Sprintf("%s/gcp-application-default-credentials.yml", deploymentDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(ContainSubstring("/cloud_provider/properties/google/json_key?"))
+
+					shellScript, err := fs.ReadFile(fmt.Sprintf("%s/create-jumpbox.sh", stateDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(shellScript)).To(Equal(formatScript("create-env", stateDir, expectedArgs)))
+				})
+			})
 		})
 
 		Context("when the iaas is vsphere", func() {
@@ -377,6 +444,30 @@ var _ = Describe("Executor", func() {
 			Expect(string(contents)).To(Equal("vsphere-cpi"))
 		})
 
+		Context("when bosh-deployment is already in the deployment dir", func() {
+			BeforeEach(func() {
+				Expect(fs.WriteFile(filepath.Join(deploymentDir, "bosh.yml"), []byte("some-bosh-manifest"), storage.StateMode)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(deploymentDir, "LICENSE"), []byte("some-old-license"), storage.StateMode)).To(Succeed())
+			})
+
+			It("leaves it for bbl upgrade", func() {
+				err := executor.PlanDirector(dirInput, deploymentDir, "gcp")
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := fs.ReadFile(filepath.Join(deploymentDir, "LICENSE"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("some-old-license"))
+
+				_, err = fs.Stat(filepath.Join(deploymentDir, "vsphere", "cpi.yml"))
+				Expect(err).To(HaveOccurred())
+
+				By("still writing the bbl ops files", func() {
+					_, err = fs.Stat(filepath.Join(stateDir, "bbl-ops-files", "gcp", "bosh-director-ephemeral-ip-ops.yml"))
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+		})
+
 		Context("aws", func() {
 			It("writes create-director.sh and delete-director.sh", func() {
 				expectedArgs := []string{
@@ -441,6 +532,53 @@ var _ = Describe("Executor", func() {
 					behavesLikePlan(expectedArgs, cli, fs, executor, dirInput, deploymentDir, "aws", stateDir, state)
 				})
 			})
+
+			Context("when a profile is set", func() {
+				It("writes create-director.sh and delete-director.sh including the session token ops file and variable", func() {
+					expectedArgs := []string{
+						filepath.Join(relativeDeploymentDir, "bosh.yml"),
+						"--state", filepath.Join(relativeVarsDir, "bosh-state.json"),
+						"--vars-store", filepath.Join(relativeVarsDir, "director-vars-store.yml"),
+						"--vars-file", filepath.Join(relativeVarsDir, "director-vars-file.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "aws", "cpi.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "jumpbox-user.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
+						"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "aws", "bosh-director-ephemeral-ip-ops.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "aws", "iam-instance-profile.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "aws", "encrypted-disk.yml"),
+						"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "aws", "aws-session-token.yml"),
+						"-v", `access_key_id="${BBL_AWS_ACCESS_KEY_ID}"`,
+						"-v", `secret_access_key="${BBL_AWS_SECRET_ACCESS_KEY}"`,
+						"-v", `session_token="${BBL_AWS_SESSION_TOKEN}"`,
+					}
+
+					state := storage.State{AWS: storage.AWS{Profile: "some-profile"}}
+					behavesLikePlan(expectedArgs, cli, fs, executor, dirInput, deploymentDir, "aws", stateDir, state)
+				})
+			})
+		})
+
+		Context("when director backups are enabled", func() {
+			It("writes create-director.sh and delete-director.sh including the bbr ops file", func() {
+				expectedArgs := []string{
+					filepath.Join(relativeDeploymentDir, "bosh.yml"),
+					"--state", filepath.Join(relativeVarsDir, "bosh-state.json"),
+					"--vars-store", filepath.Join(relativeVarsDir, "director-vars-store.yml"),
+					"--vars-file", filepath.Join(relativeVarsDir, "director-vars-file.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "gcp", "cpi.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "jumpbox-user.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
+					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "gcp", "bosh-director-ephemeral-ip-ops.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "bbr.yml"),
+					"--var-file", `gcp_credentials_json="${BBL_GCP_SERVICE_ACCOUNT_KEY_PATH}"`,
+					"-v", `project_id="${BBL_GCP_PROJECT_ID}"`,
+					"-v", `zone="${BBL_GCP_ZONE}"`,
+				}
+
+				behavesLikePlan(expectedArgs, cli, fs, executor, dirInput, deploymentDir, "gcp", stateDir, storage.State{DirectorBackups: true})
+			})
 		})
 
 		Context("gcp", func() {
@@ -477,6 +615,32 @@ var _ = Describe("Executor", func() {
   value: true
 `))
 			})
+
+			Context("when application default credentials are used", func() {
+				It("writes create-director.sh and delete-director.sh without the service account key", func() {
+					expectedArgs := []string{
+						filepath.Join(relativeDeploymentDir, "bosh.yml"),
+						"--state", filepath.Join(relativeVarsDir, "bosh-state.json"),
+						"--vars-store", filepath.Join(relativeVarsDir, "director-vars-store.yml"),
+						"--vars-file", filepath.Join(relativeVarsDir, "director-vars-file.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "gcp", "cpi.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "jumpbox-user.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
+						"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "gcp", "bosh-director-ephemeral-ip-ops.yml"),
+						"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "gcp", "gcp-application-default-credentials.yml"),
+						"-v", `project_id="${BBL_GCP_PROJECT_ID}"`,
+						"-v", `zone="${BBL_GCP_ZONE}"`,
+					}
+
+					state := storage.State{GCP: storage.GCP{CredentialSource: "application-default"}}
+					behavesLikePlan(expectedArgs, cli, fs, executor, dirInput, deploymentDir, "gcp", stateDir, state)
+
+					opsFile, err := fs.ReadFile(filepath.Join(stateDir, "bbl-ops-files", "gcp", "gcp-application-default-credentials.yml"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(Equal(bosh.GCPApplicationDefaultDirectorOps))
+				})
+			})
 		})
 
 		Context("azure", func() {
@@ -498,6 +662,33 @@ var _ = Describe("Executor", func() {
 
 				behavesLikePlan(expectedArgs, cli, fs, executor, dirInput, deploymentDir, "azure", stateDir, storage.State{})
 			})
+
+			Context("when a managed identity is used", func() {
+				It("writes create-director.sh and delete-director.sh including the managed identity ops file", func() {
+					expectedArgs := []string{
+						filepath.Join(relativeDeploymentDir, "bosh.yml"),
+						"--state", filepath.Join(relativeVarsDir, "bosh-state.json"),
+						"--vars-store", filepath.Join(relativeVarsDir, "director-vars-store.yml"),
+						"--vars-file", filepath.Join(relativeVarsDir, "director-vars-file.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "azure", "cpi.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "jumpbox-user.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
+						"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
+						"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "azure", "azure-managed-identity.yml"),
+						"-v", `subscription_id="${BBL_AZURE_SUBSCRIPTION_ID}"`,
+						"-v", `client_id="${BBL_AZURE_CLIENT_ID}"`,
+						"-v", `client_secret="${BBL_AZURE_CLIENT_SECRET}"`,
+						"-v", `tenant_id="${BBL_AZURE_TENANT_ID}"`,
+					}
+
+					state := storage.State{Azure: storage.Azure{CredentialSource: "managed-identity"}}
+					behavesLikePlan(expectedArgs, cli, fs, executor, dirInput, deploymentDir, "azure", stateDir, state)
+
+					opsFile, err := fs.ReadFile(filepath.Join(stateDir, "bbl-ops-files", "azure", "azure-managed-identity.yml"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(Equal(bosh.AzureManagedIdentityDirectorOps))
+				})
+			})
 		})
 
 		Context("vsphere", func() {
@@ -558,6 +749,86 @@ var _ = Describe("Executor", func() {
 		})
 	})
 
+	Describe("DiffDeployment", func() {
+		It("lists every embedded file as added to an empty deployment dir", func() {
+			changes, err := executor.DiffDeployment("jumpbox-deployment", deploymentDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(changes).To(Equal([]bosh.DeploymentChange{
+				{Path: "aws/cpi.yml", Added: true},
+				{Path: "azure/cpi.yml", Added: true},
+				{Path: "no-external-ip.yml", Added: true},
+			}))
+		})
+
+		Context("when the deployment dir has an older bosh-deployment", func() {
+			BeforeEach(func() {
+				Expect(executor.WriteDeployment("bosh-deployment", deploymentDir)).To(Succeed())
+
+				oldCPI := `- type: replace
+  path: /releases/-
+  value:
+    name: bosh-google-cpi
+    version: 50.0.3
+    url: https://bosh.io/d/github.com/cloudfoundry/bosh-google-cpi-release?v=50.0.3
+    sha1: some-old-cpi-sha1
+- type: replace
+  path: /resource_pools/name=vms/stemcell?
+  value:
+    url: https://bosh.io/d/stemcells/bosh-google-kvm-ubuntu-jammy-go_agent?v=1.400
+    sha1: some-old-stemcell-sha1
+`
+				Expect(fs.WriteFile(filepath.Join(deploymentDir, "gcp", "cpi.yml"), []byte(oldCPI), storage.StateMode)).To(Succeed())
+				Expect(fs.Remove(filepath.Join(deploymentDir, "LICENSE"))).To(Succeed())
+			})
+
+			It("lists the changed files with their release and stemcell versions", func() {
+				changes, err := executor.DiffDeployment("bosh-deployment", deploymentDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(changes).To(Equal([]bosh.DeploymentChange{
+					{Path: "LICENSE", Added: true},
+					{
+						Path: "gcp/cpi.yml",
+						Versions: []bosh.VersionChange{
+							{Name: "bosh-google-cpi", From: "50.0.3", To: "50.0.8"},
+							{Name: "stemcell", From: "1.400", To: "1.406"},
+						},
+					},
+				}))
+			})
+		})
+
+		Context("when the deployment dir is up to date", func() {
+			BeforeEach(func() {
+				Expect(executor.WriteDeployment("bosh-deployment", deploymentDir)).To(Succeed())
+			})
+
+			It("lists nothing", func() {
+				changes, err := executor.DiffDeployment("bosh-deployment", deploymentDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("WriteDeployment", func() {
+		It("replaces the deployment in the deployment dir", func() {
+			Expect(fs.WriteFile(filepath.Join(deploymentDir, "no-external-ip.yml"), []byte("some-old-ops"), storage.StateMode)).To(Succeed())
+
+			err := executor.WriteDeployment("jumpbox-deployment", deploymentDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFile(filepath.Join(deploymentDir, "no-external-ip.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("no-ip"))
+
+			contents, err = fs.ReadFile(filepath.Join(deploymentDir, "aws", "cpi.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("aws-cpi"))
+		})
+	})
+
 	Describe("WriteDeploymentVars", func() {
 		BeforeEach(func() {
 			dirInput.Deployment = "some-deployment"
@@ -673,6 +944,23 @@ var _ = Describe("Executor", func() {
 			})
 		})
 
+		It("writes the output of the create-env script to the executor's writers", func() {
+			overridePath := filepath.Join(stateDir, "create-some-deployment-override.sh")
+			overrideContents := "#!/bin/bash\necho 'some-stdout'\necho 'some-stderr' >&2\n"
+			fs.WriteFile(overridePath, []byte(overrideContents), storage.ScriptMode) //nolint:errcheck
+
+			stdout := bytes.NewBuffer([]byte{})
+			stderr := bytes.NewBuffer([]byte{})
+			executor.Stdout = stdout
+			executor.Stderr = stderr
+
+			_, err := executor.CreateEnv(dirInput, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(Equal("some-stdout\n"))
+			Expect(stderr.String()).To(Equal("some-stderr\n"))
+		})
+
 		Context("when iaas credentials are provided", func() {
 			Context("on aws", func() {
 				BeforeEach(func() {
@@ -680,6 +968,7 @@ var _ = Describe("Executor", func() {
 					state.AWS = storage.AWS{
 						AccessKeyID:     "some-access-key-id",
 						SecretAccessKey: "some-secret-access-key",
+						SessionToken:    "some-session-token",
 					}
 				})
 
@@ -689,6 +978,7 @@ var _ = Describe("Executor", func() {
 
 					Expect(os.Getenv("BBL_AWS_ACCESS_KEY_ID")).To(Equal("some-access-key-id"))
 					Expect(os.Getenv("BBL_AWS_SECRET_ACCESS_KEY")).To(Equal("some-secret-access-key"))
+					Expect(os.Getenv("BBL_AWS_SESSION_TOKEN")).To(Equal("some-session-token"))
 				})
 			})
 
@@ -712,6 +1002,18 @@ var _ = Describe("Executor", func() {
 					Expect(os.Getenv("BBL_AZURE_SUBSCRIPTION_ID")).To(Equal("some-subscription-id"))
 					Expect(os.Getenv("BBL_AZURE_TENANT_ID")).To(Equal("some-tenant-id"))
 				})
+
+				Context("when the credentials come from the Azure CLI", func() {
+					BeforeEach(func() {
+						state.Azure.CredentialSource = "cli"
+					})
+
+					It("returns an error without running the create-env script", func() {
+						vars, err := executor.CreateEnv(dirInput, state)
+						Expect(err).To(MatchError(ContainSubstring("The Azure CPI cannot use Azure CLI credentials.")))
+						Expect(vars).To(Equal(""))
+					})
+				})
 			})
 
 			Context("on gcp", func() {
@@ -963,6 +1265,15 @@ var _ = Describe("Executor", func() {
 					Expect(os.Getenv("BBL_AZURE_SUBSCRIPTION_ID")).To(Equal("some-subscription-id"))
 					Expect(os.Getenv("BBL_AZURE_TENANT_ID")).To(Equal("some-tenant-id"))
 				})
+
+				Context("when the credentials come from the Azure CLI", func() {
+					It("returns an error", func() {
+						state.Azure.CredentialSource = "cli"
+
+						err := executor.DeleteEnv(dirInput, state)
+						Expect(err).To(MatchError(ContainSubstring("The Azure CPI cannot use Azure CLI credentials.")))
+					})
+				})
 			})
 
 			Context("on gcp", func() {
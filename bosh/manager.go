@@ -46,6 +46,8 @@ type executor interface {
 	CreateEnv(DirInput, storage.State) (string, error)
 	DeleteEnv(DirInput, storage.State) error
 	WriteDeploymentVars(DirInput, string) error
+	DiffDeployment(string, string) ([]DeploymentChange, error)
+	WriteDeployment(string, string) error
 	Path() string
 	Version() (string, error)
 }
@@ -620,6 +620,74 @@ director_ssl:
 		})
 	})
 
+	Describe("PreviewUpgrade", func() {
+		It("compares the jumpbox and director deployments with the embedded ones", func() {
+			jumpboxChanges := []bosh.DeploymentChange{{Path: "jumpbox.yml"}}
+			directorChanges := []bosh.DeploymentChange{{Path: "bosh.yml"}}
+			boshExecutor.DiffDeploymentCall.Returns = []fakes.DiffDeploymentReturn{
+				{Changes: jumpboxChanges},
+				{Changes: directorChanges},
+			}
+
+			upgrade, err := boshManager.PreviewUpgrade()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshExecutor.DiffDeploymentCall.Receives).To(Equal([]fakes.DeploymentReceive{
+				{Repo: "jumpbox-deployment", DeploymentDir: "some-jumpbox-deployment-dir"},
+				{Repo: "bosh-deployment", DeploymentDir: "some-director-deployment-dir"},
+			}))
+			Expect(upgrade).To(Equal(bosh.DeploymentUpgrade{
+				Jumpbox:  jumpboxChanges,
+				Director: directorChanges,
+			}))
+		})
+
+		Context("when comparing a deployment fails", func() {
+			BeforeEach(func() {
+				boshExecutor.DiffDeploymentCall.Returns = []fakes.DiffDeploymentReturn{{}, {Error: errors.New("guava")}}
+			})
+
+			It("returns an error", func() {
+				_, err := boshManager.PreviewUpgrade()
+				Expect(err).To(MatchError("Compare bosh-deployment: guava"))
+			})
+		})
+
+		Context("when get deployment dir fails", func() {
+			BeforeEach(func() {
+				stateStore.GetJumpboxDeploymentDirCall.Returns.Error = errors.New("kiwi")
+			})
+
+			It("returns an error", func() {
+				_, err := boshManager.PreviewUpgrade()
+				Expect(err).To(MatchError("kiwi"))
+			})
+		})
+	})
+
+	Describe("Upgrade", func() {
+		It("writes the embedded jumpbox and director deployments", func() {
+			err := boshManager.Upgrade()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshExecutor.WriteDeploymentCall.Receives).To(Equal([]fakes.DeploymentReceive{
+				{Repo: "jumpbox-deployment", DeploymentDir: "some-jumpbox-deployment-dir"},
+				{Repo: "bosh-deployment", DeploymentDir: "some-director-deployment-dir"},
+			}))
+		})
+
+		Context("when writing a deployment fails", func() {
+			BeforeEach(func() {
+				boshExecutor.WriteDeploymentCall.Returns.Error = errors.New("guava")
+			})
+
+			It("returns an error", func() {
+				err := boshManager.Upgrade()
+				Expect(err).To(MatchError("Write jumpbox-deployment: guava"))
+			})
+		})
+	})
+
 	Describe("GetJumpboxDeploymentVars", func() {
 		It("removes the jumpbox__ prefix from variable names", func() {
 			vars := boshManager.GetJumpboxDeploymentVars(storage.State{}, terraform.Outputs{Map: map[string]interface{}{
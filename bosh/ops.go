@@ -16,3 +16,36 @@ const VSphereJumpboxNetworkOps = `---
 - type: remove
   path: /instance_groups/name=jumpbox/networks/name=public
 `
+
+const AWSSessionTokenOps = `---
+- type: replace
+  path: /cloud_provider/properties/aws/session_token?
+  value: ((session_token))
+`
+
+const GCPApplicationDefaultJumpboxOps = `---
+- type: remove
+  path: /cloud_provider/properties/google/json_key?
+`
+
+const GCPApplicationDefaultDirectorOps = `---
+- type: remove
+  path: /cloud_provider/properties/google/json_key?
+- type: remove
+  path: /instance_groups/name=bosh/properties/google/json_key?
+`
+
+const AzureManagedIdentityJumpboxOps = `---
+- type: replace
+  path: /cloud_provider/properties/azure/credentials_source?
+  value: managed_identity
+`
+
+const AzureManagedIdentityDirectorOps = `---
+- type: replace
+  path: /cloud_provider/properties/azure/credentials_source?
+  value: managed_identity
+- type: replace
+  path: /instance_groups/name=bosh/properties/azure/credentials_source?
+  value: managed_identity
+`
@@ -28,7 +28,7 @@ func (l AWSLBs) Execute(subcommandFlags []string, state storage.State) error {
 
 	switch state.LB.Type {
 	case "cf":
-		if len(subcommandFlags) > 0 && subcommandFlags[0] == "--json" {
+		if asJSON(subcommandFlags) {
 			lbOutput, err := json.Marshal(struct {
 				RouterLBName           string   `json:"cf_router_lb,omitempty"`
 				RouterLBURL            string   `json:"cf_router_lb_url,omitempty"`
@@ -64,7 +64,23 @@ func (l AWSLBs) Execute(subcommandFlags []string, state storage.State) error {
 			}
 		}
 	case "concourse":
-		l.logger.Printf("Concourse LB: %s [%s]\n", terraformOutputs.GetString("concourse_lb_name"), terraformOutputs.GetString("concourse_lb_url"))
+		if asJSON(subcommandFlags) {
+			lbOutput, err := json.Marshal(struct {
+				LBName string `json:"concourse_lb,omitempty"`
+				LBURL  string `json:"concourse_lb_url,omitempty"`
+			}{
+				LBName: terraformOutputs.GetString("concourse_lb_name"),
+				LBURL:  terraformOutputs.GetString("concourse_lb_url"),
+			})
+			if err != nil {
+				// not tested
+				return err
+			}
+
+			l.logger.Println(string(lbOutput))
+		} else {
+			l.logger.Printf("Concourse LB: %s [%s]\n", terraformOutputs.GetString("concourse_lb_name"), terraformOutputs.GetString("concourse_lb_url"))
+		}
 	default:
 		return errors.New("no lbs found")
 	}
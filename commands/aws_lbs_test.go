@@ -129,6 +129,18 @@ var _ = Describe("AWSLBs", func() {
 					"Concourse LB: some-concourse-lb-name [some-concourse-lb-url]\n",
 				}))
 			})
+
+			Context("when the json flag is provided", func() {
+				It("prints LB name and URL in json format", func() {
+					err := command.Execute([]string{"--json"}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+						"concourse_lb": "some-concourse-lb-name",
+						"concourse_lb_url": "some-concourse-lb-url"
+					}`))
+				})
+			})
 		})
 
 		Context("when lb type is not cf or concourse", func() {
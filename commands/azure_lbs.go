@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/json"
 	"errors"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -26,9 +27,39 @@ func (l AzureLBs) Execute(subcommandFlags []string, state storage.State) error {
 
 	switch state.LB.Type {
 	case "cf":
-		l.logger.Printf("CF LB: %s\n", terraformOutputs.GetString("cf_app_gateway_name"))
+		if asJSON(subcommandFlags) {
+			lbOutput, err := json.Marshal(struct {
+				AppGatewayName string `json:"cf_app_gateway,omitempty"`
+			}{
+				AppGatewayName: terraformOutputs.GetString("cf_app_gateway_name"),
+			})
+			if err != nil {
+				// not tested
+				return err
+			}
+
+			l.logger.Println(string(lbOutput))
+		} else {
+			l.logger.Printf("CF LB: %s\n", terraformOutputs.GetString("cf_app_gateway_name"))
+		}
 	case "concourse":
-		l.logger.Printf("Concourse LB: %s (%s)\n", terraformOutputs.GetString("concourse_lb_name"), terraformOutputs.GetString("concourse_lb_ip"))
+		if asJSON(subcommandFlags) {
+			lbOutput, err := json.Marshal(struct {
+				LBName string `json:"concourse_lb,omitempty"`
+				LBIP   string `json:"concourse_lb_ip,omitempty"`
+			}{
+				LBName: terraformOutputs.GetString("concourse_lb_name"),
+				LBIP:   terraformOutputs.GetString("concourse_lb_ip"),
+			})
+			if err != nil {
+				// not tested
+				return err
+			}
+
+			l.logger.Println(string(lbOutput))
+		} else {
+			l.logger.Printf("Concourse LB: %s (%s)\n", terraformOutputs.GetString("concourse_lb_name"), terraformOutputs.GetString("concourse_lb_ip"))
+		}
 	default:
 		return errors.New("no lbs found")
 	}
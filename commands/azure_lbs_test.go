@@ -52,6 +52,15 @@ var _ = Describe("Azure LBs", func() {
 					"CF LB: some-app-gateway-name\n",
 				}))
 			})
+
+			Context("when the json flag is provided", func() {
+				It("prints the LB name in json format", func() {
+					err := command.Execute([]string{"--json"}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"cf_app_gateway": "some-app-gateway-name"}`))
+				})
+			})
 		})
 
 		Context("when the lb type is concourse", func() {
@@ -77,6 +86,18 @@ var _ = Describe("Azure LBs", func() {
 					"Concourse LB: some-load-balancer-name (5.6.7.8)\n",
 				}))
 			})
+
+			Context("when the json flag is provided", func() {
+				It("prints the LB name and ip in json format", func() {
+					err := command.Execute([]string{"--json"}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+						"concourse_lb": "some-load-balancer-name",
+						"concourse_lb_ip": "5.6.7.8"
+					}`))
+				})
+			})
 		})
 
 		Context("when lb type is not cf or concourse", func() {
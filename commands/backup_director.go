@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type BackupDirector struct {
+	logger         logger
+	stateValidator stateValidator
+	bbr            bbrDirector
+	store          backupStore
+}
+
+type BackupDirectorConfig struct {
+	ArtifactDir string
+}
+
+func NewBackupDirector(logger logger, stateValidator stateValidator, bbrCLI bbrCLI, allProxyGetter allProxyGetter,
+	sshKeyGetter sshKeyGetter, fs backupFs, store backupStore) BackupDirector {
+	return BackupDirector{
+		logger:         logger,
+		stateValidator: stateValidator,
+		bbr: bbrDirector{
+			cli:            bbrCLI,
+			allProxyGetter: allProxyGetter,
+			sshKeyGetter:   sshKeyGetter,
+			fs:             fs,
+		},
+		store: store,
+	}
+}
+
+func (b BackupDirector) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := b.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	return checkBBRDirector(state)
+}
+
+func (b BackupDirector) ParseArgs(args []string) (BackupDirectorConfig, error) {
+	var config BackupDirectorConfig
+	backupFlags := flags.New("backup-director")
+	backupFlags.String(&config.ArtifactDir, "artifact-dir", "")
+
+	err := backupFlags.Parse(args)
+	if err != nil {
+		return BackupDirectorConfig{}, err
+	}
+
+	return config, nil
+}
+
+// Execute backs the director up with bbr, which names the artifact after the
+// director and the time. The artifact goes next to the remote state with
+// --state-bucket and to the current directory otherwise, unless
+// --artifact-dir chooses a local directory.
+func (b BackupDirector) Execute(args []string, state storage.State) error {
+	config, err := b.ParseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	remote := config.ArtifactDir == "" && b.store.IsRemote()
+
+	// bbr writes the artifact into its working directory, which is made in
+	// the artifact dir so that the artifact can be renamed into place.
+	parentDir := ""
+	if !remote {
+		parentDir = config.ArtifactDir
+		if parentDir == "" {
+			parentDir = "."
+		}
+		err = b.bbr.fs.MkdirAll(parentDir, storage.StateMode)
+		if err != nil {
+			return fmt.Errorf("Create artifact directory: %s", err) //nolint:staticcheck
+		}
+	}
+
+	workDir, err := b.bbr.fs.TempDir(parentDir, ".bbl-backup-director")
+	if err != nil {
+		return fmt.Errorf("Create temp directory: %s", err) //nolint:staticcheck
+	}
+	defer b.bbr.fs.RemoveAll(workDir) //nolint:errcheck
+
+	b.logger.Step("backing up the director")
+	err = b.bbr.run(state, workDir, "backup")
+	if err != nil {
+		return fmt.Errorf("bbr director backup: %s", err)
+	}
+
+	entries, err := b.bbr.fs.ReadDir(workDir)
+	if err != nil {
+		return fmt.Errorf("Find backup artifact: %s", err) //nolint:staticcheck
+	}
+
+	var artifact string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			artifact = entry.Name()
+		}
+	}
+	if artifact == "" {
+		return errors.New("bbr did not write a backup artifact")
+	}
+
+	location, restoreFrom := "", artifact
+	if remote {
+		location, err = b.store.Upload(artifact, filepath.Join(workDir, artifact))
+		if err != nil {
+			return err
+		}
+	} else {
+		location = filepath.Join(parentDir, artifact)
+		restoreFrom = location
+		err = b.bbr.fs.Rename(filepath.Join(workDir, artifact), location)
+		if err != nil {
+			return fmt.Errorf("Move backup artifact: %s", err) //nolint:staticcheck
+		}
+	}
+
+	b.logger.Println(fmt.Sprintf("backed up the director to %s, restore it with bbl restore-director --artifact-path %s", location, restoreFrom))
+	return nil
+}
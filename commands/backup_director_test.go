@@ -0,0 +1,227 @@
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BackupDirector", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		bbrCLI         *fakes.BBRCLI
+		allProxyGetter *fakes.AllProxyGetter
+		sshKeyGetter   *fakes.SSHKeyGetter
+		fileIO         *fakes.FileIO
+		backupStore    *fakes.BackupStore
+
+		command commands.BackupDirector
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		bbrCLI = &fakes.BBRCLI{}
+		allProxyGetter = &fakes.AllProxyGetter{}
+		allProxyGetter.GeneratePrivateKeyCall.Returns.PrivateKey = filepath.Join("some-key-dir", "jumpbox-private-key")
+		allProxyGetter.BoshAllProxyCall.Returns.URL = "ssh+socks5://jumpbox@some-jumpbox:22?private-key=some-key-dir/jumpbox-private-key"
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+		sshKeyGetter.GetCall.Returns.PrivateKey = "some-director-key"
+		fileIO = &fakes.FileIO{}
+		fileIO.TempDirCall.Returns.Name = "some-work-dir"
+		fileIO.ReadDirCall.Returns.FileInfos = []os.FileInfo{
+			fakes.DirFileInfo{FileInfo: fakes.FileInfo{FileName: "10.0.0.6_20261018T101010Z"}},
+		}
+		backupStore = &fakes.BackupStore{}
+
+		command = commands.NewBackupDirector(logger, stateValidator, bbrCLI, allProxyGetter, sshKeyGetter, fileIO, backupStore)
+
+		state = storage.State{
+			EnvID:           "some-env",
+			Jumpbox:         storage.Jumpbox{URL: "some-jumpbox:22"},
+			BOSH:            storage.BOSH{DirectorAddress: "https://10.0.0.6:25555"},
+			DirectorBackups: true,
+		}
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the state", func() {
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+		})
+
+		Context("when the state validator fails", func() {
+			BeforeEach(func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("apricot")
+			})
+
+			It("returns the error", func() {
+				err := command.CheckFastFails([]string{}, state)
+				Expect(err).To(MatchError("apricot"))
+			})
+		})
+
+		Context("when there is no director", func() {
+			It("returns an error", func() {
+				err := command.CheckFastFails([]string{}, storage.State{DirectorBackups: true})
+				Expect(err).To(MatchError("there is no director in the state, run bbl up first"))
+			})
+		})
+
+		Context("when backups are not enabled", func() {
+			It("returns an error", func() {
+				state.DirectorBackups = false
+				err := command.CheckFastFails([]string{}, state)
+				Expect(err).To(MatchError("backups are not enabled on the director, run bbl plan --enable-backups and bbl up first"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("runs bbr director backup through the jumpbox", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshKeyGetter.GetCall.Receives.Deployment).To(Equal("director"))
+			Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(filepath.Join("some-key-dir", "director-private-key")))
+			Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(Equal("some-director-key"))
+			Expect(fileIO.WriteFileCall.Receives[0].Mode).To(Equal(os.FileMode(0600)))
+
+			Expect(allProxyGetter.BoshAllProxyCall.Receives.JumpboxURL).To(Equal("jumpbox@some-jumpbox:22"))
+			Expect(allProxyGetter.BoshAllProxyCall.Receives.PrivateKey).To(Equal(filepath.Join("some-key-dir", "jumpbox-private-key")))
+
+			Expect(bbrCLI.RunCall.Receives.Env).To(Equal([]string{
+				"BOSH_ALL_PROXY=ssh+socks5://jumpbox@some-jumpbox:22?private-key=some-key-dir/jumpbox-private-key",
+			}))
+			Expect(bbrCLI.RunCall.Receives.WorkingDirectory).To(Equal("some-work-dir"))
+			Expect(bbrCLI.RunCall.Receives.Args).To(Equal([]string{
+				"director",
+				"--host", "10.0.0.6",
+				"--username", "jumpbox",
+				"--private-key-path", filepath.Join("some-key-dir", "director-private-key"),
+				"backup",
+			}))
+		})
+
+		It("removes the keys and the working directory", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.RemoveAllCall.Receives).To(ConsistOf(
+				fakes.RemoveAllReceive{Path: "some-key-dir"},
+				fakes.RemoveAllReceive{Path: "some-work-dir"},
+			))
+		})
+
+		It("moves the artifact into the current directory", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.TempDirCall.Receives.Dir).To(Equal("."))
+			Expect(fileIO.RenameCall.Receives.Oldpath).To(Equal(filepath.Join("some-work-dir", "10.0.0.6_20261018T101010Z")))
+			Expect(fileIO.RenameCall.Receives.Newpath).To(Equal("10.0.0.6_20261018T101010Z"))
+			Expect(backupStore.UploadCall.CallCount).To(Equal(0))
+
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("backed up the director to 10.0.0.6_20261018T101010Z, restore it with bbl restore-director --artifact-path 10.0.0.6_20261018T101010Z"))
+		})
+
+		Context("when --artifact-dir is passed", func() {
+			It("moves the artifact into that directory", func() {
+				err := command.Execute([]string{"--artifact-dir", "some-backups"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fileIO.MkdirAllCall.Receives.Dir).To(Equal("some-backups"))
+				Expect(fileIO.TempDirCall.Receives.Dir).To(Equal("some-backups"))
+				Expect(fileIO.RenameCall.Receives.Newpath).To(Equal(filepath.Join("some-backups", "10.0.0.6_20261018T101010Z")))
+			})
+		})
+
+		Context("when the state is in a state bucket", func() {
+			BeforeEach(func() {
+				backupStore.IsRemoteCall.Returns.IsRemote = true
+				backupStore.UploadCall.Returns.Location = "s3://some-bucket/some-env-backups/10.0.0.6_20261018T101010Z.tgz"
+			})
+
+			It("uploads the artifact next to the remote state", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fileIO.TempDirCall.Receives.Dir).To(Equal(""))
+				Expect(backupStore.UploadCall.Receives.Name).To(Equal("10.0.0.6_20261018T101010Z"))
+				Expect(backupStore.UploadCall.Receives.Dir).To(Equal(filepath.Join("some-work-dir", "10.0.0.6_20261018T101010Z")))
+				Expect(fileIO.RenameCall.CallCount).To(Equal(0))
+
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("backed up the director to s3://some-bucket/some-env-backups/10.0.0.6_20261018T101010Z.tgz, restore it with bbl restore-director --artifact-path 10.0.0.6_20261018T101010Z"))
+			})
+
+			Context("when the upload fails", func() {
+				BeforeEach(func() {
+					backupStore.UploadCall.Returns.Error = errors.New("blueberry")
+				})
+
+				It("returns the error", func() {
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("blueberry"))
+				})
+			})
+
+			Context("when --artifact-dir is passed", func() {
+				It("keeps the artifact locally", func() {
+					err := command.Execute([]string{"--artifact-dir", "some-backups"}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(backupStore.UploadCall.CallCount).To(Equal(0))
+					Expect(fileIO.RenameCall.Receives.Newpath).To(Equal(filepath.Join("some-backups", "10.0.0.6_20261018T101010Z")))
+				})
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when bbr fails", func() {
+				bbrCLI.RunCall.Returns.Error = errors.New("coconut")
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("bbr director backup: coconut"))
+			})
+
+			It("returns an error when the jumpbox key cannot be written", func() {
+				allProxyGetter.GeneratePrivateKeyCall.Returns.Error = errors.New("date")
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("bbr director backup: Get jumpbox private key: date"))
+			})
+
+			It("returns an error when the director key cannot be read", func() {
+				sshKeyGetter.GetCall.Returns.Error = errors.New("elderberry")
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("bbr director backup: Get director private key: elderberry"))
+			})
+
+			It("returns an error when bbr writes no artifact", func() {
+				fileIO.ReadDirCall.Returns.FileInfos = nil
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("bbr did not write a backup artifact"))
+			})
+
+			It("returns an error when the artifact cannot be moved", func() {
+				fileIO.RenameCall.Returns.Error = errors.New("feijoa")
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Move backup artifact: feijoa"))
+			})
+
+			It("returns an error when the flags are invalid", func() {
+				err := command.Execute([]string{"--some-flag"}, state)
+				Expect(err).To(MatchError("flag provided but not defined: -some-flag"))
+			})
+		})
+	})
+})
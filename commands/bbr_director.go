@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type bbrCLI interface {
+	Run(env []string, workingDirectory string, args []string) error
+}
+
+type backupStore interface {
+	IsRemote() bool
+	Upload(name, dir string) (string, error)
+	Download(name, dir string) error
+}
+
+type backupFs interface {
+	fileio.TempDirer
+	fileio.FileWriter
+	fileio.DirReader
+	fileio.Renamer
+	fileio.Stater
+	fileio.AllRemover
+	fileio.AllMkdirer
+}
+
+// bbrDirector runs bbr director against the director through the jumpbox.
+type bbrDirector struct {
+	cli            bbrCLI
+	allProxyGetter allProxyGetter
+	sshKeyGetter   sshKeyGetter
+	fs             backupFs
+}
+
+func checkBBRDirector(state storage.State) error {
+	if state.BOSH.DirectorAddress == "" || state.Jumpbox.URL == "" {
+		return errors.New("there is no director in the state, run bbl up first")
+	}
+	if !state.DirectorBackups {
+		return errors.New("backups are not enabled on the director, run bbl plan --enable-backups and bbl up first")
+	}
+	return nil
+}
+
+func (b bbrDirector) run(state storage.State, workingDirectory string, args ...string) error {
+	jumpboxKeyPath, err := b.allProxyGetter.GeneratePrivateKey()
+	if err != nil {
+		return fmt.Errorf("Get jumpbox private key: %s", err) //nolint:staticcheck
+	}
+	keyDir := filepath.Dir(jumpboxKeyPath)
+	defer b.fs.RemoveAll(keyDir) //nolint:errcheck
+
+	directorKey, err := b.sshKeyGetter.Get("director")
+	if err != nil {
+		return fmt.Errorf("Get director private key: %s", err) //nolint:staticcheck
+	}
+
+	directorKeyPath := filepath.Join(keyDir, "director-private-key")
+	err = b.fs.WriteFile(directorKeyPath, []byte(directorKey), 0600)
+	if err != nil {
+		return fmt.Errorf("Write private key file: %s", err) //nolint:staticcheck
+	}
+
+	directorURL, err := url.Parse(state.BOSH.DirectorAddress)
+	if err != nil {
+		return fmt.Errorf("Parse director address: %s", err) //nolint:staticcheck
+	}
+
+	env := []string{
+		fmt.Sprintf("BOSH_ALL_PROXY=%s", b.allProxyGetter.BoshAllProxy(state.Jumpbox.GetURLWithJumpboxUser(), jumpboxKeyPath)),
+	}
+	bbrArgs := append([]string{
+		"director",
+		"--host", directorURL.Hostname(),
+		"--username", "jumpbox",
+		"--private-key-path", directorKeyPath,
+	}, args...)
+
+	return b.cli.Run(env, workingDirectory, bbrArgs)
+}
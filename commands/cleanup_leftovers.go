@@ -24,6 +24,15 @@ func NewCleanupLeftovers(deleter FilteredDeleter) CleanupLeftovers {
 }
 
 func (l CleanupLeftovers) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	// leftovers is only set up with static credentials on gcp and azure.
+	if l.deleter == nil {
+		switch state.IAAS {
+		case "gcp":
+			return errors.New("cleanup-leftovers on gcp needs --gcp-service-account-key, it cannot use application default credentials")
+		case "azure":
+			return errors.New("cleanup-leftovers on azure needs --azure-client-secret, it cannot use the Azure CLI or a managed identity")
+		}
+	}
 	return nil
 }
 
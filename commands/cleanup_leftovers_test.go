@@ -22,6 +22,29 @@ var _ = Describe("CleanupLeftovers", func() {
 		cleanup = commands.NewCleanupLeftovers(deleter)
 	})
 
+	Describe("CheckFastFails", func() {
+		It("returns no error", func() {
+			err := cleanup.CheckFastFails([]string{}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when there are no static credentials to delete with", func() {
+			BeforeEach(func() {
+				cleanup = commands.NewCleanupLeftovers(nil)
+			})
+
+			It("returns an error on gcp", func() {
+				err := cleanup.CheckFastFails([]string{}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("cleanup-leftovers on gcp needs --gcp-service-account-key, it cannot use application default credentials"))
+			})
+
+			It("returns an error on azure", func() {
+				err := cleanup.CheckFastFails([]string{}, storage.State{IAAS: "azure"})
+				Expect(err).To(MatchError("cleanup-leftovers on azure needs --azure-client-secret, it cannot use the Azure CLI or a managed identity"))
+			})
+		})
+	})
+
 	Describe("Execute", func() {
 		It("calls delete on leftovers with the filter", func() {
 			err := cleanup.Execute([]string{"--filter", filter}, storage.State{})
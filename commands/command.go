@@ -7,3 +7,14 @@ type Command interface {
 	Execute(subcommandFlags []string, state storage.State) error
 	Usage() string
 }
+
+// ExitCodeError is returned by commands that report their outcome through
+// the exit code, so that bbl exits with Code instead of 1.
+type ExitCodeError struct {
+	Code    int
+	Message string
+}
+
+func (e ExitCodeError) Error() string {
+	return e.Message
+}
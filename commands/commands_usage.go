@@ -7,15 +7,18 @@ const (
   --aws-access-key-id                AWS Access Key ID                env: $BBL_AWS_ACCESS_KEY_ID
   --aws-secret-access-key            AWS Secret Access Key            env: $BBL_AWS_SECRET_ACCESS_KEY
   --aws-region                       AWS Region                       env: $BBL_AWS_REGION
+  --aws-profile                      AWS Profile instead of keys      env: $BBL_AWS_PROFILE
 
   --gcp-service-account-key          GCP Service Access Key to use    env: $BBL_GCP_SERVICE_ACCOUNT_KEY
   --gcp-region                       GCP Region to use                env: $BBL_GCP_REGION
+  --gcp-credential-source            application-default              env: $BBL_GCP_CREDENTIAL_SOURCE
 
   --azure-subscription-id            Azure Subscription ID            env: $BBL_AZURE_SUBSCRIPTION_ID
   --azure-tenant-id                  Azure Tenant ID                  env: $BBL_AZURE_TENANT_ID
   --azure-client-id                  Azure Client ID                  env: $BBL_AZURE_CLIENT_ID
   --azure-client-secret              Azure Client Secret              env: $BBL_AZURE_CLIENT_SECRET
   --azure-region                     Azure Region                     env: $BBL_AZURE_REGION
+  --azure-credential-source          cli or managed-identity          env: $BBL_AZURE_CREDENTIAL_SOURCE
 
   --vsphere-vcenter-user             vSphere vCenter User             env: $BBL_VSPHERE_VCENTER_USER
   --vsphere-vcenter-password         vSphere vCenter Password         env: $BBL_VSPHERE_VCENTER_PASSWORD
@@ -69,17 +72,24 @@ const (
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere", "cloudstack"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --diff                     Prints a diff of the files it would write instead of writing them (optional)
+  --enable-backups           Adds the BOSH Backup and Restore scripts to the director for bbl backup-director (optional)
 `
 
 	UpCommandUsage = `Deploys BOSH director on an IAAS
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --only                     Comma separated phases to run: "terraform", "jumpbox", "director", "cloud-config", "runtime-config" (optional)
+  --skip                     Comma separated phases to leave out (optional)
+  --enable-backups           Adds the BOSH Backup and Restore scripts to a new director for bbl backup-director (optional)
 `
 
 	DestroyCommandUsage = `Tears down BOSH director infrastructure
 
-  [--no-confirm]       Do not ask for confirmation (optional)`
+  [--no-confirm]           Do not ask for confirmation (optional)
+  [--director-only]        Delete only the director, keeping the jumpbox and infrastructure (optional)
+  [--keep-infrastructure]  Delete the director and jumpbox, keeping the infrastructure (optional)`
 
 	CleanupLeftoversCommandUsage = `Cleans up orphaned IAAS resources
 
@@ -90,6 +100,10 @@ const (
 
 	OutputsCommandUsage = "Prints the outputs from terraform."
 
+	EnvInfoCommandUsage = `Prints the director address, credentials and CA, the jumpbox URL, the load balancer and the terraform outputs.
+
+  Prints YAML, or JSON with the global --json flag.`
+
 	VersionCommandUsage = "Prints version"
 
 	UsageCommandUsage = "Prints helpful message for the given command"
@@ -104,10 +118,32 @@ const (
 
   --jumpbox                Open a connection to the jumpbox
   --director               Open a connection to the director
-  --cmd                    Execute a command on the director (jumpbox not supported)
+  --cmd                    Execute a command on the director or the jumpbox
+`
+
+	SCPCommandUsage = `Copies files to or from the director or the jumpbox, the same way bbl ssh connects to them.
+
+  bbl scp [-r] <source> <destination>
+
+  Prefix the remote path with director: or jumpbox:, such as director:/var/vcap/sys/log.
+  [-r, --recursive]        Copy directories recursively (optional)
+`
+
+	TunnelCommandUsage = `Forwards a local port to an address that the jumpbox can reach until interrupted.
+
+  --local-port             Local port to listen on
+  --remote                 host:port to forward to, where director stands for the director address, such as director:8443 for UAA
 `
 
-	RotateCommandUsage = "Rotates SSH key for the jumpbox user."
+	RotateCommandUsage = `Rotates the SSH key for the jumpbox user, or the selected credentials.
+
+  [--director-creds]  Rotate the director admin password and UAA and CredHub admin client secrets (optional)
+  [--certs]           Move the director, UAA and CredHub CAs on to the next stage of a CA rotation (optional)
+  [--all]             Rotate the SSH key, director credentials and certificates (optional)`
+
+	UpgradeCommandUsage = `Upgrades the jumpbox and director to the jumpbox-deployment and bosh-deployment embedded in bbl.
+
+  [--dry-run]  Only show the ops files and the release and stemcell versions that would change (optional)`
 
 	JumpboxAddressCommandUsage = "Prints BOSH jumpbox address"
 
@@ -123,8 +159,79 @@ const (
 
   --shell-type             Prints for the given shell (posix|powershell|yaml)
   --metadata-file          Read from Toolsmiths metadata file instead of bbl state
+  --json                   Prints the variables as JSON
 `
 	LatestErrorCommandUsage = "Prints the output from the latest call to terraform"
+
+	DriftCommandUsage = `Runs terraform plan and lists the resources that bbl up would add, change or destroy.
+
+  Exits 2 when the infrastructure differs from the terraform templates.`
+
+	ConfigCommandUsage = `Checks the bbl.yml config file in the state directory, or the file at --config.
+
+  bbl config validate
+
+  Reports every problem with the file, such as a credential whose environment variable is not set or a missing certificate, and any credential the IAAS still needs, without calling the IAAS.`
+
+	StatusCommandUsage = `Checks that the jumpbox, director and CredHub are reachable, that the director certificate verifies and whether terraform reports drift.
+
+  --json                   Prints the checks as JSON
+
+  Exits 0 when healthy, 2 when a check fails and 3 when only terraform reports drift.`
+
+	BackupDirectorCommandUsage = `Backs up the BOSH director with bbr director backup through the jumpbox.
+
+  --artifact-dir           Directory to keep the backup in (optional, defaults to next to the remote state with --state-bucket and to the current directory otherwise)
+
+  Requires bbr on the PATH and a director created after bbl plan --enable-backups.`
+
+	RestoreDirectorCommandUsage = `Restores the BOSH director with bbr director restore through the jumpbox.
+
+  --artifact-path          Backup to restore: a local directory, or with --state-bucket the name of a backup next to the remote state
+
+  Requires bbr on the PATH and a director created after bbl plan --enable-backups.`
+
+	StateCommandUsage = `Manages the bbl state directory.
+
+  bbl state SUBCOMMAND [OPTIONS]`
+
+	StateEncryptCommandUsage = `Encrypts bbl-state.json and the vars directory at rest.
+
+  Requires the global --state-encryption-key flag (a passphrase, or file:<path> to read it from a file).`
+
+	StateUnlockCommandUsage = `Removes the lock held on the state directory by a bbl process that is no longer running.
+
+  --force                  Remove the lock even though another bbl process may still hold it`
+
+	StateDecryptCommandUsage = `Decrypts bbl-state.json and the vars directory back to plaintext.
+
+  Requires the global --state-encryption-key flag (a passphrase, or file:<path> to read it from a file).`
+
+	StateHistoryCommandUsage = `Lists the snapshots of bbl-state.json and the vars directory taken before each mutating command.`
+
+	StateRollbackCommandUsage = `Restores bbl-state.json and the vars directory from a snapshot.
+
+  bbl state rollback SNAPSHOT_ID
+
+  The state being replaced is kept as a new snapshot.`
+
+	StateAcceptEditCommandUsage = `Trusts a bbl-state.json that was edited by hand by giving it a new checksum.
+
+  bbl refuses a state file that does not match its checksum. Run this after editing it on purpose.`
+
+	StateMigrateCommandUsage = `Moves a state directory written by an older bbl to the current layout.
+
+  --dry-run                Print the migrations that would apply and the files they would create, update and delete`
+
+	StateExportCommandUsage = `Writes bbl-state.json, the vars directory and your plan patches to a portable bundle.
+
+  bbl state export BUNDLE_PATH`
+
+	StateImportCommandUsage = `Unpacks a bundle written by bbl state export into an empty state directory and runs plan on it.
+
+  bbl state import BUNDLE_PATH
+
+  Requires the credentials for the IAAS of the environment.`
 )
 
 func (Up) Usage() string {
@@ -143,10 +250,18 @@ func (Rotate) Usage() string {
 	return fmt.Sprintf("%s%s%s", RotateCommandUsage, requiresCredentials, Credentials)
 }
 
+func (Upgrade) Usage() string {
+	return fmt.Sprintf("%s%s%s", UpgradeCommandUsage, requiresCredentials, Credentials)
+}
+
 func (LBs) Usage() string { return LBsCommandUsage }
 
 func (Outputs) Usage() string { return OutputsCommandUsage }
 
+func (EnvInfo) Usage() string { return EnvInfoCommandUsage }
+
+func (Config) Usage() string { return ConfigCommandUsage }
+
 func (Version) Usage() string { return VersionCommandUsage }
 
 func (Usage) Usage() string { return UsageCommandUsage }
@@ -155,6 +270,32 @@ func (PrintEnv) Usage() string { return PrintEnvCommandUsage }
 
 func (LatestError) Usage() string { return LatestErrorCommandUsage }
 
+func (Status) Usage() string { return StatusCommandUsage }
+
+func (Drift) Usage() string { return DriftCommandUsage }
+
+func (BackupDirector) Usage() string { return BackupDirectorCommandUsage }
+
+func (RestoreDirector) Usage() string { return RestoreDirectorCommandUsage }
+
+func (StateEncrypt) Usage() string { return StateEncryptCommandUsage }
+
+func (StateDecrypt) Usage() string { return StateDecryptCommandUsage }
+
+func (StateUnlock) Usage() string { return StateUnlockCommandUsage }
+
+func (StateHistory) Usage() string { return StateHistoryCommandUsage }
+
+func (StateRollback) Usage() string { return StateRollbackCommandUsage }
+
+func (StateAcceptEdit) Usage() string { return StateAcceptEditCommandUsage }
+
+func (StateMigrate) Usage() string { return StateMigrateCommandUsage }
+
+func (StateExport) Usage() string { return StateExportCommandUsage }
+
+func (StateImport) Usage() string { return StateImportCommandUsage }
+
 func (Validate) Usage() string { return "" }
 
 func (s SSHKey) Usage() string {
@@ -168,6 +309,10 @@ func (s SSH) Usage() string {
 	return SSHCommandUsage
 }
 
+func (SCP) Usage() string { return SCPCommandUsage }
+
+func (Tunnel) Usage() string { return TunnelCommandUsage }
+
 func (s StateQuery) Usage() string {
 	switch s.propertyName {
 	case EnvIDPropertyName:
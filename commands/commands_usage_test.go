@@ -19,19 +19,25 @@ var _ = Describe("Commands Usage", func() {
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --only                     Comma separated phases to run: "terraform", "jumpbox", "director", "cloud-config", "runtime-config" (optional)
+  --skip                     Comma separated phases to leave out (optional)
+  --enable-backups           Adds the BOSH Backup and Restore scripts to a new director for bbl backup-director (optional)
 
   --aws-access-key-id                AWS Access Key ID                env: $BBL_AWS_ACCESS_KEY_ID
   --aws-secret-access-key            AWS Secret Access Key            env: $BBL_AWS_SECRET_ACCESS_KEY
   --aws-region                       AWS Region                       env: $BBL_AWS_REGION
+  --aws-profile                      AWS Profile instead of keys      env: $BBL_AWS_PROFILE
 
   --gcp-service-account-key          GCP Service Access Key to use    env: $BBL_GCP_SERVICE_ACCOUNT_KEY
   --gcp-region                       GCP Region to use                env: $BBL_GCP_REGION
+  --gcp-credential-source            application-default              env: $BBL_GCP_CREDENTIAL_SOURCE
 
   --azure-subscription-id            Azure Subscription ID            env: $BBL_AZURE_SUBSCRIPTION_ID
   --azure-tenant-id                  Azure Tenant ID                  env: $BBL_AZURE_TENANT_ID
   --azure-client-id                  Azure Client ID                  env: $BBL_AZURE_CLIENT_ID
   --azure-client-secret              Azure Client Secret              env: $BBL_AZURE_CLIENT_SECRET
   --azure-region                     Azure Region                     env: $BBL_AZURE_REGION
+  --azure-credential-source          cli or managed-identity          env: $BBL_AZURE_CREDENTIAL_SOURCE
 
   --vsphere-vcenter-user             vSphere vCenter User             env: $BBL_VSPHERE_VCENTER_USER
   --vsphere-vcenter-password         vSphere vCenter Password         env: $BBL_VSPHERE_VCENTER_PASSWORD
@@ -87,6 +93,8 @@ var _ = Describe("Commands Usage", func() {
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere", "cloudstack"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --diff                     Prints a diff of the files it would write instead of writing them (optional)
+  --enable-backups           Adds the BOSH Backup and Restore scripts to the director for bbl backup-director (optional)
 %s%s`, commands.Credentials, commands.LBUsage)))
 			})
 		})
@@ -99,7 +107,9 @@ var _ = Describe("Commands Usage", func() {
 				usageText := command.Usage()
 				Expect(usageText).To(Equal(fmt.Sprintf(`Tears down BOSH director infrastructure
 
-  [--no-confirm]       Do not ask for confirmation (optional)
+  [--no-confirm]           Do not ask for confirmation (optional)
+  [--director-only]        Delete only the director, keeping the jumpbox and infrastructure (optional)
+  [--keep-infrastructure]  Delete the director and jumpbox, keeping the infrastructure (optional)
 
   Credentials for your IaaS are required:%s`, commands.Credentials)))
 			})
@@ -124,13 +134,59 @@ var _ = Describe("Commands Usage", func() {
 			It("returns string describing usage", func() {
 				command := commands.Rotate{}
 				usageText := command.Usage()
-				Expect(usageText).To(Equal(fmt.Sprintf(`Rotates SSH key for the jumpbox user.
+				Expect(usageText).To(Equal(fmt.Sprintf(`Rotates the SSH key for the jumpbox user, or the selected credentials.
+
+  [--director-creds]  Rotate the director admin password and UAA and CredHub admin client secrets (optional)
+  [--certs]           Move the director, UAA and CredHub CAs on to the next stage of a CA rotation (optional)
+  [--all]             Rotate the SSH key, director credentials and certificates (optional)
+
+  Credentials for your IaaS are required:%s`, commands.Credentials)))
+			})
+		})
+	})
+
+	Describe("Upgrade", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Upgrade{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(fmt.Sprintf(`Upgrades the jumpbox and director to the jumpbox-deployment and bosh-deployment embedded in bbl.
+
+  [--dry-run]  Only show the ops files and the release and stemcell versions that would change (optional)
 
   Credentials for your IaaS are required:%s`, commands.Credentials)))
 			})
 		})
 	})
 
+	Describe("BackupDirector", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.BackupDirector{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Backs up the BOSH director with bbr director backup through the jumpbox.
+
+  --artifact-dir           Directory to keep the backup in (optional, defaults to next to the remote state with --state-bucket and to the current directory otherwise)
+
+  Requires bbr on the PATH and a director created after bbl plan --enable-backups.`))
+			})
+		})
+	})
+
+	Describe("RestoreDirector", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.RestoreDirector{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Restores the BOSH director with bbr director restore through the jumpbox.
+
+  --artifact-path          Backup to restore: a local directory, or with --state-bucket the name of a backup next to the remote state
+
+  Requires bbr on the PATH and a director created after bbl plan --enable-backups.`))
+			})
+		})
+	})
+
 	Describe("SSH", func() {
 		Describe("Usage", func() {
 			It("returns string describing usage", func() {
@@ -140,7 +196,37 @@ var _ = Describe("Commands Usage", func() {
 
   --jumpbox                Open a connection to the jumpbox
   --director               Open a connection to the director
-  --cmd                    Execute a command on the director (jumpbox not supported)
+  --cmd                    Execute a command on the director or the jumpbox
+`))
+			})
+		})
+	})
+
+	Describe("SCP", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.SCP{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Copies files to or from the director or the jumpbox, the same way bbl ssh connects to them.
+
+  bbl scp [-r] <source> <destination>
+
+  Prefix the remote path with director: or jumpbox:, such as director:/var/vcap/sys/log.
+  [-r, --recursive]        Copy directories recursively (optional)
+`))
+			})
+		})
+	})
+
+	Describe("Tunnel", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Tunnel{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Forwards a local port to an address that the jumpbox can reach until interrupted.
+
+  --local-port             Local port to listen on
+  --remote                 host:port to forward to, where director stands for the director address, such as director:8443 for UAA
 `))
 			})
 		})
@@ -155,10 +241,59 @@ var _ = Describe("Commands Usage", func() {
 
   --shell-type             Prints for the given shell (posix|powershell|yaml)
   --metadata-file          Read from Toolsmiths metadata file instead of bbl state
+  --json                   Prints the variables as JSON
 `))
 			})
 		})
 	})
+	Describe("EnvInfo", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.EnvInfo{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints the director address, credentials and CA, the jumpbox URL, the load balancer and the terraform outputs.
+
+  Prints YAML, or JSON with the global --json flag.`))
+			})
+		})
+	})
+	Describe("Status", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Status{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Checks that the jumpbox, director and CredHub are reachable, that the director certificate verifies and whether terraform reports drift.
+
+  --json                   Prints the checks as JSON
+
+  Exits 0 when healthy, 2 when a check fails and 3 when only terraform reports drift.`))
+			})
+		})
+	})
+	Describe("Config", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Config{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Checks the bbl.yml config file in the state directory, or the file at --config.
+
+  bbl config validate
+
+  Reports every problem with the file, such as a credential whose environment variable is not set or a missing certificate, and any credential the IAAS still needs, without calling the IAAS.`))
+			})
+		})
+	})
+	Describe("Drift", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Drift{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Runs terraform plan and lists the resources that bbl up would add, change or destroy.
+
+  Exits 2 when the infrastructure differs from the terraform templates.`))
+			})
+		})
+	})
 	Describe("Usage", func() {
 		Describe("Usage", func() {
 			It("returns string describing usage", func() {
@@ -189,5 +324,5 @@ var _ = Describe("Commands Usage", func() {
 })
 
 func newStateQuery(propertyName string) commands.StateQuery {
-	return commands.NewStateQuery(nil, nil, nil, propertyName)
+	return commands.NewStateQuery(nil, nil, nil, nil, propertyName)
 }
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type configFileValidator interface {
+	Validate(state storage.State) (string, []string)
+}
+
+// Config checks the bbl.yml config file with bbl config validate.
+type Config struct {
+	logger    logger
+	validator configFileValidator
+}
+
+func NewConfig(logger logger, validator configFileValidator) Config {
+	return Config{
+		logger:    logger,
+		validator: validator,
+	}
+}
+
+func (c Config) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) == 0 {
+		return errors.New("bbl config requires a subcommand, see bbl config --help")
+	}
+	if subcommandFlags[0] != "validate" {
+		return fmt.Errorf("unknown config subcommand: %s", subcommandFlags[0])
+	}
+	return nil
+}
+
+// Execute reports every problem with the config file, rather than the
+// first, before anything is created in the IaaS.
+func (c Config) Execute(subcommandFlags []string, state storage.State) error {
+	path, problems := c.validator.Validate(state)
+	if len(problems) == 0 {
+		c.logger.Println(fmt.Sprintf("%s is valid", path))
+		return nil
+	}
+
+	c.logger.Println(fmt.Sprintf("%s:", path))
+	for _, problem := range problems {
+		c.logger.Println(fmt.Sprintf("  - %s", problem))
+	}
+	return fmt.Errorf("%s is not valid", path)
+}
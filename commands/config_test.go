@@ -0,0 +1,74 @@
+package commands_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	var (
+		logger    *fakes.Logger
+		validator *fakes.ConfigFileValidator
+
+		command commands.Config
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		validator = &fakes.ConfigFileValidator{}
+		validator.ValidateCall.Returns.Path = "/state/bbl.yml"
+
+		command = commands.NewConfig(logger, validator)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("accepts validate", func() {
+			err := command.CheckFastFails([]string{"validate"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when no subcommand is given", func() {
+			It("returns an error", func() {
+				err := command.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("bbl config requires a subcommand, see bbl config --help"))
+			})
+		})
+
+		Context("when the subcommand is unknown", func() {
+			It("returns an error", func() {
+				err := command.CheckFastFails([]string{"banana"}, storage.State{})
+				Expect(err).To(MatchError("unknown config subcommand: banana"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("validates the config file with the state", func() {
+			err := command.Execute([]string{"validate"}, storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(validator.ValidateCall.CallCount).To(Equal(1))
+			Expect(validator.ValidateCall.Receives.State.EnvID).To(Equal("some-env-id"))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"/state/bbl.yml is valid"}))
+		})
+
+		Context("when the config file has problems", func() {
+			It("prints every problem and returns an error", func() {
+				validator.ValidateCall.Returns.Problems = []string{"iaas is not set", "lb.chain is only supported on aws"}
+
+				err := command.Execute([]string{"validate"}, storage.State{})
+				Expect(err).To(MatchError("/state/bbl.yml is not valid"))
+
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"/state/bbl.yml:",
+					"  - iaas is not set",
+					"  - lb.chain is only supported on aws",
+				}))
+			})
+		})
+	})
+})
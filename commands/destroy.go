@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
@@ -19,6 +20,15 @@ type Destroy struct {
 	networkDeletionValidator NetworkDeletionValidator
 }
 
+type DestroyConfig struct {
+	// DirectorOnly deletes the director and keeps the jumpbox and the
+	// infrastructure.
+	DirectorOnly bool
+	// KeepInfrastructure deletes the director and the jumpbox and keeps what
+	// terraform created.
+	KeepInfrastructure bool
+}
+
 type NetworkDeletionValidator interface {
 	ValidateSafeToDelete(networkName string, envID string) error
 }
@@ -38,7 +48,12 @@ func NewDestroy(plan plan, logger logger, boshManager boshManager, stateStore st
 }
 
 func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) error {
-	err := fastFailBOSHVersion(d.boshManager)
+	config, err := d.ParseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	err = fastFailBOSHVersion(d.boshManager)
 	if err != nil {
 		return err
 	}
@@ -58,6 +73,11 @@ func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) e
 		return err
 	}
 
+	// The network is only deleted along with the infrastructure.
+	if config.keepsInfrastructure() {
+		return nil
+	}
+
 	isPaved, _ := d.terraformManager.IsPaved() //nolint:errcheck
 	if !isPaved {
 		return nil
@@ -90,8 +110,41 @@ func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) e
 	return nil
 }
 
+func (d Destroy) ParseArgs(args []string) (DestroyConfig, error) {
+	var config DestroyConfig
+	destroyFlags := flags.New("destroy")
+	destroyFlags.Bool(&config.DirectorOnly, "director-only")
+	destroyFlags.Bool(&config.KeepInfrastructure, "keep-infrastructure")
+
+	err := destroyFlags.Parse(args)
+	if err != nil {
+		return DestroyConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (c DestroyConfig) keepsInfrastructure() bool {
+	return c.DirectorOnly || c.KeepInfrastructure
+}
+
 func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
-	proceed := d.logger.Prompt(fmt.Sprintf("Are you sure you want to delete infrastructure for %q? This operation cannot be undone!", state.EnvID))
+	config, err := d.ParseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	target := "infrastructure"
+	switch {
+	case config.DirectorOnly:
+		target = "the BOSH director"
+	case config.KeepInfrastructure:
+		target = "the BOSH director and jumpbox"
+	}
+
+	defer d.logger.SetPhase("")
+
+	proceed := d.logger.Prompt(fmt.Sprintf("Are you sure you want to delete %s for %q? This operation cannot be undone!", target, state.EnvID))
 	if !proceed {
 		d.logger.Step("exiting")
 		return nil
@@ -103,7 +156,6 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 			LB:   state.LB,
 		}
 
-		var err error
 		state, err = d.plan.InitializePlan(planConfig, state)
 		if err != nil {
 			return fmt.Errorf("Initialize plan during destroy: %s", err) //nolint:staticcheck
@@ -116,6 +168,12 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 	}
 
 	if !isPaved {
+		// Without infrastructure there is nothing to delete, and the state
+		// is only forgotten along with the infrastructure.
+		if config.keepsInfrastructure() {
+			return nil
+		}
+
 		if err := d.stateStore.Set(storage.State{}); err != nil {
 			return err
 		}
@@ -127,7 +185,17 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 		return err
 	}
 
-	state, err = d.deleteBOSH(state, terraformOutputs)
+	// bbl up has to recreate everything from the first phase that is deleted.
+	switch {
+	case config.DirectorOnly:
+		state.Checkpoints = checkpointsBefore(state.Checkpoints, DirectorPhase)
+	case config.KeepInfrastructure:
+		state.Checkpoints = checkpointsBefore(state.Checkpoints, JumpboxPhase)
+	default:
+		state.Checkpoints = nil
+	}
+
+	state, err = d.deleteBOSH(state, terraformOutputs, config.DirectorOnly)
 	switch err.(type) { //nolint:staticcheck
 	case bosh.ManagerDeleteError:
 		mdErr := err.(bosh.ManagerDeleteError) //nolint:staticcheck
@@ -147,10 +215,15 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 		return err
 	}
 
+	if config.keepsInfrastructure() {
+		return nil
+	}
+
 	if err = d.terraformManager.Setup(state); err != nil {
 		return err
 	}
 
+	d.logger.SetPhase(TerraformPhase)
 	state, err = d.terraformManager.Destroy(state)
 	if err != nil {
 		return handleTerraformError(err, state, d.stateStore)
@@ -163,12 +236,13 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 	return nil
 }
 
-func (d Destroy) deleteBOSH(state storage.State, terraformOutputs terraform.Outputs) (storage.State, error) {
+func (d Destroy) deleteBOSH(state storage.State, terraformOutputs terraform.Outputs, directorOnly bool) (storage.State, error) {
 	err := d.boshManager.CleanUpDirector(state)
 	if err != nil {
 		return state, err
 	}
 
+	d.logger.SetPhase(DirectorPhase)
 	err = d.boshManager.DeleteDirector(state, terraformOutputs)
 	if err != nil {
 		return state, err
@@ -176,6 +250,11 @@ func (d Destroy) deleteBOSH(state storage.State, terraformOutputs terraform.Outp
 
 	state.BOSH = storage.BOSH{}
 
+	if directorOnly {
+		return state, nil
+	}
+
+	d.logger.SetPhase(JumpboxPhase)
 	err = d.boshManager.DeleteJumpbox(state, terraformOutputs)
 	if err != nil {
 		return state, err
@@ -182,6 +182,15 @@ var _ = Describe("Destroy", func() {
 					Expect(networkDeletionValidator.ValidateSafeToDeleteCall.Receives.NetworkName).To(Equal("some-vpc-id"))
 					Expect(networkDeletionValidator.ValidateSafeToDeleteCall.Receives.EnvID).To(Equal("some-env-id"))
 				})
+
+				Context("when the infrastructure is kept", func() {
+					It("does not check the network", func() {
+						err := destroy.CheckFastFails([]string{"--keep-infrastructure"}, state)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(networkDeletionValidator.ValidateSafeToDeleteCall.CallCount).To(Equal(0))
+					})
+				})
 			})
 
 			Context("when terraform manager fails to get outputs", func() {
@@ -295,6 +304,34 @@ var _ = Describe("Destroy", func() {
 			Expect(stateStore.SetCall.Receives[0].State.BOSH).To(Equal(storage.BOSH{}))
 		})
 
+		It("tags the log with each phase while it is deleted", func() {
+			state := storage.State{
+				BOSH: storage.BOSH{
+					DirectorName: "some-director",
+				},
+				Jumpbox: storage.Jumpbox{
+					Manifest: "some-manifest",
+				},
+			}
+
+			err := destroy.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.SetPhaseCall.Phases).To(Equal([]string{"director", "jumpbox", "terraform", ""}))
+		})
+
+		It("drops the bbl up checkpoints", func() {
+			state := storage.State{
+				Checkpoints: []storage.Checkpoint{{Phase: "terraform", Fingerprint: "some-fingerprint"}},
+			}
+
+			err := destroy.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshManager.DeleteDirectorCall.Receives.State.Checkpoints).To(BeNil())
+			Expect(stateStore.SetCall.Receives[0].State.Checkpoints).To(BeNil())
+		})
+
 		It("invokes bosh delete jumpbox as well", func() {
 			state := storage.State{
 				BOSH: storage.BOSH{
@@ -325,6 +362,81 @@ var _ = Describe("Destroy", func() {
 			Expect(stateStore.SetCall.Receives[0].State.BOSH).To(Equal(storage.BOSH{}))
 		})
 
+		Context("when --director-only is passed", func() {
+			var state storage.State
+
+			BeforeEach(func() {
+				state = storage.State{
+					EnvID:   "some-lake",
+					BOSH:    storage.BOSH{DirectorName: "some-director"},
+					Jumpbox: storage.Jumpbox{URL: "some-jumpbox-url"},
+					Checkpoints: []storage.Checkpoint{
+						{Phase: "terraform", Fingerprint: "terraform-fingerprint"},
+						{Phase: "jumpbox", Fingerprint: "jumpbox-fingerprint"},
+						{Phase: "director", Fingerprint: "director-fingerprint"},
+						{Phase: "cloud-config", Fingerprint: "cloud-config-fingerprint"},
+					},
+				}
+			})
+
+			It("deletes only the director", func() {
+				err := destroy.Execute([]string{"--director-only"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PromptCall.Receives.Message).To(Equal(`Are you sure you want to delete the BOSH director for "some-lake"? This operation cannot be undone!`))
+				Expect(boshManager.DeleteDirectorCall.CallCount).To(Equal(1))
+				Expect(boshManager.DeleteJumpboxCall.CallCount).To(Equal(0))
+				Expect(terraformManager.SetupCall.CallCount).To(Equal(0))
+				Expect(terraformManager.DestroyCall.CallCount).To(Equal(0))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State).To(Equal(storage.State{
+					EnvID:   "some-lake",
+					Jumpbox: storage.Jumpbox{URL: "some-jumpbox-url"},
+					Checkpoints: []storage.Checkpoint{
+						{Phase: "terraform", Fingerprint: "terraform-fingerprint"},
+						{Phase: "jumpbox", Fingerprint: "jumpbox-fingerprint"},
+					},
+				}))
+			})
+		})
+
+		Context("when --keep-infrastructure is passed", func() {
+			It("deletes the director and the jumpbox", func() {
+				state := storage.State{
+					EnvID:   "some-lake",
+					BOSH:    storage.BOSH{DirectorName: "some-director"},
+					Jumpbox: storage.Jumpbox{URL: "some-jumpbox-url"},
+					Checkpoints: []storage.Checkpoint{
+						{Phase: "terraform", Fingerprint: "terraform-fingerprint"},
+						{Phase: "jumpbox", Fingerprint: "jumpbox-fingerprint"},
+					},
+				}
+
+				err := destroy.Execute([]string{"--keep-infrastructure"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PromptCall.Receives.Message).To(Equal(`Are you sure you want to delete the BOSH director and jumpbox for "some-lake"? This operation cannot be undone!`))
+				Expect(boshManager.DeleteDirectorCall.CallCount).To(Equal(1))
+				Expect(boshManager.DeleteJumpboxCall.CallCount).To(Equal(1))
+				Expect(terraformManager.DestroyCall.CallCount).To(Equal(0))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State).To(Equal(storage.State{
+					EnvID:       "some-lake",
+					Checkpoints: []storage.Checkpoint{{Phase: "terraform", Fingerprint: "terraform-fingerprint"}},
+				}))
+			})
+		})
+
+		Context("when the flags are invalid", func() {
+			It("returns an error", func() {
+				err := destroy.Execute([]string{"--some-flag"}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("some-flag")))
+				Expect(logger.PromptCall.CallCount).To(Equal(0))
+			})
+		})
+
 		Context("when the plan is not initialized", func() {
 			It("initializes the plan", func() {
 				plan.IsInitializedCall.Returns.IsInitialized = false
@@ -358,6 +470,20 @@ var _ = Describe("Destroy", func() {
 				Expect(stateStore.SetCall.CallCount).To(Equal(1))
 				Expect(stateStore.SetCall.Receives[0].State).To(Equal(storage.State{}))
 			})
+
+			DescribeTable("keeps the state when the infrastructure is kept",
+				func(flag string) {
+					terraformManager.IsPavedCall.Returns.IsPaved = false
+
+					err := destroy.Execute([]string{flag}, storage.State{EnvID: "some-env-id"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(boshManager.DeleteDirectorCall.CallCount).To(Equal(0))
+					Expect(terraformManager.DestroyCall.CallCount).To(Equal(0))
+					Expect(stateStore.SetCall.CallCount).To(Equal(0))
+				},
+				Entry("with --director-only", "--director-only"),
+				Entry("with --keep-infrastructure", "--keep-infrastructure"),
+			)
 		})
 
 		Context("failure cases", func() {
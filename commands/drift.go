@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+)
+
+type Drift struct {
+	logger           logger
+	stateValidator   stateValidator
+	terraformManager terraformManager
+}
+
+func NewDrift(logger logger, stateValidator stateValidator, terraformManager terraformManager) Drift {
+	return Drift{
+		logger:           logger,
+		stateValidator:   stateValidator,
+		terraformManager: terraformManager,
+	}
+}
+
+func (d Drift) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return d.stateValidator.Validate()
+}
+
+func (d Drift) Execute(subcommandFlags []string, state storage.State) error {
+	plan, err := d.terraformManager.Plan(state)
+	if err != nil {
+		return err
+	}
+
+	if asJSON(subcommandFlags) {
+		err = printJSON(d.logger, driftDocument(plan))
+		if err != nil {
+			return err
+		}
+	} else {
+		d.printPlan(plan)
+	}
+
+	if !plan.HasChanges {
+		return nil
+	}
+
+	return ExitCodeError{Code: DriftExitCode, Message: "the infrastructure differs from the terraform templates, bbl up would change it"}
+}
+
+func (d Drift) printPlan(plan terraform.PlanSummary) {
+	if !plan.HasChanges {
+		d.logger.Println("no drift: the infrastructure matches the terraform templates")
+		return
+	}
+
+	d.logger.Printf("terraform plan: %s\n", planCounts(plan))
+	for _, change := range plan.Changes {
+		d.logger.Printf("  %-10s%s\n", change.Action, change.Address)
+	}
+
+	if len(plan.Drifted) > 0 {
+		d.logger.Println("changed outside of terraform:")
+		for _, change := range plan.Drifted {
+			d.logger.Printf("  %-10s%s\n", change.Action, change.Address)
+		}
+	}
+}
+
+type driftJSON struct {
+	Drift   bool                       `json:"drift"`
+	Add     int                        `json:"add"`
+	Change  int                        `json:"change"`
+	Destroy int                        `json:"destroy"`
+	Changes []terraform.ResourceChange `json:"changes"`
+	Drifted []terraform.ResourceChange `json:"drifted"`
+}
+
+func driftDocument(plan terraform.PlanSummary) driftJSON {
+	document := driftJSON{
+		Drift:   plan.HasChanges,
+		Add:     plan.Add,
+		Change:  plan.Change,
+		Destroy: plan.Destroy,
+		Changes: plan.Changes,
+		Drifted: plan.Drifted,
+	}
+	if document.Changes == nil {
+		document.Changes = []terraform.ResourceChange{}
+	}
+	if document.Drifted == nil {
+		document.Drifted = []terraform.ResourceChange{}
+	}
+	return document
+}
+
+func planCounts(plan terraform.PlanSummary) string {
+	return fmt.Sprintf("%d to add, %d to change, %d to destroy", plan.Add, plan.Change, plan.Destroy)
+}
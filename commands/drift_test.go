@@ -0,0 +1,129 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	var (
+		logger           *fakes.Logger
+		stateValidator   *fakes.StateValidator
+		terraformManager *fakes.TerraformManager
+		state            storage.State
+
+		command commands.Drift
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		terraformManager = &fakes.TerraformManager{}
+		state = storage.State{EnvID: "some-env-id"}
+
+		command = commands.NewDrift(logger, stateValidator, terraformManager)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when there is no state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("no state"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("reports that there is no drift", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.PlanCall.Receives.BBLState).To(Equal(state))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"no drift: the infrastructure matches the terraform templates"}))
+		})
+
+		Context("when terraform would change the infrastructure", func() {
+			BeforeEach(func() {
+				terraformManager.PlanCall.Returns.Summary = terraform.PlanSummary{
+					HasChanges: true,
+					Add:        1,
+					Change:     1,
+					Changes: []terraform.ResourceChange{
+						{Address: "aws_security_group.internal_security_group", Action: "update"},
+						{Address: "aws_eip.jumpbox_eip", Action: "create"},
+					},
+					Drifted: []terraform.ResourceChange{
+						{Address: "aws_security_group.internal_security_group", Action: "update"},
+					},
+				}
+			})
+
+			It("summarises the changes and exits with the drift exit code", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).To(Equal(commands.ExitCodeError{Code: 2, Message: "the infrastructure differs from the terraform templates, bbl up would change it"}))
+
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{
+					"terraform plan: 1 to add, 1 to change, 0 to destroy\n",
+					"  update    aws_security_group.internal_security_group\n",
+					"  create    aws_eip.jumpbox_eip\n",
+					"  update    aws_security_group.internal_security_group\n",
+				}))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{"changed outside of terraform:"}))
+			})
+
+			Context("with --json", func() {
+				It("prints the changes as json and exits with the drift exit code", func() {
+					err := command.Execute([]string{"--json"}, state)
+					Expect(err).To(Equal(commands.ExitCodeError{Code: 2, Message: "the infrastructure differs from the terraform templates, bbl up would change it"}))
+
+					Expect(logger.PrintfCall.CallCount).To(Equal(0))
+					Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+						"drift": true,
+						"add": 1,
+						"change": 1,
+						"destroy": 0,
+						"changes": [
+							{"address": "aws_security_group.internal_security_group", "action": "update"},
+							{"address": "aws_eip.jumpbox_eip", "action": "create"}
+						],
+						"drifted": [
+							{"address": "aws_security_group.internal_security_group", "action": "update"}
+						]
+					}`))
+				})
+			})
+		})
+
+		Context("with --json and no drift", func() {
+			It("prints an empty plan as json", func() {
+				err := command.Execute([]string{"--json"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+					"drift": false,
+					"add": 0,
+					"change": 0,
+					"destroy": 0,
+					"changes": [],
+					"drifted": []
+				}`))
+			})
+		})
+
+		Context("when terraform plan fails", func() {
+			It("returns the error", func() {
+				terraformManager.PlanCall.Returns.Error = errors.New("Executor plan: some-error")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Executor plan: some-error"))
+			})
+		})
+	})
+})
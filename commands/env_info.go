@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"gopkg.in/yaml.v2"
+)
+
+type EnvInfo struct {
+	logger           logger
+	stateValidator   stateValidator
+	terraformManager terraformManager
+	secretResolver   secretResolver
+}
+
+type envInfo struct {
+	EnvID            string                 `json:"env_id"            yaml:"env_id"`
+	IAAS             string                 `json:"iaas"              yaml:"iaas"`
+	Director         envInfoDirector        `json:"director"          yaml:"director"`
+	Jumpbox          envInfoJumpbox         `json:"jumpbox"           yaml:"jumpbox"`
+	LB               *envInfoLB             `json:"lb,omitempty"      yaml:"lb,omitempty"`
+	TerraformOutputs map[string]interface{} `json:"terraform_outputs" yaml:"terraform_outputs"`
+}
+
+type envInfoDirector struct {
+	Name     string `json:"name"     yaml:"name"`
+	Address  string `json:"address"  yaml:"address"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	CACert   string `json:"ca_cert"  yaml:"ca_cert"`
+}
+
+type envInfoJumpbox struct {
+	URL string `json:"url" yaml:"url"`
+}
+
+type envInfoLB struct {
+	Type   string `json:"type"             yaml:"type"`
+	Domain string `json:"domain,omitempty" yaml:"domain,omitempty"`
+}
+
+func NewEnvInfo(logger logger, stateValidator stateValidator, terraformManager terraformManager, secretResolver secretResolver) EnvInfo {
+	return EnvInfo{
+		logger:           logger,
+		stateValidator:   stateValidator,
+		terraformManager: terraformManager,
+		secretResolver:   secretResolver,
+	}
+}
+
+func (e EnvInfo) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return e.stateValidator.Validate()
+}
+
+// Execute prints everything that is needed to target the environment, so
+// that scripts do not have to call a command for each of them.
+func (e EnvInfo) Execute(subcommandFlags []string, state storage.State) error {
+	directorPassword, err := e.secretResolver.Resolve(state.BOSH.DirectorPassword)
+	if err != nil {
+		return fmt.Errorf("Resolve director password: %s", err) //nolint:staticcheck
+	}
+
+	outputs, err := e.terraformManager.GetOutputs()
+	if err != nil {
+		return fmt.Errorf("Get terraform outputs: %s", err) //nolint:staticcheck
+	}
+
+	info := envInfo{
+		EnvID: state.EnvID,
+		IAAS:  state.IAAS,
+		Director: envInfoDirector{
+			Name:     state.BOSH.DirectorName,
+			Address:  state.BOSH.DirectorAddress,
+			Username: state.BOSH.DirectorUsername,
+			Password: directorPassword,
+			CACert:   state.BOSH.DirectorSSLCA,
+		},
+		Jumpbox: envInfoJumpbox{
+			URL: state.Jumpbox.URL,
+		},
+		TerraformOutputs: outputs.Map,
+	}
+	if state.LB.Type != "" {
+		info.LB = &envInfoLB{
+			Type:   state.LB.Type,
+			Domain: state.LB.Domain,
+		}
+	}
+	if info.TerraformOutputs == nil {
+		info.TerraformOutputs = map[string]interface{}{}
+	}
+
+	if asJSON(subcommandFlags) {
+		return printJSON(e.logger, info)
+	}
+
+	marshalled, err := yaml.Marshal(info)
+	if err != nil {
+		return err
+	}
+	e.logger.Println(strings.TrimSuffix(string(marshalled), "\n"))
+	return nil
+}
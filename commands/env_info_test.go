@@ -0,0 +1,162 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnvInfo", func() {
+	var (
+		envInfo          commands.EnvInfo
+		logger           *fakes.Logger
+		stateValidator   *fakes.StateValidator
+		terraformManager *fakes.TerraformManager
+		secretResolver   *fakes.SecretResolver
+		state            storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		terraformManager = &fakes.TerraformManager{}
+		secretResolver = &fakes.SecretResolver{}
+
+		terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{
+			Map: map[string]interface{}{
+				"router_lb_ip": "some-router-lb-ip",
+			},
+		}
+
+		state = storage.State{
+			EnvID: "some-env-id",
+			IAAS:  "gcp",
+			BOSH: storage.BOSH{
+				DirectorName:     "some-director-name",
+				DirectorAddress:  "https://10.0.0.6:25555",
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-password",
+				DirectorSSLCA:    "some-director-ca",
+			},
+			Jumpbox: storage.Jumpbox{
+				URL: "some-jumpbox-url:22",
+			},
+			LB: storage.LB{
+				Type:   "cf",
+				Domain: "some-domain",
+			},
+		}
+
+		envInfo = commands.NewEnvInfo(logger, stateValidator, terraformManager, secretResolver)
+	})
+
+	Describe("CheckFastFails", func() {
+		Context("when state validation fails", func() {
+			BeforeEach(func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("tamarind")
+			})
+
+			It("returns an error", func() {
+				err := envInfo.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("tamarind"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints the environment as json", func() {
+			err := envInfo.Execute([]string{"--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"env_id": "some-env-id",
+				"iaas": "gcp",
+				"director": {
+					"name": "some-director-name",
+					"address": "https://10.0.0.6:25555",
+					"username": "some-director-username",
+					"password": "some-director-password",
+					"ca_cert": "some-director-ca"
+				},
+				"jumpbox": {
+					"url": "some-jumpbox-url:22"
+				},
+				"lb": {
+					"type": "cf",
+					"domain": "some-domain"
+				},
+				"terraform_outputs": {
+					"router_lb_ip": "some-router-lb-ip"
+				}
+			}`))
+		})
+
+		It("prints the environment as yaml without --json", func() {
+			err := envInfo.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring("env_id: some-env-id\n"))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring("jumpbox:\n  url: some-jumpbox-url:22\n"))
+			Expect(logger.PrintlnCall.Receives.Message).To(HaveSuffix("terraform_outputs:\n  router_lb_ip: some-router-lb-ip"))
+		})
+
+		It("prints values that look like format verbs as they are", func() {
+			state.BOSH.DirectorPassword = "some-%s-password"
+
+			err := envInfo.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring("password: some-%s-password\n"))
+		})
+
+		It("resolves the director password", func() {
+			secretResolver.ResolveCall.Stub = func(string) (string, error) { return "some-resolved-password", nil }
+
+			err := envInfo.Execute([]string{"--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(secretResolver.ResolveCall.Receives.Value).To(Equal("some-director-password"))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"password": "some-resolved-password"`))
+		})
+
+		Context("when there is no lb", func() {
+			It("leaves out the lb", func() {
+				state.LB = storage.LB{}
+
+				err := envInfo.Execute([]string{"--json"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).NotTo(ContainSubstring(`"lb"`))
+			})
+		})
+
+		Context("when the director password cannot be resolved", func() {
+			BeforeEach(func() {
+				secretResolver.ResolveCall.Stub = func(string) (string, error) { return "", errors.New("vault is sealed") }
+			})
+
+			It("returns an error", func() {
+				err := envInfo.Execute([]string{"--json"}, state)
+				Expect(err).To(MatchError("Resolve director password: vault is sealed"))
+			})
+		})
+
+		Context("when the terraform outputs cannot be read", func() {
+			BeforeEach(func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("durian")
+			})
+
+			It("returns an error", func() {
+				err := envInfo.Execute([]string{"--json"}, state)
+				Expect(err).To(MatchError("Get terraform outputs: durian"))
+			})
+		})
+	})
+})
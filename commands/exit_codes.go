@@ -0,0 +1,12 @@
+package commands
+
+// Exit codes shared by the commands that report what they found through
+// ExitCodeError. 1 is left to every other failure.
+const (
+	// DriftExitCode means the infrastructure differs from the terraform
+	// templates, like terraform plan -detailed-exitcode.
+	DriftExitCode = 2
+
+	// UnhealthyExitCode means a health check failed or was skipped.
+	UnhealthyExitCode = 3
+)
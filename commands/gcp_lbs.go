@@ -28,7 +28,7 @@ func (l GCPLBs) Execute(subcommandFlags []string, state storage.State) error {
 
 	switch state.LB.Type {
 	case "cf":
-		if len(subcommandFlags) > 0 && subcommandFlags[0] == "--json" {
+		if asJSON(subcommandFlags) {
 			lbOutput, err := json.Marshal(struct {
 				RouterLBIP             string   `json:"cf_router_lb,omitempty"`
 				SSHProxyLBIP           string   `json:"cf_ssh_proxy_lb,omitempty"`
@@ -59,7 +59,21 @@ func (l GCPLBs) Execute(subcommandFlags []string, state storage.State) error {
 			}
 		}
 	case "concourse":
-		l.logger.Printf("Concourse LB: %s\n", terraformOutputs.GetString("concourse_lb_ip"))
+		if asJSON(subcommandFlags) {
+			lbOutput, err := json.Marshal(struct {
+				LBIP string `json:"concourse_lb,omitempty"`
+			}{
+				LBIP: terraformOutputs.GetString("concourse_lb_ip"),
+			})
+			if err != nil {
+				// not tested
+				return err
+			}
+
+			l.logger.Println(string(lbOutput))
+		} else {
+			l.logger.Printf("Concourse LB: %s\n", terraformOutputs.GetString("concourse_lb_ip"))
+		}
 	default:
 		return errors.New("no lbs found")
 	}
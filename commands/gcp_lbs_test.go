@@ -120,6 +120,16 @@ var _ = Describe("GCPLBs", func() {
 			}))
 		})
 
+		It("prints LB ips for lb type concourse in json format", func() {
+			incomingState.LB = storage.LB{
+				Type: "concourse",
+			}
+			err := command.Execute([]string{"--json"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"concourse_lb": "some-concourse-lb-ip"}`))
+		})
+
 		Context("failure cases", func() {
 			Context("when terraform output provider fails", func() {
 				BeforeEach(func() {
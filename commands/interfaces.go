@@ -18,17 +18,20 @@ type up interface {
 	CheckFastFails([]string, storage.State) error
 	ParseArgs([]string, storage.State) (PlanConfig, error)
 	Execute([]string, storage.State) error
+	Run([]string, storage.State) (storage.State, error)
 }
 
 type terraformManager interface {
 	ValidateVersion() error
 	GetOutputs() (terraform.Outputs, error)
 	Setup(storage.State) error
+	Generate(storage.State) error
 	Init(storage.State) error
 	Apply(storage.State) (storage.State, error)
 	Validate(storage.State) (storage.State, error)
 	Destroy(storage.State) (storage.State, error)
 	IsPaved() (bool, error)
+	Plan(storage.State) (terraform.PlanSummary, error)
 }
 
 type boshManager interface {
@@ -70,6 +73,7 @@ type logger interface {
 	Printf(string, ...interface{})
 	Println(string)
 	Prompt(string) bool
+	SetPhase(string)
 }
 
 type stateStore interface {
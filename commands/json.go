@@ -0,0 +1,35 @@
+package commands
+
+import "encoding/json"
+
+// JSONFlag is the global --json flag. bbl passes it on to the commands that
+// print something, so that they print a single JSON document instead of text.
+const JSONFlag = "--json"
+
+func asJSON(subcommandFlags []string) bool {
+	for _, flag := range subcommandFlags {
+		if flag == JSONFlag {
+			return true
+		}
+	}
+	return false
+}
+
+func printJSON(logger logger, document interface{}) error {
+	output, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	logger.Println(string(output))
+	return nil
+}
+
+// JSONError is the document that bbl prints instead of the error message
+// when --json is set.
+func JSONError(err error) string {
+	// A struct of one string always marshals.
+	output, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+	return string(output)
+}
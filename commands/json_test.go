@@ -0,0 +1,16 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSONError", func() {
+	It("renders the error as a json document", func() {
+		Expect(commands.JSONError(errors.New(`no "lbs" found`))).To(MatchJSON(`{"error": "no \"lbs\" found"}`))
+	})
+})
@@ -24,6 +24,10 @@ func (l LatestError) CheckFastFails(subcommandFlags []string, state storage.Stat
 }
 
 func (l LatestError) Execute(subcommandFlags []string, bblState storage.State) error {
+	if asJSON(subcommandFlags) {
+		return printJSON(l.logger, map[string]string{"latest_error": bblState.LatestTFOutput})
+	}
+
 	l.logger.Println(bblState.LatestTFOutput)
 	return nil
 }
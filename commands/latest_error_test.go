@@ -50,5 +50,16 @@ var _ = Describe("latest-error", func() {
 
 			Expect(logger.PrintlnCall.Messages).To(ContainElement("some tf output"))
 		})
+
+		It("prints the latest terraform output as json with --json", func() {
+			bblState := storage.State{
+				LatestTFOutput: "some tf output",
+			}
+
+			err := command.Execute([]string{"--json"}, bblState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"latest_error": "some tf output"}`))
+		})
 	})
 })
@@ -28,6 +28,9 @@ func (o Outputs) Execute(subcommandFlags []string, state storage.State) error {
 	if err != nil {
 		return err
 	}
+	if asJSON(subcommandFlags) {
+		return printJSON(o.logger, outputs.Map)
+	}
 	marshalled, err := yaml.Marshal(outputs.Map)
 	if err != nil {
 		return err
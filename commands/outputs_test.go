@@ -57,6 +57,18 @@ make sure we quote multiline strings`,
 			Expect(logger.PrintfCall.Receives.Message).To(ContainSubstring("external: address\nfirewall: |-\n  cidr\n  make sure we quote multiline strings"))
 		})
 
+		It("prints the terraform outputs as json", func() {
+			terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{
+				Map: map[string]interface{}{
+					"external": "address",
+					"zones":    []string{"z1", "z2"},
+				},
+			}
+			err := outputsCommand.Execute([]string{"--json"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"external": "address", "zones": ["z1", "z2"]}`))
+		})
+
 		Context("failure cases", func() {
 			Context("when getOutputs failes", func() {
 				It("returns an error", func() {
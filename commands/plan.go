@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -12,6 +14,13 @@ type patchDetector interface {
 	Find() error
 }
 
+// stateDiffer shows how bbl plan --diff changed the files it renders into
+// the state dir.
+type stateDiffer interface {
+	Snapshot() error
+	Diff() (string, error)
+}
+
 type Plan struct {
 	boshManager          boshManager
 	cloudConfigManager   cloudConfigManager
@@ -21,13 +30,23 @@ type Plan struct {
 	envIDManager         envIDManager
 	terraformManager     terraformManager
 	lbArgsHandler        lbArgsHandler
+	stateDiffer          stateDiffer
 	logger               logger
+	diffLogger           logger
 	bblVersion           string
 }
 
 type PlanConfig struct {
 	Name string
 	LB   storage.LB
+	Diff bool
+
+	// EnableBackups adds the BOSH Backup and Restore scripts to the director.
+	EnableBackups bool
+
+	// Only and Skip choose the phases of bbl up that run.
+	Only []string
+	Skip []string
 }
 
 func NewPlan(
@@ -39,7 +58,9 @@ func NewPlan(
 	envIDManager envIDManager,
 	terraformManager terraformManager,
 	lbArgsHandler lbArgsHandler,
+	stateDiffer stateDiffer,
 	logger logger,
+	diffLogger logger,
 	bblVersion string,
 ) Plan {
 	return Plan{
@@ -51,7 +72,9 @@ func NewPlan(
 		envIDManager:         envIDManager,
 		terraformManager:     terraformManager,
 		lbArgsHandler:        lbArgsHandler,
+		stateDiffer:          stateDiffer,
 		logger:               logger,
+		diffLogger:           diffLogger,
 		bblVersion:           bblVersion,
 	}
 }
@@ -79,8 +102,9 @@ func (p Plan) CheckFastFails(args []string, state storage.State) error {
 
 func (p Plan) ParseArgs(args []string, state storage.State) (PlanConfig, error) {
 	var (
-		config PlanConfig
-		lbArgs LBArgs
+		config     PlanConfig
+		lbArgs     LBArgs
+		only, skip string
 	)
 	planFlags := flags.New("up")
 	planFlags.String(&config.Name, "name", os.Getenv("BBL_ENV_NAME"))
@@ -88,6 +112,10 @@ func (p Plan) ParseArgs(args []string, state storage.State) (PlanConfig, error)
 	planFlags.String(&lbArgs.CertPath, "lb-cert", "")
 	planFlags.String(&lbArgs.KeyPath, "lb-key", "")
 	planFlags.String(&lbArgs.Domain, "lb-domain", "")
+	planFlags.Bool(&config.Diff, "diff")
+	planFlags.Bool(&config.EnableBackups, "enable-backups")
+	planFlags.String(&only, "only", "")
+	planFlags.String(&skip, "skip", "")
 	if state.IAAS == "aws" {
 		planFlags.String(&lbArgs.ChainPath, "lb-chain", "")
 	}
@@ -96,6 +124,8 @@ func (p Plan) ParseArgs(args []string, state storage.State) (PlanConfig, error)
 	if err != nil {
 		return PlanConfig{}, err
 	}
+	config.Only = splitPhases(only)
+	config.Skip = splitPhases(skip)
 
 	if (lbArgs != LBArgs{}) {
 		lbState, err := p.lbArgsHandler.GetLBState(state.IAAS, lbArgs)
@@ -114,13 +144,45 @@ func (p Plan) Execute(args []string, state storage.State) error {
 		return err
 	}
 
+	if len(config.Only) > 0 || len(config.Skip) > 0 {
+		return errors.New("--only and --skip are only supported by bbl up")
+	}
+
+	if !config.Diff {
+		_, err = p.InitializePlan(config, state)
+		return err
+	}
+
+	err = p.stateDiffer.Snapshot()
+	if err != nil {
+		return fmt.Errorf("Snapshot state dir: %s", err) //nolint:staticcheck
+	}
+
 	_, err = p.InitializePlan(config, state)
-	return err
+	if err != nil {
+		return err
+	}
+
+	diff, err := p.stateDiffer.Diff()
+	if err != nil {
+		return fmt.Errorf("Diff state dir: %s", err) //nolint:staticcheck
+	}
+
+	if diff == "" {
+		p.logger.Println("bbl plan would not change the state dir")
+		return nil
+	}
+
+	p.diffLogger.Printf("%s", diff)
+	return nil
 }
 
 func (p Plan) InitializePlan(config PlanConfig, state storage.State) (storage.State, error) {
 	state.BBLVersion = p.bblVersion
 	state.LB = config.LB
+	if config.EnableBackups {
+		state.DirectorBackups = true
+	}
 
 	var err error
 	state, err = p.envIDManager.Sync(state, config.Name)
@@ -133,7 +195,12 @@ func (p Plan) InitializePlan(config PlanConfig, state storage.State) (storage.St
 		return storage.State{}, fmt.Errorf("Save state: %s", err) //nolint:staticcheck
 	}
 
-	if err := p.terraformManager.Setup(state); err != nil {
+	if config.Diff {
+		// terraform init would download providers into the scratch dir for nothing.
+		if err := p.terraformManager.Generate(state); err != nil {
+			return storage.State{}, fmt.Errorf("Terraform manager generate: %s", err) //nolint:staticcheck
+		}
+	} else if err := p.terraformManager.Setup(state); err != nil {
 		return storage.State{}, fmt.Errorf("Terraform manager init: %s", err) //nolint:staticcheck
 	}
 
@@ -160,6 +227,16 @@ func (p Plan) InitializePlan(config PlanConfig, state storage.State) (storage.St
 	return state, nil
 }
 
+func splitPhases(phases string) []string {
+	var names []string
+	for _, name := range strings.Split(phases, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func (p Plan) IsInitialized(state storage.State) bool {
 	// If it is older than bbl v5.4.0 with schema 13, we want to re-initialize.
 	return state.Version >= 13
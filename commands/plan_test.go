@@ -26,6 +26,8 @@ var _ = Describe("Plan", func() {
 		stateStore           *fakes.StateStore
 		terraformManager     *fakes.TerraformManager
 		patchDetector        *fakes.PatchDetector
+		stateDiffer          *fakes.StateDiffer
+		diffLogger           *fakes.Logger
 		bblVersion           string
 	)
 
@@ -39,6 +41,8 @@ var _ = Describe("Plan", func() {
 		stateStore = &fakes.StateStore{}
 		terraformManager = &fakes.TerraformManager{}
 		patchDetector = &fakes.PatchDetector{}
+		stateDiffer = &fakes.StateDiffer{}
+		diffLogger = &fakes.Logger{}
 		bblVersion = "42.0.0"
 
 		boshManager.VersionCall.Returns.Version = "2.0.48"
@@ -52,7 +56,9 @@ var _ = Describe("Plan", func() {
 			envIDManager,
 			terraformManager,
 			lbArgsHandler,
+			stateDiffer,
 			logger,
+			diffLogger,
 			bblVersion,
 		)
 	})
@@ -138,6 +144,95 @@ var _ = Describe("Plan", func() {
 			})
 		})
 
+		Context("when --enable-backups is passed", func() {
+			It("enables director backups in the state", func() {
+				err := command.Execute([]string{"--enable-backups"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.DirectorBackups).To(BeTrue())
+			})
+		})
+
+		Context("when director backups are already enabled", func() {
+			It("keeps them enabled", func() {
+				state.DirectorBackups = true
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.DirectorBackups).To(BeTrue())
+			})
+		})
+
+		Context("when --only is passed", func() {
+			It("returns an error", func() {
+				err := command.Execute([]string{"--only", "terraform"}, state)
+				Expect(err).To(MatchError("--only and --skip are only supported by bbl up"))
+				Expect(envIDManager.SyncCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when --diff is passed", func() {
+			BeforeEach(func() {
+				stateDiffer.DiffCall.Returns.Diff = "--- a/create-director.sh\n+++ b/create-director.sh\n"
+			})
+
+			It("prints how rendering the plan changed the state dir", func() {
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateDiffer.SnapshotCall.CallCount).To(Equal(1))
+				Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(1))
+				Expect(stateDiffer.DiffCall.CallCount).To(Equal(1))
+				Expect(diffLogger.PrintfCall.Messages).To(Equal([]string{"--- a/create-director.sh\n+++ b/create-director.sh\n"}))
+			})
+
+			It("writes the terraform files without running terraform init", func() {
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.GenerateCall.CallCount).To(Equal(1))
+				Expect(terraformManager.GenerateCall.Receives.BBLState).To(Equal(syncedState))
+				Expect(terraformManager.SetupCall.CallCount).To(Equal(0))
+			})
+
+			Context("when nothing changed", func() {
+				BeforeEach(func() {
+					stateDiffer.DiffCall.Returns.Diff = ""
+				})
+
+				It("says so", func() {
+					err := command.Execute([]string{"--diff"}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(diffLogger.PrintfCall.CallCount).To(Equal(0))
+					Expect(logger.PrintlnCall.Messages).To(Equal([]string{"bbl plan would not change the state dir"}))
+				})
+			})
+
+			It("returns an error if the snapshot fails", func() {
+				stateDiffer.SnapshotCall.Returns.Error = errors.New("mango")
+
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).To(MatchError("Snapshot state dir: mango"))
+				Expect(envIDManager.SyncCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error if terraform manager generate fails", func() {
+				terraformManager.GenerateCall.Returns.Error = errors.New("lychee")
+
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).To(MatchError("Terraform manager generate: lychee"))
+				Expect(stateDiffer.DiffCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error if the diff fails", func() {
+				stateDiffer.DiffCall.Returns.Error = errors.New("papaya")
+
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).To(MatchError("Diff state dir: papaya"))
+			})
+		})
+
 		Describe("failure cases", func() {
 			It("returns an error if state store set fails", func() {
 				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("peach")}}
@@ -272,6 +367,18 @@ var _ = Describe("Plan", func() {
 			})
 		})
 
+		Context("when the user chooses up phases", func() {
+			It("splits the phase lists", func() {
+				config, err := command.ParseArgs([]string{
+					"--only", "cloud-config, runtime-config",
+					"--skip", "terraform",
+				}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Only).To(Equal([]string{"cloud-config", "runtime-config"}))
+				Expect(config.Skip).To(Equal([]string{"terraform"}))
+			})
+		})
+
 		Context("when the user provides the name flag as an environment variable", func() {
 			BeforeEach(func() {
 				os.Setenv("BBL_ENV_NAME", "a-better-name") //nolint:errcheck
@@ -20,6 +20,7 @@ type PrintEnv struct {
 	allProxyGetter   allProxyGetter
 	terraformManager terraformManager
 	credhubGetter    credhubGetter
+	secretResolver   secretResolver
 	fs               fs
 	rendererFactory  renderers.Factory
 }
@@ -30,6 +31,10 @@ type credhubGetter interface {
 	GetPassword() (string, error)
 }
 
+type secretResolver interface {
+	Resolve(value string) (string, error)
+}
+
 type allProxyGetter interface {
 	GeneratePrivateKey() (string, error)
 	BoshAllProxy(string, string) string
@@ -43,6 +48,7 @@ type fs interface {
 type PrintEnvConfig struct {
 	shellType    string
 	metadataFile string
+	json         bool
 }
 
 // NewPrintEnv creates a new PrintEnv Command
@@ -52,6 +58,7 @@ func NewPrintEnv(
 	stateValidator stateValidator,
 	allProxyGetter allProxyGetter,
 	credhubGetter credhubGetter,
+	secretResolver secretResolver,
 	terraformManager terraformManager,
 	fs fs,
 	rendererFactory renderers.Factory) PrintEnv {
@@ -62,6 +69,7 @@ func NewPrintEnv(
 		allProxyGetter:   allProxyGetter,
 		terraformManager: terraformManager,
 		credhubGetter:    credhubGetter,
+		secretResolver:   secretResolver,
 		fs:               fs,
 		rendererFactory:  rendererFactory,
 	}
@@ -82,6 +90,7 @@ func (p PrintEnv) ParseArgs(args []string, state storage.State) (PrintEnvConfig,
 	printEnvFlags := flags.New("print-env")
 	printEnvFlags.String(&config.shellType, "shell-type", "")
 	printEnvFlags.String(&config.metadataFile, "metadata-file", "")
+	printEnvFlags.Bool(&config.json, "json")
 
 	err := printEnvFlags.Parse(args)
 	if err != nil {
@@ -145,8 +154,7 @@ func (p PrintEnv) Execute(args []string, state storage.State) error {
 		variables["BOSH_ALL_PROXY"] = boshAllProxy
 		variables["CREDHUB_PROXY"] = boshAllProxy
 
-		p.renderVariables(renderer, variables)
-		return nil
+		return p.printVariables(config, renderer, variables)
 	}
 
 	err = p.stateValidator.Validate()
@@ -154,8 +162,13 @@ func (p PrintEnv) Execute(args []string, state storage.State) error {
 		return err
 	}
 
+	directorPassword, err := p.secretResolver.Resolve(state.BOSH.DirectorPassword)
+	if err != nil {
+		return err
+	}
+
 	variables["BOSH_CLIENT"] = state.BOSH.DirectorUsername
-	variables["BOSH_CLIENT_SECRET"] = state.BOSH.DirectorPassword
+	variables["BOSH_CLIENT_SECRET"] = directorPassword
 	variables["BOSH_ENVIRONMENT"] = state.BOSH.DirectorAddress
 	variables["BOSH_CA_CERT"] = state.BOSH.DirectorSSLCA
 	variables["CREDHUB_CLIENT"] = "credhub-admin"
@@ -183,7 +196,10 @@ func (p PrintEnv) Execute(args []string, state storage.State) error {
 
 	privateKeyPath, err := p.allProxyGetter.GeneratePrivateKey()
 	if err != nil {
-		p.renderVariables(renderer, variables)
+		// --json prints the error document only.
+		if !config.json {
+			p.renderVariables(renderer, variables)
+		}
 		return err
 	}
 
@@ -191,6 +207,14 @@ func (p PrintEnv) Execute(args []string, state storage.State) error {
 	variables["BOSH_ALL_PROXY"] = p.allProxyGetter.BoshAllProxy(state.Jumpbox.GetURLWithJumpboxUser(), privateKeyPath)
 	variables["CREDHUB_PROXY"] = p.allProxyGetter.BoshAllProxy(state.Jumpbox.GetURLWithJumpboxUser(), privateKeyPath)
 
+	return p.printVariables(config, renderer, variables)
+}
+
+func (p PrintEnv) printVariables(config PrintEnvConfig, renderer renderers.Renderer, variables map[string]string) error {
+	if config.json {
+		return printJSON(p.logger, variables)
+	}
+
 	p.renderVariables(renderer, variables)
 	return nil
 }
@@ -23,6 +23,7 @@ var _ = Describe("PrintEnv", func() {
 		terraformManager *fakes.TerraformManager
 		allProxyGetter   *fakes.AllProxyGetter
 		credhubGetter    *fakes.CredhubGetter
+		secretResolver   *fakes.SecretResolver
 		fileIO           *fakes.FileIO
 		printEnv         commands.PrintEnv
 		rendererFactory  renderers.Factory
@@ -42,6 +43,7 @@ var _ = Describe("PrintEnv", func() {
 		credhubGetter.GetCertsCall.Returns.Certs = "-----BEGIN CERTIFICATE-----\nsome-credhub-certs\n-----END CERTIFICATE-----\n"
 		credhubGetter.GetPasswordCall.Returns.Password = "some-credhub-password"
 
+		secretResolver = &fakes.SecretResolver{}
 		fileIO = &fakes.FileIO{}
 
 		state = storage.State{
@@ -56,7 +58,7 @@ var _ = Describe("PrintEnv", func() {
 			},
 		}
 		rendererFactory = renderers.NewFactory(&fakes.EnvGetter{Values: make(map[string]string)})
-		printEnv = commands.NewPrintEnv(logger, stderrLogger, stateValidator, allProxyGetter, credhubGetter, terraformManager, fileIO, rendererFactory)
+		printEnv = commands.NewPrintEnv(logger, stderrLogger, stateValidator, allProxyGetter, credhubGetter, secretResolver, terraformManager, fileIO, rendererFactory)
 	})
 	Describe("CheckFastFails", func() {
 		Context("when the state does not exist", func() {
@@ -97,12 +99,45 @@ var _ = Describe("PrintEnv", func() {
 			Expect(logger.PrintlnCall.Messages).To(ContainElement(`export BOSH_ALL_PROXY=ipfs://some-domain-with?private_key=the-key-path`))
 		})
 
+		It("prints the environment variables as json", func() {
+			err := printEnv.Execute([]string{"--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.CallCount).To(Equal(1))
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"BOSH_CLIENT": "some-director-username",
+				"BOSH_CLIENT_SECRET": "some-director-password",
+				"BOSH_ENVIRONMENT": "some-director-address",
+				"BOSH_CA_CERT": "-----BEGIN CERTIFICATE-----\nsome-director-ca-cert\n-----END CERTIFICATE-----\n",
+				"CREDHUB_CLIENT": "credhub-admin",
+				"CREDHUB_SECRET": "some-credhub-password",
+				"CREDHUB_SERVER": "some-credhub-server",
+				"CREDHUB_CA_CERT": "-----BEGIN CERTIFICATE-----\nsome-credhub-certs\n-----END CERTIFICATE-----\n",
+				"CREDHUB_PROXY": "ipfs://some-domain-with?private_key=the-key-path",
+				"JUMPBOX_PRIVATE_KEY": "the-key-path",
+				"BOSH_ALL_PROXY": "ipfs://some-domain-with?private_key=the-key-path"
+			}`))
+		})
+
+		Context("when the director password is kept in a secret store", func() {
+			It("prints the resolved password", func() {
+				secretResolver.ResolveCall.Stub = func(string) (string, error) { return "some-resolved-password", nil }
+				state.BOSH.DirectorPassword = "secret:director-password"
+
+				err := printEnv.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(secretResolver.ResolveCall.Receives.Value).To(Equal("secret:director-password"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("export BOSH_CLIENT_SECRET=some-resolved-password"))
+			})
+		})
+
 		Context("WhenPSModulePathIsSet", func() {
 			It("prints powershell environment variables", func() {
 
 				values := map[string]string{"PSModulePath": "something"}
 				rendererFactory = renderers.NewFactory(&fakes.EnvGetter{Values: values})
-				printEnv = commands.NewPrintEnv(logger, stderrLogger, stateValidator, allProxyGetter, credhubGetter, terraformManager, fileIO, rendererFactory)
+				printEnv = commands.NewPrintEnv(logger, stderrLogger, stateValidator, allProxyGetter, credhubGetter, secretResolver, terraformManager, fileIO, rendererFactory)
 
 				err := printEnv.Execute([]string{}, state)
 				Expect(err).NotTo(HaveOccurred())
@@ -246,6 +281,17 @@ var _ = Describe("PrintEnv", func() {
 				})
 			})
 
+			Context("when the director password cannot be resolved", func() {
+				BeforeEach(func() {
+					secretResolver.ResolveCall.Stub = func(string) (string, error) { return "", errors.New("guava") }
+				})
+
+				It("returns an error", func() {
+					err := printEnv.Execute([]string{}, state)
+					Expect(err).To(MatchError("guava"))
+				})
+			})
+
 			Context("when the allproxy getter fails to get a private key", func() {
 				BeforeEach(func() {
 					allProxyGetter.GeneratePrivateKeyCall.Returns.Error = errors.New("papaya")
@@ -255,6 +301,13 @@ var _ = Describe("PrintEnv", func() {
 					err := printEnv.Execute([]string{}, storage.State{})
 					Expect(err).To(MatchError("papaya"))
 				})
+
+				It("prints nothing with --json", func() {
+					err := printEnv.Execute([]string{"--json"}, storage.State{})
+					Expect(err).To(MatchError("papaya"))
+
+					Expect(logger.PrintlnCall.CallCount).To(Equal(0))
+				})
 			})
 
 			Context("when credhub getter fails to get the password", func() {
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type RestoreDirector struct {
+	logger         logger
+	stateValidator stateValidator
+	bbr            bbrDirector
+	store          backupStore
+}
+
+type RestoreDirectorConfig struct {
+	ArtifactPath string
+}
+
+func NewRestoreDirector(logger logger, stateValidator stateValidator, bbrCLI bbrCLI, allProxyGetter allProxyGetter,
+	sshKeyGetter sshKeyGetter, fs backupFs, store backupStore) RestoreDirector {
+	return RestoreDirector{
+		logger:         logger,
+		stateValidator: stateValidator,
+		bbr: bbrDirector{
+			cli:            bbrCLI,
+			allProxyGetter: allProxyGetter,
+			sshKeyGetter:   sshKeyGetter,
+			fs:             fs,
+		},
+		store: store,
+	}
+}
+
+func (r RestoreDirector) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	config, err := r.ParseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+	if config.ArtifactPath == "" {
+		return errors.New("--artifact-path is required")
+	}
+
+	return checkBBRDirector(state)
+}
+
+func (r RestoreDirector) ParseArgs(args []string) (RestoreDirectorConfig, error) {
+	var config RestoreDirectorConfig
+	restoreFlags := flags.New("restore-director")
+	restoreFlags.String(&config.ArtifactPath, "artifact-path", "")
+
+	err := restoreFlags.Parse(args)
+	if err != nil {
+		return RestoreDirectorConfig{}, err
+	}
+
+	return config, nil
+}
+
+// Execute restores the director with bbr from a local artifact, or with
+// --state-bucket from an artifact of that name next to the remote state.
+func (r RestoreDirector) Execute(args []string, state storage.State) error {
+	config, err := r.ParseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	proceed := r.logger.Prompt(fmt.Sprintf("Are you sure you want to restore the BOSH director for %q from %s? Its current data will be replaced!", state.EnvID, config.ArtifactPath))
+	if !proceed {
+		r.logger.Step("exiting")
+		return nil
+	}
+
+	artifactPath := config.ArtifactPath
+	_, err = r.bbr.fs.Stat(artifactPath)
+	if err != nil {
+		if !os.IsNotExist(err) || !r.store.IsRemote() {
+			return fmt.Errorf("Find backup artifact: %s", err) //nolint:staticcheck
+		}
+
+		workDir, err := r.bbr.fs.TempDir("", "bbl-restore-director")
+		if err != nil {
+			return fmt.Errorf("Create temp directory: %s", err) //nolint:staticcheck
+		}
+		defer r.bbr.fs.RemoveAll(workDir) //nolint:errcheck
+
+		artifactPath = filepath.Join(workDir, filepath.Base(config.ArtifactPath))
+		err = r.store.Download(config.ArtifactPath, artifactPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.logger.Step("restoring the director")
+	err = r.bbr.run(state, "", "restore", "--artifact-path", artifactPath)
+	if err != nil {
+		return fmt.Errorf("bbr director restore: %s", err)
+	}
+
+	r.logger.Println(fmt.Sprintf("restored the director from %s", config.ArtifactPath))
+	return nil
+}
@@ -0,0 +1,158 @@
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RestoreDirector", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		bbrCLI         *fakes.BBRCLI
+		allProxyGetter *fakes.AllProxyGetter
+		sshKeyGetter   *fakes.SSHKeyGetter
+		fileIO         *fakes.FileIO
+		backupStore    *fakes.BackupStore
+
+		command commands.RestoreDirector
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		logger.PromptCall.Returns.Proceed = true
+		stateValidator = &fakes.StateValidator{}
+		bbrCLI = &fakes.BBRCLI{}
+		allProxyGetter = &fakes.AllProxyGetter{}
+		allProxyGetter.GeneratePrivateKeyCall.Returns.PrivateKey = filepath.Join("some-key-dir", "jumpbox-private-key")
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+		fileIO = &fakes.FileIO{}
+		fileIO.TempDirCall.Returns.Name = "some-work-dir"
+		backupStore = &fakes.BackupStore{}
+
+		command = commands.NewRestoreDirector(logger, stateValidator, bbrCLI, allProxyGetter, sshKeyGetter, fileIO, backupStore)
+
+		state = storage.State{
+			EnvID:           "some-env",
+			Jumpbox:         storage.Jumpbox{URL: "some-jumpbox:22"},
+			BOSH:            storage.BOSH{DirectorAddress: "https://10.0.0.6:25555"},
+			DirectorBackups: true,
+		}
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the state", func() {
+			err := command.CheckFastFails([]string{"--artifact-path", "some-artifact"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+		})
+
+		Context("when --artifact-path is missing", func() {
+			It("returns an error", func() {
+				err := command.CheckFastFails([]string{}, state)
+				Expect(err).To(MatchError("--artifact-path is required"))
+			})
+		})
+
+		Context("when backups are not enabled", func() {
+			It("returns an error", func() {
+				state.DirectorBackups = false
+				err := command.CheckFastFails([]string{"--artifact-path", "some-artifact"}, state)
+				Expect(err).To(MatchError("backups are not enabled on the director, run bbl plan --enable-backups and bbl up first"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("asks for confirmation", func() {
+			err := command.Execute([]string{"--artifact-path", "some-artifact"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PromptCall.Receives.Message).To(Equal(`Are you sure you want to restore the BOSH director for "some-env" from some-artifact? Its current data will be replaced!`))
+		})
+
+		It("runs bbr director restore with a local artifact", func() {
+			err := command.Execute([]string{"--artifact-path", "some-artifact"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.StatCall.Receives.Name).To(Equal("some-artifact"))
+			Expect(backupStore.DownloadCall.CallCount).To(Equal(0))
+			Expect(bbrCLI.RunCall.Receives.Args).To(Equal([]string{
+				"director",
+				"--host", "10.0.0.6",
+				"--username", "jumpbox",
+				"--private-key-path", filepath.Join("some-key-dir", "director-private-key"),
+				"restore", "--artifact-path", "some-artifact",
+			}))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("restored the director from some-artifact"))
+		})
+
+		Context("when the user does not confirm", func() {
+			It("does not restore", func() {
+				logger.PromptCall.Returns.Proceed = false
+				err := command.Execute([]string{"--artifact-path", "some-artifact"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(bbrCLI.RunCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the artifact is not local", func() {
+			BeforeEach(func() {
+				fileIO.StatCall.Returns.Error = os.ErrNotExist
+			})
+
+			It("returns an error", func() {
+				err := command.Execute([]string{"--artifact-path", "some-artifact"}, state)
+				Expect(err).To(MatchError("Find backup artifact: file does not exist"))
+				Expect(bbrCLI.RunCall.CallCount).To(Equal(0))
+			})
+
+			Context("when the state is in a state bucket", func() {
+				BeforeEach(func() {
+					backupStore.IsRemoteCall.Returns.IsRemote = true
+				})
+
+				It("downloads the artifact from next to the remote state", func() {
+					err := command.Execute([]string{"--artifact-path", "some-artifact"}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(backupStore.DownloadCall.Receives.Name).To(Equal("some-artifact"))
+					Expect(backupStore.DownloadCall.Receives.Dir).To(Equal(filepath.Join("some-work-dir", "some-artifact")))
+					Expect(bbrCLI.RunCall.Receives.Args).To(ContainElement(filepath.Join("some-work-dir", "some-artifact")))
+					Expect(fileIO.RemoveAllCall.Receives).To(ContainElement(fakes.RemoveAllReceive{Path: "some-work-dir"}))
+				})
+
+				Context("when the download fails", func() {
+					BeforeEach(func() {
+						backupStore.DownloadCall.Returns.Error = errors.New("guava")
+					})
+
+					It("returns the error", func() {
+						err := command.Execute([]string{"--artifact-path", "some-artifact"}, state)
+						Expect(err).To(MatchError("guava"))
+						Expect(bbrCLI.RunCall.CallCount).To(Equal(0))
+					})
+				})
+			})
+		})
+
+		Context("when bbr fails", func() {
+			It("returns an error", func() {
+				bbrCLI.RunCall.Returns.Error = errors.New("huckleberry")
+				err := command.Execute([]string{"--artifact-path", "some-artifact"}, state)
+				Expect(err).To(MatchError("bbr director restore: huckleberry"))
+			})
+		})
+	})
+})
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
@@ -10,17 +11,43 @@ type sshKeyDeleter interface {
 	Delete() error
 }
 
+type credentialRotator interface {
+	RotateDirectorCredentials() error
+	RotateCertificates(stage string) (string, error)
+}
+
 type Rotate struct {
-	stateValidator stateValidator
-	sshKeyDeleter  sshKeyDeleter
-	up             up
+	stateValidator    stateValidator
+	sshKeyDeleter     sshKeyDeleter
+	credentialRotator credentialRotator
+	stateStore        stateStore
+	up                up
+	logger            logger
+}
+
+type RotateConfig struct {
+	SSHKey        bool
+	DirectorCreds bool
+	Certs         bool
 }
 
-func NewRotate(stateValidator stateValidator, sshKeyDeleter sshKeyDeleter, up up) Rotate {
+// caRotationNextSteps tell the user what to do after each stage of
+// bbl rotate --certs.
+var caRotationNextSteps = map[string]string{
+	storage.CARotationNewCATrusted: "the new CAs are trusted next to the old ones, redeploy everything that trusts the director and then run bbl rotate --certs again to sign with the new CAs",
+	storage.CARotationNewCASigning: "the director certificates are signed by the new CAs, redeploy everything that trusts the director and then run bbl rotate --certs again to stop trusting the old CAs",
+	"":                             "the old CAs are no longer trusted, the CA rotation is finished",
+}
+
+func NewRotate(stateValidator stateValidator, sshKeyDeleter sshKeyDeleter, credentialRotator credentialRotator,
+	stateStore stateStore, up up, logger logger) Rotate {
 	return Rotate{
-		stateValidator: stateValidator,
-		sshKeyDeleter:  sshKeyDeleter,
-		up:             up,
+		stateValidator:    stateValidator,
+		sshKeyDeleter:     sshKeyDeleter,
+		credentialRotator: credentialRotator,
+		stateStore:        stateStore,
+		up:                up,
+		logger:            logger,
 	}
 }
 
@@ -30,7 +57,8 @@ func (r Rotate) CheckFastFails(subcommandFlags []string, state storage.State) er
 		return fmt.Errorf("validate state: %s", err)
 	}
 
-	err = r.up.CheckFastFails(subcommandFlags, state)
+	_, upFlags := r.ParseArgs(subcommandFlags)
+	err = r.up.CheckFastFails(upFlags, state)
 	if err != nil {
 		return fmt.Errorf("up: %s", err)
 	}
@@ -38,15 +66,103 @@ func (r Rotate) CheckFastFails(subcommandFlags []string, state storage.State) er
 }
 
 func (r Rotate) Execute(args []string, state storage.State) error {
-	err := r.sshKeyDeleter.Delete()
+	config, upArgs := r.ParseArgs(args)
+
+	// The vars stores are not part of the phase fingerprints, so the phases
+	// that read them have to run again.
+	firstPhase := DirectorPhase
+
+	if config.SSHKey {
+		err := r.sshKeyDeleter.Delete()
+		if err != nil {
+			return fmt.Errorf("delete ssh key: %s", err)
+		}
+		firstPhase = JumpboxPhase
+	}
+
+	if config.DirectorCreds {
+		err := r.credentialRotator.RotateDirectorCredentials()
+		if err != nil {
+			return fmt.Errorf("rotate director credentials: %s", err)
+		}
+	}
+
+	// The CA rotation only moves on to the next stage once up succeeds, so
+	// that bbl rotate --certs retries the same stage after a failure.
+	stage := state.CARotation
+	if config.Certs {
+		if state.CARotation == "" {
+			firstPhase = JumpboxPhase
+		}
+
+		var err error
+		stage, err = r.credentialRotator.RotateCertificates(state.CARotation)
+		if err != nil {
+			return fmt.Errorf("rotate certificates: %s", err)
+		}
+	}
+
+	state.Checkpoints = checkpointsBefore(state.Checkpoints, firstPhase)
+	err := r.stateStore.Set(state)
 	if err != nil {
-		return fmt.Errorf("delete ssh key: %s", err)
+		return fmt.Errorf("save state: %s", err)
 	}
 
-	err = r.up.Execute(args, state)
+	state, err = r.up.Run(upArgs, state)
 	if err != nil {
 		return fmt.Errorf("up: %s", err)
 	}
 
+	if config.Certs {
+		state.CARotation = stage
+		err = r.stateStore.Set(state)
+		if err != nil {
+			return fmt.Errorf("save state: %s", err)
+		}
+
+		r.logger.Println(caRotationNextSteps[stage])
+	}
+
 	return nil
 }
+
+// ParseArgs picks the rotate flags out of args and returns the rest for up.
+// Without any of them only the jumpbox SSH key is rotated.
+func (r Rotate) ParseArgs(args []string) (RotateConfig, []string) {
+	var (
+		config   RotateConfig
+		upArgs   []string
+		selected bool
+		rotates  = map[string]func(){
+			"director-creds": func() { config.DirectorCreds = true },
+			"certs":          func() { config.Certs = true },
+			"all": func() {
+				config.SSHKey = true
+				config.DirectorCreds = true
+				config.Certs = true
+			},
+		}
+	)
+
+	for _, arg := range args {
+		if rotate, ok := rotates[flagName(arg)]; ok {
+			rotate()
+			selected = true
+			continue
+		}
+		upArgs = append(upArgs, arg)
+	}
+
+	if !selected {
+		config.SSHKey = true
+	}
+
+	return config, upArgs
+}
+
+func flagName(arg string) string {
+	if !strings.HasPrefix(arg, "-") {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+}
@@ -12,17 +12,23 @@ import (
 
 var _ = Describe("Rotate", func() {
 	var (
-		stateValidator *fakes.StateValidator
-		sshKeyDeleter  *fakes.SSHKeyDeleter
-		up             *fakes.Up
-		rotate         commands.Rotate
+		stateValidator    *fakes.StateValidator
+		sshKeyDeleter     *fakes.SSHKeyDeleter
+		credentialRotator *fakes.CredentialRotator
+		stateStore        *fakes.StateStore
+		up                *fakes.Up
+		logger            *fakes.Logger
+		rotate            commands.Rotate
 	)
 
 	BeforeEach(func() {
 		stateValidator = &fakes.StateValidator{}
 		sshKeyDeleter = &fakes.SSHKeyDeleter{}
+		credentialRotator = &fakes.CredentialRotator{}
+		stateStore = &fakes.StateStore{}
 		up = &fakes.Up{}
-		rotate = commands.NewRotate(stateValidator, sshKeyDeleter, up)
+		logger = &fakes.Logger{}
+		rotate = commands.NewRotate(stateValidator, sshKeyDeleter, credentialRotator, stateStore, up, logger)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -44,6 +50,13 @@ var _ = Describe("Rotate", func() {
 			Expect(up.CheckFastFailsCall.Receives.State).To(Equal(state))
 		})
 
+		It("leaves the rotate flags out of the flags for up", func() {
+			err := rotate.CheckFastFails([]string{"--certs", "--name", "some-name", "--director-creds"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(up.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{"--name", "some-name"}))
+		})
+
 		Context("when the state validator returns an error", func() {
 			BeforeEach(func() {
 				stateValidator.ValidateCall.Returns.Error = errors.New("coconut")
@@ -95,9 +108,200 @@ var _ = Describe("Rotate", func() {
 			err := rotate.Execute(args, state)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(up.ExecuteCall.CallCount).To(Equal(1))
-			Expect(up.ExecuteCall.Receives.Args).To(Equal(args))
-			Expect(up.ExecuteCall.Receives.State).To(Equal(state))
+			Expect(up.RunCall.CallCount).To(Equal(1))
+			Expect(up.RunCall.Receives.Args).To(Equal(args))
+			Expect(up.RunCall.Receives.State).To(Equal(state))
+		})
+
+		It("saves the state before calling up", func() {
+			err := rotate.Execute(args, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives[0].State).To(Equal(state))
+		})
+
+		It("does not rotate the director credentials or certificates", func() {
+			err := rotate.Execute(args, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(credentialRotator.RotateDirectorCredentialsCall.CallCount).To(Equal(0))
+			Expect(credentialRotator.RotateCertificatesCall.CallCount).To(Equal(0))
+		})
+
+		Context("when the state has checkpoints", func() {
+			BeforeEach(func() {
+				state.Checkpoints = []storage.Checkpoint{
+					{Phase: commands.TerraformPhase, Fingerprint: "some-terraform-fingerprint"},
+					{Phase: commands.JumpboxPhase, Fingerprint: "some-jumpbox-fingerprint"},
+					{Phase: commands.DirectorPhase, Fingerprint: "some-director-fingerprint"},
+				}
+			})
+
+			It("runs the jumpbox phase and the ones after it again", func() {
+				err := rotate.Execute(args, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(up.RunCall.Receives.State.Checkpoints).To(Equal([]storage.Checkpoint{
+					{Phase: commands.TerraformPhase, Fingerprint: "some-terraform-fingerprint"},
+				}))
+			})
+
+			Context("when only the director credentials are rotated", func() {
+				It("runs the director phase and the ones after it again", func() {
+					err := rotate.Execute([]string{"--director-creds"}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(up.RunCall.Receives.State.Checkpoints).To(Equal([]storage.Checkpoint{
+						{Phase: commands.TerraformPhase, Fingerprint: "some-terraform-fingerprint"},
+						{Phase: commands.JumpboxPhase, Fingerprint: "some-jumpbox-fingerprint"},
+					}))
+				})
+			})
+		})
+
+		Context("when --director-creds is passed", func() {
+			BeforeEach(func() {
+				args = []string{"--director-creds", "--name", "some-name"}
+			})
+
+			It("rotates the director credentials and not the ssh key", func() {
+				err := rotate.Execute(args, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(credentialRotator.RotateDirectorCredentialsCall.CallCount).To(Equal(1))
+				Expect(credentialRotator.RotateCertificatesCall.CallCount).To(Equal(0))
+				Expect(sshKeyDeleter.DeleteCall.CallCount).To(Equal(0))
+			})
+
+			It("calls up without the rotate flags", func() {
+				err := rotate.Execute(args, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(up.RunCall.Receives.Args).To(Equal([]string{"--name", "some-name"}))
+			})
+
+			Context("when rotating the director credentials fails", func() {
+				BeforeEach(func() {
+					credentialRotator.RotateDirectorCredentialsCall.Returns.Error = errors.New("kiwi")
+				})
+
+				It("returns the error without calling up", func() {
+					err := rotate.Execute(args, state)
+					Expect(err).To(MatchError("rotate director credentials: kiwi"))
+
+					Expect(up.RunCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when --certs is passed", func() {
+			BeforeEach(func() {
+				args = []string{"--certs"}
+				credentialRotator.RotateCertificatesCall.Returns.Stage = storage.CARotationNewCATrusted
+			})
+
+			It("moves the CA rotation on to the next stage", func() {
+				err := rotate.Execute(args, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(credentialRotator.RotateCertificatesCall.CallCount).To(Equal(1))
+				Expect(credentialRotator.RotateCertificatesCall.Receives.Stage).To(Equal(""))
+				Expect(sshKeyDeleter.DeleteCall.CallCount).To(Equal(0))
+
+				Expect(up.RunCall.Receives.State.CARotation).To(Equal(""))
+				Expect(up.RunCall.Receives.Args).To(BeEmpty())
+			})
+
+			It("saves the next stage on top of the state up saved", func() {
+				up.RunCall.Returns.State = storage.State{EnvID: "some-env-id", LatestTFOutput: "some-tf-output"}
+
+				err := rotate.Execute(args, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(2))
+				Expect(stateStore.SetCall.Receives[0].State.CARotation).To(Equal(""))
+				Expect(stateStore.SetCall.Receives[1].State).To(Equal(storage.State{
+					EnvID:          "some-env-id",
+					LatestTFOutput: "some-tf-output",
+					CARotation:     storage.CARotationNewCATrusted,
+				}))
+			})
+
+			It("tells the user what to do next", func() {
+				err := rotate.Execute(args, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring("run bbl rotate --certs again to sign with the new CAs"))
+			})
+
+			Context("when the last stage is reached", func() {
+				BeforeEach(func() {
+					state.CARotation = storage.CARotationNewCASigning
+					credentialRotator.RotateCertificatesCall.Returns.Stage = ""
+				})
+
+				It("clears the CA rotation from the state", func() {
+					err := rotate.Execute(args, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(credentialRotator.RotateCertificatesCall.Receives.Stage).To(Equal(storage.CARotationNewCASigning))
+					Expect(up.RunCall.Receives.State.CARotation).To(Equal(storage.CARotationNewCASigning))
+					Expect(stateStore.SetCall.Receives[1].State.CARotation).To(Equal(""))
+					Expect(logger.PrintlnCall.Receives.Message).To(Equal("the old CAs are no longer trusted, the CA rotation is finished"))
+				})
+			})
+
+			Context("when rotating the certificates fails", func() {
+				BeforeEach(func() {
+					credentialRotator.RotateCertificatesCall.Returns.Error = errors.New("lychee")
+				})
+
+				It("returns the error without saving the state", func() {
+					err := rotate.Execute(args, state)
+					Expect(err).To(MatchError("rotate certificates: lychee"))
+
+					Expect(stateStore.SetCall.CallCount).To(Equal(0))
+					Expect(up.RunCall.CallCount).To(Equal(0))
+				})
+			})
+
+			Context("when up fails", func() {
+				BeforeEach(func() {
+					up.RunCall.Returns.Error = errors.New("mango")
+				})
+
+				It("keeps the current stage in the state so that a rerun retries it", func() {
+					err := rotate.Execute(args, state)
+					Expect(err).To(MatchError("up: mango"))
+
+					Expect(stateStore.SetCall.CallCount).To(Equal(1))
+					Expect(stateStore.SetCall.Receives[0].State.CARotation).To(Equal(""))
+					Expect(logger.PrintlnCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when --all is passed", func() {
+			It("rotates the ssh key, director credentials and certificates", func() {
+				err := rotate.Execute([]string{"--all"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshKeyDeleter.DeleteCall.CallCount).To(Equal(1))
+				Expect(credentialRotator.RotateDirectorCredentialsCall.CallCount).To(Equal(1))
+				Expect(credentialRotator.RotateCertificatesCall.CallCount).To(Equal(1))
+			})
+		})
+
+		Context("when saving the state fails", func() {
+			BeforeEach(func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("papaya")}}
+			})
+
+			It("returns the error", func() {
+				err := rotate.Execute(args, state)
+				Expect(err).To(MatchError("save state: papaya"))
+			})
 		})
 
 		Context("when the ssh key deleter returns an error", func() {
@@ -113,7 +317,7 @@ var _ = Describe("Rotate", func() {
 
 		Context("when up returns an error", func() {
 			BeforeEach(func() {
-				up.ExecuteCall.Returns.Error = errors.New("fig")
+				up.RunCall.Returns.Error = errors.New("fig")
 			})
 
 			It("returns the error from up", func() {
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type scpCLI interface {
+	Run([]string) error
+}
+
+type SCP struct {
+	ssh SSH
+	cli scpCLI
+}
+
+// scpTargets are the prefixes that mark a path on the jumpbox or director.
+var scpTargets = []string{"jumpbox", "director"}
+
+func NewSCP(logger logger, sshCLI sshCLI, scpCLI scpCLI, sshKeyGetter sshKeyGetter, pathFinder pathFinder, tempDirWriter tempDirWriter, randomPort randomPort) SCP {
+	return SCP{
+		ssh: NewSSH(logger, sshCLI, sshKeyGetter, pathFinder, tempDirWriter, randomPort),
+		cli: scpCLI,
+	}
+}
+
+func (s SCP) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(state.Jumpbox.URL) == 0 {
+		return errors.New("Invalid bbl state for bbl scp.") //nolint:staticcheck
+	}
+
+	return nil
+}
+
+// Execute copies a file to or from the jumpbox, or to or from the director
+// through a tunnel through the jumpbox, like bbl ssh.
+func (s SCP) Execute(args []string, state storage.State) error {
+	var recursive bool
+	scpFlags := flags.New("scp")
+	scpFlags.Bool(&recursive, "recursive")
+	scpFlags.Bool(&recursive, "r")
+	err := scpFlags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	paths := scpFlags.Args()
+	if len(paths) != 2 {
+		return errors.New("bbl scp needs a source and a destination, such as director:/var/vcap/sys/log/director .")
+	}
+
+	source, destination := parseSCPPath(paths[0]), parseSCPPath(paths[1])
+	if source.target != "" && destination.target != "" {
+		return errors.New("bbl scp copies between this machine and the jumpbox or director, only one of the paths can be remote")
+	}
+
+	// Only one of them is set.
+	target := source.target + destination.target
+	if target == "" {
+		return fmt.Errorf("one of the paths has to start with %s:", strings.Join(scpTargets, ": or "))
+	}
+
+	tempDir, err := s.ssh.tempDirWriter.TempDir("", "")
+	if err != nil {
+		return fmt.Errorf("Create temp directory: %s", err) //nolint:staticcheck
+	}
+
+	jumpboxKeyPath, err := s.ssh.writePrivateKey(tempDir, "jumpbox")
+	if err != nil {
+		return err
+	}
+
+	jumpboxURL := jumpboxHost(state)
+
+	var toExecute []string
+	host := jumpboxURL
+	if target == "jumpbox" {
+		toExecute = []string{
+			"-o", "ServerAliveInterval=300",
+			"-i", jumpboxKeyPath,
+		}
+	} else {
+		directorKeyPath, err := s.ssh.writePrivateKey(tempDir, "director")
+		if err != nil {
+			return err
+		}
+
+		proxyCommand, closeProxy, err := s.ssh.openProxy(jumpboxURL, jumpboxKeyPath)
+		if err != nil {
+			return err
+		}
+		defer closeProxy()
+
+		toExecute = []string{
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "ServerAliveInterval=300",
+			"-o", proxyCommand,
+			"-i", directorKeyPath,
+		}
+		host = directorHost(state)
+
+		time.Sleep(2 * time.Second) // make sure we give that tunnel a moment to open
+	}
+
+	if recursive {
+		toExecute = append(toExecute, "-r")
+	}
+	toExecute = append(toExecute, scpPath(source, host), scpPath(destination, host))
+
+	return s.cli.Run(toExecute)
+}
+
+// scpPathSpec is a path given to bbl scp, with the jumpbox or director as
+// its target when it is remote.
+type scpPathSpec struct {
+	target string
+	path   string
+}
+
+func parseSCPPath(path string) scpPathSpec {
+	for _, target := range scpTargets {
+		if strings.HasPrefix(path, target+":") {
+			return scpPathSpec{target: target, path: strings.TrimPrefix(path, target+":")}
+		}
+	}
+	return scpPathSpec{path: path}
+}
+
+func scpPath(spec scpPathSpec, host string) string {
+	if spec.target == "" {
+		return spec.path
+	}
+	return fmt.Sprintf("jumpbox@%s:%s", host, spec.path)
+}
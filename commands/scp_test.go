@@ -0,0 +1,141 @@
+package commands_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SCP", func() {
+	var (
+		scp          commands.SCP
+		sshCLI       *fakes.SSHCLI
+		scpCLI       *fakes.SSHCLI
+		pathFinder   *fakes.PathFinder
+		sshKeyGetter *fakes.FancySSHKeyGetter
+		fileIO       *fakes.FileIO
+		randomPort   *fakes.RandomPort
+		logger       *fakes.Logger
+	)
+
+	BeforeEach(func() {
+		sshCLI = &fakes.SSHCLI{}
+		scpCLI = &fakes.SSHCLI{}
+		sshKeyGetter = &fakes.FancySSHKeyGetter{}
+		pathFinder = &fakes.PathFinder{}
+		fileIO = &fakes.FileIO{}
+		randomPort = &fakes.RandomPort{}
+		logger = &fakes.Logger{}
+
+		scp = commands.NewSCP(logger, sshCLI, scpCLI, sshKeyGetter, pathFinder, fileIO, randomPort)
+	})
+
+	Describe("CheckFastFails", func() {
+		Context("where there is no jumpbox url", func() {
+			It("returns an error", func() {
+				err := scp.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("Invalid bbl state for bbl scp."))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		var (
+			jumpboxPrivateKeyPath  string
+			directorPrivateKeyPath string
+			state                  storage.State
+		)
+
+		BeforeEach(func() {
+			fileIO.TempDirCall.Returns.Name = "some-temp-dir"
+			sshKeyGetter.JumpboxGetCall.Returns.PrivateKey = "jumpbox-private-key"
+			sshKeyGetter.DirectorGetCall.Returns.PrivateKey = "director-private-key"
+			jumpboxPrivateKeyPath = filepath.Join("some-temp-dir", "jumpbox-private-key")
+			directorPrivateKeyPath = filepath.Join("some-temp-dir", "director-private-key")
+			randomPort.GetPortCall.Returns.Port = "60000"
+
+			state = storage.State{
+				Jumpbox: storage.Jumpbox{
+					URL: "jumpboxURL:22",
+				},
+				BOSH: storage.BOSH{
+					DirectorAddress: "https://directorURL:25",
+				},
+			}
+		})
+
+		It("copies a file to the jumpbox", func() {
+			err := scp.Execute([]string{"some-file", "jumpbox:/tmp/some-file"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshCLI.StartCall.CallCount).To(Equal(0))
+			Expect(scpCLI.RunCall.Receives).To(Equal([][]string{{
+				"-o", "ServerAliveInterval=300",
+				"-i", jumpboxPrivateKeyPath,
+				"some-file", "jumpbox@jumpboxURL:/tmp/some-file",
+			}}))
+		})
+
+		It("copies a directory from the director through a tunnel through the jumpbox", func() {
+			err := scp.Execute([]string{"-r", "director:/var/vcap/sys/log", "logs"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshCLI.StartCall.Receives[0]).To(ConsistOf(
+				"-4", "-D", "60000", "-nNC", "jumpbox@jumpboxURL", "-i", jumpboxPrivateKeyPath,
+			))
+			Expect(scpCLI.RunCall.Receives).To(Equal([][]string{{
+				"-o", "StrictHostKeyChecking=no",
+				"-o", "ServerAliveInterval=300",
+				"-o", "ProxyCommand=nc -x localhost:60000 %h %p",
+				"-i", directorPrivateKeyPath,
+				"-r",
+				"jumpbox@directorURL:/var/vcap/sys/log", "logs",
+			}}))
+		})
+
+		Context("when neither path is remote", func() {
+			It("returns an error", func() {
+				err := scp.Execute([]string{"some-file", "some-other-file"}, state)
+				Expect(err).To(MatchError("one of the paths has to start with jumpbox: or director:"))
+			})
+		})
+
+		Context("when both paths are remote", func() {
+			It("returns an error", func() {
+				err := scp.Execute([]string{"jumpbox:some-file", "director:some-file"}, state)
+				Expect(err).To(MatchError("bbl scp copies between this machine and the jumpbox or director, only one of the paths can be remote"))
+			})
+		})
+
+		Context("when there are not two paths", func() {
+			It("returns an error", func() {
+				err := scp.Execute([]string{"jumpbox:some-file"}, state)
+				Expect(err).To(MatchError("bbl scp needs a source and a destination, such as director:/var/vcap/sys/log/director ."))
+			})
+		})
+
+		Context("when the jumpbox private key cannot be written", func() {
+			It("returns an error", func() {
+				fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("boisenberry")}}
+
+				err := scp.Execute([]string{"some-file", "jumpbox:some-file"}, state)
+				Expect(err).To(MatchError("Write private key file: boisenberry"))
+			})
+		})
+
+		Context("when the tunnel to the jumpbox cannot be opened", func() {
+			It("returns an error", func() {
+				sshCLI.StartCall.Returns = []fakes.SSHStartReturn{{Error: errors.New("lignonberry")}}
+
+				err := scp.Execute([]string{"some-file", "director:some-file"}, state)
+				Expect(err).To(MatchError("Open tunnel to jumpbox: lignonberry"))
+			})
+		})
+	})
+})
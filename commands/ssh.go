@@ -79,53 +79,85 @@ func (s SSH) Execute(args []string, state storage.State) error {
 		return fmt.Errorf("This command requires the --jumpbox or --director flag.") //nolint:staticcheck
 	}
 
-	if jumpbox && len(cmd) > 0 {
-		return fmt.Errorf("Executing commands on jumpbox not supported (only on director).") //nolint:staticcheck
-	}
-
 	tempDir, err := s.tempDirWriter.TempDir("", "")
 	if err != nil {
 		return fmt.Errorf("Create temp directory: %s", err) //nolint:staticcheck
 	}
 
-	jumpboxKey, err := s.keyGetter.Get("jumpbox")
-	if err != nil {
-		return fmt.Errorf("Get jumpbox private key: %s", err) //nolint:staticcheck
-	}
-
-	jumpboxKeyPath := filepath.Join(tempDir, "jumpbox-private-key")
-
-	err = s.tempDirWriter.WriteFile(jumpboxKeyPath, []byte(jumpboxKey), 0600)
+	jumpboxKeyPath, err := s.writePrivateKey(tempDir, "jumpbox")
 	if err != nil {
-		return fmt.Errorf("Write private key file: %s", err) //nolint:staticcheck
+		return err
 	}
 
-	jumpboxURL := strings.Split(state.Jumpbox.URL, ":")[0]
+	jumpboxURL := jumpboxHost(state)
 
 	if jumpbox {
-		return s.cli.Run([]string{
+		toExecute := []string{
 			"-tt",
 			"-o", "ServerAliveInterval=300",
 			fmt.Sprintf("jumpbox@%s", jumpboxURL),
 			"-i", jumpboxKeyPath,
-		})
+		}
+		if len(cmd) > 0 {
+			toExecute = append(toExecute, cmd)
+			s.logger.Printf("executing command on jumpbox:\n%s\n", cmd)
+		}
+		return s.cli.Run(toExecute)
+	}
+
+	directorKeyPath, err := s.writePrivateKey(tempDir, "director")
+	if err != nil {
+		return err
 	}
 
-	directorPrivateKey, err := s.keyGetter.Get("director")
+	proxyCommand, closeProxy, err := s.openProxy(jumpboxURL, jumpboxKeyPath)
 	if err != nil {
-		return fmt.Errorf("Get director private key: %s", err) //nolint:staticcheck
+		return err
 	}
+	defer closeProxy()
 
-	directorKeyPath := filepath.Join(tempDir, "director-private-key")
+	toExecute := []string{
+		"-tt",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "ServerAliveInterval=300",
+		"-o", proxyCommand,
+		"-i", directorKeyPath,
+		fmt.Sprintf("jumpbox@%s", directorHost(state)),
+	}
+	if len(cmd) > 0 {
+		toExecute = append(toExecute, cmd)
+		s.logger.Printf("executing command on director:\n%s\n", cmd)
+	}
 
-	err = s.tempDirWriter.WriteFile(directorKeyPath, []byte(directorPrivateKey), 0600)
+	time.Sleep(2 * time.Second) // make sure we give that tunnel a moment to open
+	return s.cli.Run(toExecute)
+}
+
+// writePrivateKey writes the private key of the jumpbox or director to dir
+// for ssh to use.
+func (s SSH) writePrivateKey(dir, deployment string) (string, error) {
+	privateKey, err := s.keyGetter.Get(deployment)
+	if err != nil {
+		return "", fmt.Errorf("Get %s private key: %s", deployment, err) //nolint:staticcheck
+	}
+
+	keyPath := filepath.Join(dir, fmt.Sprintf("%s-private-key", deployment))
+
+	err = s.tempDirWriter.WriteFile(keyPath, []byte(privateKey), 0600)
 	if err != nil {
-		return fmt.Errorf("Write private key file: %s", err) //nolint:staticcheck
+		return "", fmt.Errorf("Write private key file: %s", err) //nolint:staticcheck
 	}
 
+	return keyPath, nil
+}
+
+// openProxy opens a SOCKS tunnel through the jumpbox and returns the
+// ProxyCommand option that reaches the director through it, along with a
+// function that closes the tunnel.
+func (s SSH) openProxy(jumpboxURL, jumpboxKeyPath string) (string, func(), error) {
 	port, err := s.randomPort.GetPort()
 	if err != nil {
-		return fmt.Errorf("Open proxy port: %s", err) //nolint:staticcheck
+		return "", nil, fmt.Errorf("Open proxy port: %s", err) //nolint:staticcheck
 	}
 
 	s.logger.Println("checking host key")
@@ -136,7 +168,7 @@ func (s SSH) Execute(args []string, state storage.State) error {
 		"echo", "host key confirmed",
 	})
 	if err != nil {
-		return fmt.Errorf("unable to verify host key fingerprint: %s", err)
+		return "", nil, fmt.Errorf("unable to verify host key fingerprint: %s", err)
 	}
 	s.logger.Println("opening a tunnel through your jumpbox")
 	backgroundTunnel, err := s.cli.Start([]string{
@@ -147,34 +179,26 @@ func (s SSH) Execute(args []string, state storage.State) error {
 		"-i", jumpboxKeyPath,
 	})
 	if err != nil {
-		return fmt.Errorf("Open tunnel to jumpbox: %s", err) //nolint:staticcheck
+		return "", nil, fmt.Errorf("Open tunnel to jumpbox: %s", err) //nolint:staticcheck
 	}
-	defer func() {
+	closeProxy := func() {
 		if backgroundTunnel != nil {
 			backgroundTunnel.Process.Signal(syscall.SIGINT) //nolint:errcheck
 		} // removing this will break the acceptance test
-	}()
+	}
 
 	proxyCommandPrefix := "nc -x"
 	if s.pathFinder.CommandExists("connect-proxy") {
 		proxyCommandPrefix = "connect-proxy -S"
 	}
 
-	ip := strings.Split(strings.TrimPrefix(state.BOSH.DirectorAddress, "https://"), ":")[0]
+	return fmt.Sprintf("ProxyCommand=%s localhost:%s %%h %%p", proxyCommandPrefix, port), closeProxy, nil
+}
 
-	toExecute := []string{
-		"-tt",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "ServerAliveInterval=300",
-		"-o", fmt.Sprintf("ProxyCommand=%s localhost:%s %%h %%p", proxyCommandPrefix, port),
-		"-i", directorKeyPath,
-		fmt.Sprintf("jumpbox@%s", ip),
-	}
-	if len(cmd) > 0 {
-		toExecute = append(toExecute, cmd)
-		s.logger.Printf("executing command on director:\n%s\n", cmd)
-	}
+func jumpboxHost(state storage.State) string {
+	return strings.Split(state.Jumpbox.URL, ":")[0]
+}
 
-	time.Sleep(2 * time.Second) // make sure we give that tunnel a moment to open
-	return s.cli.Run(toExecute)
+func directorHost(state storage.State) string {
+	return strings.Split(strings.TrimPrefix(state.BOSH.DirectorAddress, "https://"), ":")[0]
 }
@@ -53,6 +53,10 @@ func (s SSHKey) Execute(subcommandFlags []string, state storage.State) error {
 		return errors.New("Could not retrieve the ssh key, please make sure you are targeting the proper state dir.") //nolint:staticcheck
 	}
 
+	if asJSON(subcommandFlags) {
+		return printJSON(s.logger, map[string]string{deployment + "_ssh_key": privateKey})
+	}
+
 	s.logger.Println(privateKey)
 
 	return nil
@@ -55,6 +55,13 @@ var _ = Describe("SSHKey", func() {
 			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"some-private-ssh-key"}))
 		})
 
+		It("prints the key as json with --json", func() {
+			err := sshKeyCommand.Execute([]string{"--json"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"jumpbox_ssh_key": "some-private-ssh-key"}`))
+		})
+
 		Context("director-ssh-key", func() {
 			BeforeEach(func() {
 				sshKeyCommand = commands.NewDirectorSSHKey(logger, stateValidator, sshKeyGetter)
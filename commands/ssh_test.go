@@ -267,6 +267,20 @@ var _ = Describe("SSH", func() {
 				))
 			})
 
+			It("executes a command on the jumpbox", func() {
+				err := ssh.Execute([]string{"--jumpbox", "--cmd", "echo hello"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshCLI.RunCall.Receives[0]).To(Equal([]string{
+					"-tt",
+					"-o", "ServerAliveInterval=300",
+					"jumpbox@jumpboxURL",
+					"-i", jumpboxPrivateKeyPath,
+					"echo hello",
+				}))
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{"executing command on jumpbox:\necho hello\n"}))
+			})
+
 			Context("when ssh key getter fails to get the jumpbox ssh private key", func() {
 				It("returns the error", func() {
 					sshKeyGetter.JumpboxGetCall.Returns.Error = errors.New("fig")
@@ -295,13 +309,6 @@ var _ = Describe("SSH", func() {
 			})
 		})
 
-		Context("when the user provides a command to execute on the jumpbox", func() {
-			It("returns an error", func() {
-				err := ssh.Execute([]string{"--jumpbox", "--cmd", "bogus"}, storage.State{})
-				Expect(err).To(MatchError("Executing commands on jumpbox not supported (only on director)."))
-			})
-		})
-
 		Context("when the user provides invalid flags", func() {
 			It("returns an error", func() {
 				err := ssh.Execute([]string{"--bogus-flag"}, storage.State{})
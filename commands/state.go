@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type StateSubcommands map[string]Command
+
+type State struct {
+	subcommands StateSubcommands
+}
+
+func NewState(subcommands StateSubcommands) State {
+	return State{
+		subcommands: subcommands,
+	}
+}
+
+func (s State) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	subcommand, args, err := s.subcommand(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return subcommand.CheckFastFails(args, state)
+}
+
+func (s State) Execute(subcommandFlags []string, state storage.State) error {
+	subcommand, args, err := s.subcommand(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return subcommand.Execute(args, state)
+}
+
+func (s State) Usage() string {
+	names := []string{}
+	for name := range s.subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{}
+	for _, name := range names {
+		usage := strings.SplitN(s.subcommands[name].Usage(), "\n", 2)[0]
+		lines = append(lines, fmt.Sprintf("  %-24s%s", name, usage))
+	}
+
+	return fmt.Sprintf("%s\n\n%s", StateCommandUsage, strings.Join(lines, "\n"))
+}
+
+func (s State) subcommand(subcommandFlags []string) (Command, []string, error) {
+	if len(subcommandFlags) == 0 {
+		return nil, nil, errors.New("bbl state requires a subcommand, see bbl state --help")
+	}
+
+	subcommand, ok := s.subcommands[subcommandFlags[0]]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown state subcommand: %s", subcommandFlags[0])
+	}
+
+	return subcommand, subcommandFlags[1:], nil
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateEditAccepter interface {
+	AcceptEdit() error
+}
+
+// StateAcceptEdit trusts a bbl-state.json that no longer matches its checksum
+// because it was edited by hand on purpose.
+type StateAcceptEdit struct {
+	logger            logger
+	stateValidator    stateValidator
+	stateEditAccepter stateEditAccepter
+}
+
+func NewStateAcceptEdit(logger logger, stateValidator stateValidator, stateEditAccepter stateEditAccepter) StateAcceptEdit {
+	return StateAcceptEdit{
+		logger:            logger,
+		stateValidator:    stateValidator,
+		stateEditAccepter: stateEditAccepter,
+	}
+}
+
+func (s StateAcceptEdit) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return s.stateValidator.Validate()
+}
+
+func (s StateAcceptEdit) Execute(subcommandFlags []string, state storage.State) error {
+	err := s.stateEditAccepter.AcceptEdit()
+	if err != nil {
+		return fmt.Errorf("accept edit: %s", err)
+	}
+
+	s.logger.Println("bbl-state.json has a new checksum and will be used as it is")
+	return nil
+}
@@ -0,0 +1,60 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateAcceptEdit", func() {
+	var (
+		logger            *fakes.Logger
+		stateValidator    *fakes.StateValidator
+		stateEditAccepter *fakes.StateEditAccepter
+
+		command commands.StateAcceptEdit
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateEditAccepter = &fakes.StateEditAccepter{}
+
+		command = commands.NewStateAcceptEdit(logger, stateValidator, stateEditAccepter)
+	})
+
+	Describe("CheckFastFails", func() {
+		Context("when the state does not exist", func() {
+			It("returns an error", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("failed to validate state")
+
+				err := command.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("failed to validate state"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("gives the state file a new checksum", func() {
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateEditAccepter.AcceptEditCall.CallCount).To(Equal(1))
+			Expect(logger.PrintlnCall.Messages).To(ContainElement("bbl-state.json has a new checksum and will be used as it is"))
+		})
+
+		Context("when accepting the edit fails", func() {
+			It("returns an error", func() {
+				stateEditAccepter.AcceptEditCall.Returns.Error = errors.New("banana")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("accept edit: banana"))
+			})
+		})
+	})
+})
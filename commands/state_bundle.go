@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateExporter interface {
+	Export(bundlePath string, state storage.State) error
+}
+
+type StateExport struct {
+	logger         logger
+	stateValidator stateValidator
+	stateExporter  stateExporter
+}
+
+func NewStateExport(logger logger, stateValidator stateValidator, stateExporter stateExporter) StateExport {
+	return StateExport{
+		logger:         logger,
+		stateValidator: stateValidator,
+		stateExporter:  stateExporter,
+	}
+}
+
+func (s StateExport) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) != 1 {
+		return errors.New("bbl state export requires the path to write the bundle to")
+	}
+	return s.stateValidator.Validate()
+}
+
+func (s StateExport) Execute(subcommandFlags []string, state storage.State) error {
+	err := s.stateExporter.Export(subcommandFlags[0], state)
+	if err != nil {
+		return fmt.Errorf("export state: %s", err)
+	}
+
+	s.logger.Printf("exported %s to %s\n", state.EnvID, subcommandFlags[0])
+	return nil
+}
+
+// StateImport regenerates the bbl-managed files for an environment whose
+// bundle was unpacked into the state directory while bbl was bootstrapping.
+type StateImport struct {
+	logger logger
+	plan   plan
+}
+
+func NewStateImport(logger logger, plan plan) StateImport {
+	return StateImport{
+		logger: logger,
+		plan:   plan,
+	}
+}
+
+func (s StateImport) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) != 1 {
+		return errors.New("bbl state import requires the path to a bundle")
+	}
+	return nil
+}
+
+func (s StateImport) Execute(subcommandFlags []string, state storage.State) error {
+	_, err := s.plan.InitializePlan(PlanConfig{Name: state.EnvID, LB: state.LB}, state)
+	if err != nil {
+		return fmt.Errorf("plan imported state: %s", err)
+	}
+
+	s.logger.Printf("imported %s from %s\n", state.EnvID, subcommandFlags[0])
+	return nil
+}
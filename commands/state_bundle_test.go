@@ -0,0 +1,130 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateExport", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		stateBundler   *fakes.StateBundler
+
+		command commands.StateExport
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateBundler = &fakes.StateBundler{}
+
+		command = commands.NewStateExport(logger, stateValidator, stateBundler)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates that there is a state", func() {
+			err := command.CheckFastFails([]string{"some-bundle.tgz"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+		})
+
+		Context("when the bundle path is missing", func() {
+			It("returns an error", func() {
+				err := command.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("bbl state export requires the path to write the bundle to"))
+			})
+		})
+
+		Context("when there is no state", func() {
+			It("returns an error", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+
+				err := command.CheckFastFails([]string{"some-bundle.tgz"}, storage.State{})
+				Expect(err).To(MatchError("no state"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("exports the state to the bundle", func() {
+			state := storage.State{EnvID: "some-env"}
+
+			err := command.Execute([]string{"some-bundle.tgz"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateBundler.ExportCall.Receives.Path).To(Equal("some-bundle.tgz"))
+			Expect(stateBundler.ExportCall.Receives.State).To(Equal(state))
+			Expect(logger.PrintfCall.Messages).To(ContainElement("exported some-env to some-bundle.tgz\n"))
+		})
+
+		Context("when the export fails", func() {
+			It("returns an error", func() {
+				stateBundler.ExportCall.Returns.Error = errors.New("banana")
+
+				err := command.Execute([]string{"some-bundle.tgz"}, storage.State{})
+				Expect(err).To(MatchError("export state: banana"))
+			})
+		})
+	})
+})
+
+var _ = Describe("StateImport", func() {
+	var (
+		logger *fakes.Logger
+		plan   *fakes.Plan
+
+		command commands.StateImport
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		plan = &fakes.Plan{}
+
+		command = commands.NewStateImport(logger, plan)
+	})
+
+	Describe("CheckFastFails", func() {
+		Context("when the bundle path is missing", func() {
+			It("returns an error", func() {
+				err := command.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("bbl state import requires the path to a bundle"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("plans the imported environment", func() {
+			state := storage.State{
+				EnvID: "some-env",
+				LB:    storage.LB{Type: "cf"},
+			}
+
+			err := command.Execute([]string{"some-bundle.tgz"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plan.InitializePlanCall.Receives.Plan).To(Equal(commands.PlanConfig{
+				Name: "some-env",
+				LB:   storage.LB{Type: "cf"},
+			}))
+			Expect(plan.InitializePlanCall.Receives.State).To(Equal(state))
+			Expect(logger.PrintfCall.Messages).To(ContainElement("imported some-env from some-bundle.tgz\n"))
+		})
+
+		Context("when planning fails", func() {
+			It("returns an error", func() {
+				plan.InitializePlanCall.Returns.Error = errors.New("banana")
+
+				err := command.Execute([]string{"some-bundle.tgz"}, storage.State{})
+				Expect(err).To(MatchError("plan imported state: banana"))
+			})
+		})
+	})
+})
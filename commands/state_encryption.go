@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateEncrypter interface {
+	EncryptStateDir() error
+	DecryptStateDir() error
+}
+
+type StateEncrypt struct {
+	logger         logger
+	stateValidator stateValidator
+	stateEncrypter stateEncrypter
+}
+
+func NewStateEncrypt(logger logger, stateValidator stateValidator, stateEncrypter stateEncrypter) StateEncrypt {
+	return StateEncrypt{
+		logger:         logger,
+		stateValidator: stateValidator,
+		stateEncrypter: stateEncrypter,
+	}
+}
+
+func (s StateEncrypt) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return s.stateValidator.Validate()
+}
+
+func (s StateEncrypt) Execute(subcommandFlags []string, state storage.State) error {
+	err := s.stateEncrypter.EncryptStateDir()
+	if err != nil {
+		return fmt.Errorf("encrypt state dir: %s", err)
+	}
+
+	s.logger.Println("bbl-state.json and vars/ are now encrypted")
+	return nil
+}
+
+type StateDecrypt struct {
+	logger         logger
+	stateValidator stateValidator
+	stateEncrypter stateEncrypter
+}
+
+func NewStateDecrypt(logger logger, stateValidator stateValidator, stateEncrypter stateEncrypter) StateDecrypt {
+	return StateDecrypt{
+		logger:         logger,
+		stateValidator: stateValidator,
+		stateEncrypter: stateEncrypter,
+	}
+}
+
+func (s StateDecrypt) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return s.stateValidator.Validate()
+}
+
+func (s StateDecrypt) Execute(subcommandFlags []string, state storage.State) error {
+	err := s.stateEncrypter.DecryptStateDir()
+	if err != nil {
+		return fmt.Errorf("decrypt state dir: %s", err)
+	}
+
+	s.logger.Println("bbl-state.json and vars/ are now stored in plaintext")
+	return nil
+}
@@ -0,0 +1,91 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("state encryption", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		stateEncrypter *fakes.StateEncrypter
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateEncrypter = &fakes.StateEncrypter{}
+	})
+
+	Describe("StateEncrypt", func() {
+		var command commands.StateEncrypt
+
+		BeforeEach(func() {
+			command = commands.NewStateEncrypt(logger, stateValidator, stateEncrypter)
+		})
+
+		Describe("CheckFastFails", func() {
+			Context("when the state does not exist", func() {
+				It("returns an error", func() {
+					stateValidator.ValidateCall.Returns.Error = errors.New("failed to validate state")
+
+					err := command.CheckFastFails([]string{}, storage.State{})
+					Expect(err).To(MatchError("failed to validate state"))
+				})
+			})
+		})
+
+		Describe("Execute", func() {
+			It("encrypts the state directory", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateEncrypter.EncryptStateDirCall.CallCount).To(Equal(1))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("bbl-state.json and vars/ are now encrypted"))
+			})
+
+			Context("when encryption fails", func() {
+				It("returns an error", func() {
+					stateEncrypter.EncryptStateDirCall.Returns.Error = errors.New("banana")
+
+					err := command.Execute([]string{}, storage.State{})
+					Expect(err).To(MatchError("encrypt state dir: banana"))
+				})
+			})
+		})
+	})
+
+	Describe("StateDecrypt", func() {
+		var command commands.StateDecrypt
+
+		BeforeEach(func() {
+			command = commands.NewStateDecrypt(logger, stateValidator, stateEncrypter)
+		})
+
+		Describe("Execute", func() {
+			It("decrypts the state directory", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateEncrypter.DecryptStateDirCall.CallCount).To(Equal(1))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("bbl-state.json and vars/ are now stored in plaintext"))
+			})
+
+			Context("when decryption fails", func() {
+				It("returns an error", func() {
+					stateEncrypter.DecryptStateDirCall.Returns.Error = errors.New("banana")
+
+					err := command.Execute([]string{}, storage.State{})
+					Expect(err).To(MatchError("decrypt state dir: banana"))
+				})
+			})
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateHistory interface {
+	List() ([]storage.Snapshot, error)
+	Restore(id string) error
+}
+
+type StateHistory struct {
+	logger       logger
+	stateHistory stateHistory
+}
+
+func NewStateHistory(logger logger, stateHistory stateHistory) StateHistory {
+	return StateHistory{
+		logger:       logger,
+		stateHistory: stateHistory,
+	}
+}
+
+func (s StateHistory) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return nil
+}
+
+func (s StateHistory) Execute(subcommandFlags []string, state storage.State) error {
+	snapshots, err := s.stateHistory.List()
+	if err != nil {
+		return fmt.Errorf("list state history: %s", err)
+	}
+
+	if asJSON(subcommandFlags) {
+		if snapshots == nil {
+			snapshots = []storage.Snapshot{}
+		}
+		return printJSON(s.logger, map[string][]storage.Snapshot{"snapshots": snapshots})
+	}
+
+	if len(snapshots) == 0 {
+		s.logger.Println("there are no state snapshots yet")
+		return nil
+	}
+
+	s.logger.Printf("%-24s%-24s%s\n", "ID", "COMMAND", "TAKEN")
+	for _, snapshot := range snapshots {
+		s.logger.Printf("%-24s%-24s%s\n", snapshot.ID, snapshot.Command, snapshot.Created.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+type StateRollback struct {
+	logger       logger
+	stateHistory stateHistory
+}
+
+// NewStateRollback expects bootstrap to hold the state lock while it runs.
+func NewStateRollback(logger logger, stateHistory stateHistory) StateRollback {
+	return StateRollback{
+		logger:       logger,
+		stateHistory: stateHistory,
+	}
+}
+
+func (s StateRollback) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) != 1 {
+		return errors.New("bbl state rollback requires a snapshot id, see bbl state history")
+	}
+	return nil
+}
+
+func (s StateRollback) Execute(subcommandFlags []string, state storage.State) error {
+	id := subcommandFlags[0]
+
+	err := s.stateHistory.Restore(id)
+	if err != nil {
+		return fmt.Errorf("roll back state: %s", err)
+	}
+
+	s.logger.Printf("rolled back bbl-state.json and vars/ to snapshot %s\n", id)
+	return nil
+}
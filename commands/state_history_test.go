@@ -0,0 +1,131 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateHistory", func() {
+	var (
+		logger       *fakes.Logger
+		stateHistory *fakes.StateHistory
+
+		command commands.StateHistory
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateHistory = &fakes.StateHistory{}
+
+		command = commands.NewStateHistory(logger, stateHistory)
+	})
+
+	Describe("Execute", func() {
+		It("lists the snapshots", func() {
+			stateHistory.ListCall.Returns.Snapshots = []storage.Snapshot{{
+				ID:      "20180102T030405Z",
+				Command: "up",
+				Created: time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+			}}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"ID                      COMMAND                 TAKEN\n",
+				"20180102T030405Z        up                      2018-01-02T03:04:05Z\n",
+			}))
+		})
+
+		It("lists the snapshots as json with --json", func() {
+			stateHistory.ListCall.Returns.Snapshots = []storage.Snapshot{{
+				ID:      "20180102T030405Z",
+				Command: "up",
+				Created: time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+			}}
+
+			err := command.Execute([]string{"--json"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"snapshots": [
+					{"id": "20180102T030405Z", "command": "up", "created": "2018-01-02T03:04:05Z"}
+				]
+			}`))
+		})
+
+		Context("when there are no snapshots", func() {
+			It("prints an empty list as json with --json", func() {
+				err := command.Execute([]string{"--json"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"snapshots": []}`))
+			})
+
+			It("says so", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("there are no state snapshots yet"))
+			})
+		})
+
+		Context("when listing the snapshots fails", func() {
+			It("returns an error", func() {
+				stateHistory.ListCall.Returns.Error = errors.New("banana")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("list state history: banana"))
+			})
+		})
+	})
+})
+
+var _ = Describe("StateRollback", func() {
+	var (
+		logger       *fakes.Logger
+		stateHistory *fakes.StateHistory
+
+		command commands.StateRollback
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateHistory = &fakes.StateHistory{}
+
+		command = commands.NewStateRollback(logger, stateHistory)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("requires a snapshot id", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("bbl state rollback requires a snapshot id, see bbl state history"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("restores the snapshot", func() {
+			err := command.Execute([]string{"some-id"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateHistory.RestoreCall.Receives.ID).To(Equal("some-id"))
+			Expect(logger.PrintfCall.Messages).To(ContainElement("rolled back bbl-state.json and vars/ to snapshot some-id\n"))
+		})
+
+		Context("when restoring fails", func() {
+			It("returns an error", func() {
+				stateHistory.RestoreCall.Returns.Error = errors.New("banana")
+
+				err := command.Execute([]string{"some-id"}, storage.State{})
+				Expect(err).To(MatchError("roll back state: banana"))
+			})
+		})
+	})
+})
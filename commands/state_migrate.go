@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateMigrator interface {
+	Plan(state storage.State) ([]storage.MigrationStep, error)
+	Migrate(state storage.State) (storage.State, error)
+}
+
+type StateMigrate struct {
+	logger        logger
+	stateMigrator stateMigrator
+}
+
+// NewStateMigrate expects bootstrap to hold the state lock and take a
+// snapshot unless --dry-run is given.
+func NewStateMigrate(logger logger, stateMigrator stateMigrator) StateMigrate {
+	return StateMigrate{
+		logger:        logger,
+		stateMigrator: stateMigrator,
+	}
+}
+
+func (s StateMigrate) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return nil
+}
+
+func (s StateMigrate) Execute(subcommandFlags []string, state storage.State) error {
+	var dryRun bool
+	migrateFlags := flags.New("state migrate")
+	migrateFlags.Bool(&dryRun, "dry-run")
+	err := migrateFlags.Parse(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	steps, err := s.stateMigrator.Plan(state)
+	if err != nil {
+		return fmt.Errorf("plan state migration: %s", err)
+	}
+
+	if len(steps) == 0 {
+		s.logger.Println("the bbl state directory is up to date")
+		return nil
+	}
+
+	for _, step := range steps {
+		s.logger.Printf("%s\n", step.Name)
+		s.printFiles("create", step.Creates)
+		s.printFiles("update", step.Updates)
+		s.printFiles("delete", step.Deletes)
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	_, err = s.stateMigrator.Migrate(state)
+	if err != nil {
+		return fmt.Errorf("migrate state: %s", err)
+	}
+
+	s.logger.Printf("applied %d migrations\n", len(steps))
+	return nil
+}
+
+func (s StateMigrate) printFiles(action string, files []string) {
+	if len(files) > 0 {
+		s.logger.Printf("  %s %s\n", action, strings.Join(files, ", "))
+	}
+}
@@ -0,0 +1,105 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateMigrate", func() {
+	var (
+		logger        *fakes.Logger
+		stateMigrator *fakes.StateMigrator
+		state         storage.State
+
+		command commands.StateMigrate
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateMigrator = &fakes.StateMigrator{}
+		state = storage.State{EnvID: "some-env-id"}
+
+		stateMigrator.PlanCall.Returns.Steps = []storage.MigrationStep{
+			{
+				Name:    "MigrateTerraformState",
+				Creates: []string{"vars/terraform.tfstate"},
+				Updates: []string{"bbl-state.json"},
+			},
+			{
+				Name:    "MigrateDirectorVarsFile",
+				Creates: []string{"vars/director-vars-file.yml"},
+				Deletes: []string{"vars/director-deployment-vars.yml"},
+			},
+		}
+
+		command = commands.NewStateMigrate(logger, stateMigrator)
+	})
+
+	Describe("Execute", func() {
+		It("applies the migrations", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateMigrator.MigrateCall.Receives.State).To(Equal(state))
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"MigrateTerraformState\n",
+				"  create vars/terraform.tfstate\n",
+				"  update bbl-state.json\n",
+				"MigrateDirectorVarsFile\n",
+				"  create vars/director-vars-file.yml\n",
+				"  delete vars/director-deployment-vars.yml\n",
+				"applied 2 migrations\n",
+			}))
+		})
+
+		Context("with --dry-run", func() {
+			It("prints the migrations without applying them", func() {
+				err := command.Execute([]string{"--dry-run"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateMigrator.PlanCall.Receives.State).To(Equal(state))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("MigrateDirectorVarsFile\n"))
+				Expect(stateMigrator.MigrateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when there is nothing to migrate", func() {
+			It("says so", func() {
+				stateMigrator.PlanCall.Returns.Steps = nil
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("the bbl state directory is up to date"))
+				Expect(stateMigrator.MigrateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--banana"}, state)
+				Expect(err).To(MatchError("flag provided but not defined: -banana"))
+			})
+
+			It("returns an error when planning fails", func() {
+				stateMigrator.PlanCall.Returns.Error = errors.New("banana")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("plan state migration: banana"))
+			})
+
+			It("returns an error when migrating fails", func() {
+				stateMigrator.MigrateCall.Returns.Error = errors.New("banana")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("migrate state: banana"))
+			})
+		})
+	})
+})
@@ -22,18 +22,30 @@ const (
 	DirectorCACertPropertyName   = "director ca cert"
 )
 
+// stateQueryJSONKeys are the keys of the properties in the output of --json.
+var stateQueryJSONKeys = map[string]string{
+	EnvIDPropertyName:            "env_id",
+	JumpboxAddressPropertyName:   "jumpbox_address",
+	DirectorUsernamePropertyName: "director_username",
+	DirectorPasswordPropertyName: "director_password",
+	DirectorAddressPropertyName:  "director_address",
+	DirectorCACertPropertyName:   "director_ca_cert",
+}
+
 type StateQuery struct {
 	logger           logger
 	stateValidator   stateValidator
 	terraformManager terraformManager
+	secretResolver   secretResolver
 	propertyName     string
 }
 
-func NewStateQuery(logger logger, stateValidator stateValidator, terraformManager terraformManager, propertyName string) StateQuery {
+func NewStateQuery(logger logger, stateValidator stateValidator, terraformManager terraformManager, secretResolver secretResolver, propertyName string) StateQuery {
 	return StateQuery{
 		logger:           logger,
 		stateValidator:   stateValidator,
 		terraformManager: terraformManager,
+		secretResolver:   secretResolver,
 		propertyName:     propertyName,
 	}
 }
@@ -66,7 +78,10 @@ func (s StateQuery) Execute(subcommandFlags []string, state storage.State) error
 	case DirectorUsernamePropertyName:
 		propertyValue = state.BOSH.DirectorUsername
 	case DirectorPasswordPropertyName:
-		propertyValue = state.BOSH.DirectorPassword
+		propertyValue, err = s.secretResolver.Resolve(state.BOSH.DirectorPassword)
+		if err != nil {
+			return err
+		}
 	case DirectorCACertPropertyName:
 		propertyValue = state.BOSH.DirectorSSLCA
 	case EnvIDPropertyName:
@@ -77,6 +92,10 @@ func (s StateQuery) Execute(subcommandFlags []string, state storage.State) error
 		return fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", s.propertyName) //nolint:staticcheck
 	}
 
+	if asJSON(subcommandFlags) {
+		return printJSON(s.logger, map[string]string{stateQueryJSONKeys[s.propertyName]: propertyValue})
+	}
+
 	s.logger.Println(propertyValue)
 	return nil
 }
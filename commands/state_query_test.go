@@ -19,12 +19,14 @@ var _ = Describe("StateQuery", func() {
 		fakeLogger         *fakes.Logger
 		fakeStateValidator *fakes.StateValidator
 		terraformManager   *fakes.TerraformManager
+		secretResolver     *fakes.SecretResolver
 	)
 
 	BeforeEach(func() {
 		fakeLogger = &fakes.Logger{}
 		fakeStateValidator = &fakes.StateValidator{}
 		terraformManager = &fakes.TerraformManager{}
+		secretResolver = &fakes.SecretResolver{}
 	})
 
 	Describe("CheckFastFails", func() {
@@ -34,7 +36,7 @@ var _ = Describe("StateQuery", func() {
 			})
 
 			It("returns an error", func() {
-				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, "")
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, secretResolver, "")
 
 				err := command.CheckFastFails([]string{}, storage.State{})
 				Expect(err).To(MatchError("state validator failed"))
@@ -51,7 +53,7 @@ var _ = Describe("StateQuery", func() {
 			})
 
 			It("prints out the jumpbox information", func() {
-				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, "jumpbox address")
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, secretResolver, "jumpbox address")
 
 				err := command.Execute([]string{}, storage.State{})
 				Expect(err).NotTo(HaveOccurred())
@@ -77,7 +79,7 @@ var _ = Describe("StateQuery", func() {
 
 			DescribeTable("prints out the director information",
 				func(propertyName, expectedOutput string) {
-					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, propertyName)
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, secretResolver, propertyName)
 
 					err := command.Execute([]string{}, state)
 					Expect(err).NotTo(HaveOccurred())
@@ -89,6 +91,43 @@ var _ = Describe("StateQuery", func() {
 				Entry("director-password", "director password", "some-director-password"),
 				Entry("director-ssl-ca", "director ca cert", "some-director-ssl-ca"),
 			)
+
+			DescribeTable("prints out the director information as json",
+				func(propertyName, expectedOutput string) {
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, secretResolver, propertyName)
+
+					err := command.Execute([]string{"--json"}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeLogger.PrintlnCall.Receives.Message).To(MatchJSON(expectedOutput))
+				},
+				Entry("director-address", "director address", `{"director_address": "some-director-address"}`),
+				Entry("director-username", "director username", `{"director_username": "some-director-username"}`),
+				Entry("director-password", "director password", `{"director_password": "some-director-password"}`),
+				Entry("director-ssl-ca", "director ca cert", `{"director_ca_cert": "some-director-ssl-ca"}`),
+			)
+
+			Context("when the director password is kept in a secret store", func() {
+				It("prints the resolved password", func() {
+					secretResolver.ResolveCall.Stub = func(string) (string, error) { return "some-resolved-password", nil }
+					state.BOSH.DirectorPassword = "secret:director-password"
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, secretResolver, "director password")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(secretResolver.ResolveCall.Receives.Value).To(Equal("secret:director-password"))
+					Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("some-resolved-password"))
+				})
+
+				It("returns an error when the password cannot be resolved", func() {
+					secretResolver.ResolveCall.Stub = func(string) (string, error) { return "", errors.New("vault is sealed") }
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, secretResolver, "director password")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("vault is sealed"))
+				})
+			})
 		})
 
 		Context("failure cases", func() {
@@ -98,7 +137,7 @@ var _ = Describe("StateQuery", func() {
 				})
 
 				It("jumpbox-address returns an error", func() {
-					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, "jumpbox address")
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, secretResolver, "jumpbox address")
 
 					err := command.Execute([]string{}, storage.State{})
 					Expect(err).To(MatchError("failed to get terraform output"))
@@ -108,7 +147,7 @@ var _ = Describe("StateQuery", func() {
 			Context("when the state value is empty", func() {
 				It("returns an error", func() {
 					propertyName := fmt.Sprintf("%s-%d", "some-name", rand.Int())
-					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, propertyName)
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, secretResolver, propertyName)
 					err := command.Execute([]string{}, storage.State{
 						BOSH: storage.BOSH{},
 					})
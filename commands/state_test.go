@@ -0,0 +1,79 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("State", func() {
+	var (
+		subcommand *fakes.Command
+		command    commands.State
+	)
+
+	BeforeEach(func() {
+		subcommand = &fakes.Command{}
+		command = commands.NewState(commands.StateSubcommands{
+			"some-subcommand": subcommand,
+		})
+	})
+
+	Describe("CheckFastFails", func() {
+		It("delegates to the subcommand", func() {
+			err := command.CheckFastFails([]string{"some-subcommand", "--some-flag"}, storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(subcommand.CheckFastFailsCall.CallCount).To(Equal(1))
+			Expect(subcommand.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(subcommand.CheckFastFailsCall.Receives.State.EnvID).To(Equal("some-env-id"))
+		})
+
+		Context("when no subcommand is given", func() {
+			It("returns an error", func() {
+				err := command.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("bbl state requires a subcommand, see bbl state --help"))
+			})
+		})
+
+		Context("when the subcommand is unknown", func() {
+			It("returns an error", func() {
+				err := command.CheckFastFails([]string{"banana"}, storage.State{})
+				Expect(err).To(MatchError("unknown state subcommand: banana"))
+			})
+		})
+
+		Context("when the subcommand fast fails", func() {
+			It("returns the error", func() {
+				subcommand.CheckFastFailsCall.Returns.Error = errors.New("failed")
+
+				err := command.CheckFastFails([]string{"some-subcommand"}, storage.State{})
+				Expect(err).To(MatchError("failed"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("delegates to the subcommand", func() {
+			err := command.Execute([]string{"some-subcommand", "--some-flag"}, storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(subcommand.ExecuteCall.CallCount).To(Equal(1))
+			Expect(subcommand.ExecuteCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(subcommand.ExecuteCall.Receives.State.EnvID).To(Equal("some-env-id"))
+		})
+	})
+
+	Describe("Usage", func() {
+		It("lists the subcommands", func() {
+			subcommand.UsageCall.Returns.Usage = "Does something useful.\n\n  --some-flag   some flag"
+
+			Expect(command.Usage()).To(ContainSubstring("  some-subcommand         Does something useful."))
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateLocker interface {
+	Info() (storage.LockInfo, bool, error)
+	ForceUnlock() error
+}
+
+type StateUnlock struct {
+	logger      logger
+	stateLocker stateLocker
+}
+
+func NewStateUnlock(logger logger, stateLocker stateLocker) StateUnlock {
+	return StateUnlock{
+		logger:      logger,
+		stateLocker: stateLocker,
+	}
+}
+
+func (s StateUnlock) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return nil
+}
+
+func (s StateUnlock) Execute(subcommandFlags []string, state storage.State) error {
+	var force bool
+	unlockFlags := flags.New("state unlock")
+	unlockFlags.Bool(&force, "force")
+	err := unlockFlags.Parse(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	// --force removes a lock file that cannot be parsed.
+	info, locked, err := s.stateLocker.Info()
+	if err != nil && !(locked && force) {
+		return err
+	}
+
+	if !locked {
+		s.logger.Println("the bbl state directory is not locked")
+		return nil
+	}
+
+	if !force {
+		return fmt.Errorf("the bbl state directory is locked by `bbl %s` (pid %d on %s, started %s), re-run with --force to remove the lock",
+			info.Command, info.PID, info.Hostname, info.Started.Format(time.RFC3339))
+	}
+
+	err = s.stateLocker.ForceUnlock()
+	if err != nil {
+		return fmt.Errorf("unlock state dir: %s", err)
+	}
+
+	s.logger.Println("removed the bbl state directory lock")
+	return nil
+}
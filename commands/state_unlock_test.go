@@ -0,0 +1,115 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateUnlock", func() {
+	var (
+		logger      *fakes.Logger
+		stateLocker *fakes.StateLocker
+
+		command commands.StateUnlock
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateLocker = &fakes.StateLocker{}
+
+		command = commands.NewStateUnlock(logger, stateLocker)
+	})
+
+	Describe("Execute", func() {
+		Context("when the state dir is locked", func() {
+			BeforeEach(func() {
+				stateLocker.InfoCall.Returns.Locked = true
+				stateLocker.InfoCall.Returns.Info = storage.LockInfo{
+					PID:      1234,
+					Hostname: "some-host",
+					Command:  "up",
+					Started:  time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+				}
+			})
+
+			It("removes the lock when --force is passed", func() {
+				err := command.Execute([]string{"--force"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateLocker.ForceUnlockCall.CallCount).To(Equal(1))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("removed the bbl state directory lock"))
+			})
+
+			It("refuses to remove the lock without --force", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("the bbl state directory is locked by `bbl up` (pid 1234 on some-host, started 2018-01-02T03:04:05Z), re-run with --force to remove the lock"))
+
+				Expect(stateLocker.ForceUnlockCall.CallCount).To(Equal(0))
+			})
+
+			Context("when removing the lock fails", func() {
+				It("returns an error", func() {
+					stateLocker.ForceUnlockCall.Returns.Error = errors.New("banana")
+
+					err := command.Execute([]string{"--force"}, storage.State{})
+					Expect(err).To(MatchError("unlock state dir: banana"))
+				})
+			})
+		})
+
+		Context("when the lock file is unreadable", func() {
+			It("removes it with --force", func() {
+				stateLocker.InfoCall.Returns.Locked = true
+				stateLocker.InfoCall.Returns.Error = errors.New("unreadable")
+
+				err := command.Execute([]string{"--force"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stateLocker.ForceUnlockCall.CallCount).To(Equal(1))
+			})
+
+			It("returns the error without --force", func() {
+				stateLocker.InfoCall.Returns.Locked = true
+				stateLocker.InfoCall.Returns.Error = errors.New("unreadable")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("unreadable"))
+				Expect(stateLocker.ForceUnlockCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the lock file cannot be read", func() {
+			It("returns an error rather than reporting that there is no lock", func() {
+				stateLocker.InfoCall.Returns.Error = errors.New("Read lock file: permission denied")
+
+				err := command.Execute([]string{"--force"}, storage.State{})
+				Expect(err).To(MatchError("Read lock file: permission denied"))
+				Expect(stateLocker.ForceUnlockCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages).NotTo(ContainElement("the bbl state directory is not locked"))
+			})
+		})
+
+		Context("when the state dir is not locked", func() {
+			It("does nothing", func() {
+				err := command.Execute([]string{"--force"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateLocker.ForceUnlockCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("the bbl state directory is not locked"))
+			})
+		})
+
+		Context("when the flags cannot be parsed", func() {
+			It("returns an error", func() {
+				err := command.Execute([]string{"--banana"}, storage.State{})
+				Expect(err).To(MatchError("flag provided but not defined: -banana"))
+			})
+		})
+	})
+})
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/health"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type healthChecker interface {
+	Check(storage.State) []health.Result
+}
+
+type Status struct {
+	logger           logger
+	stateValidator   stateValidator
+	healthChecker    healthChecker
+	terraformManager terraformManager
+}
+
+func NewStatus(logger logger, stateValidator stateValidator, healthChecker healthChecker, terraformManager terraformManager) Status {
+	return Status{
+		logger:           logger,
+		stateValidator:   stateValidator,
+		healthChecker:    healthChecker,
+		terraformManager: terraformManager,
+	}
+}
+
+func (s Status) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return s.stateValidator.Validate()
+}
+
+func (s Status) Execute(subcommandFlags []string, state storage.State) error {
+	results := append(s.healthChecker.Check(state), s.terraform(state))
+	overall := overallStatus(results)
+
+	if asJSON(subcommandFlags) {
+		err := printJSON(s.logger, struct {
+			Status health.Status   `json:"status"`
+			Checks []health.Result `json:"checks"`
+		}{overall, results})
+		if err != nil {
+			return err
+		}
+	} else {
+		s.logger.Printf("%-24s%-10s%s\n", "CHECK", "STATUS", "DETAIL")
+		for _, result := range results {
+			s.logger.Printf("%-24s%-10s%s\n", result.Check, result.Status, result.Detail)
+		}
+	}
+
+	switch overall {
+	case health.Failed:
+		return ExitCodeError{Code: UnhealthyExitCode, Message: "the environment is unhealthy"}
+	case health.Drift:
+		return ExitCodeError{Code: DriftExitCode, Message: "the infrastructure differs from the terraform templates, run bbl drift for details"}
+	}
+
+	return nil
+}
+
+func (s Status) terraform(state storage.State) health.Result {
+	plan, err := s.terraformManager.Plan(state)
+	if err != nil {
+		return health.Result{Check: health.TerraformCheck, Status: health.Failed, Detail: err.Error()}
+	}
+
+	if plan.HasChanges {
+		return health.Result{Check: health.TerraformCheck, Status: health.Drift, Detail: planCounts(plan)}
+	}
+
+	return health.Result{Check: health.TerraformCheck, Status: health.OK, Detail: "no changes"}
+}
+
+// overallStatus is failed when any check failed or could not run, drift when
+// terraform has drifted but everything else is ok, and ok otherwise.
+func overallStatus(results []health.Result) health.Status {
+	overall := health.OK
+	for _, result := range results {
+		switch result.Status {
+		case health.Failed, health.Skipped:
+			return health.Failed
+		case health.Drift:
+			overall = health.Drift
+		}
+	}
+	return overall
+}
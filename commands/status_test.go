@@ -0,0 +1,121 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/health"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Status", func() {
+	var (
+		logger           *fakes.Logger
+		stateValidator   *fakes.StateValidator
+		healthChecker    *fakes.HealthChecker
+		terraformManager *fakes.TerraformManager
+		state            storage.State
+
+		command commands.Status
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		healthChecker = &fakes.HealthChecker{}
+		terraformManager = &fakes.TerraformManager{}
+		state = storage.State{EnvID: "some-env-id"}
+
+		healthChecker.CheckCall.Returns.Results = []health.Result{
+			{Check: health.JumpboxCheck, Status: health.OK, Detail: "jumpbox@10.0.0.5:22"},
+			{Check: health.DirectorCheck, Status: health.OK, Detail: "some-director 277.1.0"},
+		}
+
+		command = commands.NewStatus(logger, stateValidator, healthChecker, terraformManager)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when there is no state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("no state"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints a table of the checks", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(healthChecker.CheckCall.Receives.State).To(Equal(state))
+			Expect(terraformManager.PlanCall.Receives.BBLState).To(Equal(state))
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"CHECK                   STATUS    DETAIL\n",
+				"jumpbox                 ok        jumpbox@10.0.0.5:22\n",
+				"director                ok        some-director 277.1.0\n",
+				"terraform               ok        no changes\n",
+			}))
+		})
+
+		Context("when --json is passed", func() {
+			It("prints the checks as json", func() {
+				err := command.Execute([]string{"--json"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				var output struct {
+					Status string          `json:"status"`
+					Checks []health.Result `json:"checks"`
+				}
+				Expect(json.Unmarshal([]byte(logger.PrintlnCall.Receives.Message), &output)).To(Succeed())
+				Expect(output.Status).To(Equal("ok"))
+				Expect(output.Checks).To(HaveLen(3))
+				Expect(output.Checks[2]).To(Equal(health.Result{Check: "terraform", Status: health.OK, Detail: "no changes"}))
+				Expect(logger.PrintfCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when a check fails", func() {
+			BeforeEach(func() {
+				healthChecker.CheckCall.Returns.Results[1] = health.Result{Check: health.DirectorCheck, Status: health.Failed, Detail: "connection refused"}
+				terraformManager.PlanCall.Returns.Summary = terraform.PlanSummary{HasChanges: true, Change: 1}
+			})
+
+			It("exits with the unhealthy exit code", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).To(Equal(commands.ExitCodeError{Code: 3, Message: "the environment is unhealthy"}))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("director                failed    connection refused\n"))
+			})
+		})
+
+		Context("when terraform reports drift", func() {
+			BeforeEach(func() {
+				terraformManager.PlanCall.Returns.Summary = terraform.PlanSummary{HasChanges: true, Change: 1}
+			})
+
+			It("exits with the drift exit code", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).To(Equal(commands.ExitCodeError{Code: 2, Message: "the infrastructure differs from the terraform templates, run bbl drift for details"}))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("terraform               drift     0 to add, 1 to change, 0 to destroy\n"))
+			})
+		})
+
+		Context("when terraform plan fails", func() {
+			BeforeEach(func() {
+				terraformManager.PlanCall.Returns.Error = errors.New("Executor plan: some-error")
+			})
+
+			It("fails the terraform check", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).To(Equal(commands.ExitCodeError{Code: 3, Message: "the environment is unhealthy"}))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("terraform               failed    Executor plan: some-error\n"))
+			})
+		})
+	})
+})
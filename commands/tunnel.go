@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type Tunnel struct {
+	ssh SSH
+}
+
+type TunnelConfig struct {
+	LocalPort string
+	Remote    string
+}
+
+func NewTunnel(logger logger, sshCLI sshCLI, sshKeyGetter sshKeyGetter, tempDirWriter tempDirWriter) Tunnel {
+	return Tunnel{
+		ssh: NewSSH(logger, sshCLI, sshKeyGetter, nil, tempDirWriter, nil),
+	}
+}
+
+func (t Tunnel) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(state.Jumpbox.URL) == 0 {
+		return errors.New("Invalid bbl state for bbl tunnel.") //nolint:staticcheck
+	}
+
+	_, err := t.ParseArgs(subcommandFlags, state)
+	return err
+}
+
+func (t Tunnel) ParseArgs(args []string, state storage.State) (TunnelConfig, error) {
+	var config TunnelConfig
+	tunnelFlags := flags.New("tunnel")
+	tunnelFlags.String(&config.LocalPort, "local-port", "")
+	tunnelFlags.String(&config.Remote, "remote", "")
+	err := tunnelFlags.Parse(args)
+	if err != nil {
+		return TunnelConfig{}, err
+	}
+
+	if config.LocalPort == "" || config.Remote == "" {
+		return TunnelConfig{}, errors.New("bbl tunnel requires --local-port and --remote")
+	}
+
+	if port, err := strconv.Atoi(config.LocalPort); err != nil || port < 1 || port > 65535 {
+		return TunnelConfig{}, fmt.Errorf("--local-port %q is not a port number", config.LocalPort)
+	}
+
+	host, port, err := net.SplitHostPort(config.Remote)
+	if err != nil {
+		return TunnelConfig{}, fmt.Errorf("--remote %q is not host:port", config.Remote)
+	}
+
+	// director stands for the address of the director, so that its UAA and
+	// CredHub can be reached without looking it up.
+	if host == "director" {
+		if state.BOSH.DirectorAddress == "" {
+			return TunnelConfig{}, errors.New("there is no director in the state, run bbl up first")
+		}
+		config.Remote = net.JoinHostPort(directorHost(state), port)
+	}
+
+	return config, nil
+}
+
+// Execute forwards the local port to the remote address through the jumpbox
+// until ssh is interrupted.
+func (t Tunnel) Execute(args []string, state storage.State) error {
+	config, err := t.ParseArgs(args, state)
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := t.ssh.tempDirWriter.TempDir("", "")
+	if err != nil {
+		return fmt.Errorf("Create temp directory: %s", err) //nolint:staticcheck
+	}
+
+	jumpboxKeyPath, err := t.ssh.writePrivateKey(tempDir, "jumpbox")
+	if err != nil {
+		return err
+	}
+
+	t.ssh.logger.Printf("forwarding localhost:%s to %s through the jumpbox, press Ctrl-C to stop\n", config.LocalPort, config.Remote)
+	return t.ssh.cli.Run([]string{
+		"-4",
+		"-N",
+		"-o", "ServerAliveInterval=300",
+		"-o", "ExitOnForwardFailure=yes",
+		"-L", fmt.Sprintf("%s:%s", config.LocalPort, config.Remote),
+		fmt.Sprintf("jumpbox@%s", jumpboxHost(state)),
+		"-i", jumpboxKeyPath,
+	})
+}
@@ -0,0 +1,125 @@
+package commands_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tunnel", func() {
+	var (
+		tunnel       commands.Tunnel
+		sshCLI       *fakes.SSHCLI
+		sshKeyGetter *fakes.FancySSHKeyGetter
+		fileIO       *fakes.FileIO
+		logger       *fakes.Logger
+		state        storage.State
+	)
+
+	BeforeEach(func() {
+		sshCLI = &fakes.SSHCLI{}
+		sshKeyGetter = &fakes.FancySSHKeyGetter{}
+		fileIO = &fakes.FileIO{}
+		logger = &fakes.Logger{}
+
+		fileIO.TempDirCall.Returns.Name = "some-temp-dir"
+		sshKeyGetter.JumpboxGetCall.Returns.PrivateKey = "jumpbox-private-key"
+
+		state = storage.State{
+			Jumpbox: storage.Jumpbox{
+				URL: "jumpboxURL:22",
+			},
+			BOSH: storage.BOSH{
+				DirectorAddress: "https://10.0.0.6:25555",
+			},
+		}
+
+		tunnel = commands.NewTunnel(logger, sshCLI, sshKeyGetter, fileIO)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("checks the flags", func() {
+			err := tunnel.CheckFastFails([]string{"--local-port", "8443", "--remote", "10.0.0.6:8443"}, state)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("where there is no jumpbox url", func() {
+			It("returns an error", func() {
+				err := tunnel.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("Invalid bbl state for bbl tunnel."))
+			})
+		})
+
+		Context("when a flag is missing", func() {
+			It("returns an error", func() {
+				err := tunnel.CheckFastFails([]string{"--local-port", "8443"}, state)
+				Expect(err).To(MatchError("bbl tunnel requires --local-port and --remote"))
+			})
+		})
+
+		Context("when the local port is not a port number", func() {
+			It("returns an error", func() {
+				err := tunnel.CheckFastFails([]string{"--local-port", "70000", "--remote", "10.0.0.6:8443"}, state)
+				Expect(err).To(MatchError(`--local-port "70000" is not a port number`))
+			})
+		})
+
+		Context("when the remote has no port", func() {
+			It("returns an error", func() {
+				err := tunnel.CheckFastFails([]string{"--local-port", "8443", "--remote", "10.0.0.6"}, state)
+				Expect(err).To(MatchError(`--remote "10.0.0.6" is not host:port`))
+			})
+		})
+
+		Context("when the remote is the director and there is none", func() {
+			It("returns an error", func() {
+				state.BOSH = storage.BOSH{}
+
+				err := tunnel.CheckFastFails([]string{"--local-port", "8443", "--remote", "director:8443"}, state)
+				Expect(err).To(MatchError("there is no director in the state, run bbl up first"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("forwards the local port through the jumpbox", func() {
+			err := tunnel.Execute([]string{"--local-port", "8844", "--remote", "10.0.0.6:8844"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshCLI.RunCall.Receives).To(Equal([][]string{{
+				"-4",
+				"-N",
+				"-o", "ServerAliveInterval=300",
+				"-o", "ExitOnForwardFailure=yes",
+				"-L", "8844:10.0.0.6:8844",
+				"jumpbox@jumpboxURL",
+				"-i", filepath.Join("some-temp-dir", "jumpbox-private-key"),
+			}}))
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"forwarding localhost:8844 to 10.0.0.6:8844 through the jumpbox, press Ctrl-C to stop\n",
+			}))
+		})
+
+		It("resolves director to the director address", func() {
+			err := tunnel.Execute([]string{"--local-port", "8443", "--remote", "director:8443"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshCLI.RunCall.Receives[0]).To(ContainElement("8443:10.0.0.6:8443"))
+		})
+
+		Context("when the jumpbox private key cannot be read", func() {
+			It("returns an error", func() {
+				sshKeyGetter.JumpboxGetCall.Returns.Error = errors.New("fig")
+
+				err := tunnel.Execute([]string{"--local-port", "8443", "--remote", "director:8443"}, state)
+				Expect(err).To(MatchError("Get jumpbox private key: fig"))
+			})
+		})
+	})
+})
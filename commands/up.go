@@ -1,12 +1,41 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
 )
 
+const (
+	TerraformPhase     = "terraform"
+	JumpboxPhase       = "jumpbox"
+	DirectorPhase      = "director"
+	CloudConfigPhase   = "cloud-config"
+	RuntimeConfigPhase = "runtime-config"
+)
+
+// Phases are the steps of bbl up in the order they run, which --only and
+// --skip choose from.
+var Phases = []string{TerraformPhase, JumpboxPhase, DirectorPhase, CloudConfigPhase, RuntimeConfigPhase}
+
+// phaseInputs are the files in the state dir that each phase reads, which
+// its checkpoint fingerprints.
+var phaseInputs = map[string][]string{
+	TerraformPhase:     {"terraform", "vars/*.tfvars"},
+	JumpboxPhase:       {"create-jumpbox.sh", "create-jumpbox-override.sh", "jumpbox-deployment", "bbl-ops-files"},
+	DirectorPhase:      {"create-director.sh", "create-director-override.sh", "bosh-deployment", "bbl-ops-files"},
+	CloudConfigPhase:   {"cloud-config"},
+	RuntimeConfigPhase: {"runtime-config"},
+}
+
+type phaseFingerprinter interface {
+	Fingerprint(patterns []string, extra ...string) (string, error)
+}
+
 type Up struct {
 	plan                 plan
 	boshManager          boshManager
@@ -14,12 +43,15 @@ type Up struct {
 	runtimeConfigManager runtimeConfigManager
 	stateStore           stateStore
 	terraformManager     terraformManager
+	fingerprinter        phaseFingerprinter
+	logger               logger
 }
 
 func NewUp(plan plan, boshManager boshManager,
 	cloudConfigManager cloudConfigManager,
 	runtimeConfigManager runtimeConfigManager,
-	stateStore stateStore, terraformManager terraformManager) Up {
+	stateStore stateStore, terraformManager terraformManager,
+	fingerprinter phaseFingerprinter, logger logger) Up {
 	return Up{
 		plan:                 plan,
 		boshManager:          boshManager,
@@ -27,6 +59,8 @@ func NewUp(plan plan, boshManager boshManager,
 		runtimeConfigManager: runtimeConfigManager,
 		stateStore:           stateStore,
 		terraformManager:     terraformManager,
+		fingerprinter:        fingerprinter,
+		logger:               logger,
 	}
 }
 
@@ -35,81 +69,362 @@ func (u Up) CheckFastFails(args []string, state storage.State) error {
 }
 
 func (u Up) Execute(args []string, state storage.State) error {
+	_, err := u.Run(args, state)
+	return err
+}
+
+// Run is Execute that also returns the state bbl up saved, for the commands
+// that change it further once bbl up succeeds.
+func (u Up) Run(args []string, state storage.State) (storage.State, error) {
 	config, err := u.ParseArgs(args, state)
 	if err != nil {
-		return err
+		return storage.State{}, err
+	}
+	phases := selectPhases(config)
+
+	err = checkPhasePrerequisites(phases, state)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	// bbl up only renders the state dir the first time, so backups are
+	// enabled on an existing environment by bbl plan.
+	if config.EnableBackups && !state.DirectorBackups && u.plan.IsInitialized(state) {
+		return storage.State{}, errors.New("--enable-backups needs bbl plan --enable-backups on an existing environment")
 	}
 
 	if !u.plan.IsInitialized(state) {
 		planState, err := u.plan.InitializePlan(config, state)
 		if err != nil {
-			return err
+			return storage.State{}, err
 		}
 		state = planState
 	}
 
-	state, err = u.terraformManager.Apply(state)
-	if err != nil {
-		return handleTerraformError(err, state, u.stateStore)
+	// Without the terraform phase the outputs have to exist already, so check
+	// for them before anything else runs.
+	var terraformOutputs terraform.Outputs
+	if !phases[TerraformPhase] && (phases[JumpboxPhase] || phases[DirectorPhase] || phases[CloudConfigPhase]) {
+		terraformOutputs, err = u.terraformManager.GetOutputs()
+		if err != nil {
+			return storage.State{}, fmt.Errorf("Parse terraform outputs: %s", err) //nolint:staticcheck
+		}
+		if len(terraformOutputs.Map) == 0 {
+			return storage.State{}, errors.New("there are no terraform outputs in the state, run the terraform phase first")
+		}
 	}
 
-	err = u.stateStore.Set(state)
-	if err != nil {
-		return fmt.Errorf("Save state after terraform apply: %s", err) //nolint:staticcheck
+	defer u.logger.SetPhase("")
+
+	run := upRun{Up: u, forced: map[string]bool{}}
+	for _, phase := range config.Only {
+		run.forced[phase] = true
 	}
 
-	terraformOutputs, err := u.terraformManager.GetOutputs()
-	if err != nil {
-		return fmt.Errorf("Parse terraform outputs: %s", err) //nolint:staticcheck
+	if phases[TerraformPhase] {
+		fingerprint, err := u.fingerprint(TerraformPhase)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		var start bool
+		state, start, err = run.start(TerraformPhase, fingerprint, state)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		if start {
+			state, err = u.terraformManager.Apply(state)
+			if err != nil {
+				return storage.State{}, handleTerraformError(err, state, u.stateStore)
+			}
+
+			state = withCheckpoint(state, TerraformPhase, fingerprint)
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return storage.State{}, fmt.Errorf("Save state after terraform apply: %s", err) //nolint:staticcheck
+			}
+		}
+
+		terraformOutputs, err = u.terraformManager.GetOutputs()
+		if err != nil {
+			return storage.State{}, fmt.Errorf("Parse terraform outputs: %s", err) //nolint:staticcheck
+		}
 	}
 
-	state, err = u.boshManager.CreateJumpbox(state, terraformOutputs)
-	switch err.(type) { //nolint:staticcheck
-	case bosh.ManagerCreateError:
-		bcErr := err.(bosh.ManagerCreateError) //nolint:staticcheck
-		if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
-			return fmt.Errorf("Save state after jumpbox create error: %s, %s", err, setErr) //nolint:staticcheck
+	if phases[JumpboxPhase] {
+		fingerprint, err := u.fingerprint(JumpboxPhase, u.boshManager.GetJumpboxDeploymentVars(state, terraformOutputs))
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		var start bool
+		state, start, err = run.start(JumpboxPhase, fingerprint, state)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		if start {
+			state, err = u.boshManager.CreateJumpbox(state, terraformOutputs)
+			switch err.(type) { //nolint:staticcheck
+			case bosh.ManagerCreateError:
+				bcErr := err.(bosh.ManagerCreateError) //nolint:staticcheck
+				if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
+					return storage.State{}, fmt.Errorf("Save state after jumpbox create error: %s, %s", err, setErr) //nolint:staticcheck
+				}
+				return storage.State{}, fmt.Errorf("Create jumpbox: %s", err) //nolint:staticcheck
+			case error:
+				return storage.State{}, fmt.Errorf("Create jumpbox: %s", err) //nolint:staticcheck
+			}
+
+			state = withCheckpoint(state, JumpboxPhase, fingerprint)
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return storage.State{}, fmt.Errorf("Save state after create jumpbox: %s", err) //nolint:staticcheck
+			}
 		}
-		return fmt.Errorf("Create jumpbox: %s", err) //nolint:staticcheck
-	case error:
-		return fmt.Errorf("Create jumpbox: %s", err) //nolint:staticcheck
 	}
 
-	err = u.stateStore.Set(state)
-	if err != nil {
-		return fmt.Errorf("Save state after create jumpbox: %s", err) //nolint:staticcheck
+	if phases[DirectorPhase] {
+		fingerprint, err := u.fingerprint(DirectorPhase, u.boshManager.GetDirectorDeploymentVars(state, terraformOutputs))
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		var start bool
+		state, start, err = run.start(DirectorPhase, fingerprint, state)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		if start {
+			state, err = u.boshManager.CreateDirector(state, terraformOutputs)
+			switch err.(type) { //nolint:staticcheck
+			case bosh.ManagerCreateError:
+				bcErr := err.(bosh.ManagerCreateError) //nolint:staticcheck
+				if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
+					return storage.State{}, fmt.Errorf("Save state after bosh director create error: %s, %s", err, setErr) //nolint:staticcheck
+				}
+				return storage.State{}, fmt.Errorf("Create bosh director: %s", err) //nolint:staticcheck
+			case error:
+				return storage.State{}, fmt.Errorf("Create bosh director: %s", err) //nolint:staticcheck
+			}
+
+			state = withCheckpoint(state, DirectorPhase, fingerprint)
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return storage.State{}, fmt.Errorf("Save state after create director: %s", err) //nolint:staticcheck
+			}
+		}
 	}
 
-	state, err = u.boshManager.CreateDirector(state, terraformOutputs)
-	switch err.(type) { //nolint:staticcheck
-	case bosh.ManagerCreateError:
-		bcErr := err.(bosh.ManagerCreateError) //nolint:staticcheck
-		if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
-			return fmt.Errorf("Save state after bosh director create error: %s, %s", err, setErr) //nolint:staticcheck
+	if phases[CloudConfigPhase] {
+		fingerprint, err := u.fingerprint(CloudConfigPhase)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		var start bool
+		state, start, err = run.start(CloudConfigPhase, fingerprint, state)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		if start {
+			err = u.cloudConfigManager.Update(state)
+			if err != nil {
+				return storage.State{}, fmt.Errorf("Update cloud config: %s", err) //nolint:staticcheck
+			}
+
+			state = withCheckpoint(state, CloudConfigPhase, fingerprint)
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return storage.State{}, fmt.Errorf("Save state after update cloud config: %s", err) //nolint:staticcheck
+			}
 		}
-		return fmt.Errorf("Create bosh director: %s", err) //nolint:staticcheck
-	case error:
-		return fmt.Errorf("Create bosh director: %s", err) //nolint:staticcheck
 	}
 
-	err = u.stateStore.Set(state)
-	if err != nil {
-		return fmt.Errorf("Save state after create director: %s", err) //nolint:staticcheck
+	if phases[RuntimeConfigPhase] {
+		fingerprint, err := u.fingerprint(RuntimeConfigPhase)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		var start bool
+		state, start, err = run.start(RuntimeConfigPhase, fingerprint, state)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		if start {
+			err = u.runtimeConfigManager.Update(state)
+			if err != nil {
+				return storage.State{}, fmt.Errorf("Update runtime config: %s", err) //nolint:staticcheck
+			}
+
+			state = withCheckpoint(state, RuntimeConfigPhase, fingerprint)
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return storage.State{}, fmt.Errorf("Save state after update runtime config: %s", err) //nolint:staticcheck
+			}
+		}
+	}
+
+	// A bbl up that got through every phase has nothing left to resume, so
+	// the next one runs them all again.
+	if allPhases(phases) && len(state.Checkpoints) > 0 {
+		state.Checkpoints = nil
+		err = u.stateStore.Set(state)
+		if err != nil {
+			return storage.State{}, fmt.Errorf("Save state after bbl up: %s", err) //nolint:staticcheck
+		}
 	}
 
-	err = u.cloudConfigManager.Update(state)
+	return state, nil
+}
+
+func (u Up) fingerprint(phase string, extra ...string) (string, error) {
+	fingerprint, err := u.fingerprinter.Fingerprint(phaseInputs[phase], extra...)
 	if err != nil {
-		return fmt.Errorf("Update cloud config: %s", err) //nolint:staticcheck
+		return "", fmt.Errorf("Fingerprint %s inputs: %s", phase, err) //nolint:staticcheck
+	}
+	return fingerprint, nil
+}
+
+// upRun decides which phases of one bbl up can resume from their checkpoints.
+type upRun struct {
+	Up
+	forced  map[string]bool
+	running bool
+	resumed bool
+}
+
+// start reports whether phase has to run. A phase is skipped when it finished
+// before with the same inputs, nothing before it ran again and it was not
+// chosen with --only. A phase that runs loses its checkpoint and those after
+// it until it finishes again.
+func (r *upRun) start(phase, fingerprint string, state storage.State) (storage.State, bool, error) {
+	if !r.running && !r.forced[phase] && finished(state, phase, fingerprint) {
+		r.logger.Step("skipping %s, its inputs have not changed since it finished", phase)
+		r.resumed = true
+		return state, false, nil
+	}
+
+	if !r.running && r.resumed {
+		r.logger.Step("resuming bbl up at %s", phase)
 	}
+	r.running = true
+	r.logger.SetPhase(phase)
 
-	err = u.runtimeConfigManager.Update(state)
+	checkpoints := checkpointsBefore(state.Checkpoints, phase)
+	if len(checkpoints) == len(state.Checkpoints) {
+		return state, true, nil
+	}
+
+	state.Checkpoints = checkpoints
+	err := r.stateStore.Set(state)
 	if err != nil {
-		return fmt.Errorf("Update runtime config: %s", err) //nolint:staticcheck
+		return state, false, fmt.Errorf("Save state before %s: %s", phase, err) //nolint:staticcheck
 	}
+	return state, true, nil
+}
 
-	return nil
+func finished(state storage.State, phase, fingerprint string) bool {
+	for _, checkpoint := range state.Checkpoints {
+		if checkpoint.Phase == phase {
+			return checkpoint.Fingerprint == fingerprint
+		}
+	}
+	return false
+}
+
+// checkpointsBefore drops the checkpoints of phase and the phases after it.
+func checkpointsBefore(checkpoints []storage.Checkpoint, phase string) []storage.Checkpoint {
+	var before []storage.Checkpoint
+	for _, checkpoint := range checkpoints {
+		if phaseIndex(checkpoint.Phase) < phaseIndex(phase) {
+			before = append(before, checkpoint)
+		}
+	}
+	return before
+}
+
+func withCheckpoint(state storage.State, phase, fingerprint string) storage.State {
+	state.Checkpoints = append(checkpointsBefore(state.Checkpoints, phase), storage.Checkpoint{Phase: phase, Fingerprint: fingerprint})
+	return state
+}
+
+func phaseIndex(name string) int {
+	for i, phase := range Phases {
+		if phase == name {
+			return i
+		}
+	}
+	return len(Phases)
 }
 
 func (u Up) ParseArgs(args []string, state storage.State) (PlanConfig, error) {
-	return u.plan.ParseArgs(args, state)
+	config, err := u.plan.ParseArgs(args, state)
+	if err != nil {
+		return PlanConfig{}, err
+	}
+
+	if config.Diff {
+		return PlanConfig{}, errors.New("--diff is only supported by bbl plan")
+	}
+
+	if len(config.Only) > 0 && len(config.Skip) > 0 {
+		return PlanConfig{}, errors.New("--only and --skip cannot be used together")
+	}
+
+	for _, phase := range append(config.Only, config.Skip...) {
+		if !isPhase(phase) {
+			return PlanConfig{}, fmt.Errorf("unknown phase %q, expected one of: %s", phase, strings.Join(Phases, ", "))
+		}
+	}
+
+	return config, nil
+}
+
+func isPhase(name string) bool {
+	return phaseIndex(name) < len(Phases)
+}
+
+func selectPhases(config PlanConfig) map[string]bool {
+	selected := map[string]bool{}
+	for _, phase := range Phases {
+		selected[phase] = len(config.Only) == 0
+	}
+	for _, phase := range config.Only {
+		selected[phase] = true
+	}
+	for _, phase := range config.Skip {
+		selected[phase] = false
+	}
+	return selected
+}
+
+func allPhases(phases map[string]bool) bool {
+	for _, phase := range Phases {
+		if !phases[phase] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPhasePrerequisites makes sure that what a skipped phase would have
+// created is already in the state.
+func checkPhasePrerequisites(phases map[string]bool, state storage.State) error {
+	if phases[DirectorPhase] && !phases[JumpboxPhase] && state.Jumpbox.URL == "" {
+		return errors.New("the director phase needs a jumpbox in the state, run the jumpbox phase first")
+	}
+
+	for _, phase := range []string{CloudConfigPhase, RuntimeConfigPhase} {
+		if phases[phase] && !phases[DirectorPhase] && state.BOSH.DirectorAddress == "" {
+			return fmt.Errorf("the %s phase needs a director address in the state, run the director phase first", phase)
+		}
+	}
+
+	return nil
 }
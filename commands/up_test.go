@@ -23,6 +23,8 @@ var _ = Describe("Up", func() {
 		cloudConfigManager   *fakes.CloudConfigManager
 		runtimeConfigManager *fakes.RuntimeConfigManager
 		stateStore           *fakes.StateStore
+		fingerprinter        *fakes.PhaseFingerprinter
+		logger               *fakes.Logger
 	)
 
 	BeforeEach(func() {
@@ -32,8 +34,10 @@ var _ = Describe("Up", func() {
 		cloudConfigManager = &fakes.CloudConfigManager{}
 		runtimeConfigManager = &fakes.RuntimeConfigManager{}
 		stateStore = &fakes.StateStore{}
+		fingerprinter = &fakes.PhaseFingerprinter{}
+		logger = &fakes.Logger{}
 
-		command = commands.NewUp(plan, boshManager, cloudConfigManager, runtimeConfigManager, stateStore, terraformManager)
+		command = commands.NewUp(plan, boshManager, cloudConfigManager, runtimeConfigManager, stateStore, terraformManager, fingerprinter, logger)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -83,6 +87,14 @@ var _ = Describe("Up", func() {
 			terraformManager.GetOutputsCall.Returns.Outputs = terraformOutputs
 
 			plan.IsInitializedCall.Returns.IsInitialized = true
+
+			fingerprinter.FingerprintCall.Returns.Fingerprints = map[string]string{
+				"terraform":          "terraform-fingerprint",
+				"create-jumpbox.sh":  "jumpbox-fingerprint",
+				"create-director.sh": "director-fingerprint",
+				"cloud-config":       "cloud-config-fingerprint",
+				"runtime-config":     "runtime-config-fingerprint",
+			}
 		})
 
 		Context("when bbl plan has been run", func() {
@@ -101,8 +113,15 @@ var _ = Describe("Up", func() {
 
 				Expect(terraformManager.SetupCall.CallCount).To(Equal(0))
 
+				terraformCheckpoint := storage.Checkpoint{Phase: "terraform", Fingerprint: "terraform-fingerprint"}
+				jumpboxCheckpoint := storage.Checkpoint{Phase: "jumpbox", Fingerprint: "jumpbox-fingerprint"}
+				directorCheckpoint := storage.Checkpoint{Phase: "director", Fingerprint: "director-fingerprint"}
+				cloudConfigCheckpoint := storage.Checkpoint{Phase: "cloud-config", Fingerprint: "cloud-config-fingerprint"}
+				runtimeConfigCheckpoint := storage.Checkpoint{Phase: "runtime-config", Fingerprint: "runtime-config-fingerprint"}
+
 				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
 				Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(incomingState))
+				terraformApplyState.Checkpoints = []storage.Checkpoint{terraformCheckpoint}
 				Expect(stateStore.SetCall.Receives[0].State).To(Equal(terraformApplyState))
 
 				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
@@ -111,21 +130,225 @@ var _ = Describe("Up", func() {
 				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
 				Expect(boshManager.CreateJumpboxCall.Receives.State).To(Equal(terraformApplyState))
 				Expect(boshManager.CreateJumpboxCall.Receives.TerraformOutputs).To(Equal(terraformOutputs))
+				createJumpboxState.Checkpoints = []storage.Checkpoint{jumpboxCheckpoint}
 				Expect(stateStore.SetCall.Receives[1].State).To(Equal(createJumpboxState))
 
 				Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(0))
 				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
 				Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(createJumpboxState))
 				Expect(boshManager.CreateDirectorCall.Receives.TerraformOutputs).To(Equal(terraformOutputs))
+				createDirectorState.Checkpoints = []storage.Checkpoint{directorCheckpoint}
 				Expect(stateStore.SetCall.Receives[2].State).To(Equal(createDirectorState))
 
 				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
 				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(createDirectorState))
+				Expect(stateStore.SetCall.Receives[3].State.Checkpoints).To(Equal([]storage.Checkpoint{directorCheckpoint, cloudConfigCheckpoint}))
+
+				Expect(runtimeConfigManager.UpdateCall.CallCount).To(Equal(1))
+				Expect(runtimeConfigManager.UpdateCall.Receives.State.Checkpoints).To(Equal([]storage.Checkpoint{directorCheckpoint, cloudConfigCheckpoint}))
+				Expect(stateStore.SetCall.Receives[4].State.Checkpoints).To(Equal([]storage.Checkpoint{directorCheckpoint, cloudConfigCheckpoint, runtimeConfigCheckpoint}))
+
+				Expect(stateStore.SetCall.Receives[5].State.Checkpoints).To(BeEmpty())
+				Expect(stateStore.SetCall.CallCount).To(Equal(6))
+			})
+
+			It("tags the log with each phase while it runs", func() {
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.SetPhaseCall.Phases).To(Equal([]string{
+					"terraform", "jumpbox", "director", "cloud-config", "runtime-config", "",
+				}))
+			})
+		})
+
+		Context("when --only is passed", func() {
+			BeforeEach(func() {
+				plan.ParseArgsCall.Returns.Config = commands.PlanConfig{Only: []string{"cloud-config", "runtime-config"}}
+				incomingState.BOSH.DirectorAddress = "https://10.0.0.6:25555"
+			})
+
+			It("runs only those phases", func() {
+				err := command.Execute([]string{"--only", "cloud-config,runtime-config"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+
+				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(incomingState))
+				Expect(runtimeConfigManager.UpdateCall.Receives.State.BOSH).To(Equal(incomingState.BOSH))
+				Expect(stateStore.SetCall.CallCount).To(Equal(2))
+			})
+
+			Context("when there is no director in the state", func() {
+				BeforeEach(func() {
+					incomingState.BOSH.DirectorAddress = ""
+				})
+
+				It("returns an error before running anything", func() {
+					err := command.Execute([]string{"--only", "cloud-config,runtime-config"}, incomingState)
+					Expect(err).To(MatchError("the cloud-config phase needs a director address in the state, run the director phase first"))
+					Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				})
+			})
+
+			Context("when there are no terraform outputs", func() {
+				BeforeEach(func() {
+					terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{}
+				})
+
+				It("returns an error before running anything", func() {
+					err := command.Execute([]string{"--only", "cloud-config,runtime-config"}, incomingState)
+					Expect(err).To(MatchError("there are no terraform outputs in the state, run the terraform phase first"))
+					Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				})
+			})
+
+			Context("when only the runtime config is updated", func() {
+				BeforeEach(func() {
+					plan.ParseArgsCall.Returns.Config = commands.PlanConfig{Only: []string{"runtime-config"}}
+				})
+
+				It("does not need terraform outputs", func() {
+					err := command.Execute([]string{"--only", "runtime-config"}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(0))
+					Expect(runtimeConfigManager.UpdateCall.CallCount).To(Equal(1))
+					Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when --skip is passed", func() {
+			BeforeEach(func() {
+				plan.ParseArgsCall.Returns.Config = commands.PlanConfig{Skip: []string{"jumpbox"}}
+				incomingState.Jumpbox.URL = "10.0.0.5:22"
+			})
+
+			It("runs the other phases", func() {
+				err := command.Execute([]string{"--skip", "jumpbox"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateDirectorCall.Receives.State.LatestTFOutput).To(Equal(terraformApplyState.LatestTFOutput))
+				Expect(boshManager.CreateDirectorCall.Receives.TerraformOutputs).To(Equal(terraformOutputs))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+				Expect(runtimeConfigManager.UpdateCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State.Checkpoints).NotTo(BeEmpty())
+			})
+
+			Context("when there is no jumpbox in the state", func() {
+				BeforeEach(func() {
+					incomingState.Jumpbox.URL = ""
+				})
+
+				It("returns an error before running anything", func() {
+					err := command.Execute([]string{"--skip", "jumpbox"}, incomingState)
+					Expect(err).To(MatchError("the director phase needs a jumpbox in the state, run the jumpbox phase first"))
+					Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				})
+			})
+		})
 
+		Context("when a previous bbl up finished some phases", func() {
+			BeforeEach(func() {
+				incomingState.Checkpoints = []storage.Checkpoint{
+					{Phase: "terraform", Fingerprint: "terraform-fingerprint"},
+					{Phase: "jumpbox", Fingerprint: "jumpbox-fingerprint"},
+					{Phase: "director", Fingerprint: "old-director-fingerprint"},
+				}
+				boshManager.GetJumpboxDeploymentVarsCall.Returns.Vars = "some-jumpbox-vars"
+			})
+
+			It("resumes at the first phase whose inputs changed", func() {
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
+				Expect(fingerprinter.FingerprintCall.Receives[1]).To(Equal(fakes.FingerprintCallReceive{
+					Patterns: []string{"create-jumpbox.sh", "create-jumpbox-override.sh", "jumpbox-deployment", "bbl-ops-files"},
+					Extra:    []string{"some-jumpbox-vars"},
+				}))
+
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.Receives.State.Checkpoints).To(Equal([]storage.Checkpoint{
+					{Phase: "terraform", Fingerprint: "terraform-fingerprint"},
+					{Phase: "jumpbox", Fingerprint: "jumpbox-fingerprint"},
+				}))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
 				Expect(runtimeConfigManager.UpdateCall.CallCount).To(Equal(1))
-				Expect(runtimeConfigManager.UpdateCall.Receives.State).To(Equal(createDirectorState))
 
-				Expect(stateStore.SetCall.CallCount).To(Equal(3))
+				Expect(logger.StepCall.Messages).To(Equal([]string{
+					"skipping terraform, its inputs have not changed since it finished",
+					"skipping jumpbox, its inputs have not changed since it finished",
+					"resuming bbl up at director",
+				}))
+			})
+
+			It("drops the checkpoints once every phase has finished", func() {
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State.Checkpoints).To(BeEmpty())
+			})
+
+			It("drops the checkpoint of a phase before running it again", func() {
+				boshManager.CreateDirectorCall.Returns.Error = errors.New("some-error")
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("Create bosh director: some-error"))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State.Checkpoints).To(Equal([]storage.Checkpoint{
+					{Phase: "terraform", Fingerprint: "terraform-fingerprint"},
+					{Phase: "jumpbox", Fingerprint: "jumpbox-fingerprint"},
+				}))
+			})
+
+			Context("when an earlier phase has to run again", func() {
+				BeforeEach(func() {
+					incomingState.Checkpoints[0].Fingerprint = "old-terraform-fingerprint"
+				})
+
+				It("runs every phase after it", func() {
+					err := command.Execute([]string{}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+					Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
+					Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+					Expect(logger.StepCall.CallCount).To(Equal(0))
+				})
+			})
+
+			Context("when a finished phase is chosen with --only", func() {
+				BeforeEach(func() {
+					plan.ParseArgsCall.Returns.Config = commands.PlanConfig{Only: []string{"jumpbox"}}
+				})
+
+				It("runs it anyway", func() {
+					err := command.Execute([]string{"--only", "jumpbox"}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
+				})
+			})
+
+			Context("when fingerprinting fails", func() {
+				BeforeEach(func() {
+					fingerprinter.FingerprintCall.Returns.Error = errors.New("some-error")
+				})
+
+				It("returns an error", func() {
+					err := command.Execute([]string{}, incomingState)
+					Expect(err).To(MatchError("Fingerprint terraform inputs: some-error"))
+				})
 			})
 		})
 
@@ -158,6 +381,34 @@ var _ = Describe("Up", func() {
 			})
 		})
 
+		Context("when --enable-backups is passed", func() {
+			BeforeEach(func() {
+				plan.ParseArgsCall.Returns.Config = commands.PlanConfig{EnableBackups: true}
+			})
+
+			It("asks for bbl plan on an existing environment", func() {
+				err := command.Execute([]string{"--enable-backups"}, storage.State{})
+				Expect(err).To(MatchError("--enable-backups needs bbl plan --enable-backups on an existing environment"))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+			})
+
+			Context("when backups are already enabled", func() {
+				It("runs", func() {
+					err := command.Execute([]string{"--enable-backups"}, storage.State{DirectorBackups: true})
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("when nothing is initialized", func() {
+				It("calls bbl plan", func() {
+					plan.IsInitializedCall.Returns.IsInitialized = false
+					err := command.Execute([]string{"--enable-backups"}, storage.State{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(plan.InitializePlanCall.Receives.Plan.EnableBackups).To(BeTrue())
+				})
+			})
+		})
+
 		Describe("failure cases", func() {
 			Context("when parse args fails", func() {
 				BeforeEach(func() {
@@ -376,5 +627,29 @@ var _ = Describe("Up", func() {
 			Expect(plan.ParseArgsCall.Receives.State).To(Equal(storage.State{ID: "some-state-id"}))
 			Expect(config.Name).To(Equal("environment name"))
 		})
+
+		Context("when --only and --skip are both passed", func() {
+			It("returns an error", func() {
+				plan.ParseArgsCall.Returns.Config = commands.PlanConfig{Only: []string{"terraform"}, Skip: []string{"jumpbox"}}
+				_, err := command.ParseArgs([]string{"--only", "terraform", "--skip", "jumpbox"}, storage.State{})
+				Expect(err).To(MatchError("--only and --skip cannot be used together"))
+			})
+		})
+
+		Context("when a phase is unknown", func() {
+			It("returns an error", func() {
+				plan.ParseArgsCall.Returns.Config = commands.PlanConfig{Skip: []string{"dns"}}
+				_, err := command.ParseArgs([]string{"--skip", "dns"}, storage.State{})
+				Expect(err).To(MatchError(`unknown phase "dns", expected one of: terraform, jumpbox, director, cloud-config, runtime-config`))
+			})
+		})
+
+		Context("when --diff is passed", func() {
+			It("returns an error", func() {
+				plan.ParseArgsCall.Returns.Config = commands.PlanConfig{Diff: true}
+				_, err := command.ParseArgs([]string{"--diff"}, storage.State{})
+				Expect(err).To(MatchError("--diff is only supported by bbl plan"))
+			})
+		})
 	})
 })
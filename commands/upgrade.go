@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type deploymentUpgrader interface {
+	PreviewUpgrade() (bosh.DeploymentUpgrade, error)
+	Upgrade() error
+}
+
+type Upgrade struct {
+	stateValidator stateValidator
+	upgrader       deploymentUpgrader
+	stateStore     stateStore
+	up             up
+	logger         logger
+}
+
+type UpgradeConfig struct {
+	// DryRun only shows what the upgrade would change.
+	DryRun bool
+}
+
+func NewUpgrade(stateValidator stateValidator, upgrader deploymentUpgrader, stateStore stateStore, up up, logger logger) Upgrade {
+	return Upgrade{
+		stateValidator: stateValidator,
+		upgrader:       upgrader,
+		stateStore:     stateStore,
+		up:             up,
+		logger:         logger,
+	}
+}
+
+func (u Upgrade) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := u.stateValidator.Validate()
+	if err != nil {
+		return fmt.Errorf("validate state: %s", err)
+	}
+
+	config, upFlags := u.ParseArgs(subcommandFlags)
+	if config.DryRun {
+		return nil
+	}
+
+	err = u.up.CheckFastFails(upFlags, state)
+	if err != nil {
+		return fmt.Errorf("up: %s", err)
+	}
+	return nil
+}
+
+// Execute shows how the jumpbox-deployment and bosh-deployment embedded in
+// bbl differ from the ones in the state dir, then writes them and runs bbl up
+// so that the jumpbox and director are recreated from them.
+func (u Upgrade) Execute(args []string, state storage.State) error {
+	config, upArgs := u.ParseArgs(args)
+
+	upgrade, err := u.upgrader.PreviewUpgrade()
+	if err != nil {
+		return fmt.Errorf("preview upgrade: %s", err)
+	}
+
+	if upgrade.IsEmpty() {
+		u.logger.Println("the jumpbox and director deployments are up to date with this bbl")
+		return nil
+	}
+
+	u.printChanges("jumpbox-deployment", upgrade.Jumpbox)
+	u.printChanges("bosh-deployment", upgrade.Director)
+
+	if config.DryRun {
+		return nil
+	}
+
+	proceed := u.logger.Prompt(fmt.Sprintf("Are you sure you want to upgrade %q? The director will be recreated.", state.EnvID))
+	if !proceed {
+		u.logger.Step("exiting")
+		return nil
+	}
+
+	err = u.upgrader.Upgrade()
+	if err != nil {
+		return fmt.Errorf("upgrade deployments: %s", err)
+	}
+
+	firstPhase := DirectorPhase
+	if len(upgrade.Jumpbox) > 0 {
+		firstPhase = JumpboxPhase
+	}
+
+	state.Checkpoints = checkpointsBefore(state.Checkpoints, firstPhase)
+	err = u.stateStore.Set(state)
+	if err != nil {
+		return fmt.Errorf("save state: %s", err)
+	}
+
+	err = u.up.Execute(upArgs, state)
+	if err != nil {
+		return fmt.Errorf("up: %s", err)
+	}
+
+	return nil
+}
+
+// ParseArgs picks --dry-run out of args and returns the rest for up.
+func (u Upgrade) ParseArgs(args []string) (UpgradeConfig, []string) {
+	var (
+		config UpgradeConfig
+		upArgs []string
+	)
+
+	for _, arg := range args {
+		if flagName(arg) == "dry-run" {
+			config.DryRun = true
+			continue
+		}
+		upArgs = append(upArgs, arg)
+	}
+
+	return config, upArgs
+}
+
+func (u Upgrade) printChanges(repo string, changes []bosh.DeploymentChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	u.logger.Printf("%s:\n", repo)
+	for _, change := range changes {
+		action := "update"
+		if change.Added {
+			action = "add"
+		}
+		u.logger.Printf("  %-10s%s\n", action, change.Path)
+
+		for _, version := range change.Versions {
+			from := version.From
+			if from == "" {
+				from = "none"
+			}
+			u.logger.Printf("  %-10s  %s %s -> %s\n", "", version.Name, from, version.To)
+		}
+	}
+}
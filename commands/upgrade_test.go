@@ -0,0 +1,242 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Upgrade", func() {
+	var (
+		stateValidator *fakes.StateValidator
+		upgrader       *fakes.DeploymentUpgrader
+		stateStore     *fakes.StateStore
+		up             *fakes.Up
+		logger         *fakes.Logger
+		upgrade        commands.Upgrade
+	)
+
+	BeforeEach(func() {
+		stateValidator = &fakes.StateValidator{}
+		upgrader = &fakes.DeploymentUpgrader{}
+		stateStore = &fakes.StateStore{}
+		up = &fakes.Up{}
+		logger = &fakes.Logger{}
+		logger.PromptCall.Returns.Proceed = true
+		upgrade = commands.NewUpgrade(stateValidator, upgrader, stateStore, up, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the state and calls up.CheckFastFails without --dry-run", func() {
+			state := storage.State{EnvID: "some-env-id"}
+			err := upgrade.CheckFastFails([]string{"--name", "some-name"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(up.CheckFastFailsCall.CallCount).To(Equal(1))
+			Expect(up.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{"--name", "some-name"}))
+			Expect(up.CheckFastFailsCall.Receives.State).To(Equal(state))
+		})
+
+		Context("with --dry-run", func() {
+			It("does not call up.CheckFastFails", func() {
+				err := upgrade.CheckFastFails([]string{"--dry-run"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(up.CheckFastFailsCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the state validator returns an error", func() {
+			BeforeEach(func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("coconut")
+			})
+
+			It("returns the error", func() {
+				err := upgrade.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("validate state: coconut"))
+			})
+		})
+
+		Context("when up.CheckFastFails returns an error", func() {
+			BeforeEach(func() {
+				up.CheckFastFailsCall.Returns.Error = errors.New("passionfruit")
+			})
+
+			It("wraps and returns the error", func() {
+				err := upgrade.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("up: passionfruit"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		var state storage.State
+
+		BeforeEach(func() {
+			state = storage.State{
+				EnvID: "some-env-id",
+				Checkpoints: []storage.Checkpoint{
+					{Phase: commands.TerraformPhase, Fingerprint: "some-terraform-fingerprint"},
+					{Phase: commands.JumpboxPhase, Fingerprint: "some-jumpbox-fingerprint"},
+					{Phase: commands.DirectorPhase, Fingerprint: "some-director-fingerprint"},
+				},
+			}
+
+			upgrader.PreviewUpgradeCall.Returns.Upgrade = bosh.DeploymentUpgrade{
+				Director: []bosh.DeploymentChange{
+					{
+						Path: "bosh.yml",
+						Versions: []bosh.VersionChange{
+							{Name: "bosh", From: "280.0.1", To: "280.0.14"},
+						},
+					},
+					{
+						Path:     "bbr.yml",
+						Added:    true,
+						Versions: []bosh.VersionChange{{Name: "backup-and-restore-sdk", To: "1.18.0"}},
+					},
+				},
+			}
+		})
+
+		It("shows the changes, upgrades the deployments and runs up from the director phase", func() {
+			err := upgrade.Execute([]string{"--name", "some-name"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"bosh-deployment:\n",
+				"  update    bosh.yml\n",
+				"              bosh 280.0.1 -> 280.0.14\n",
+				"  add       bbr.yml\n",
+				"              backup-and-restore-sdk none -> 1.18.0\n",
+			}))
+			Expect(logger.PromptCall.Receives.Message).To(Equal(`Are you sure you want to upgrade "some-env-id"? The director will be recreated.`))
+
+			Expect(upgrader.UpgradeCall.CallCount).To(Equal(1))
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(up.ExecuteCall.CallCount).To(Equal(1))
+			Expect(up.ExecuteCall.Receives.Args).To(Equal([]string{"--name", "some-name"}))
+			Expect(up.ExecuteCall.Receives.State.Checkpoints).To(Equal([]storage.Checkpoint{
+				{Phase: commands.TerraformPhase, Fingerprint: "some-terraform-fingerprint"},
+				{Phase: commands.JumpboxPhase, Fingerprint: "some-jumpbox-fingerprint"},
+			}))
+		})
+
+		Context("when jumpbox-deployment changes", func() {
+			BeforeEach(func() {
+				upgrader.PreviewUpgradeCall.Returns.Upgrade.Jumpbox = []bosh.DeploymentChange{
+					{Path: "gcp/cpi.yml", Versions: []bosh.VersionChange{{Name: "stemcell", From: "1.400", To: "1.406"}}},
+				}
+			})
+
+			It("runs up from the jumpbox phase", func() {
+				err := upgrade.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintfCall.Messages[:3]).To(Equal([]string{
+					"jumpbox-deployment:\n",
+					"  update    gcp/cpi.yml\n",
+					"              stemcell 1.400 -> 1.406\n",
+				}))
+				Expect(up.ExecuteCall.Receives.State.Checkpoints).To(Equal([]storage.Checkpoint{
+					{Phase: commands.TerraformPhase, Fingerprint: "some-terraform-fingerprint"},
+				}))
+			})
+		})
+
+		Context("with --dry-run", func() {
+			It("only shows the changes", func() {
+				err := upgrade.Execute([]string{"--dry-run"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintfCall.Messages).To(ContainElement("  update    bosh.yml\n"))
+				Expect(logger.PromptCall.CallCount).To(Equal(0))
+				Expect(upgrader.UpgradeCall.CallCount).To(Equal(0))
+				Expect(up.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the deployments are up to date", func() {
+			BeforeEach(func() {
+				upgrader.PreviewUpgradeCall.Returns.Upgrade = bosh.DeploymentUpgrade{}
+			})
+
+			It("says so and does not run up", func() {
+				err := upgrade.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("the jumpbox and director deployments are up to date with this bbl"))
+				Expect(upgrader.UpgradeCall.CallCount).To(Equal(0))
+				Expect(up.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the user does not confirm", func() {
+			BeforeEach(func() {
+				logger.PromptCall.Returns.Proceed = false
+			})
+
+			It("exits without upgrading", func() {
+				err := upgrade.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.StepCall.Messages).To(ContainElement("exiting"))
+				Expect(upgrader.UpgradeCall.CallCount).To(Equal(0))
+				Expect(up.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when previewing the upgrade fails", func() {
+			BeforeEach(func() {
+				upgrader.PreviewUpgradeCall.Returns.Error = errors.New("kiwi")
+			})
+
+			It("returns the error", func() {
+				err := upgrade.Execute([]string{}, state)
+				Expect(err).To(MatchError("preview upgrade: kiwi"))
+			})
+		})
+
+		Context("when upgrading the deployments fails", func() {
+			BeforeEach(func() {
+				upgrader.UpgradeCall.Returns.Error = errors.New("mango")
+			})
+
+			It("returns the error without calling up", func() {
+				err := upgrade.Execute([]string{}, state)
+				Expect(err).To(MatchError("upgrade deployments: mango"))
+
+				Expect(up.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when saving the state fails", func() {
+			BeforeEach(func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("lime")}}
+			})
+
+			It("returns the error", func() {
+				err := upgrade.Execute([]string{}, state)
+				Expect(err).To(MatchError("save state: lime"))
+			})
+		})
+
+		Context("when up fails", func() {
+			BeforeEach(func() {
+				up.ExecuteCall.Returns.Error = errors.New("papaya")
+			})
+
+			It("returns the error", func() {
+				err := upgrade.Execute([]string{}, state)
+				Expect(err).To(MatchError("up: papaya"))
+			})
+		})
+	})
+})
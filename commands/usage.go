@@ -15,11 +15,19 @@ Usage:
 Global Options:
   --help                    [-h] Prints usage. Use "bbl [command] --help" for more information about a command
   --state-dir               [-s] Directory containing the bbl state                                                             env:"BBL_STATE_DIRECTORY"
+  --config                       Path of a bbl.yml config file, instead of the one in the state directory                       env:"BBL_CONFIG"
   --debug                   [-d] Prints debugging output                                                                        env:"BBL_DEBUG"
   --version                 [-v] Prints version
   --no-confirm              [-n] No confirm
   --terraform-binary             Path of a terraform binary (optional). If the file does not exist the embedded binary is used. env:"BBL_TERRAFORM_BINARY"
   --disable-tf-auto-approve      Do not use the '-auto-approve' option with terraform (debug mode required)                     env:"BBL_DISABLE_TF_AUTO_APPROVE"
+  --state-encryption-key         Passphrase, or file:<path> to read it from, used to encrypt the state directory at rest        env:"BBL_STATE_ENCRYPTION_KEY"
+  --no-auto-migrate              Only migrate a state directory written by an older bbl when running bbl state migrate          env:"BBL_NO_AUTO_MIGRATE"
+  --secret-store                 Where to keep director credentials: file, vault://host/mount/path or credhub://host/path       env:"BBL_SECRET_STORE"
+  --json                         Prints the output of query commands and errors as JSON
+  --log-level                    Lowest level to log: debug, info, warn or error (default: info)                                env:"BBL_LOG_LEVEL"
+  --log-format                   Format of the log: text or json (default: text)                                                env:"BBL_LOG_FORMAT"
+  --log-file                     Also logs to a rotating bbl.log in the state directory                                         env:"BBL_LOG_FILE"
 %s
 `
 	CommandUsage = `
@@ -34,9 +42,14 @@ Basic Commands: A good place to start
 
 Maintenance Lifecycle Commands:
   destroy                 Tears down BOSH director infrastructure. Cleans up state directory
-  rotate                  Rotates SSH key for the jumpbox user
+  rotate                  Rotates the jumpbox SSH key, director credentials or CAs
+  upgrade                 Upgrades the jumpbox and director to the deployments embedded in bbl
   plan                    Populates a state directory with the latest config without applying it
   cleanup-leftovers       Cleans up orphaned IAAS resources
+  backup-director         Backs up the BOSH director with BOSH Backup and Restore
+  restore-director        Restores the BOSH director from a backup
+  state                   Manages the state directory: encrypt, decrypt, unlock
+  config                  Validates the bbl.yml config file
 
 Environmental Detail Commands: Useful for automation and gaining access
   jumpbox-address         Prints BOSH jumpbox address
@@ -49,12 +62,17 @@ Environmental Detail Commands: Useful for automation and gaining access
   director-ssh-key        Prints director SSH private key
   lbs                     Prints load balancer(s) and DNS records
   outputs                 Prints the outputs from terraform
+  env-info                Prints the director, jumpbox, load balancer and terraform outputs together
   ssh                     Opens an SSH connection to the director or jumpbox
+  scp                     Copies files to or from the director or jumpbox
+  tunnel                  Forwards a local port through the jumpbox
 
 Troubleshooting Commands:
   help                    Prints usage
   version                 Prints version
-  latest-error            Prints the output from the latest call to terraform`
+  latest-error            Prints the output from the latest call to terraform
+  status                  Checks the jumpbox, director, CredHub and terraform drift
+  drift                   Lists the changes terraform would make to the infrastructure`
 
 type Usage struct {
 	logger logger
@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"fmt"
 	"runtime"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -15,12 +14,20 @@ type Version struct {
 func NewVersion(version string, logger logger) Version {
 	return Version{
 		logger:  logger,
-		version: fmt.Sprintf("%s (%s/%s)", version, runtime.GOOS, runtime.GOARCH),
+		version: version,
 	}
 }
 
 func (v Version) Execute(subcommandFlags []string, state storage.State) error {
-	v.logger.Printf("bbl %s\n", v.version)
+	if asJSON(subcommandFlags) {
+		return printJSON(v.logger, struct {
+			Version string `json:"version"`
+			OS      string `json:"os"`
+			Arch    string `json:"arch"`
+		}{v.version, runtime.GOOS, runtime.GOARCH})
+	}
+
+	v.logger.Printf("bbl %s (%s/%s)\n", v.version, runtime.GOOS, runtime.GOARCH)
 	return nil
 }
 
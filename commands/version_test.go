@@ -65,6 +65,17 @@ var _ = Describe("Version", func() {
 						fmt.Sprintf("bbl 1.2.3 (%s/%s)\n", runtime.GOOS, runtime.GOARCH),
 					}))
 				})
+
+				It("prints out the version information as json", func() {
+					err := version.Execute([]string{"--json"}, storage.State{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(fmt.Sprintf(`{
+						"version": "1.2.3",
+						"os": %q,
+						"arch": %q
+					}`, runtime.GOOS, runtime.GOARCH)))
+				})
 			})
 		})
 	})
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+)
+
+// BackupStore keeps director backups next to the remote state when bbl runs
+// with --state-bucket, sealed with the state encryption key when there is
+// one.
+type BackupStore struct {
+	provider      backends.Provider
+	flags         GlobalFlags
+	encryptionKey string
+}
+
+func NewBackupStore(provider backends.Provider, flags GlobalFlags, encryptionKey string) BackupStore {
+	return BackupStore{
+		provider:      provider,
+		flags:         flags,
+		encryptionKey: encryptionKey,
+	}
+}
+
+// IsRemote reports whether there is a remote state to keep backups next to.
+func (b BackupStore) IsRemote() bool {
+	return b.flags.StateBucket != ""
+}
+
+// Upload stores the backup in dir under name and returns where it went.
+func (b BackupStore) Upload(name, dir string) (string, error) {
+	backend, config, err := b.backend()
+	if err != nil {
+		return "", err
+	}
+
+	err = backend.PutBackup(config, b.path(name), dir)
+	if err != nil {
+		return "", fmt.Errorf("Uploading backup to %s: %s", b.flags.StateBucket, err) //nolint:staticcheck
+	}
+
+	return fmt.Sprintf("%s/%s", b.flags.StateBucket, b.path(name)), nil
+}
+
+// Download fetches the backup called name into dir.
+func (b BackupStore) Download(name, dir string) error {
+	backend, config, err := b.backend()
+	if err != nil {
+		return err
+	}
+
+	err = backend.GetBackup(config, b.path(name), dir)
+	if err != nil {
+		return fmt.Errorf("Downloading backup from %s: %s", b.flags.StateBucket, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func (b BackupStore) backend() (backends.Backend, backends.Config, error) {
+	kind, config, err := backendConfig(b.flags)
+	if err != nil {
+		return nil, backends.Config{}, err
+	}
+
+	backend, err := b.provider.Client(kind)
+	if err != nil {
+		return nil, backends.Config{}, err
+	}
+
+	config.EncryptionKey = b.encryptionKey
+	return backend, config, nil
+}
+
+// path puts the backups of an env under a prefix named after it, next to
+// the state tarball.
+func (b BackupStore) path(name string) string {
+	return fmt.Sprintf("%s-backups/%s.tgz", b.flags.EnvID, name)
+}
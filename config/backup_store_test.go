@@ -0,0 +1,97 @@
+package config_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+	"github.com/cloudfoundry/bosh-bootloader/config"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BackupStore", func() {
+	var (
+		provider    *fakes.BackendProvider
+		backend     *fakes.Backend
+		flags       config.GlobalFlags
+		backupStore config.BackupStore
+	)
+
+	BeforeEach(func() {
+		backend = &fakes.Backend{}
+		provider = &fakes.BackendProvider{}
+		provider.ClientCall.Returns.Backend = backend
+
+		flags = config.GlobalFlags{
+			EnvID:       "some-env",
+			StateDir:    "/some/state-dir",
+			StateBucket: "file:///some/share",
+		}
+		backupStore = config.NewBackupStore(provider, flags, "some-encryption-key")
+	})
+
+	Describe("IsRemote", func() {
+		It("is true with a state bucket", func() {
+			Expect(backupStore.IsRemote()).To(BeTrue())
+		})
+
+		It("is false without one", func() {
+			Expect(config.NewBackupStore(provider, config.GlobalFlags{}, "").IsRemote()).To(BeFalse())
+		})
+	})
+
+	Describe("Upload", func() {
+		It("uploads the backup next to the remote state", func() {
+			location, err := backupStore.Upload("some-backup", "/some/backup-dir")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(location).To(Equal("file:///some/share/some-env-backups/some-backup.tgz"))
+
+			Expect(provider.ClientCall.Receives.Kind).To(Equal("file"))
+			Expect(backend.PutBackupCall.Receives.Name).To(Equal("some-env-backups/some-backup.tgz"))
+			Expect(backend.PutBackupCall.Receives.Dir).To(Equal("/some/backup-dir"))
+			Expect(backend.PutBackupCall.Receives.Config.Bucket).To(Equal("/some/share"))
+		})
+
+		It("seals the backup with the state encryption key", func() {
+			_, err := backupStore.Upload("some-backup", "/some/backup-dir")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(backend.PutBackupCall.Receives.Config.EncryptionKey).To(Equal("some-encryption-key"))
+		})
+
+		Context("when the upload fails", func() {
+			BeforeEach(func() {
+				backend.PutBackupCall.Returns.Error = errors.New("grape")
+			})
+
+			It("returns an error", func() {
+				_, err := backupStore.Upload("some-backup", "/some/backup-dir")
+				Expect(err).To(MatchError("Uploading backup to file:///some/share: grape"))
+			})
+		})
+	})
+
+	Describe("Download", func() {
+		It("downloads the backup from next to the remote state", func() {
+			err := backupStore.Download("some-backup", "/some/backup-dir")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(backend.GetBackupCall.Receives.Name).To(Equal("some-env-backups/some-backup.tgz"))
+			Expect(backend.GetBackupCall.Receives.Dir).To(Equal("/some/backup-dir"))
+			Expect(backend.GetBackupCall.Receives.Config.EncryptionKey).To(Equal("some-encryption-key"))
+		})
+
+		Context("when the backup does not exist", func() {
+			BeforeEach(func() {
+				backend.GetBackupCall.Returns.Error = backends.ErrBackupNotFound
+			})
+
+			It("returns an error", func() {
+				err := backupStore.Download("some-backup", "/some/backup-dir")
+				Expect(err).To(MatchError("Downloading backup from file:///some/share: the backup does not exist in the remote state storage"))
+			})
+		})
+	})
+})
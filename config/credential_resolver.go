@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type awsCredentialProvider interface {
+	Resolve(storage.AWS) (storage.AWS, error)
+}
+
+type gcpCredentialProvider interface {
+	Resolve(storage.GCP) (storage.GCP, error)
+}
+
+type azureCredentialProvider interface {
+	Resolve(storage.Azure) (storage.Azure, error)
+}
+
+// CredentialResolver resolves short-lived credentials from an AWS profile,
+// GCP application default credentials or the Azure CLI or managed identity,
+// so that they reach terraform and bosh in place of static keys.
+type CredentialResolver struct {
+	aws   awsCredentialProvider
+	gcp   gcpCredentialProvider
+	azure azureCredentialProvider
+}
+
+func NewCredentialResolver(aws awsCredentialProvider, gcp gcpCredentialProvider, azure azureCredentialProvider) CredentialResolver {
+	return CredentialResolver{
+		aws:   aws,
+		gcp:   gcp,
+		azure: azure,
+	}
+}
+
+func (c CredentialResolver) Resolve(state storage.State) (storage.State, error) {
+	var err error
+	switch state.IAAS {
+	case "aws":
+		if state.AWS.Profile != "" {
+			state.AWS, err = c.aws.Resolve(state.AWS)
+		}
+	case "gcp":
+		if state.GCP.CredentialSource != "" {
+			state.GCP, err = c.gcp.Resolve(state.GCP)
+		}
+	case "azure":
+		if state.Azure.CredentialSource != "" {
+			state.Azure, err = c.azure.Resolve(state.Azure)
+		}
+	}
+	if err != nil {
+		return storage.State{}, fmt.Errorf("Resolve %s credentials: %s", state.IAAS, err) //nolint:staticcheck
+	}
+
+	return state, nil
+}
@@ -0,0 +1,80 @@
+package config_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/config"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CredentialResolver", func() {
+	var (
+		awsProvider   *fakes.AWSCredentialProvider
+		gcpProvider   *fakes.GCPCredentialProvider
+		azureProvider *fakes.AzureCredentialProvider
+
+		resolver config.CredentialResolver
+	)
+
+	BeforeEach(func() {
+		awsProvider = &fakes.AWSCredentialProvider{}
+		gcpProvider = &fakes.GCPCredentialProvider{}
+		azureProvider = &fakes.AzureCredentialProvider{}
+
+		resolver = config.NewCredentialResolver(awsProvider, gcpProvider, azureProvider)
+	})
+
+	Describe("Resolve", func() {
+		It("resolves the credentials of an AWS profile", func() {
+			awsProvider.ResolveCall.Returns.AWS = storage.AWS{Profile: "some-profile", AccessKeyID: "some-access-key-id", SessionToken: "some-session-token"}
+
+			state, err := resolver.Resolve(storage.State{IAAS: "aws", EnvID: "some-env-id", AWS: storage.AWS{Profile: "some-profile"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsProvider.ResolveCall.Receives.AWS).To(Equal(storage.AWS{Profile: "some-profile"}))
+			Expect(state.EnvID).To(Equal("some-env-id"))
+			Expect(state.AWS.SessionToken).To(Equal("some-session-token"))
+		})
+
+		It("resolves GCP application default credentials", func() {
+			gcpProvider.ResolveCall.Returns.GCP = storage.GCP{CredentialSource: "application-default", AccessToken: "some-access-token"}
+
+			state, err := resolver.Resolve(storage.State{IAAS: "gcp", GCP: storage.GCP{CredentialSource: "application-default"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.GCP.AccessToken).To(Equal("some-access-token"))
+		})
+
+		It("resolves the Azure CLI or managed identity", func() {
+			azureProvider.ResolveCall.Returns.Azure = storage.Azure{CredentialSource: "managed-identity"}
+
+			state, err := resolver.Resolve(storage.State{IAAS: "azure", Azure: storage.Azure{CredentialSource: "managed-identity"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureProvider.ResolveCall.CallCount).To(Equal(1))
+			Expect(state.Azure.CredentialSource).To(Equal("managed-identity"))
+		})
+
+		It("leaves static credentials alone", func() {
+			state := storage.State{IAAS: "aws", AWS: storage.AWS{AccessKeyID: "some-access-key-id"}}
+
+			resolved, err := resolver.Resolve(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resolved).To(Equal(state))
+			Expect(awsProvider.ResolveCall.CallCount).To(Equal(0))
+		})
+
+		Context("when resolving fails", func() {
+			It("returns an error", func() {
+				gcpProvider.ResolveCall.Returns.Error = errors.New("could not find default credentials")
+
+				_, err := resolver.Resolve(storage.State{IAAS: "gcp", GCP: storage.GCP{CredentialSource: "application-default"}})
+				Expect(err).To(MatchError("Resolve gcp credentials: could not find default credentials"))
+			})
+		})
+	})
+})
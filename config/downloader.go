@@ -2,6 +2,9 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/backends"
 )
@@ -14,34 +17,138 @@ func NewDownloader(provider backends.Provider) Downloader {
 	return Downloader{provider: provider}
 }
 
-func (d Downloader) DownloadAndPrepareState(flags GlobalFlags) error {
-	backend, err := d.provider.Client(flags.IAAS)
+// DownloadAndPrepareState fetches the remote state into the state dir and
+// returns the version that was downloaded.
+func (d Downloader) DownloadAndPrepareState(flags GlobalFlags) (string, error) {
+	kind, config, err := backendConfig(flags)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	backend, err := d.provider.Client(kind)
+	if err != nil {
+		return "", err
+	}
+
+	return backend.GetState(config, flags.EnvID)
+}
+
+// backendConfig works out where --state-bucket points. A URL picks the
+// backend by its scheme, while a bare bucket name lives in the object store
+// of the IaaS.
+func backendConfig(flags GlobalFlags) (string, backends.Config, error) {
+	if strings.Contains(flags.StateBucket, "://") {
+		return backendConfigFromURL(flags)
 	}
 
-	var config backends.Config
 	switch flags.IAAS {
 	case "aws":
-		config = backends.Config{
+		if flags.AWSAssumeRole != "" {
+			return "", backends.Config{}, errors.New("Assume role not supported when using an AWS state bucket") //nolint:staticcheck
+		}
+
+		return "s3", backends.Config{
 			Dest:               flags.StateDir,
 			Bucket:             flags.StateBucket,
 			Region:             flags.AWSRegion,
 			AWSAccessKeyID:     flags.AWSAccessKeyID,
 			AWSSecretAccessKey: flags.AWSSecretAccessKey,
-		}
-
-		if flags.AWSAssumeRole != "" {
-			return errors.New("Assume role not supported when using an AWS state bucket") //nolint:staticcheck
-		}
+		}, nil
 	case "gcp":
-		config = backends.Config{
+		return "gcs", backends.Config{
 			Dest:                 flags.StateDir,
 			Bucket:               flags.StateBucket,
 			Region:               flags.GCPRegion,
 			GCPServiceAccountKey: flags.GCPServiceAccountKey,
+		}, nil
+	case "azure":
+		account, container, found := strings.Cut(flags.StateBucket, "/")
+		if !found || account == "" || container == "" || strings.Contains(container, "/") {
+			return "", backends.Config{}, fmt.Errorf("state bucket %q must look like account/container on azure", flags.StateBucket)
 		}
+
+		return "azure", backends.Config{
+			Dest:              flags.StateDir,
+			Bucket:            container,
+			Endpoint:          fmt.Sprintf("https://%s.blob.core.windows.net", account),
+			AzureTenantID:     flags.AzureTenantID,
+			AzureClientID:     flags.AzureClientID,
+			AzureClientSecret: flags.AzureClientSecret,
+			AzureSASToken:     flags.AzureStorageSASToken,
+		}, nil
+	default:
+		return "", backends.Config{}, fmt.Errorf("remote state storage is unsupported for %s environments, use an s3://, azblob:// or file:// state bucket", flags.IAAS)
 	}
+}
 
-	return backend.GetState(config, flags.EnvID)
+func backendConfigFromURL(flags GlobalFlags) (string, backends.Config, error) {
+	location, err := url.Parse(flags.StateBucket)
+	if err != nil {
+		return "", backends.Config{}, fmt.Errorf("Parsing state bucket: %s", err) //nolint:staticcheck
+	}
+
+	switch location.Scheme {
+	case "s3", "s3+http":
+		bucket := strings.Trim(location.Path, "/")
+		if location.Host == "" || bucket == "" {
+			return "", backends.Config{}, fmt.Errorf("state bucket %q must look like s3://host/bucket", flags.StateBucket)
+		}
+
+		endpoint := url.URL{Scheme: "https", Host: location.Host}
+		if location.Scheme == "s3+http" {
+			endpoint.Scheme = "http"
+		}
+
+		region := flags.AWSRegion
+		if region == "" {
+			region = "us-east-1"
+		}
+
+		return "s3", backends.Config{
+			Dest:               flags.StateDir,
+			Bucket:             bucket,
+			Region:             region,
+			Endpoint:           endpoint.String(),
+			AWSAccessKeyID:     flags.AWSAccessKeyID,
+			AWSSecretAccessKey: flags.AWSSecretAccessKey,
+		}, nil
+	case "azblob", "azblob+http":
+		path := strings.Trim(location.Path, "/")
+		if location.Host == "" || path == "" {
+			return "", backends.Config{}, fmt.Errorf("state bucket %q must look like azblob://account.blob.core.windows.net/container", flags.StateBucket)
+		}
+
+		// Azurite serves every account from one host, so the account name
+		// comes first in the path and the container is always last.
+		account := url.URL{Scheme: "https", Host: location.Host}
+		if location.Scheme == "azblob+http" {
+			account.Scheme = "http"
+		}
+		container := path
+		if i := strings.LastIndex(path, "/"); i >= 0 {
+			account.Path = "/" + path[:i]
+			container = path[i+1:]
+		}
+
+		return "azure", backends.Config{
+			Dest:              flags.StateDir,
+			Bucket:            container,
+			Endpoint:          account.String(),
+			AzureTenantID:     flags.AzureTenantID,
+			AzureClientID:     flags.AzureClientID,
+			AzureClientSecret: flags.AzureClientSecret,
+			AzureSASToken:     flags.AzureStorageSASToken,
+		}, nil
+	case "file":
+		if location.Host != "" || location.Path == "" {
+			return "", backends.Config{}, fmt.Errorf("state bucket %q must look like file:///path/to/dir", flags.StateBucket)
+		}
+
+		return "file", backends.Config{
+			Dest:   flags.StateDir,
+			Bucket: location.Path,
+		}, nil
+	default:
+		return "", backends.Config{}, fmt.Errorf("remote state storage %q is unsupported, use s3://, s3+http://, azblob://, azblob+http:// or file://", location.Scheme)
+	}
 }
@@ -0,0 +1,179 @@
+package config_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+	"github.com/cloudfoundry/bosh-bootloader/config"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Downloader", func() {
+	var (
+		provider   *fakes.BackendProvider
+		backend    *fakes.Backend
+		downloader config.Downloader
+	)
+
+	BeforeEach(func() {
+		backend = &fakes.Backend{}
+		backend.GetStateCall.Returns.Version = "some-version"
+		provider = &fakes.BackendProvider{}
+		provider.ClientCall.Returns.Backend = backend
+
+		downloader = config.NewDownloader(provider)
+	})
+
+	Describe("DownloadAndPrepareState", func() {
+		It("downloads a bare bucket from the object store of the iaas", func() {
+			version, err := downloader.DownloadAndPrepareState(config.GlobalFlags{
+				IAAS:               "aws",
+				EnvID:              "some-env",
+				StateDir:           "/some/state-dir",
+				StateBucket:        "some-bucket",
+				AWSRegion:          "some-region",
+				AWSAccessKeyID:     "some-access-key-id",
+				AWSSecretAccessKey: "some-secret-access-key",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("some-version"))
+
+			Expect(provider.ClientCall.Receives.Kind).To(Equal("s3"))
+			Expect(backend.GetStateCall.Receives.Name).To(Equal("some-env"))
+			Expect(backend.GetStateCall.Receives.Config).To(Equal(backends.Config{
+				Dest:               "/some/state-dir",
+				Bucket:             "some-bucket",
+				Region:             "some-region",
+				AWSAccessKeyID:     "some-access-key-id",
+				AWSSecretAccessKey: "some-secret-access-key",
+			}))
+		})
+
+		It("downloads from an s3-compatible store for any iaas", func() {
+			_, err := downloader.DownloadAndPrepareState(config.GlobalFlags{
+				IAAS:               "vsphere",
+				StateDir:           "/some/state-dir",
+				StateBucket:        "s3+http://minio.example.com:9000/some-bucket",
+				AWSAccessKeyID:     "some-access-key-id",
+				AWSSecretAccessKey: "some-secret-access-key",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(provider.ClientCall.Receives.Kind).To(Equal("s3"))
+			Expect(backend.GetStateCall.Receives.Config).To(Equal(backends.Config{
+				Dest:               "/some/state-dir",
+				Bucket:             "some-bucket",
+				Region:             "us-east-1",
+				Endpoint:           "http://minio.example.com:9000",
+				AWSAccessKeyID:     "some-access-key-id",
+				AWSSecretAccessKey: "some-secret-access-key",
+			}))
+		})
+
+		It("downloads from an azure storage account for any iaas", func() {
+			_, err := downloader.DownloadAndPrepareState(config.GlobalFlags{
+				IAAS:              "azure",
+				StateDir:          "/some/state-dir",
+				StateBucket:       "azblob://someaccount.blob.core.windows.net/some-container",
+				AzureTenantID:     "some-tenant-id",
+				AzureClientID:     "some-client-id",
+				AzureClientSecret: "some-client-secret",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(provider.ClientCall.Receives.Kind).To(Equal("azure"))
+			Expect(backend.GetStateCall.Receives.Config).To(Equal(backends.Config{
+				Dest:              "/some/state-dir",
+				Bucket:            "some-container",
+				Endpoint:          "https://someaccount.blob.core.windows.net",
+				AzureTenantID:     "some-tenant-id",
+				AzureClientID:     "some-client-id",
+				AzureClientSecret: "some-client-secret",
+			}))
+		})
+
+		It("downloads from a container in the storage account named by a bare azure state bucket", func() {
+			_, err := downloader.DownloadAndPrepareState(config.GlobalFlags{
+				IAAS:                 "azure",
+				StateDir:             "/some/state-dir",
+				StateBucket:          "someaccount/some-container",
+				AzureStorageSASToken: "sv=some-version&sig=some-signature",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(provider.ClientCall.Receives.Kind).To(Equal("azure"))
+			Expect(backend.GetStateCall.Receives.Config).To(Equal(backends.Config{
+				Dest:          "/some/state-dir",
+				Bucket:        "some-container",
+				Endpoint:      "https://someaccount.blob.core.windows.net",
+				AzureSASToken: "sv=some-version&sig=some-signature",
+			}))
+		})
+
+		It("downloads from azurite with the account in the path", func() {
+			_, err := downloader.DownloadAndPrepareState(config.GlobalFlags{
+				IAAS:                 "openstack",
+				StateDir:             "/some/state-dir",
+				StateBucket:          "azblob+http://127.0.0.1:10000/devstoreaccount1/some-container",
+				AzureStorageSASToken: "sv=some-version&sig=some-signature",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(provider.ClientCall.Receives.Kind).To(Equal("azure"))
+			Expect(backend.GetStateCall.Receives.Config).To(Equal(backends.Config{
+				Dest:          "/some/state-dir",
+				Bucket:        "some-container",
+				Endpoint:      "http://127.0.0.1:10000/devstoreaccount1",
+				AzureSASToken: "sv=some-version&sig=some-signature",
+			}))
+		})
+
+		It("downloads from a directory for a file url", func() {
+			_, err := downloader.DownloadAndPrepareState(config.GlobalFlags{
+				IAAS:        "openstack",
+				StateDir:    "/some/state-dir",
+				StateBucket: "file:///mnt/bbl",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(provider.ClientCall.Receives.Kind).To(Equal("file"))
+			Expect(backend.GetStateCall.Receives.Config).To(Equal(backends.Config{
+				Dest:   "/some/state-dir",
+				Bucket: "/mnt/bbl",
+			}))
+		})
+
+		DescribeTable("rejects state buckets it cannot use",
+			func(iaas, stateBucket, message string) {
+				_, err := downloader.DownloadAndPrepareState(config.GlobalFlags{
+					IAAS:        iaas,
+					StateBucket: stateBucket,
+				})
+				Expect(err).To(MatchError(ContainSubstring(message)))
+				Expect(provider.ClientCall.CallCount).To(Equal(0))
+			},
+			Entry("a bare bucket on vsphere", "vsphere", "some-bucket", "unsupported for vsphere environments"),
+			Entry("an s3 url without a bucket", "aws", "s3://minio.example.com", "must look like s3://host/bucket"),
+			Entry("a bare azure state bucket without a container", "azure", "someaccount", "must look like account/container on azure"),
+			Entry("an azblob url without a container", "azure", "azblob://someaccount.blob.core.windows.net", "must look like azblob://account.blob.core.windows.net/container"),
+			Entry("a file url with a host", "aws", "file://some-host/mnt/bbl", "must look like file:///path/to/dir"),
+			Entry("an unknown scheme", "aws", "ftp://some-host/bbl", `"ftp" is unsupported`),
+		)
+
+		Context("when the backend fails", func() {
+			BeforeEach(func() {
+				backend.GetStateCall.Returns.Error = errors.New("failed to download")
+			})
+
+			It("returns the error", func() {
+				_, err := downloader.DownloadAndPrepareState(config.GlobalFlags{
+					StateBucket: "file:///mnt/bbl",
+				})
+				Expect(err).To(MatchError("failed to download"))
+			})
+		})
+	})
+})
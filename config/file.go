@@ -0,0 +1,328 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"gopkg.in/yaml.v2"
+)
+
+// FILE is the config file that bbl loads from the state directory when
+// --config is not set.
+const FILE = "bbl.yml"
+
+// File declares an environment, so that bbl can be run without its flags.
+// Flags and environment variables take precedence over it.
+type File struct {
+	Name        string               `yaml:"name"`
+	IAAS        string               `yaml:"iaas"`
+	Region      string               `yaml:"region"`
+	Credentials map[string]Reference `yaml:"credentials"`
+	LB          FileLB               `yaml:"lb"`
+
+	// Path is where the file was loaded from. Relative paths in the file
+	// are relative to its directory.
+	Path string `yaml:"-"`
+}
+
+// Reference points at a credential rather than holding it, so that the
+// file can be committed.
+type Reference struct {
+	Env  string `yaml:"env"`
+	File string `yaml:"file"`
+}
+
+type FileLB struct {
+	Type   string `yaml:"type"`
+	Cert   string `yaml:"cert"`
+	Key    string `yaml:"key"`
+	Chain  string `yaml:"chain"`
+	Domain string `yaml:"domain"`
+}
+
+// regionFlags are the flags that the region in the file sets, by IAAS.
+var regionFlags = map[string]string{
+	"aws":       "aws-region",
+	"azure":     "azure-region",
+	"gcp":       "gcp-region",
+	"openstack": "openstack-region",
+}
+
+var iaases = []string{"aws", "azure", "gcp", "vsphere", "openstack", "cloudstack"}
+
+// LoadFile reads the file at --config, or bbl.yml in the state directory.
+// It is not an error for the state directory not to have one.
+func LoadFile(fs fileio.FileReader, globalFlags GlobalFlags) (File, error) {
+	path := filePath(globalFlags)
+	contents, err := fs.ReadFile(path)
+	if err != nil {
+		if globalFlags.Config == "" && errors.Is(err, os.ErrNotExist) {
+			return File{}, nil
+		}
+		return File{}, fmt.Errorf("Read config file: %s", err) //nolint:staticcheck
+	}
+
+	var file File
+	err = yaml.UnmarshalStrict(contents, &file)
+	if err != nil {
+		return File{}, fmt.Errorf("Parse %s: %s", path, err) //nolint:staticcheck
+	}
+	file.Path = path
+
+	return file, nil
+}
+
+func filePath(globalFlags GlobalFlags) string {
+	if globalFlags.Config == "" {
+		return filepath.Join(globalFlags.StateDir, FILE)
+	}
+
+	path, err := filepath.Abs(globalFlags.Config)
+	if err != nil {
+		return globalFlags.Config // not tested
+	}
+	return path
+}
+
+// path resolves a path in the file relative to the file's directory.
+func (f File) path(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(f.Path), path)
+}
+
+// LBArgs are the load balancer flags of bbl plan and bbl up that the file
+// declares, with the certificate paths resolved.
+func (f File) LBArgs() []string {
+	args := []string{}
+	for _, arg := range []struct{ name, value string }{
+		{"--lb-type", f.LB.Type},
+		{"--lb-cert", f.path(f.LB.Cert)},
+		{"--lb-key", f.path(f.LB.Key)},
+		{"--lb-chain", f.path(f.LB.Chain)},
+		{"--lb-domain", f.LB.Domain},
+	} {
+		if arg.value != "" {
+			args = append(args, arg.name, arg.value)
+		}
+	}
+	return args
+}
+
+// applyTo sets the flags that were given neither on the command line nor in
+// the environment from the file.
+func (f File) applyTo(fs fileio.FileReader, globalFlags GlobalFlags) (GlobalFlags, error) {
+	if globalFlags.IAAS == "" {
+		globalFlags.IAAS = f.IAAS
+	}
+
+	if f.Region != "" && isIAAS(globalFlags.IAAS) {
+		name, ok := regionFlags[globalFlags.IAAS]
+		if !ok {
+			return GlobalFlags{}, fmt.Errorf("region is not supported for %s, use the %s flags in credentials", globalFlags.IAAS, globalFlags.IAAS)
+		}
+		setEmptyFlag(&globalFlags, name, f.Region)
+	}
+
+	names := []string{}
+	for name := range f.Credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := f.resolve(fs, name, f.Credentials[name])
+		if err != nil {
+			return GlobalFlags{}, err
+		}
+		if !setEmptyFlag(&globalFlags, name, value) {
+			return GlobalFlags{}, fmt.Errorf("unknown credential %q, use the name of an IaaS flag such as aws-secret-access-key", name)
+		}
+	}
+
+	return globalFlags, nil
+}
+
+func (f File) resolve(fs fileio.FileReader, name string, reference Reference) (string, error) {
+	switch {
+	case reference.Env != "" && reference.File != "":
+		return "", fmt.Errorf("credential %s has both env and file, use one", name)
+	case reference.Env != "":
+		value, ok := os.LookupEnv(reference.Env)
+		if !ok {
+			return "", fmt.Errorf("credential %s: environment variable %s is not set", name, reference.Env)
+		}
+		return value, nil
+	case reference.File != "":
+		contents, err := fs.ReadFile(f.path(reference.File))
+		if err != nil {
+			return "", fmt.Errorf("credential %s: %s", name, err)
+		}
+		return strings.TrimRight(string(contents), "\n"), nil
+	}
+	return "", fmt.Errorf("credential %s needs env or file", name)
+}
+
+// problems lists what is wrong with the file for iaas, the IAAS that the
+// flags or the file select, without stopping at the first.
+func (f File) problems(fs fileio.FileReader, iaas string) []string {
+	problems := []string{}
+
+	if iaas == "" {
+		problems = append(problems, fmt.Sprintf("iaas is not set, use one of %s", strings.Join(iaases, ", ")))
+	} else if !isIAAS(iaas) {
+		problems = append(problems, fmt.Sprintf("iaas %q is not one of %s", iaas, strings.Join(iaases, ", ")))
+	}
+
+	if _, ok := regionFlags[iaas]; f.Region != "" && !ok && isIAAS(iaas) {
+		problems = append(problems, fmt.Sprintf("region is not supported for %s, use the %s flags in credentials", iaas, iaas))
+	}
+
+	names := []string{}
+	for name := range f.Credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !setEmptyFlag(&GlobalFlags{}, name, "") {
+			problems = append(problems, fmt.Sprintf("unknown credential %q, use the name of an IaaS flag such as aws-secret-access-key", name))
+			continue
+		}
+		if !strings.HasPrefix(name, iaas+"-") && isIAAS(iaas) {
+			problems = append(problems, fmt.Sprintf("credential %s is not for %s", name, iaas))
+		}
+		_, err := f.resolve(fs, name, f.Credentials[name])
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	switch f.LB.Type {
+	case "":
+		if f.LB != (FileLB{}) {
+			problems = append(problems, "lb.type is not set, use cf or concourse")
+		}
+	case "concourse":
+		if f.LB.Domain != "" {
+			problems = append(problems, "lb.domain is not implemented for concourse load balancers")
+		}
+	case "cf":
+		if f.LB.Cert == "" || f.LB.Key == "" {
+			problems = append(problems, "lb.cert and lb.key are required for cf load balancers")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("lb.type %q is not one of cf, concourse", f.LB.Type))
+	}
+	if f.LB.Chain != "" && iaas != "aws" {
+		problems = append(problems, "lb.chain is only supported on aws")
+	}
+	for _, path := range []struct{ name, path string }{
+		{"lb.cert", f.LB.Cert},
+		{"lb.key", f.LB.Key},
+		{"lb.chain", f.LB.Chain},
+	} {
+		if path.path == "" {
+			continue
+		}
+		_, err := fs.ReadFile(f.path(path.path))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", path.name, err))
+		}
+	}
+
+	return problems
+}
+
+// setEmptyFlag sets the IaaS string flag with the long name name, unless
+// it is already set. It is false when there is no such flag.
+func setEmptyFlag(globalFlags *GlobalFlags, name, value string) bool {
+	if !isIAASFlag(name) {
+		return false
+	}
+
+	flags := reflect.ValueOf(globalFlags).Elem()
+	for i := 0; i < flags.NumField(); i++ {
+		field := flags.Type().Field(i)
+		if field.Tag.Get("long") != name || field.Type.Kind() != reflect.String {
+			continue
+		}
+		if flags.Field(i).String() == "" {
+			flags.Field(i).SetString(value)
+		}
+		return true
+	}
+	return false
+}
+
+func isIAAS(name string) bool {
+	for _, iaas := range iaases {
+		if name == iaas {
+			return true
+		}
+	}
+	return false
+}
+
+func isIAASFlag(name string) bool {
+	for _, iaas := range iaases {
+		if strings.HasPrefix(name, iaas+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// FileValidator checks the config file, and that the IaaS configuration it
+// leads to is complete, without calling the IaaS.
+type FileValidator struct {
+	fs          fileio.FileReader
+	merger      merger
+	globalFlags GlobalFlags
+}
+
+func NewFileValidator(fs fileio.FileReader, merger merger, globalFlags GlobalFlags) FileValidator {
+	return FileValidator{
+		fs:          fs,
+		merger:      merger,
+		globalFlags: globalFlags,
+	}
+}
+
+// Validate returns the path of the config file and its problems.
+func (v FileValidator) Validate(state storage.State) (string, []string) {
+	file, err := LoadFile(v.fs, v.globalFlags)
+	if err != nil {
+		return filePath(v.globalFlags), []string{err.Error()}
+	}
+	if file.Path == "" {
+		return filePath(v.globalFlags), []string{"there is no config file, write one or pass --config"}
+	}
+
+	iaas := v.globalFlags.IAAS
+	if iaas == "" {
+		iaas = file.IAAS
+	}
+	problems := file.problems(v.fs, iaas)
+	if len(problems) > 0 {
+		return file.Path, problems
+	}
+
+	state, err = v.merger.MergeGlobalFlagsToState(v.globalFlags, file, state)
+	if err == nil {
+		err = ValidateIAAS(state)
+	}
+	if err != nil {
+		problems = append(problems, strings.TrimSpace(err.Error()))
+	}
+
+	return file.Path, problems
+}
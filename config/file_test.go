@@ -0,0 +1,262 @@
+package config_test
+
+import (
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/config"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File", func() {
+	var (
+		fileIO *fakes.FileIO
+		files  map[string]string
+	)
+
+	BeforeEach(func() {
+		files = map[string]string{}
+		fileIO = &fakes.FileIO{}
+		fileIO.ReadFileCall.Fake = func(path string) ([]byte, error) {
+			contents, ok := files[path]
+			if !ok {
+				return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+			}
+			return []byte(contents), nil
+		}
+	})
+
+	Describe("LoadFile", func() {
+		It("loads bbl.yml from the state directory", func() {
+			files["/state/bbl.yml"] = `
+name: some-env
+iaas: aws
+region: us-west-2
+credentials:
+  aws-access-key-id: {env: SOME_ACCESS_KEY_ID}
+  aws-secret-access-key: {file: secret-access-key}
+lb:
+  type: cf
+  cert: certs/lb.crt
+  key: /certs/lb.key
+`
+			file, err := config.LoadFile(fileIO, config.GlobalFlags{StateDir: "/state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(file.Path).To(Equal("/state/bbl.yml"))
+			Expect(file.Name).To(Equal("some-env"))
+			Expect(file.IAAS).To(Equal("aws"))
+			Expect(file.Region).To(Equal("us-west-2"))
+			Expect(file.Credentials).To(Equal(map[string]config.Reference{
+				"aws-access-key-id":     {Env: "SOME_ACCESS_KEY_ID"},
+				"aws-secret-access-key": {File: "secret-access-key"},
+			}))
+			Expect(file.LBArgs()).To(Equal([]string{"--lb-type", "cf", "--lb-cert", "/state/certs/lb.crt", "--lb-key", "/certs/lb.key"}))
+		})
+
+		It("loads the file at --config", func() {
+			files["/some/bbl-config.yml"] = "iaas: gcp\n"
+
+			file, err := config.LoadFile(fileIO, config.GlobalFlags{StateDir: "/state", Config: "/some/bbl-config.yml"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.IAAS).To(Equal("gcp"))
+		})
+
+		Context("when the state directory has no bbl.yml", func() {
+			It("returns an empty file", func() {
+				file, err := config.LoadFile(fileIO, config.GlobalFlags{StateDir: "/state"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(file).To(Equal(config.File{}))
+			})
+		})
+
+		Context("when the file at --config does not exist", func() {
+			It("returns an error", func() {
+				_, err := config.LoadFile(fileIO, config.GlobalFlags{Config: "/some/missing.yml"})
+				Expect(err).To(MatchError(ContainSubstring("Read config file: open /some/missing.yml")))
+			})
+		})
+
+		Context("when the file has an unknown key", func() {
+			It("returns an error", func() {
+				files["/state/bbl.yml"] = "iaas: aws\nbanana: true\n"
+
+				_, err := config.LoadFile(fileIO, config.GlobalFlags{StateDir: "/state"})
+				Expect(err).To(MatchError(ContainSubstring("Parse /state/bbl.yml:")))
+			})
+		})
+	})
+
+	Describe("Merger", func() {
+		var (
+			merger config.Merger
+			file   config.File
+		)
+
+		BeforeEach(func() {
+			merger = config.NewMerger(fileIO)
+			files["/state/secret-access-key"] = "some-secret-access-key\n"
+			os.Setenv("SOME_ACCESS_KEY_ID", "some-access-key-id")
+
+			file = config.File{
+				IAAS:   "aws",
+				Region: "us-west-2",
+				Credentials: map[string]config.Reference{
+					"aws-access-key-id":     {Env: "SOME_ACCESS_KEY_ID"},
+					"aws-secret-access-key": {File: "secret-access-key"},
+				},
+				Path: "/state/bbl.yml",
+			}
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("SOME_ACCESS_KEY_ID")
+		})
+
+		It("fills in the flags that are not set from the file", func() {
+			state, err := merger.MergeGlobalFlagsToState(config.GlobalFlags{}, file, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(state.IAAS).To(Equal("aws"))
+			Expect(state.AWS).To(Equal(storage.AWS{
+				AccessKeyID:     "some-access-key-id",
+				SecretAccessKey: "some-secret-access-key",
+				Region:          "us-west-2",
+			}))
+		})
+
+		It("prefers the flags and environment variables to the file", func() {
+			state, err := merger.MergeGlobalFlagsToState(config.GlobalFlags{
+				AWSRegion:      "eu-central-1",
+				AWSAccessKeyID: "other-access-key-id",
+			}, file, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(state.AWS.Region).To(Equal("eu-central-1"))
+			Expect(state.AWS.AccessKeyID).To(Equal("other-access-key-id"))
+			Expect(state.AWS.SecretAccessKey).To(Equal("some-secret-access-key"))
+		})
+
+		Describe("credential sources", func() {
+			It("keeps the AWS profile in the state", func() {
+				state, err := merger.MergeGlobalFlagsToState(config.GlobalFlags{IAAS: "aws", AWSProfile: "some-profile"}, config.File{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.AWS.Profile).To(Equal("some-profile"))
+			})
+
+			It("switches back to static credentials when they are passed", func() {
+				files["/state/key.json"] = `{"project_id": "some-project-id"}`
+
+				state, err := merger.MergeGlobalFlagsToState(config.GlobalFlags{GCPServiceAccountKey: "/state/key.json"}, config.File{}, storage.State{
+					IAAS: "gcp",
+					GCP:  storage.GCP{CredentialSource: "application-default"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.GCP.CredentialSource).To(BeEmpty())
+				Expect(state.GCP.ProjectID).To(Equal("some-project-id"))
+			})
+
+			It("does not take a credential source and a static credential together", func() {
+				_, err := merger.MergeGlobalFlagsToState(config.GlobalFlags{AzureCredentialSource: "cli", AzureClientSecret: "some-secret"}, config.File{}, storage.State{IAAS: "azure"})
+				Expect(err).To(MatchError("--azure-credential-source and --azure-client-secret cannot both be set"))
+			})
+		})
+
+		Context("when a credential's environment variable is not set", func() {
+			It("returns an error", func() {
+				os.Unsetenv("SOME_ACCESS_KEY_ID")
+
+				_, err := merger.MergeGlobalFlagsToState(config.GlobalFlags{}, file, storage.State{})
+				Expect(err).To(MatchError("credential aws-access-key-id: environment variable SOME_ACCESS_KEY_ID is not set"))
+			})
+		})
+
+		Context("when a credential is not an IaaS flag", func() {
+			It("returns an error", func() {
+				file.Credentials["state-dir"] = config.Reference{Env: "SOME_ACCESS_KEY_ID"}
+
+				_, err := merger.MergeGlobalFlagsToState(config.GlobalFlags{}, file, storage.State{})
+				Expect(err).To(MatchError(`unknown credential "state-dir", use the name of an IaaS flag such as aws-secret-access-key`))
+			})
+		})
+	})
+
+	Describe("FileValidator", func() {
+		var (
+			merger      *fakes.Merger
+			globalFlags config.GlobalFlags
+		)
+
+		BeforeEach(func() {
+			merger = &fakes.Merger{}
+			merger.MergeCall.Returns.State = storage.State{
+				IAAS: "aws",
+				AWS: storage.AWS{
+					AccessKeyID:     "some-access-key-id",
+					SecretAccessKey: "some-secret-access-key",
+					Region:          "us-west-2",
+				},
+			}
+			globalFlags = config.GlobalFlags{StateDir: "/state"}
+		})
+
+		It("merges a valid file and checks the IaaS configuration", func() {
+			files["/state/bbl.yml"] = "iaas: aws\nregion: us-west-2\n"
+
+			path, problems := config.NewFileValidator(fileIO, merger, globalFlags).Validate(storage.State{EnvID: "some-env"})
+			Expect(path).To(Equal("/state/bbl.yml"))
+			Expect(problems).To(BeEmpty())
+
+			Expect(merger.MergeCall.Receives.File.Region).To(Equal("us-west-2"))
+			Expect(merger.MergeCall.Receives.State.EnvID).To(Equal("some-env"))
+		})
+
+		It("reports every problem with the file", func() {
+			files["/state/bbl.yml"] = `
+iaas: gcp
+credentials:
+  aws-access-key-id: {env: SOME_MISSING_VARIABLE}
+  banana: {file: banana}
+lb:
+  type: cf
+  cert: lb.crt
+  chain: lb-chain.crt
+`
+			files["/state/lb.crt"] = "some-cert"
+
+			path, problems := config.NewFileValidator(fileIO, merger, globalFlags).Validate(storage.State{})
+			Expect(path).To(Equal("/state/bbl.yml"))
+			Expect(problems).To(Equal([]string{
+				"credential aws-access-key-id is not for gcp",
+				"credential aws-access-key-id: environment variable SOME_MISSING_VARIABLE is not set",
+				`unknown credential "banana", use the name of an IaaS flag such as aws-secret-access-key`,
+				"lb.cert and lb.key are required for cf load balancers",
+				"lb.chain is only supported on aws",
+				"lb.chain: open /state/lb-chain.crt: file does not exist",
+			}))
+			Expect(merger.MergeCall.CallCount).To(Equal(0))
+		})
+
+		Context("when the IaaS configuration is incomplete", func() {
+			It("reports what is missing", func() {
+				files["/state/bbl.yml"] = "iaas: aws\n"
+				merger.MergeCall.Returns.State = storage.State{IAAS: "aws"}
+
+				_, problems := config.NewFileValidator(fileIO, merger, globalFlags).Validate(storage.State{})
+				Expect(problems).To(HaveLen(1))
+				Expect(problems[0]).To(Equal("Missing --aws-access-key-id. To see all required credentials run `bbl plan --help`."))
+			})
+		})
+
+		Context("when there is no config file", func() {
+			It("says so", func() {
+				path, problems := config.NewFileValidator(fileIO, merger, globalFlags).Validate(storage.State{})
+				Expect(path).To(Equal("/state/bbl.yml"))
+				Expect(problems).To(Equal([]string{"there is no config file, write one or pass --config"}))
+			})
+		})
+	})
+})
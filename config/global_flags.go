@@ -7,24 +7,36 @@ type GlobalFlags struct {
 	NoConfirm            bool   `short:"n" long:"no-confirm"`
 	StateDir             string `short:"s" long:"state-dir"               env:"BBL_STATE_DIRECTORY"`
 	StateBucket          string `          long:"state-bucket"            env:"BBL_STATE_BUCKET"`
+	Config               string `          long:"config"                  env:"BBL_CONFIG"`
 	EnvID                string `          long:"name"`
 	IAAS                 string `          long:"iaas"                    env:"BBL_IAAS"`
 	TerraformBinary      string `          long:"terraform-binary"        env:"BBL_TERRAFORM_BINARY"`
 	DisableTfAutoApprove bool   `          long:"disable-tf-auto-approve" env:"BBL_DISABLE_TF_AUTO_APPROVE"`
+	StateEncryptionKey   string `          long:"state-encryption-key"    env:"BBL_STATE_ENCRYPTION_KEY"`
+	NoAutoMigrate        bool   `          long:"no-auto-migrate"         env:"BBL_NO_AUTO_MIGRATE"`
+	SecretStore          string `          long:"secret-store"            env:"BBL_SECRET_STORE"`
+	JSON                 bool   `          long:"json"`
+	LogLevel             string `          long:"log-level"               env:"BBL_LOG_LEVEL"  default:"info"`
+	LogFormat            string `          long:"log-format"              env:"BBL_LOG_FORMAT" default:"text"`
+	LogFile              bool   `          long:"log-file"                env:"BBL_LOG_FILE"`
 
 	AWSAccessKeyID     string `long:"aws-access-key-id"       env:"BBL_AWS_ACCESS_KEY_ID"`
 	AWSSecretAccessKey string `long:"aws-secret-access-key"   env:"BBL_AWS_SECRET_ACCESS_KEY"`
 	AWSRegion          string `long:"aws-region"              env:"BBL_AWS_REGION"`
 	AWSAssumeRole      string `long:"aws-assume-role"         env:"BBL_AWS_ASSUME_ROLE"`
+	AWSProfile         string `long:"aws-profile"             env:"BBL_AWS_PROFILE"`
 
-	AzureClientID       string `long:"azure-client-id"        env:"BBL_AZURE_CLIENT_ID"`
-	AzureClientSecret   string `long:"azure-client-secret"    env:"BBL_AZURE_CLIENT_SECRET"`
-	AzureRegion         string `long:"azure-region"           env:"BBL_AZURE_REGION"`
-	AzureSubscriptionID string `long:"azure-subscription-id"  env:"BBL_AZURE_SUBSCRIPTION_ID"`
-	AzureTenantID       string `long:"azure-tenant-id"        env:"BBL_AZURE_TENANT_ID"`
+	AzureClientID         string `long:"azure-client-id"         env:"BBL_AZURE_CLIENT_ID"`
+	AzureClientSecret     string `long:"azure-client-secret"     env:"BBL_AZURE_CLIENT_SECRET"`
+	AzureRegion           string `long:"azure-region"            env:"BBL_AZURE_REGION"`
+	AzureSubscriptionID   string `long:"azure-subscription-id"   env:"BBL_AZURE_SUBSCRIPTION_ID"`
+	AzureTenantID         string `long:"azure-tenant-id"         env:"BBL_AZURE_TENANT_ID"`
+	AzureStorageSASToken  string `long:"azure-storage-sas-token" env:"BBL_AZURE_STORAGE_SAS_TOKEN"`
+	AzureCredentialSource string `long:"azure-credential-source" env:"BBL_AZURE_CREDENTIAL_SOURCE"`
 
 	GCPServiceAccountKey string `long:"gcp-service-account-key" env:"BBL_GCP_SERVICE_ACCOUNT_KEY"`
 	GCPRegion            string `long:"gcp-region"              env:"BBL_GCP_REGION"`
+	GCPCredentialSource  string `long:"gcp-credential-source"   env:"BBL_GCP_CREDENTIAL_SOURCE"`
 
 	VSphereNetwork            string `long:"vsphere-network"            env:"BBL_VSPHERE_NETWORK"`
 	VSphereSubnetCIDR         string `long:"vsphere-subnet-cidr"        env:"BBL_VSPHERE_SUBNET_CIDR"`
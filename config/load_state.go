@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
@@ -19,15 +22,26 @@ type StateBootstrap interface {
 }
 
 type migrator interface {
+	Plan(storage.State) ([]storage.MigrationStep, error)
 	Migrate(storage.State) (storage.State, error)
 }
 
 type merger interface {
-	MergeGlobalFlagsToState(globalflags GlobalFlags, state storage.State) (storage.State, error)
+	MergeGlobalFlagsToState(globalflags GlobalFlags, file File, state storage.State) (storage.State, error)
 }
 
 type downloader interface {
-	DownloadAndPrepareState(globalflags GlobalFlags) error
+	DownloadAndPrepareState(globalflags GlobalFlags) (string, error)
+}
+
+type importer interface {
+	Import(bundlePath string) (storage.BundleManifest, error)
+}
+
+type locker interface {
+	Lock(command string) error
+	Unlock() error
+	IsLocked() (bool, error)
 }
 
 type fs interface {
@@ -37,12 +51,14 @@ type fs interface {
 	fileio.FileWriter
 }
 
-func NewConfig(bootstrap StateBootstrap, migrator migrator, merger merger, downloader downloader, logger logger, fs fs) Config {
+func NewConfig(bootstrap StateBootstrap, migrator migrator, merger merger, downloader downloader, locker locker, importer importer, logger logger, fs fs) Config {
 	return Config{
 		stateBootstrap: bootstrap,
 		migrator:       migrator,
 		merger:         merger,
 		downloader:     downloader,
+		locker:         locker,
+		importer:       importer,
 		logger:         logger,
 		fs:             fs,
 	}
@@ -53,6 +69,8 @@ type Config struct {
 	migrator       migrator
 	merger         merger
 	downloader     downloader
+	locker         locker
+	importer       importer
 	logger         logger
 	fs             fs
 }
@@ -84,7 +102,7 @@ func ParseArgs(args []string) (GlobalFlags, []string, error) {
 	return globals, remainingArgs, nil
 }
 
-func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsLen int) (application.Configuration, error) {
+func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsLen int) (appConfig application.Configuration, err error) {
 	if argsLen == 1 {
 		return application.Configuration{
 			Command: "help",
@@ -99,6 +117,9 @@ func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsL
 	if globalFlags.Version || command == "version" {
 		command = "version"
 		return application.Configuration{
+			Global: application.GlobalConfiguration{
+				JSON: globalFlags.JSON,
+			},
 			ShowCommandHelp: globalFlags.Help,
 			Command:         command,
 		}, nil
@@ -130,8 +151,35 @@ func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsL
 		}, nil
 	}
 
-	if !modifiesState(command) && globalFlags.StateBucket != "" {
-		err := c.downloader.DownloadAndPrepareState(globalFlags)
+	// The lock is taken first, so a mutating command that finds it held
+	// fails before the remote state overwrites the one being changed.
+	name := CommandName(remainingArgs)
+	if locksState(remainingArgs) {
+		err = c.locker.Lock(name)
+		if err != nil {
+			return application.Configuration{}, err
+		}
+		defer func() {
+			if err != nil {
+				c.locker.Unlock() //nolint:errcheck
+			}
+		}()
+	}
+
+	var remoteStateVersion string
+	if globalFlags.StateBucket != "" {
+		remoteStateVersion, err = c.downloader.DownloadAndPrepareState(globalFlags)
+		if err != nil {
+			return application.Configuration{}, err
+		}
+	}
+
+	if name == "state import" {
+		// The bundle decides the IAAS, so it is unpacked before anything is built for it.
+		if len(remainingArgs) < 3 {
+			return application.Configuration{}, errors.New("bbl state import requires the path to a bundle")
+		}
+		_, err = c.importer.Import(remainingArgs[2])
 		if err != nil {
 			return application.Configuration{}, err
 		}
@@ -139,7 +187,38 @@ func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsL
 
 	state, err := c.stateBootstrap.GetState(globalFlags.StateDir)
 	if err != nil {
-		return application.Configuration{}, err
+		// bbl state rollback has to be able to replace a corrupted state file.
+		var corrupt storage.CorruptStateError
+		if command != "state" || !errors.As(err, &corrupt) {
+			return application.Configuration{}, err
+		}
+		state, err = storage.State{}, nil
+	}
+
+	if !locksState(remainingArgs) {
+		// Another bbl is mutating the state, so leave migrating and saving it to that process.
+		locked, err := c.locker.IsLocked()
+		if err != nil {
+			return application.Configuration{}, err
+		}
+		if locked {
+			return c.configuration(globalFlags, remainingArgs, command, state, remoteStateVersion)
+		}
+	}
+
+	if name == "state migrate" {
+		return c.configuration(globalFlags, remainingArgs, command, state, remoteStateVersion)
+	}
+
+	if globalFlags.NoAutoMigrate {
+		steps, err := c.migrator.Plan(state)
+		if err != nil {
+			return application.Configuration{}, err
+		}
+		if len(steps) > 0 {
+			c.logger.Println("the bbl state directory was written by an older bbl, run `bbl state migrate` to update it")
+		}
+		return c.configuration(globalFlags, remainingArgs, command, state, remoteStateVersion)
 	}
 
 	state, err = c.migrator.Migrate(state)
@@ -147,12 +226,43 @@ func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsL
 		return application.Configuration{}, err
 	}
 
-	state, err = c.merger.MergeGlobalFlagsToState(globalFlags, state)
+	return c.configuration(globalFlags, remainingArgs, command, state, remoteStateVersion)
+}
+
+func (c Config) configuration(globalFlags GlobalFlags, remainingArgs []string, command string, state storage.State, remoteStateVersion string) (application.Configuration, error) {
+	// bbl config validate reports the problems with the file itself.
+	var file File
+	if command != "config" {
+		var err error
+		file, err = LoadFile(c.fs, globalFlags)
+		if err != nil {
+			return application.Configuration{}, err
+		}
+	}
+
+	// The credential references in the file are only resolved for the
+	// commands that use them, so that a missing environment variable or
+	// file does not stop bbl print-env, for example.
+	if !reachesIAAS(CommandName(remainingArgs)) {
+		file.Credentials = nil
+	}
+
+	state, err := c.merger.MergeGlobalFlagsToState(globalFlags, file, state)
 	if err != nil {
 		return application.Configuration{}, err
 	}
 
-	if modifiesState(command) {
+	name := globalFlags.EnvID
+	if name == "" && os.Getenv("BBL_ENV_NAME") == "" {
+		name = file.Name
+	}
+
+	subcommandFlags := remainingArgs[1:]
+	if (command == "plan" || command == "up") && !hasLBFlags(subcommandFlags) {
+		subcommandFlags = append(subcommandFlags, file.LBArgs()...)
+	}
+
+	if reachesIAAS(CommandName(remainingArgs)) {
 		err = ValidateIAAS(state)
 		if err != nil {
 			return application.Configuration{}, err
@@ -163,18 +273,92 @@ func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsL
 		Global: application.GlobalConfiguration{
 			Debug:    globalFlags.Debug,
 			StateDir: globalFlags.StateDir,
-			Name:     globalFlags.EnvID,
+			Name:     name,
+			JSON:     globalFlags.JSON,
+
+			RemoteStateVersion: remoteStateVersion,
 		},
 		State:                state,
 		Command:              command,
-		SubcommandFlags:      remainingArgs[1:],
+		SubcommandFlags:      subcommandFlags,
 		ShowCommandHelp:      false,
-		CommandModifiesState: modifiesState(command),
+		CommandLocksState:    locksState(remainingArgs),
+		CommandModifiesState: modifiesState(remainingArgs),
+		CommandReachesIAAS:   reachesIAAS(CommandName(remainingArgs)),
 	}, nil
 }
 
-func modifiesState(command string) bool {
-	_, ok := map[string]struct{}{ // membership in this is untested
+// hasLBFlags reports whether the load balancer is given on the command
+// line, in which case the one in the config file is left out.
+func hasLBFlags(subcommandFlags []string) bool {
+	for _, flag := range subcommandFlags {
+		if strings.HasPrefix(flag, "--lb-") || strings.HasPrefix(flag, "-lb-") {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandName includes the subcommand of bbl state, since some of those
+// subcommands change the state and others only read it.
+func CommandName(remainingArgs []string) string {
+	if len(remainingArgs) > 1 && remainingArgs[0] == "state" {
+		return fmt.Sprintf("state %s", remainingArgs[1])
+	}
+	return remainingArgs[0]
+}
+
+// PreviewsPlan reports whether the command is bbl plan --diff, which renders
+// into a copy of the state dir instead of the state dir itself.
+func PreviewsPlan(remainingArgs []string) bool {
+	if len(remainingArgs) == 0 || remainingArgs[0] != "plan" {
+		return false
+	}
+
+	for _, arg := range remainingArgs[1:] {
+		switch arg {
+		case "--diff", "-diff", "--diff=true", "-diff=true":
+			return true
+		}
+	}
+	return false
+}
+
+// locksState reports whether the command holds the state directory lock
+// until it finishes. A --dry-run leaves the state alone, so it does not lock.
+func locksState(remainingArgs []string) bool {
+	return mutatingCommand(CommandName(remainingArgs)) && !isDryRun(remainingArgs)
+}
+
+// modifiesState reports whether the state is snapshotted before the
+// command and uploaded to --state-bucket after it.
+func modifiesState(remainingArgs []string) bool {
+	command := CommandName(remainingArgs)
+	return (mutatingCommand(command) || command == "leftovers" || command == "cleanup-leftovers") && !isDryRun(remainingArgs)
+}
+
+func mutatingCommand(command string) bool {
+	_, ok := map[string]struct{}{
+		"up":                {},
+		"down":              {},
+		"plan":              {},
+		"destroy":           {},
+		"rotate":            {},
+		"upgrade":           {},
+		"state import":      {},
+		"state encrypt":     {},
+		"state decrypt":     {},
+		"state rollback":    {},
+		"state migrate":     {},
+		"state accept-edit": {},
+	}[command]
+	return ok
+}
+
+// reachesIAAS lists the commands that call the IAAS, so they need
+// credentials and the clients built from them.
+func reachesIAAS(command string) bool {
+	_, ok := map[string]struct{}{
 		"up":                {},
 		"down":              {},
 		"plan":              {},
@@ -182,6 +366,21 @@ func modifiesState(command string) bool {
 		"leftovers":         {},
 		"cleanup-leftovers": {},
 		"rotate":            {},
+		"upgrade":           {},
+		"lbs":               {},
+		"drift":             {},
+		"status":            {},
+		"state import":      {},
 	}[command]
 	return ok
 }
+
+func isDryRun(remainingArgs []string) bool {
+	for _, arg := range remainingArgs[1:] {
+		switch arg {
+		case "--dry-run", "-dry-run", "--dry-run=true", "-dry-run=true":
+			return true
+		}
+	}
+	return false
+}
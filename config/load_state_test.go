@@ -29,6 +29,8 @@ var _ = Describe("LoadState", func() {
 		fakeStateMigrator  *fakes.StateMigrator
 		fakeFileIO         *fakes.FileIO
 		fakeDownloader     *fakes.Downloader
+		fakeStateLocker    *fakes.StateLocker
+		fakeStateBundler   *fakes.StateBundler
 		c                  config.Config
 	)
 
@@ -38,9 +40,11 @@ var _ = Describe("LoadState", func() {
 		fakeStateMigrator = &fakes.StateMigrator{}
 		fakeFileIO = &fakes.FileIO{}
 		fakeDownloader = &fakes.Downloader{}
+		fakeStateLocker = &fakes.StateLocker{}
+		fakeStateBundler = &fakes.StateBundler{}
 		os.Clearenv()
 
-		c = config.NewConfig(fakeStateBootstrap, fakeStateMigrator, config.NewMerger(fakeFileIO), fakeDownloader, fakeLogger, fakeFileIO)
+		c = config.NewConfig(fakeStateBootstrap, fakeStateMigrator, config.NewMerger(fakeFileIO), fakeDownloader, fakeStateLocker, fakeStateBundler, fakeLogger, fakeFileIO)
 	})
 
 	AfterEach(func() {
@@ -124,6 +128,34 @@ var _ = Describe("LoadState", func() {
 				Expect(appConfig.Global.StateDir).To(Equal(fullStateDirPath))
 			})
 
+			Context("when --json is passed in after a command", func() {
+				It("returns it as a global flag", func() {
+					args := []string{
+						"bbl", "lbs",
+						"--json",
+						"--iaas", "aws",
+						"--aws-access-key-id", "some-access-key-id",
+						"--aws-secret-access-key", "some-secret-access-key",
+						"--aws-region", "some-region",
+					}
+
+					appConfig, err := c.Bootstrap(bootstrapArgs(args))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(appConfig.Command).To(Equal("lbs"))
+					Expect(appConfig.Global.JSON).To(BeTrue())
+					Expect(appConfig.SubcommandFlags).To(BeEmpty())
+				})
+
+				It("returns it for the version command", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "version", "--json"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(appConfig.Command).To(Equal("version"))
+					Expect(appConfig.Global.JSON).To(BeTrue())
+				})
+			})
+
 			Context("when --help is passed in after a command", func() {
 				It("returns command help", func() {
 					args := []string{
@@ -197,6 +229,71 @@ var _ = Describe("LoadState", func() {
 					Expect(flags.AWSSecretAccessKey).To(Equal("some-aws-secret-access-key"))
 					Expect(flags.AWSAssumeRole).To(Equal("some-aws-assume-role"))
 				})
+
+				Context("when the command modifies state", func() {
+					BeforeEach(func() {
+						fakeDownloader.DownloadCall.Returns.Version = "some-version"
+					})
+
+					It("downloads the bbl state and remembers its version", func() {
+						appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+							"bbl", "up",
+							"--state-bucket", "some-state-bucket",
+							"--name", "some-name",
+							"--iaas", "aws",
+							"--aws-access-key-id", "some-access-key-id",
+							"--aws-secret-access-key", "some-secret-access-key",
+							"--aws-region", "some-region",
+						}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeDownloader.DownloadCall.CallCount).To(Equal(1))
+						Expect(appConfig.Global.RemoteStateVersion).To(Equal("some-version"))
+					})
+
+					Context("when the lock is held by another bbl", func() {
+						BeforeEach(func() {
+							fakeStateLocker.LockCall.Returns.Error = errors.New("locked")
+						})
+
+						It("fails without downloading the bbl state", func() {
+							_, err := c.Bootstrap(bootstrapArgs([]string{
+								"bbl", "up",
+								"--state-bucket", "some-state-bucket",
+								"--iaas", "aws",
+							}))
+							Expect(err).To(MatchError("locked"))
+
+							Expect(fakeDownloader.DownloadCall.CallCount).To(Equal(0))
+						})
+					})
+				})
+
+				Context("when downloading the bbl state fails", func() {
+					BeforeEach(func() {
+						fakeDownloader.DownloadCall.Returns.Error = errors.New("failed to download")
+					})
+
+					It("returns the error", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{
+							"bbl", "print-env",
+							"--state-bucket", "some-state-bucket",
+						}))
+						Expect(err).To(MatchError("failed to download"))
+					})
+
+					It("releases the lock of a mutating command", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{
+							"bbl", "up",
+							"--state-bucket", "some-state-bucket",
+							"--iaas", "aws",
+						}))
+						Expect(err).To(MatchError("failed to download"))
+
+						Expect(fakeStateLocker.LockCall.CallCount).To(Equal(1))
+						Expect(fakeStateLocker.UnlockCall.CallCount).To(Equal(1))
+					})
+				})
 			})
 		})
 
@@ -314,6 +411,24 @@ var _ = Describe("LoadState", func() {
 				})
 			})
 
+			Context("when the state file is corrupted", func() {
+				BeforeEach(func() {
+					fakeStateBootstrap.GetStateCall.Returns.Error = storage.CorruptStateError{Reason: "its checksum does not match"}
+				})
+
+				It("returns an error", func() {
+					_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "print-env"}))
+					Expect(err).To(MatchError(ContainSubstring("bbl-state.json is corrupted")))
+				})
+
+				It("still lets bbl state replace it", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "state", "rollback", "some-id"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(appConfig.Command).To(Equal("state"))
+				})
+			})
+
 			Context("when migrating the state fails", func() {
 				BeforeEach(func() {
 					fakeStateMigrator.MigrateCall.Returns.Error = errors.New("coconut")
@@ -324,6 +439,272 @@ var _ = Describe("LoadState", func() {
 				})
 			})
 
+			Context("when the command mutates state", func() {
+				It("locks the state dir", func() {
+					_, err := c.Bootstrap(bootstrapArgs([]string{
+						"bbl", "plan",
+						"--aws-access-key-id", "some-access-key-id",
+						"--aws-secret-access-key", "some-secret-access-key",
+						"--aws-region", "some-region",
+					}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateLocker.LockCall.CallCount).To(Equal(1))
+					Expect(fakeStateLocker.LockCall.Receives.Command).To(Equal("plan"))
+					Expect(fakeStateLocker.UnlockCall.CallCount).To(Equal(0))
+				})
+
+				Context("when the lock is held by another bbl", func() {
+					BeforeEach(func() {
+						fakeStateLocker.LockCall.Returns.Error = errors.New("locked")
+					})
+
+					It("returns an error without migrating the state", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "plan"}))
+						Expect(err).To(MatchError("locked"))
+
+						Expect(fakeStateMigrator.MigrateCall.CallCount).To(Equal(0))
+					})
+				})
+
+				Context("when bootstrapping fails after locking", func() {
+					BeforeEach(func() {
+						fakeStateMigrator.MigrateCall.Returns.Error = errors.New("coconut")
+					})
+
+					It("releases the lock", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "plan"}))
+						Expect(err).To(MatchError("coconut"))
+
+						Expect(fakeStateLocker.UnlockCall.CallCount).To(Equal(1))
+					})
+				})
+			})
+
+			Context("when running bbl state migrate", func() {
+				It("leaves migrating the state to the command", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "state", "migrate", "--dry-run"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateMigrator.MigrateCall.CallCount).To(Equal(0))
+					Expect(appConfig.State).To(Equal(gotState))
+					Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"migrate", "--dry-run"}))
+				})
+
+				It("locks the state dir unless it is a dry run", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "state", "migrate"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateLocker.LockCall.Receives.Command).To(Equal("state migrate"))
+					Expect(appConfig.CommandLocksState).To(BeTrue())
+					Expect(appConfig.CommandModifiesState).To(BeTrue())
+					Expect(appConfig.CommandReachesIAAS).To(BeFalse())
+				})
+
+				Context("with --dry-run", func() {
+					It("neither locks nor modifies the state", func() {
+						appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "state", "migrate", "--dry-run"}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeStateLocker.LockCall.CallCount).To(Equal(0))
+						Expect(appConfig.CommandLocksState).To(BeFalse())
+						Expect(appConfig.CommandModifiesState).To(BeFalse())
+					})
+				})
+			})
+
+			Context("when running bbl upgrade", func() {
+				It("locks and modifies the state", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+						"bbl", "upgrade",
+						"--aws-access-key-id", "some-access-key-id",
+						"--aws-secret-access-key", "some-secret-access-key",
+						"--aws-region", "some-region",
+					}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateLocker.LockCall.Receives.Command).To(Equal("upgrade"))
+					Expect(appConfig.CommandLocksState).To(BeTrue())
+					Expect(appConfig.CommandModifiesState).To(BeTrue())
+				})
+
+				Context("with --dry-run", func() {
+					It("neither locks nor modifies the state", func() {
+						appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+							"bbl", "upgrade", "--dry-run",
+							"--aws-access-key-id", "some-access-key-id",
+							"--aws-secret-access-key", "some-secret-access-key",
+							"--aws-region", "some-region",
+						}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeStateLocker.LockCall.CallCount).To(Equal(0))
+						Expect(appConfig.CommandLocksState).To(BeFalse())
+						Expect(appConfig.CommandModifiesState).To(BeFalse())
+						Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"--dry-run"}))
+					})
+				})
+			})
+
+			DescribeTable("commands that run terraform plan without changing the state",
+				func(command string) {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+						"bbl", command,
+						"--aws-profile", "some-profile",
+						"--aws-region", "some-region",
+					}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateLocker.LockCall.CallCount).To(Equal(0))
+					Expect(appConfig.CommandLocksState).To(BeFalse())
+					Expect(appConfig.CommandModifiesState).To(BeFalse())
+					Expect(appConfig.CommandReachesIAAS).To(BeTrue())
+					Expect(appConfig.State.AWS.Profile).To(Equal("some-profile"))
+					Expect(appConfig.State.AWS.Region).To(Equal("some-region"))
+				},
+				Entry("drift", "drift"),
+				Entry("status", "status"),
+			)
+
+			DescribeTable("bbl state subcommands that change the state dir",
+				func(subcommand string) {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "state", subcommand, "some-arg"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateLocker.LockCall.Receives.Command).To(Equal("state " + subcommand))
+					Expect(appConfig.CommandLocksState).To(BeTrue())
+					Expect(appConfig.CommandModifiesState).To(BeTrue())
+				},
+				Entry("encrypt", "encrypt"),
+				Entry("decrypt", "decrypt"),
+				Entry("rollback", "rollback"),
+				Entry("accept-edit", "accept-edit"),
+			)
+
+			Context("when a read-only command runs", func() {
+				It("neither locks nor modifies the state", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "state", "history"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateLocker.LockCall.CallCount).To(Equal(0))
+					Expect(appConfig.CommandLocksState).To(BeFalse())
+					Expect(appConfig.CommandModifiesState).To(BeFalse())
+					Expect(appConfig.CommandReachesIAAS).To(BeFalse())
+				})
+			})
+
+			Context("when automatic migration is turned off", func() {
+				It("does not migrate the state", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "print-env", "--no-auto-migrate"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateMigrator.MigrateCall.CallCount).To(Equal(0))
+					Expect(fakeLogger.PrintlnCall.CallCount).To(Equal(0))
+					Expect(appConfig.State).To(Equal(gotState))
+				})
+
+				Context("when the state needs migrating", func() {
+					BeforeEach(func() {
+						fakeStateMigrator.PlanCall.Returns.Steps = []storage.MigrationStep{{Name: "MigrateTerraformState"}}
+					})
+
+					It("warns that the state is out of date", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "print-env", "--no-auto-migrate"}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeStateMigrator.PlanCall.Receives.State).To(Equal(gotState))
+						Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("the bbl state directory was written by an older bbl, run `bbl state migrate` to update it"))
+					})
+				})
+
+				Context("when planning the migration fails", func() {
+					BeforeEach(func() {
+						fakeStateMigrator.PlanCall.Returns.Error = errors.New("coconut")
+					})
+
+					It("returns an error", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "print-env", "--no-auto-migrate"}))
+						Expect(err).To(MatchError("coconut"))
+					})
+				})
+			})
+
+			Context("when importing a bundle", func() {
+				It("unpacks the bundle under the lock before reading the state", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+						"bbl", "state", "import", "some-bundle.tgz",
+						"--aws-access-key-id", "some-access-key-id",
+						"--aws-secret-access-key", "some-secret-access-key",
+						"--aws-region", "some-region",
+					}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateLocker.LockCall.Receives.Command).To(Equal("state import"))
+					Expect(fakeStateBundler.ImportCall.Receives.Path).To(Equal("some-bundle.tgz"))
+					Expect(fakeStateBootstrap.GetStateCall.CallCount).To(Equal(1))
+					Expect(appConfig.CommandModifiesState).To(BeTrue())
+				})
+
+				Context("when the bundle path is missing", func() {
+					It("returns an error and releases the lock", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "state", "import"}))
+						Expect(err).To(MatchError("bbl state import requires the path to a bundle"))
+
+						Expect(fakeStateBundler.ImportCall.CallCount).To(Equal(0))
+						Expect(fakeStateLocker.UnlockCall.CallCount).To(Equal(1))
+					})
+				})
+
+				Context("when importing fails", func() {
+					BeforeEach(func() {
+						fakeStateBundler.ImportCall.Returns.Error = errors.New("coconut")
+					})
+
+					It("returns an error without reading the state", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "state", "import", "some-bundle.tgz"}))
+						Expect(err).To(MatchError("coconut"))
+
+						Expect(fakeStateBootstrap.GetStateCall.CallCount).To(Equal(0))
+						Expect(fakeStateLocker.UnlockCall.CallCount).To(Equal(1))
+					})
+				})
+			})
+
+			Context("when a read-only command runs while the state dir is locked", func() {
+				BeforeEach(func() {
+					fakeStateLocker.IsLockedCall.Returns.Locked = true
+				})
+
+				It("reads the state without locking or migrating it", func() {
+					appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "director-address"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeStateLocker.LockCall.CallCount).To(Equal(0))
+					Expect(fakeStateMigrator.MigrateCall.CallCount).To(Equal(0))
+					Expect(appConfig.State).To(Equal(gotState))
+				})
+			})
+
+			Context("when the log flags are passed", func() {
+				It("parses them after the command", func() {
+					globals, _, err := config.ParseArgs([]string{"bbl", "up", "--log-level", "warn", "--log-format", "json", "--log-file"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(globals.LogLevel).To(Equal("warn"))
+					Expect(globals.LogFormat).To(Equal("json"))
+					Expect(globals.LogFile).To(BeTrue())
+				})
+
+				It("defaults to text at the info level", func() {
+					globals, _, err := config.ParseArgs([]string{"bbl", "up"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(globals.LogLevel).To(Equal("info"))
+					Expect(globals.LogFormat).To(Equal("text"))
+					Expect(globals.LogFile).To(BeFalse())
+				})
+			})
+
 			Context("when state-dir flag is passed without an argument", func() {
 				It("returns an error", func() {
 					_, _, err := config.ParseArgs([]string{"bbl", "print-env", "--state-dir", "--help"})
@@ -739,6 +1120,76 @@ var _ = Describe("LoadState", func() {
 					})
 				})
 
+				Context("when configuration is passed in by a config file", func() {
+					BeforeEach(func() {
+						os.Setenv("SOME_SECRET_KEY", "some-secret-key")
+						fakeFileIO.ReadFileCall.Fake = func(path string) ([]byte, error) {
+							switch path {
+							case "/some/bbl.yml":
+								return []byte(`
+name: some-file-env-id
+iaas: aws
+region: some-region
+credentials:
+  aws-access-key-id: {file: access-key}
+  aws-secret-access-key: {env: SOME_SECRET_KEY}
+lb:
+  type: concourse
+`), nil
+							case "/some/access-key":
+								return []byte("some-access-key\n"), nil
+							}
+							return nil, os.ErrNotExist
+						}
+					})
+
+					It("fills in the configuration that the flags leave out", func() {
+						appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+							"bbl", "--config", "/some/bbl.yml", "--aws-region", "some-other-region", "up",
+						}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(appConfig.State.IAAS).To(Equal("aws"))
+						Expect(appConfig.State.AWS.AccessKeyID).To(Equal("some-access-key"))
+						Expect(appConfig.State.AWS.SecretAccessKey).To(Equal("some-secret-key"))
+						Expect(appConfig.State.AWS.Region).To(Equal("some-other-region"))
+						Expect(appConfig.Global.Name).To(Equal("some-file-env-id"))
+						Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"--lb-type", "concourse"}))
+					})
+
+					DescribeTable("only resolves the credentials for the commands that reach the IAAS",
+						func(command string, resolves bool) {
+							os.Unsetenv("SOME_SECRET_KEY") //nolint:errcheck
+
+							appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+								"bbl", "--config", "/some/bbl.yml", command,
+							}))
+							if resolves {
+								Expect(err).To(MatchError("credential aws-secret-access-key: environment variable SOME_SECRET_KEY is not set"))
+								return
+							}
+
+							Expect(err).NotTo(HaveOccurred())
+							Expect(appConfig.State.IAAS).To(Equal("aws"))
+							Expect(appConfig.State.AWS.AccessKeyID).To(BeEmpty())
+						},
+						Entry("print-env", "print-env", false),
+						Entry("director-address", "director-address", false),
+						Entry("up", "up", true),
+						Entry("lbs", "lbs", true),
+					)
+
+					It("prefers the load balancer and name on the command line", func() {
+						appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+							"bbl", "--config", "/some/bbl.yml", "up", "--name", "some-env-id", "--lb-type", "cf",
+						}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(appConfig.Global.Name).To(Equal("some-env-id"))
+						Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"--lb-type", "cf"}))
+					})
+				})
+
 				Context("when configuration is passed in by env vars", func() {
 					var args []string
 
@@ -828,6 +1279,12 @@ var _ = Describe("LoadState", func() {
 
 				fakeFileIO.TempFileCall.Returns.File = tempFile
 				fakeFileIO.ReadFileCall.Returns.Contents = []byte(serviceAccountKey)
+				fakeFileIO.ReadFileCall.Fake = func(filename string) ([]byte, error) {
+					if filepath.Base(filename) == "bbl.yml" {
+						return nil, os.ErrNotExist
+					}
+					return fakeFileIO.ReadFileCall.Returns.Contents, fakeFileIO.ReadFileCall.Returns.Error
+				}
 			})
 
 			Context("when a previous state does not exist", func() {
@@ -1335,7 +1792,7 @@ var _ = Describe("LoadState", func() {
 			var fakeMerger *fakes.Merger
 			BeforeEach(func() {
 				fakeMerger = &fakes.Merger{}
-				c = config.NewConfig(fakeStateBootstrap, fakeStateMigrator, fakeMerger, fakeDownloader, fakeLogger, fakeFileIO)
+				c = config.NewConfig(fakeStateBootstrap, fakeStateMigrator, fakeMerger, fakeDownloader, fakeStateLocker, fakeStateBundler, fakeLogger, fakeFileIO)
 
 				fakeMerger.MergeCall.Returns.State = storage.State{
 					IAAS:  "gcp",
@@ -1449,7 +1906,66 @@ var _ = Describe("LoadState", func() {
 					},
 				},
 				"Missing --cloudstack-endpoint. To see all required credentials run `bbl plan --help`."),
+			Entry("when the GCP credential source is unknown",
+				storage.State{
+					IAAS: "gcp",
+					GCP: storage.GCP{
+						CredentialSource: "banana",
+						Region:           "value",
+					},
+				},
+				"--gcp-credential-source must be application-default, not banana"),
+			Entry("when the Azure credential source is unknown",
+				storage.State{
+					IAAS: "azure",
+					Azure: storage.Azure{
+						CredentialSource: "banana",
+						Region:           "value",
+						TenantID:         "value",
+						SubscriptionID:   "value",
+					},
+				},
+				"--azure-credential-source must be cli or managed-identity, not banana"),
 		)
 
+		DescribeTable("when credentials are resolved from a profile or credential source",
+			func(state storage.State) {
+				Expect(config.ValidateIAAS(state)).To(Succeed())
+			},
+			Entry("AWS profile",
+				storage.State{
+					IAAS: "aws",
+					AWS:  storage.AWS{Profile: "some-profile", Region: "value"},
+				}),
+			Entry("GCP application default credentials",
+				storage.State{
+					IAAS: "gcp",
+					GCP:  storage.GCP{CredentialSource: "application-default", Region: "value"},
+				}),
+			Entry("Azure CLI",
+				storage.State{
+					IAAS:  "azure",
+					Azure: storage.Azure{CredentialSource: "cli", Region: "value", TenantID: "value", SubscriptionID: "value"},
+				}),
+			Entry("Azure managed identity",
+				storage.State{
+					IAAS:  "azure",
+					Azure: storage.Azure{CredentialSource: "managed-identity", Region: "value", TenantID: "value", SubscriptionID: "value"},
+				}),
+		)
+	})
+
+	Describe("PreviewsPlan", func() {
+		DescribeTable("detects bbl plan --diff",
+			func(args []string, previews bool) {
+				Expect(config.PreviewsPlan(args)).To(Equal(previews))
+			},
+			Entry("plan --diff", []string{"plan", "--name", "some-name", "--diff"}, true),
+			Entry("plan -diff=true", []string{"plan", "-diff=true"}, true),
+			Entry("plan", []string{"plan", "--name", "some-name"}, false),
+			Entry("plan --diff=false", []string{"plan", "--diff=false"}, false),
+			Entry("up --diff", []string{"up", "--diff"}, false),
+			Entry("no command", []string{}, false),
+		)
 	})
 })
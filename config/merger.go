@@ -17,7 +17,15 @@ func NewMerger(fs fs) Merger {
 	return Merger{fs: fs}
 }
 
-func (m Merger) MergeGlobalFlagsToState(globalFlags GlobalFlags, state storage.State) (storage.State, error) {
+// MergeGlobalFlagsToState copies the IaaS flags to state. A flag set on the
+// command line takes precedence over its environment variable, which takes
+// precedence over the config file.
+func (m Merger) MergeGlobalFlagsToState(globalFlags GlobalFlags, file File, state storage.State) (storage.State, error) {
+	globalFlags, err := file.applyTo(m.fs, globalFlags)
+	if err != nil {
+		return storage.State{}, err
+	}
+
 	if globalFlags.IAAS != "" {
 		if state.IAAS != "" && globalFlags.IAAS != state.IAAS {
 			return storage.State{}, fmt.Errorf("The iaas type cannot be changed for an existing environment. The current iaas type is %s.", state.IAAS) //nolint:staticcheck
@@ -43,6 +51,21 @@ func (m Merger) MergeGlobalFlagsToState(globalFlags GlobalFlags, state storage.S
 	return state, nil
 }
 
+// credentialSource is the profile or credential source that is kept in the
+// state. Passing a static credential instead switches the environment back to
+// static credentials.
+func credentialSource(sourceFlag, source, staticFlag, static, current string) (string, error) {
+	switch {
+	case source != "" && static != "":
+		return "", fmt.Errorf("%s and %s cannot both be set", sourceFlag, staticFlag)
+	case source != "":
+		return source, nil
+	case static != "":
+		return "", nil
+	}
+	return current, nil
+}
+
 func copyFlagToState(source string, sink *string) {
 	if source != "" {
 		*sink = source
@@ -114,6 +137,12 @@ func (m Merger) updateVSphereState(globalFlags GlobalFlags, state storage.State)
 }
 
 func (m Merger) updateAWSState(globalFlags GlobalFlags, state storage.State) (storage.State, error) {
+	profile, err := credentialSource("--aws-profile", globalFlags.AWSProfile, "--aws-access-key-id", globalFlags.AWSAccessKeyID, state.AWS.Profile)
+	if err != nil {
+		return storage.State{}, err
+	}
+	state.AWS.Profile = profile
+
 	copyFlagToState(globalFlags.AWSAccessKeyID, &state.AWS.AccessKeyID)
 	copyFlagToState(globalFlags.AWSSecretAccessKey, &state.AWS.SecretAccessKey)
 	copyFlagToState(globalFlags.AWSAssumeRole, &state.AWS.AssumeRoleArn)
@@ -129,6 +158,12 @@ func (m Merger) updateAWSState(globalFlags GlobalFlags, state storage.State) (st
 }
 
 func (m Merger) updateAzureState(globalFlags GlobalFlags, state storage.State) (storage.State, error) {
+	source, err := credentialSource("--azure-credential-source", globalFlags.AzureCredentialSource, "--azure-client-secret", globalFlags.AzureClientSecret, state.Azure.CredentialSource)
+	if err != nil {
+		return storage.State{}, err
+	}
+	state.Azure.CredentialSource = source
+
 	copyFlagToState(globalFlags.AzureClientID, &state.Azure.ClientID)
 	copyFlagToState(globalFlags.AzureClientSecret, &state.Azure.ClientSecret)
 	copyFlagToState(globalFlags.AzureRegion, &state.Azure.Region)
@@ -139,6 +174,12 @@ func (m Merger) updateAzureState(globalFlags GlobalFlags, state storage.State) (
 }
 
 func (m Merger) updateGCPState(globalFlags GlobalFlags, state storage.State) (storage.State, error) {
+	source, err := credentialSource("--gcp-credential-source", globalFlags.GCPCredentialSource, "--gcp-service-account-key", globalFlags.GCPServiceAccountKey, state.GCP.CredentialSource)
+	if err != nil {
+		return storage.State{}, err
+	}
+	state.GCP.CredentialSource = source
+
 	if globalFlags.GCPServiceAccountKey != "" {
 		path, key, err := m.getGCPServiceAccountKey(globalFlags.GCPServiceAccountKey)
 		if err != nil {
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const stateEncryptionKeyFilePrefix = "file:"
+
+// GetStateEncryptionKey returns the passphrase given with
+// --state-encryption-key. A value of file:<path> reads the passphrase from
+// that file so it does not have to appear in shell history. Any other value
+// is the passphrase itself, even when a file of that name exists.
+func GetStateEncryptionKey(key string) (string, error) {
+	path, isFile := strings.CutPrefix(key, stateEncryptionKeyFilePrefix)
+	if !isFile {
+		return key, nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Reading state encryption key: %s", err) //nolint:staticcheck
+	}
+
+	passphrase := strings.TrimSpace(string(contents))
+	if passphrase == "" {
+		return "", fmt.Errorf("State encryption key file %s is empty", path) //nolint:staticcheck
+	}
+
+	return passphrase, nil
+}
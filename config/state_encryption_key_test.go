@@ -0,0 +1,77 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-bootloader/config"
+)
+
+var _ = Describe("GetStateEncryptionKey", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir) //nolint:errcheck
+	})
+
+	It("returns an empty key when none is provided", func() {
+		key, err := config.GetStateEncryptionKey("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key).To(Equal(""))
+	})
+
+	It("returns the flag value as the passphrase", func() {
+		key, err := config.GetStateEncryptionKey("some-passphrase")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key).To(Equal("some-passphrase"))
+	})
+
+	It("does not read a file that happens to have the passphrase as its path", func() {
+		keyPath := filepath.Join(tempDir, "key")
+		err := os.WriteFile(keyPath, []byte("some-other-passphrase"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		key, err := config.GetStateEncryptionKey(keyPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key).To(Equal(keyPath))
+	})
+
+	Context("when the flag value is file: and a path to a key file", func() {
+		It("returns the trimmed contents of the file", func() {
+			keyPath := filepath.Join(tempDir, "key")
+			err := os.WriteFile(keyPath, []byte("  some-passphrase\n"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+
+			key, err := config.GetStateEncryptionKey("file:" + keyPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal("some-passphrase"))
+		})
+
+		Context("when the file does not exist", func() {
+			It("returns an error", func() {
+				_, err := config.GetStateEncryptionKey("file:" + filepath.Join(tempDir, "missing"))
+				Expect(err).To(MatchError(ContainSubstring("Reading state encryption key")))
+			})
+		})
+
+		Context("when the file is empty", func() {
+			It("returns an error", func() {
+				keyPath := filepath.Join(tempDir, "key")
+				err := os.WriteFile(keyPath, []byte("\n"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = config.GetStateEncryptionKey("file:" + keyPath)
+				Expect(err).To(MatchError(ContainSubstring("is empty")))
+			})
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+)
+
+type Uploader struct {
+	provider backends.Provider
+}
+
+func NewUploader(provider backends.Provider) Uploader {
+	return Uploader{provider: provider}
+}
+
+// UploadState writes the state dir back to the remote state bucket, provided
+// nobody else has updated it since version was downloaded.
+func (u Uploader) UploadState(flags GlobalFlags, version string) error {
+	kind, config, err := backendConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	backend, err := u.provider.Client(kind)
+	if err != nil {
+		return err
+	}
+	config.Version = version
+
+	err = backend.PutState(config, flags.EnvID)
+	if err != nil {
+		return fmt.Errorf("Uploading state to %s: %s", flags.StateBucket, err) //nolint:staticcheck
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/backends"
+	"github.com/cloudfoundry/bosh-bootloader/config"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Uploader", func() {
+	var (
+		provider *fakes.BackendProvider
+		backend  *fakes.Backend
+		uploader config.Uploader
+		flags    config.GlobalFlags
+	)
+
+	BeforeEach(func() {
+		backend = &fakes.Backend{}
+		provider = &fakes.BackendProvider{}
+		provider.ClientCall.Returns.Backend = backend
+
+		uploader = config.NewUploader(provider)
+
+		flags = config.GlobalFlags{
+			IAAS:               "aws",
+			EnvID:              "some-env",
+			StateDir:           "/some/state-dir",
+			StateBucket:        "some-bucket",
+			AWSRegion:          "some-region",
+			AWSAccessKeyID:     "some-access-key-id",
+			AWSSecretAccessKey: "some-secret-access-key",
+		}
+	})
+
+	Describe("UploadState", func() {
+		It("uploads the state dir on top of the version that was downloaded", func() {
+			err := uploader.UploadState(flags, "some-version")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(provider.ClientCall.Receives.Kind).To(Equal("s3"))
+			Expect(backend.PutStateCall.Receives.Name).To(Equal("some-env"))
+			Expect(backend.PutStateCall.Receives.Config).To(Equal(backends.Config{
+				Dest:               "/some/state-dir",
+				Bucket:             "some-bucket",
+				Region:             "some-region",
+				AWSAccessKeyID:     "some-access-key-id",
+				AWSSecretAccessKey: "some-secret-access-key",
+				Version:            "some-version",
+			}))
+		})
+
+		Context("when there was no remote state", func() {
+			It("only creates it", func() {
+				err := uploader.UploadState(flags, "")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(backend.PutStateCall.Receives.Config.Version).To(BeEmpty())
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state bucket cannot be used", func() {
+				flags.AWSAssumeRole = "some-role"
+
+				err := uploader.UploadState(flags, "some-version")
+				Expect(err).To(MatchError("Assume role not supported when using an AWS state bucket"))
+				Expect(backend.PutStateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the backend is unsupported", func() {
+				provider.ClientCall.Returns.Error = errors.New("banana")
+
+				err := uploader.UploadState(flags, "some-version")
+				Expect(err).To(MatchError("banana"))
+			})
+
+			It("returns an error when the remote state was changed by another bbl", func() {
+				backend.PutStateCall.Returns.Error = backends.ErrStateConflict
+
+				err := uploader.UploadState(flags, "some-version")
+				Expect(err).To(MatchError(ContainSubstring("Uploading state to some-bucket: the remote state was changed by another bbl run")))
+			})
+		})
+	})
+})
@@ -35,12 +35,17 @@ func ValidateIAAS(state storage.State) error {
 
 const CRED_ERROR = "Missing %s. To see all required credentials run `bbl plan --help`."
 
+// AWS, Azure and GCP credentials can instead be resolved from a profile or
+// credential source when bbl runs, so the static keys are only required
+// without one.
 func aws(state storage.AWS) error {
-	if state.AccessKeyID == "" {
-		return fmt.Errorf(CRED_ERROR, "--aws-access-key-id") //nolint:staticcheck
-	}
-	if state.SecretAccessKey == "" {
-		return fmt.Errorf(CRED_ERROR, "--aws-secret-access-key") //nolint:staticcheck
+	if state.Profile == "" {
+		if state.AccessKeyID == "" {
+			return fmt.Errorf(CRED_ERROR, "--aws-access-key-id") //nolint:staticcheck
+		}
+		if state.SecretAccessKey == "" {
+			return fmt.Errorf(CRED_ERROR, "--aws-secret-access-key") //nolint:staticcheck
+		}
 	}
 	if state.Region == "" {
 		return fmt.Errorf(CRED_ERROR, "--aws-region") //nolint:staticcheck
@@ -49,11 +54,17 @@ func aws(state storage.AWS) error {
 }
 
 func azure(state storage.Azure) error {
-	if state.ClientID == "" {
-		return fmt.Errorf(CRED_ERROR, "--azure-client-id") //nolint:staticcheck
-	}
-	if state.ClientSecret == "" {
-		return fmt.Errorf(CRED_ERROR, "--azure-client-secret") //nolint:staticcheck
+	switch state.CredentialSource {
+	case "":
+		if state.ClientID == "" {
+			return fmt.Errorf(CRED_ERROR, "--azure-client-id") //nolint:staticcheck
+		}
+		if state.ClientSecret == "" {
+			return fmt.Errorf(CRED_ERROR, "--azure-client-secret") //nolint:staticcheck
+		}
+	case "cli", "managed-identity":
+	default:
+		return fmt.Errorf("--azure-credential-source must be cli or managed-identity, not %s", state.CredentialSource)
 	}
 	if state.Region == "" {
 		return fmt.Errorf(CRED_ERROR, "--azure-region") //nolint:staticcheck
@@ -68,8 +79,14 @@ func azure(state storage.Azure) error {
 }
 
 func gcp(state storage.GCP) error {
-	if state.ServiceAccountKey == "" {
-		return fmt.Errorf(CRED_ERROR, "--gcp-service-account-key") //nolint:staticcheck
+	switch state.CredentialSource {
+	case "":
+		if state.ServiceAccountKey == "" {
+			return fmt.Errorf(CRED_ERROR, "--gcp-service-account-key") //nolint:staticcheck
+		}
+	case "application-default":
+	default:
+		return fmt.Errorf("--gcp-credential-source must be application-default, not %s", state.CredentialSource)
 	}
 	if state.Region == "" {
 		return fmt.Errorf(CRED_ERROR, "--gcp-region") //nolint:staticcheck
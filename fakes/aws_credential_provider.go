@@ -0,0 +1,22 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type AWSCredentialProvider struct {
+	ResolveCall struct {
+		CallCount int
+		Receives  struct {
+			AWS storage.AWS
+		}
+		Returns struct {
+			AWS   storage.AWS
+			Error error
+		}
+	}
+}
+
+func (p *AWSCredentialProvider) Resolve(aws storage.AWS) (storage.AWS, error) {
+	p.ResolveCall.CallCount++
+	p.ResolveCall.Receives.AWS = aws
+	return p.ResolveCall.Returns.AWS, p.ResolveCall.Returns.Error
+}
@@ -0,0 +1,22 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type AzureCredentialProvider struct {
+	ResolveCall struct {
+		CallCount int
+		Receives  struct {
+			Azure storage.Azure
+		}
+		Returns struct {
+			Azure storage.Azure
+			Error error
+		}
+	}
+}
+
+func (p *AzureCredentialProvider) Resolve(azure storage.Azure) (storage.Azure, error) {
+	p.ResolveCall.CallCount++
+	p.ResolveCall.Receives.Azure = azure
+	return p.ResolveCall.Returns.Azure, p.ResolveCall.Returns.Error
+}
@@ -0,0 +1,106 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/backends"
+
+type BackendProvider struct {
+	ClientCall struct {
+		CallCount int
+		Receives  struct {
+			Kind string
+		}
+		Returns struct {
+			Backend backends.Backend
+			Error   error
+		}
+	}
+}
+
+func (p *BackendProvider) Client(kind string) (backends.Backend, error) {
+	p.ClientCall.CallCount++
+	p.ClientCall.Receives.Kind = kind
+
+	return p.ClientCall.Returns.Backend, p.ClientCall.Returns.Error
+}
+
+type Backend struct {
+	GetStateCall struct {
+		CallCount int
+		Receives  struct {
+			Config backends.Config
+			Name   string
+		}
+		Returns struct {
+			Version string
+			Error   error
+		}
+	}
+
+	PutStateCall struct {
+		CallCount int
+		Receives  struct {
+			Config backends.Config
+			Name   string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	PutBackupCall struct {
+		CallCount int
+		Receives  struct {
+			Config backends.Config
+			Name   string
+			Dir    string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	GetBackupCall struct {
+		CallCount int
+		Receives  struct {
+			Config backends.Config
+			Name   string
+			Dir    string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (b *Backend) GetState(config backends.Config, name string) (string, error) {
+	b.GetStateCall.CallCount++
+	b.GetStateCall.Receives.Config = config
+	b.GetStateCall.Receives.Name = name
+
+	return b.GetStateCall.Returns.Version, b.GetStateCall.Returns.Error
+}
+
+func (b *Backend) PutState(config backends.Config, name string) error {
+	b.PutStateCall.CallCount++
+	b.PutStateCall.Receives.Config = config
+	b.PutStateCall.Receives.Name = name
+
+	return b.PutStateCall.Returns.Error
+}
+
+func (b *Backend) PutBackup(config backends.Config, name, dir string) error {
+	b.PutBackupCall.CallCount++
+	b.PutBackupCall.Receives.Config = config
+	b.PutBackupCall.Receives.Name = name
+	b.PutBackupCall.Receives.Dir = dir
+
+	return b.PutBackupCall.Returns.Error
+}
+
+func (b *Backend) GetBackup(config backends.Config, name, dir string) error {
+	b.GetBackupCall.CallCount++
+	b.GetBackupCall.Receives.Config = config
+	b.GetBackupCall.Receives.Name = name
+	b.GetBackupCall.Receives.Dir = dir
+
+	return b.GetBackupCall.Returns.Error
+}
@@ -0,0 +1,53 @@
+package fakes
+
+type BackupStore struct {
+	IsRemoteCall struct {
+		CallCount int
+		Returns   struct {
+			IsRemote bool
+		}
+	}
+	UploadCall struct {
+		CallCount int
+		Receives  struct {
+			Name string
+			Dir  string
+		}
+		Returns struct {
+			Location string
+			Error    error
+		}
+	}
+	DownloadCall struct {
+		CallCount int
+		Receives  struct {
+			Name string
+			Dir  string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (b *BackupStore) IsRemote() bool {
+	b.IsRemoteCall.CallCount++
+
+	return b.IsRemoteCall.Returns.IsRemote
+}
+
+func (b *BackupStore) Upload(name, dir string) (string, error) {
+	b.UploadCall.CallCount++
+	b.UploadCall.Receives.Name = name
+	b.UploadCall.Receives.Dir = dir
+
+	return b.UploadCall.Returns.Location, b.UploadCall.Returns.Error
+}
+
+func (b *BackupStore) Download(name, dir string) error {
+	b.DownloadCall.CallCount++
+	b.DownloadCall.Receives.Name = name
+	b.DownloadCall.Receives.Dir = dir
+
+	return b.DownloadCall.Returns.Error
+}
@@ -0,0 +1,29 @@
+package fakes
+
+type BBRCLI struct {
+	RunCall struct {
+		CallCount int
+		Stub      func(workingDirectory string)
+		Receives  struct {
+			Env              []string
+			WorkingDirectory string
+			Args             []string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (b *BBRCLI) Run(env []string, workingDirectory string, args []string) error {
+	b.RunCall.CallCount++
+	b.RunCall.Receives.Env = env
+	b.RunCall.Receives.WorkingDirectory = workingDirectory
+	b.RunCall.Receives.Args = args
+
+	if b.RunCall.Stub != nil {
+		b.RunCall.Stub(workingDirectory)
+	}
+
+	return b.RunCall.Returns.Error
+}
@@ -66,6 +66,20 @@ type BOSHExecutor struct {
 		}
 	}
 
+	DiffDeploymentCall struct {
+		CallCount int
+		Receives  []DeploymentReceive
+		Returns   []DiffDeploymentReturn
+	}
+
+	WriteDeploymentCall struct {
+		CallCount int
+		Receives  []DeploymentReceive
+		Returns   struct {
+			Error error
+		}
+	}
+
 	PathCall struct {
 		CallCount int
 		Returns   struct {
@@ -82,6 +96,16 @@ type BOSHExecutor struct {
 	}
 }
 
+type DeploymentReceive struct {
+	Repo          string
+	DeploymentDir string
+}
+
+type DiffDeploymentReturn struct {
+	Changes []bosh.DeploymentChange
+	Error   error
+}
+
 func (e *BOSHExecutor) WriteDeploymentVars(input bosh.DirInput, deploymentVars string) error {
 	e.WriteDeploymentVarsCall.CallCount++
 	e.WriteDeploymentVarsCall.Receives.DirInput = input
@@ -126,6 +150,31 @@ func (e *BOSHExecutor) PlanDirectorWithState(input bosh.DirInput, deploymentDir,
 	return e.PlanDirectorWithStateCall.Returns.Error
 }
 
+func (e *BOSHExecutor) DiffDeployment(repo, deploymentDir string) ([]bosh.DeploymentChange, error) {
+	e.DiffDeploymentCall.CallCount++
+	e.DiffDeploymentCall.Receives = append(e.DiffDeploymentCall.Receives, DeploymentReceive{
+		Repo:          repo,
+		DeploymentDir: deploymentDir,
+	})
+
+	if len(e.DiffDeploymentCall.Returns) < e.DiffDeploymentCall.CallCount {
+		return nil, nil
+	}
+
+	r := e.DiffDeploymentCall.Returns[e.DiffDeploymentCall.CallCount-1]
+	return r.Changes, r.Error
+}
+
+func (e *BOSHExecutor) WriteDeployment(repo, deploymentDir string) error {
+	e.WriteDeploymentCall.CallCount++
+	e.WriteDeploymentCall.Receives = append(e.WriteDeploymentCall.Receives, DeploymentReceive{
+		Repo:          repo,
+		DeploymentDir: deploymentDir,
+	})
+
+	return e.WriteDeploymentCall.Returns.Error
+}
+
 func (e *BOSHExecutor) Path() string {
 	e.PathCall.CallCount++
 	return e.PathCall.Returns.Path
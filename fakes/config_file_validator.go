@@ -0,0 +1,22 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type ConfigFileValidator struct {
+	ValidateCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			Path     string
+			Problems []string
+		}
+	}
+}
+
+func (c *ConfigFileValidator) Validate(state storage.State) (string, []string) {
+	c.ValidateCall.CallCount++
+	c.ValidateCall.Receives.State = state
+	return c.ValidateCall.Returns.Path, c.ValidateCall.Returns.Problems
+}
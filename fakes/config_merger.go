@@ -10,6 +10,7 @@ type Merger struct {
 		CallCount int
 		Receives  struct {
 			GlobalFlags config.GlobalFlags
+			File        config.File
 			State       storage.State
 		}
 		Returns struct {
@@ -19,9 +20,10 @@ type Merger struct {
 	}
 }
 
-func (f *Merger) MergeGlobalFlagsToState(globalFlags config.GlobalFlags, state storage.State) (storage.State, error) {
+func (f *Merger) MergeGlobalFlagsToState(globalFlags config.GlobalFlags, file config.File, state storage.State) (storage.State, error) {
 	f.MergeCall.CallCount++
 	f.MergeCall.Receives.GlobalFlags = globalFlags
+	f.MergeCall.Receives.File = file
 	f.MergeCall.Receives.State = state
 
 	return f.MergeCall.Returns.State, f.MergeCall.Returns.Error
@@ -0,0 +1,33 @@
+package fakes
+
+type CredentialRotator struct {
+	RotateDirectorCredentialsCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+	RotateCertificatesCall struct {
+		CallCount int
+		Receives  struct {
+			Stage string
+		}
+		Returns struct {
+			Stage string
+			Error error
+		}
+	}
+}
+
+func (c *CredentialRotator) RotateDirectorCredentials() error {
+	c.RotateDirectorCredentialsCall.CallCount++
+
+	return c.RotateDirectorCredentialsCall.Returns.Error
+}
+
+func (c *CredentialRotator) RotateCertificates(stage string) (string, error) {
+	c.RotateCertificatesCall.CallCount++
+	c.RotateCertificatesCall.Receives.Stage = stage
+
+	return c.RotateCertificatesCall.Returns.Stage, c.RotateCertificatesCall.Returns.Error
+}
@@ -0,0 +1,31 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/bosh"
+
+type DeploymentUpgrader struct {
+	PreviewUpgradeCall struct {
+		CallCount int
+		Returns   struct {
+			Upgrade bosh.DeploymentUpgrade
+			Error   error
+		}
+	}
+	UpgradeCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (d *DeploymentUpgrader) PreviewUpgrade() (bosh.DeploymentUpgrade, error) {
+	d.PreviewUpgradeCall.CallCount++
+
+	return d.PreviewUpgradeCall.Returns.Upgrade, d.PreviewUpgradeCall.Returns.Error
+}
+
+func (d *DeploymentUpgrader) Upgrade() error {
+	d.UpgradeCall.CallCount++
+
+	return d.UpgradeCall.Returns.Error
+}
@@ -12,14 +12,15 @@ type Downloader struct {
 		}
 
 		Returns struct {
-			Error error
+			Version string
+			Error   error
 		}
 	}
 }
 
-func (d *Downloader) DownloadAndPrepareState(flags config.GlobalFlags) error {
+func (d *Downloader) DownloadAndPrepareState(flags config.GlobalFlags) (string, error) {
 	d.DownloadCall.CallCount++
 	d.DownloadCall.Receives.GlobalFlags = flags
 
-	return d.DownloadCall.Returns.Error
+	return d.DownloadCall.Returns.Version, d.DownloadCall.Returns.Error
 }
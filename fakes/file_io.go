@@ -139,6 +139,19 @@ type FileIO struct {
 			Error error
 		}
 	}
+
+	OpenFileCall struct {
+		CallCount int
+		Receives  struct {
+			Name string
+			Flag int
+			Perm os.FileMode
+		}
+		Returns struct {
+			File  afero.File
+			Error error
+		}
+	}
 }
 
 type WriteFileReceive struct {
@@ -280,3 +293,11 @@ func (f *FileIO) MkdirAll(dir string, perm os.FileMode) error {
 	f.MkdirAllCall.Receives.Perm = perm
 	return f.MkdirAllCall.Returns.Error
 }
+
+func (f *FileIO) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f.OpenFileCall.CallCount++
+	f.OpenFileCall.Receives.Name = name
+	f.OpenFileCall.Receives.Flag = flag
+	f.OpenFileCall.Receives.Perm = perm
+	return f.OpenFileCall.Returns.File, f.OpenFileCall.Returns.Error
+}
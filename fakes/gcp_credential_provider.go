@@ -0,0 +1,22 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type GCPCredentialProvider struct {
+	ResolveCall struct {
+		CallCount int
+		Receives  struct {
+			GCP storage.GCP
+		}
+		Returns struct {
+			GCP   storage.GCP
+			Error error
+		}
+	}
+}
+
+func (p *GCPCredentialProvider) Resolve(gcp storage.GCP) (storage.GCP, error) {
+	p.ResolveCall.CallCount++
+	p.ResolveCall.Receives.GCP = gcp
+	return p.ResolveCall.Returns.GCP, p.ResolveCall.Returns.Error
+}
@@ -0,0 +1,24 @@
+package fakes
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/health"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type HealthChecker struct {
+	CheckCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			Results []health.Result
+		}
+	}
+}
+
+func (h *HealthChecker) Check(state storage.State) []health.Result {
+	h.CheckCall.CallCount++
+	h.CheckCall.Receives.State = state
+	return h.CheckCall.Returns.Results
+}
@@ -48,6 +48,11 @@ type Logger struct {
 			Proceed bool
 		}
 	}
+
+	SetPhaseCall struct {
+		CallCount int
+		Phases    []string
+	}
 }
 
 func (l *Logger) Step(message string, a ...interface{}) {
@@ -91,6 +96,11 @@ func (l *Logger) Prompt(message string) bool {
 	return l.PromptCall.Returns.Proceed
 }
 
+func (l *Logger) SetPhase(phase string) {
+	l.SetPhaseCall.CallCount++
+	l.SetPhaseCall.Phases = append(l.SetPhaseCall.Phases, phase)
+}
+
 func (l *Logger) PrintlnMessages() []string {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
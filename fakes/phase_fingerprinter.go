@@ -0,0 +1,26 @@
+package fakes
+
+type PhaseFingerprinter struct {
+	FingerprintCall struct {
+		CallCount int
+		Receives  []FingerprintCallReceive
+		Returns   struct {
+			Fingerprints map[string]string
+			Error        error
+		}
+	}
+}
+
+type FingerprintCallReceive struct {
+	Patterns []string
+	Extra    []string
+}
+
+// Fingerprint returns the fingerprint stubbed for the first pattern, which
+// tells the phases of bbl up apart.
+func (p *PhaseFingerprinter) Fingerprint(patterns []string, extra ...string) (string, error) {
+	p.FingerprintCall.CallCount++
+	p.FingerprintCall.Receives = append(p.FingerprintCall.Receives, FingerprintCallReceive{Patterns: patterns, Extra: extra})
+
+	return p.FingerprintCall.Returns.Fingerprints[patterns[0]], p.FingerprintCall.Returns.Error
+}
@@ -0,0 +1,41 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type Redactor struct {
+	AddCall struct {
+		CallCount int
+		Receives  struct {
+			Secrets []string
+		}
+	}
+
+	AddStateCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+	}
+
+	AddVarsStoreCall struct {
+		CallCount int
+		Receives  struct {
+			Contents []string
+		}
+	}
+}
+
+func (r *Redactor) Add(secrets ...string) {
+	r.AddCall.CallCount++
+	r.AddCall.Receives.Secrets = append(r.AddCall.Receives.Secrets, secrets...)
+}
+
+func (r *Redactor) AddState(state storage.State) {
+	r.AddStateCall.CallCount++
+	r.AddStateCall.Receives.State = state
+}
+
+func (r *Redactor) AddVarsStore(contents string) {
+	r.AddVarsStoreCall.CallCount++
+	r.AddVarsStoreCall.Receives.Contents = append(r.AddVarsStoreCall.Receives.Contents, contents)
+}
@@ -0,0 +1,75 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type SecretStore struct {
+	SealSecretsCall struct {
+		CallCount int
+		Receives  struct {
+			State   storage.State
+			VarsDir string
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
+
+	FetchVarsStoresCall struct {
+		CallCount int
+		Receives  struct {
+			VarsDir string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	StoreVarsStoresCall struct {
+		CallCount int
+		Receives  struct {
+			VarsDir string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *SecretStore) SealSecrets(state storage.State, varsDir string) (storage.State, error) {
+	s.SealSecretsCall.CallCount++
+	s.SealSecretsCall.Receives.State = state
+	s.SealSecretsCall.Receives.VarsDir = varsDir
+	return s.SealSecretsCall.Returns.State, s.SealSecretsCall.Returns.Error
+}
+
+func (s *SecretStore) FetchVarsStores(varsDir string) error {
+	s.FetchVarsStoresCall.CallCount++
+	s.FetchVarsStoresCall.Receives.VarsDir = varsDir
+	return s.FetchVarsStoresCall.Returns.Error
+}
+
+func (s *SecretStore) StoreVarsStores(varsDir string) error {
+	s.StoreVarsStoresCall.CallCount++
+	s.StoreVarsStoresCall.Receives.VarsDir = varsDir
+	return s.StoreVarsStoresCall.Returns.Error
+}
+
+type SecretResolver struct {
+	ResolveCall struct {
+		CallCount int
+		Stub      func(string) (string, error)
+		Receives  struct {
+			Value string
+		}
+	}
+}
+
+func (s *SecretResolver) Resolve(value string) (string, error) {
+	s.ResolveCall.CallCount++
+	s.ResolveCall.Receives.Value = value
+	if s.ResolveCall.Stub != nil {
+		return s.ResolveCall.Stub(value)
+	}
+	return value, nil
+}
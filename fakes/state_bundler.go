@@ -0,0 +1,40 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateBundler struct {
+	ExportCall struct {
+		CallCount int
+		Receives  struct {
+			Path  string
+			State storage.State
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	ImportCall struct {
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			Manifest storage.BundleManifest
+			Error    error
+		}
+	}
+}
+
+func (s *StateBundler) Export(path string, state storage.State) error {
+	s.ExportCall.CallCount++
+	s.ExportCall.Receives.Path = path
+	s.ExportCall.Receives.State = state
+	return s.ExportCall.Returns.Error
+}
+
+func (s *StateBundler) Import(path string) (storage.BundleManifest, error) {
+	s.ImportCall.CallCount++
+	s.ImportCall.Receives.Path = path
+	return s.ImportCall.Returns.Manifest, s.ImportCall.Returns.Error
+}
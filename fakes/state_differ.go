@@ -0,0 +1,29 @@
+package fakes
+
+type StateDiffer struct {
+	SnapshotCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+	DiffCall struct {
+		CallCount int
+		Returns   struct {
+			Diff  string
+			Error error
+		}
+	}
+}
+
+func (s *StateDiffer) Snapshot() error {
+	s.SnapshotCall.CallCount++
+
+	return s.SnapshotCall.Returns.Error
+}
+
+func (s *StateDiffer) Diff() (string, error) {
+	s.DiffCall.CallCount++
+
+	return s.DiffCall.Returns.Diff, s.DiffCall.Returns.Error
+}
@@ -0,0 +1,15 @@
+package fakes
+
+type StateEditAccepter struct {
+	AcceptEditCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (s *StateEditAccepter) AcceptEdit() error {
+	s.AcceptEditCall.CallCount++
+	return s.AcceptEditCall.Returns.Error
+}
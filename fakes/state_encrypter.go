@@ -0,0 +1,27 @@
+package fakes
+
+type StateEncrypter struct {
+	EncryptStateDirCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	DecryptStateDirCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (s *StateEncrypter) EncryptStateDir() error {
+	s.EncryptStateDirCall.CallCount++
+	return s.EncryptStateDirCall.Returns.Error
+}
+
+func (s *StateEncrypter) DecryptStateDir() error {
+	s.DecryptStateDirCall.CallCount++
+	return s.DecryptStateDirCall.Returns.Error
+}
@@ -0,0 +1,50 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateHistory struct {
+	ListCall struct {
+		CallCount int
+		Returns   struct {
+			Snapshots []storage.Snapshot
+			Error     error
+		}
+	}
+
+	SnapshotCall struct {
+		CallCount int
+		Receives  struct {
+			Command string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	RestoreCall struct {
+		CallCount int
+		Receives  struct {
+			ID string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *StateHistory) List() ([]storage.Snapshot, error) {
+	s.ListCall.CallCount++
+	return s.ListCall.Returns.Snapshots, s.ListCall.Returns.Error
+}
+
+func (s *StateHistory) Restore(id string) error {
+	s.RestoreCall.CallCount++
+	s.RestoreCall.Receives.ID = id
+	return s.RestoreCall.Returns.Error
+}
+
+func (s *StateHistory) Snapshot(command string) error {
+	s.SnapshotCall.CallCount++
+	s.SnapshotCall.Receives.Command = command
+	return s.SnapshotCall.Returns.Error
+}
@@ -0,0 +1,72 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateLocker struct {
+	LockCall struct {
+		CallCount int
+		Receives  struct {
+			Command string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	UnlockCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	ForceUnlockCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	IsLockedCall struct {
+		CallCount int
+		Returns   struct {
+			Locked bool
+			Error  error
+		}
+	}
+
+	InfoCall struct {
+		CallCount int
+		Returns   struct {
+			Info   storage.LockInfo
+			Locked bool
+			Error  error
+		}
+	}
+}
+
+func (s *StateLocker) Lock(command string) error {
+	s.LockCall.CallCount++
+	s.LockCall.Receives.Command = command
+	return s.LockCall.Returns.Error
+}
+
+func (s *StateLocker) Unlock() error {
+	s.UnlockCall.CallCount++
+	return s.UnlockCall.Returns.Error
+}
+
+func (s *StateLocker) ForceUnlock() error {
+	s.ForceUnlockCall.CallCount++
+	return s.ForceUnlockCall.Returns.Error
+}
+
+func (s *StateLocker) IsLocked() (bool, error) {
+	s.IsLockedCall.CallCount++
+	return s.IsLockedCall.Returns.Locked, s.IsLockedCall.Returns.Error
+}
+
+func (s *StateLocker) Info() (storage.LockInfo, bool, error) {
+	s.InfoCall.CallCount++
+	return s.InfoCall.Returns.Info, s.InfoCall.Returns.Locked, s.InfoCall.Returns.Error
+}
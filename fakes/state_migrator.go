@@ -3,6 +3,17 @@ package fakes
 import "github.com/cloudfoundry/bosh-bootloader/storage"
 
 type StateMigrator struct {
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			Steps []storage.MigrationStep
+			Error error
+		}
+	}
+
 	MigrateCall struct {
 		CallCount int
 		Receives  struct {
@@ -21,3 +32,10 @@ func (s *StateMigrator) Migrate(state storage.State) (storage.State, error) {
 
 	return s.MigrateCall.Returns.State, s.MigrateCall.Returns.Error
 }
+
+func (s *StateMigrator) Plan(state storage.State) ([]storage.MigrationStep, error) {
+	s.PlanCall.CallCount++
+	s.PlanCall.Receives.State = state
+
+	return s.PlanCall.Returns.Steps, s.PlanCall.Returns.Error
+}
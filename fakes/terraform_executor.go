@@ -1,5 +1,7 @@
 package fakes
 
+import "github.com/cloudfoundry/bosh-bootloader/terraform"
+
 type Import struct {
 	Addr string
 	ID   string
@@ -56,6 +58,16 @@ type TerraformExecutor struct {
 			Error error
 		}
 	}
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			Credentials map[string]string
+		}
+		Returns struct {
+			Summary terraform.PlanSummary
+			Error   error
+		}
+	}
 	VersionCall struct {
 		CallCount int
 		Returns   struct {
@@ -126,6 +138,12 @@ func (t *TerraformExecutor) Validate(credentials map[string]string) error {
 	return t.ValidateCall.Returns.Error
 }
 
+func (t *TerraformExecutor) Plan(credentials map[string]string) (terraform.PlanSummary, error) {
+	t.PlanCall.CallCount++
+	t.PlanCall.Receives.Credentials = credentials
+	return t.PlanCall.Returns.Summary, t.PlanCall.Returns.Error
+}
+
 func (t *TerraformExecutor) Version() (string, error) {
 	t.VersionCall.CallCount++
 	return t.VersionCall.Returns.Version, t.VersionCall.Returns.Error
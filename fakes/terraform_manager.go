@@ -24,6 +24,15 @@ type TerraformManager struct {
 			Error error
 		}
 	}
+	GenerateCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Error error
+		}
+	}
 	ApplyCall struct {
 		CallCount int
 		Receives  struct {
@@ -85,6 +94,16 @@ type TerraformManager struct {
 			Error error
 		}
 	}
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Summary terraform.PlanSummary
+			Error   error
+		}
+	}
 	IsPavedCall struct {
 		CallCount int
 		Returns   struct {
@@ -101,6 +120,13 @@ func (t *TerraformManager) Setup(bblState storage.State) error {
 	return t.SetupCall.Returns.Error
 }
 
+func (t *TerraformManager) Generate(bblState storage.State) error {
+	t.GenerateCall.CallCount++
+	t.GenerateCall.Receives.BBLState = bblState
+
+	return t.GenerateCall.Returns.Error
+}
+
 func (t *TerraformManager) Init(bblState storage.State) error {
 	t.InitCall.CallCount++
 	t.InitCall.Receives.BBLState = bblState
@@ -156,3 +182,9 @@ func (t *TerraformManager) IsPaved() (bool, error) {
 	t.IsPavedCall.CallCount++
 	return t.IsPavedCall.Returns.IsPaved, t.IsPavedCall.Returns.Error
 }
+
+func (t *TerraformManager) Plan(bblState storage.State) (terraform.PlanSummary, error) {
+	t.PlanCall.CallCount++
+	t.PlanCall.Receives.BBLState = bblState
+	return t.PlanCall.Returns.Summary, t.PlanCall.Returns.Error
+}
@@ -37,6 +37,17 @@ type Up struct {
 			Error error
 		}
 	}
+	RunCall struct {
+		CallCount int
+		Receives  struct {
+			Args  []string
+			State storage.State
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
 }
 
 func (u *Up) CheckFastFails(subcommandFlags []string, state storage.State) error {
@@ -62,3 +73,11 @@ func (u *Up) Execute(args []string, state storage.State) error {
 
 	return u.ExecuteCall.Returns.Error
 }
+
+func (u *Up) Run(args []string, state storage.State) (storage.State, error) {
+	u.RunCall.CallCount++
+	u.RunCall.Receives.Args = args
+	u.RunCall.Receives.State = state
+
+	return u.RunCall.Returns.State, u.RunCall.Returns.Error
+}
@@ -6,6 +6,10 @@ import (
 	"github.com/spf13/afero"
 )
 
+type FileOpener interface {
+	OpenFile(name string, flag int, perm os.FileMode) (afero.File, error)
+}
+
 type FileWriter interface {
 	WriteFile(filename string, data []byte, perm os.FileMode) error
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"google.golang.org/api/compute/v1"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
 )
@@ -16,19 +17,22 @@ func gcpHTTPClientFunc(config *jwt.Config) *http.Client {
 	return config.Client(context.Background())
 }
 
-var gcpHTTPClient = gcpHTTPClientFunc
+func gcpTokenHTTPClientFunc(tokenSource oauth2.TokenSource) *http.Client {
+	return oauth2.NewClient(context.Background(), tokenSource)
+}
+
+var (
+	gcpHTTPClient      = gcpHTTPClientFunc
+	gcpTokenHTTPClient = gcpTokenHTTPClientFunc
+)
 
 func NewClient(gcpConfig storage.GCP, basePath string) (Client, error) {
-	config, err := google.JWTConfigFromJSON([]byte(gcpConfig.ServiceAccountKey), compute.ComputeScope)
+	httpClient, err := newHTTPClient(gcpConfig, basePath)
 	if err != nil {
-		return Client{}, fmt.Errorf("parse service account key: %s", err)
-	}
-
-	if basePath != "" {
-		config.TokenURL = basePath
+		return Client{}, err
 	}
 
-	service, err := compute.New(gcpHTTPClient(config))
+	service, err := compute.New(httpClient)
 	if err != nil {
 		return Client{}, fmt.Errorf("create gcp client: %s", err)
 	}
@@ -50,3 +54,22 @@ func NewClient(gcpConfig storage.GCP, basePath string) (Client, error) {
 
 	return client, nil
 }
+
+// newHTTPClient authenticates with the access token of application default
+// credentials, or else with the service account key.
+func newHTTPClient(gcpConfig storage.GCP, basePath string) (*http.Client, error) {
+	if gcpConfig.AccessToken != "" {
+		return gcpTokenHTTPClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: gcpConfig.AccessToken})), nil
+	}
+
+	config, err := google.JWTConfigFromJSON([]byte(gcpConfig.ServiceAccountKey), compute.ComputeScope)
+	if err != nil {
+		return nil, fmt.Errorf("parse service account key: %s", err)
+	}
+
+	if basePath != "" {
+		config.TokenURL = basePath
+	}
+
+	return gcpHTTPClient(config), nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jwt"
 )
 
@@ -65,6 +66,31 @@ var _ = Describe("NewClient", func() {
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	Context("when there is an access token", func() {
+		It("authenticates with it instead of a service account key", func() {
+			var token *oauth2.Token
+			gcp.SetGCPTokenHTTPClient(func(tokenSource oauth2.TokenSource) *http.Client {
+				token, _ = tokenSource.Token() //nolint:errcheck
+				return &http.Client{
+					Transport: &http.Transport{
+						TLSClientConfig: &tls.Config{
+							InsecureSkipVerify: true,
+						},
+					},
+				}
+			})
+
+			_, err := gcp.NewClient(storage.GCP{
+				AccessToken: "some-access-token",
+				ProjectID:   "proj-id",
+				Region:      "some-region",
+				Zone:        "some-zone",
+			}, basePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("some-access-token"))
+		})
+	})
+
 	Context("when the service account key is not valid json", func() {
 		It("returns an error", func() {
 			_, err := gcp.NewClient(storage.GCP{
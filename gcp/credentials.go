@@ -0,0 +1,56 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"google.golang.org/api/compute/v1"
+
+	"golang.org/x/oauth2/google"
+)
+
+type credentialsFinder func(ctx context.Context, scopes ...string) (*google.Credentials, error)
+
+// DefaultCredentials resolves application default credentials: those of
+// gcloud auth application-default login, GOOGLE_APPLICATION_CREDENTIALS or
+// the service account of the VM that bbl runs on.
+type DefaultCredentials struct {
+	find credentialsFinder
+}
+
+func NewDefaultCredentials() DefaultCredentials {
+	return DefaultCredentials{find: google.FindDefaultCredentials}
+}
+
+// Resolve sets a short-lived access token and the project of the
+// credentials. GOOGLE_CLOUD_PROJECT or CLOUDSDK_CORE_PROJECT names the
+// project when the credentials do not.
+func (d DefaultCredentials) Resolve(gcpConfig storage.GCP) (storage.GCP, error) {
+	credentials, err := d.find(context.Background(), compute.CloudPlatformScope)
+	if err != nil {
+		return storage.GCP{}, fmt.Errorf("find application default credentials: %s", err)
+	}
+
+	token, err := credentials.TokenSource.Token()
+	if err != nil {
+		return storage.GCP{}, fmt.Errorf("get access token: %s", err)
+	}
+
+	projectID := credentials.ProjectID
+	for _, name := range []string{"GOOGLE_CLOUD_PROJECT", "CLOUDSDK_CORE_PROJECT"} {
+		if projectID == "" {
+			projectID = os.Getenv(name)
+		}
+	}
+	if projectID == "" {
+		return storage.GCP{}, errors.New("the application default credentials have no project, set GOOGLE_CLOUD_PROJECT")
+	}
+
+	gcpConfig.AccessToken = token.AccessToken
+	gcpConfig.ProjectID = projectID
+
+	return gcpConfig, nil
+}
@@ -0,0 +1,86 @@
+package gcp_test
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+var _ = Describe("DefaultCredentials", func() {
+	var (
+		scopes      []string
+		credentials *google.Credentials
+		findErr     error
+
+		defaultCredentials gcp.DefaultCredentials
+	)
+
+	BeforeEach(func() {
+		credentials = &google.Credentials{
+			ProjectID:   "some-project-id",
+			TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "some-access-token"}),
+		}
+		findErr = nil
+
+		defaultCredentials = gcp.NewDefaultCredentialsWithFinder(func(ctx context.Context, s ...string) (*google.Credentials, error) {
+			scopes = s
+			return credentials, findErr
+		})
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("GOOGLE_CLOUD_PROJECT") //nolint:errcheck
+	})
+
+	Describe("Resolve", func() {
+		It("sets an access token and the project", func() {
+			gcpConfig, err := defaultCredentials.Resolve(storage.GCP{CredentialSource: "application-default", Region: "some-region"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(scopes).To(Equal([]string{"https://www.googleapis.com/auth/cloud-platform"}))
+			Expect(gcpConfig).To(Equal(storage.GCP{
+				AccessToken:      "some-access-token",
+				ProjectID:        "some-project-id",
+				CredentialSource: "application-default",
+				Region:           "some-region",
+			}))
+		})
+
+		Context("when the credentials have no project", func() {
+			BeforeEach(func() {
+				credentials.ProjectID = ""
+			})
+
+			It("uses GOOGLE_CLOUD_PROJECT", func() {
+				os.Setenv("GOOGLE_CLOUD_PROJECT", "other-project-id") //nolint:errcheck
+
+				gcpConfig, err := defaultCredentials.Resolve(storage.GCP{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gcpConfig.ProjectID).To(Equal("other-project-id"))
+			})
+
+			It("returns an error without it", func() {
+				os.Unsetenv("CLOUDSDK_CORE_PROJECT") //nolint:errcheck
+
+				_, err := defaultCredentials.Resolve(storage.GCP{})
+				Expect(err).To(MatchError("the application default credentials have no project, set GOOGLE_CLOUD_PROJECT"))
+			})
+		})
+
+		Context("when there are no application default credentials", func() {
+			It("returns an error", func() {
+				findErr = errors.New("could not find default credentials")
+
+				_, err := defaultCredentials.Resolve(storage.GCP{})
+				Expect(err).To(MatchError("find application default credentials: could not find default credentials"))
+			})
+		})
+	})
+})
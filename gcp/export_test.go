@@ -1,8 +1,11 @@
 package gcp
 
 import (
+	"context"
 	"net/http"
 
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
 )
 
@@ -10,8 +13,13 @@ func SetGCPHTTPClient(f func(*jwt.Config) *http.Client) {
 	gcpHTTPClient = f
 }
 
+func SetGCPTokenHTTPClient(f func(oauth2.TokenSource) *http.Client) {
+	gcpTokenHTTPClient = f
+}
+
 func ResetGCPHTTPClient() {
 	gcpHTTPClient = gcpHTTPClientFunc
+	gcpTokenHTTPClient = gcpTokenHTTPClientFunc
 }
 
 func NewClientWithInjectedComputeClient(computeClient ComputeClient, projectID, zone string) Client {
@@ -21,3 +29,7 @@ func NewClientWithInjectedComputeClient(computeClient ComputeClient, projectID,
 		zone:          zone,
 	}
 }
+
+func NewDefaultCredentialsWithFinder(find func(ctx context.Context, scopes ...string) (*google.Credentials, error)) DefaultCredentials {
+	return DefaultCredentials{find: find}
+}
@@ -0,0 +1,42 @@
+package health
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+// dialAllProxy connects to the jumpbox named by a BOSH_ALL_PROXY of the form
+// ssh+socks5://user@host:port?private-key=path, as print-env prints it, so
+// that the checks reach the director the same way the bosh cli does.
+func dialAllProxy(fs fileio.FileReader, allProxy string, timeout time.Duration) (*ssh.Client, error) {
+	proxyURL, err := url.Parse(allProxy)
+	if err != nil {
+		return nil, fmt.Errorf("Parse BOSH_ALL_PROXY: %s", err) //nolint:staticcheck
+	}
+
+	if proxyURL.Scheme != "ssh+socks5" {
+		return nil, fmt.Errorf("unsupported BOSH_ALL_PROXY scheme %q", proxyURL.Scheme)
+	}
+
+	privateKey, err := fs.ReadFile(proxyURL.Query().Get("private-key"))
+	if err != nil {
+		return nil, fmt.Errorf("Read jumpbox private key: %s", err) //nolint:staticcheck
+	}
+
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("Parse jumpbox private key: %s", err) //nolint:staticcheck
+	}
+
+	return ssh.Dial("tcp", proxyURL.Host, &ssh.ClientConfig{
+		User:            proxyURL.User.Username(),
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), //nolint:gosec
+		Timeout:         timeout,
+	})
+}
@@ -0,0 +1,239 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type Status string
+
+const (
+	OK      Status = "ok"
+	Failed  Status = "failed"
+	Skipped Status = "skipped"
+	Drift   Status = "drift"
+)
+
+const (
+	JumpboxCheck             = "jumpbox"
+	DirectorCheck            = "director"
+	DirectorCertificateCheck = "director certificate"
+	CredHubCheck             = "credhub"
+	TerraformCheck           = "terraform"
+)
+
+type Result struct {
+	Check  string `json:"check"`
+	Status Status `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type allProxyGetter interface {
+	GeneratePrivateKey() (string, error)
+	BoshAllProxy(string, string) string
+}
+
+type credhubGetter interface {
+	GetServer() (string, error)
+	GetCerts() (string, error)
+}
+
+// tunnel dials through the jumpbox.
+type tunnel interface {
+	Dial(network, address string) (net.Conn, error)
+	Close() error
+}
+
+type Checker struct {
+	allProxyGetter allProxyGetter
+	credhubGetter  credhubGetter
+	fs             fileio.FileReader
+	timeout        time.Duration
+}
+
+func NewChecker(allProxyGetter allProxyGetter, credhubGetter credhubGetter, fs fileio.FileReader, timeout time.Duration) Checker {
+	return Checker{
+		allProxyGetter: allProxyGetter,
+		credhubGetter:  credhubGetter,
+		fs:             fs,
+		timeout:        timeout,
+	}
+}
+
+// Check connects to the jumpbox and, through the same ssh tunnel that
+// BOSH_ALL_PROXY describes, to the director and CredHub.
+func (c Checker) Check(state storage.State) []Result {
+	client, result := c.jumpbox(state)
+	if client == nil {
+		return []Result{
+			result,
+			{Check: DirectorCheck, Status: Skipped, Detail: "the jumpbox is unreachable"},
+			{Check: DirectorCertificateCheck, Status: Skipped, Detail: "the jumpbox is unreachable"},
+			{Check: CredHubCheck, Status: Skipped, Detail: "the jumpbox is unreachable"},
+		}
+	}
+	defer client.Close() //nolint:errcheck
+
+	return []Result{
+		result,
+		c.director(client, state),
+		c.directorCertificate(client, state),
+		c.credhub(client),
+	}
+}
+
+func (c Checker) jumpbox(state storage.State) (tunnel, Result) {
+	if state.Jumpbox.URL == "" {
+		return nil, failed(JumpboxCheck, "there is no jumpbox in the state")
+	}
+	address := state.Jumpbox.GetURLWithJumpboxUser()
+
+	privateKeyPath, err := c.allProxyGetter.GeneratePrivateKey()
+	if err != nil {
+		return nil, failed(JumpboxCheck, fmt.Sprintf("Get jumpbox private key: %s", err))
+	}
+
+	client, err := dialAllProxy(c.fs, c.allProxyGetter.BoshAllProxy(address, privateKeyPath), c.timeout)
+	if err != nil {
+		return nil, failed(JumpboxCheck, err.Error())
+	}
+
+	return client, Result{Check: JumpboxCheck, Status: OK, Detail: address}
+}
+
+func (c Checker) director(client tunnel, state storage.State) Result {
+	// The certificate has a check of its own, so a director that only fails
+	// verification still shows up as answering here.
+	var info struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	err := c.getJSON(client, &tls.Config{InsecureSkipVerify: true}, state.BOSH.DirectorAddress+"/info", &info) //nolint:gosec
+	if err != nil {
+		return failed(DirectorCheck, err.Error())
+	}
+
+	return Result{Check: DirectorCheck, Status: OK, Detail: fmt.Sprintf("%s %s", info.Name, info.Version)}
+}
+
+func (c Checker) directorCertificate(client tunnel, state storage.State) Result {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(state.BOSH.DirectorSSLCA)) {
+		return failed(DirectorCertificateCheck, "there is no director CA certificate in the state")
+	}
+
+	director, err := url.Parse(state.BOSH.DirectorAddress)
+	if err != nil || director.Host == "" {
+		return failed(DirectorCertificateCheck, fmt.Sprintf("invalid director address %q", state.BOSH.DirectorAddress))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conn, err := dial(ctx, client, director.Host)
+	if err != nil {
+		return failed(DirectorCertificateCheck, err.Error())
+	}
+	defer conn.Close() //nolint:errcheck
+
+	tlsConn := tls.Client(conn, &tls.Config{RootCAs: pool, ServerName: director.Hostname()})
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return failed(DirectorCertificateCheck, err.Error())
+	}
+
+	leaf := tlsConn.ConnectionState().PeerCertificates[0]
+	return Result{Check: DirectorCertificateCheck, Status: OK, Detail: fmt.Sprintf("expires %s", leaf.NotAfter.Format("2006-01-02"))}
+}
+
+func (c Checker) credhub(client tunnel) Result {
+	server, err := c.credhubGetter.GetServer()
+	if err != nil {
+		return failed(CredHubCheck, fmt.Sprintf("Get credhub server: %s", err))
+	}
+
+	certs, err := c.credhubGetter.GetCerts()
+	if err != nil {
+		return failed(CredHubCheck, fmt.Sprintf("Get credhub certs: %s", err))
+	}
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM([]byte(certs))
+
+	var info map[string]interface{}
+	err = c.getJSON(client, &tls.Config{RootCAs: pool}, server+"/info", &info)
+	if err != nil {
+		return failed(CredHubCheck, err.Error())
+	}
+
+	return Result{Check: CredHubCheck, Status: OK, Detail: server}
+}
+
+func (c Checker) getJSON(client tunnel, tlsConfig *tls.Config, endpoint string, v interface{}) error {
+	httpClient := &http.Client{
+		Timeout: c.timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return dial(ctx, client, address)
+			},
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	response, err := httpClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", endpoint, response.Status)
+	}
+
+	err = json.NewDecoder(response.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("GET %s: %s", endpoint, err)
+	}
+
+	return nil
+}
+
+// dial opens a connection from the jumpbox, giving up when ctx is done since
+// the tunnel has no dial timeout of its own.
+func dial(ctx context.Context, client tunnel, address string) (net.Conn, error) {
+	type dialed struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan dialed, 1)
+	go func() {
+		conn, err := client.Dial("tcp", address)
+		done <- dialed{conn: conn, err: err}
+	}()
+
+	select {
+	case d := <-done:
+		return d.conn, d.err
+	case <-ctx.Done():
+		go func() {
+			if d := <-done; d.conn != nil {
+				d.conn.Close() //nolint:errcheck
+			}
+		}()
+		return nil, fmt.Errorf("dial %s through the jumpbox: %s", address, ctx.Err())
+	}
+}
+
+func failed(check, detail string) Result {
+	return Result{Check: check, Status: Failed, Detail: detail}
+}
@@ -0,0 +1,294 @@
+package health_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/health"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// startJumpbox runs an ssh server that accepts clientKey and forwards
+// direct-tcpip channels, which is all a jumpbox does for bbl.
+func startJumpbox(clientKey ssh.PublicKey) net.Listener {
+	hostKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "jumpbox" && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key for %s", conn.User())
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveJumpbox(conn, config)
+		}
+	}()
+
+	return listener
+}
+
+func serveJumpbox(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		var target struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported") //nolint:errcheck
+			continue
+		}
+
+		upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error()) //nolint:errcheck
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			upstream.Close() //nolint:errcheck
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+		go func() {
+			io.Copy(channel, upstream) //nolint:errcheck
+			channel.Close()            //nolint:errcheck
+		}()
+		go func() {
+			io.Copy(upstream, channel) //nolint:errcheck
+			upstream.Close()           //nolint:errcheck
+		}()
+	}
+}
+
+func certificatePEM(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+var _ = Describe("Checker", func() {
+	var (
+		allProxyGetter *fakes.AllProxyGetter
+		credhubGetter  *fakes.CredhubGetter
+		fs             *afero.Afero
+		jumpbox        net.Listener
+		director       *httptest.Server
+		credhub        *httptest.Server
+		state          storage.State
+
+		checker health.Checker
+	)
+
+	BeforeEach(func() {
+		clientKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		clientPublicKey, err := ssh.NewPublicKey(&clientKey.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		Expect(fs.WriteFile("/some/bosh_jumpbox_private.key", pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(clientKey),
+		}), 0600)).To(Succeed())
+		jumpbox = startJumpbox(clientPublicKey)
+
+		allProxyGetter = &fakes.AllProxyGetter{}
+		allProxyGetter.GeneratePrivateKeyCall.Returns.PrivateKey = "/some/bosh_jumpbox_private.key"
+		allProxyGetter.BoshAllProxyCall.Returns.URL = fmt.Sprintf("ssh+socks5://jumpbox@%s?private-key=/some/bosh_jumpbox_private.key", jumpbox.Addr())
+
+		director = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/info"))
+			fmt.Fprint(w, `{"name": "some-director", "version": "277.1.0"}`)
+		}))
+		credhub = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/info"))
+			fmt.Fprint(w, `{"app": {"name": "CredHub"}}`)
+		}))
+
+		credhubGetter = &fakes.CredhubGetter{}
+		credhubGetter.GetServerCall.Returns.Server = credhub.URL
+		credhubGetter.GetCertsCall.Returns.Certs = certificatePEM(credhub)
+
+		state = storage.State{
+			Jumpbox: storage.Jumpbox{URL: jumpbox.Addr().String()},
+			BOSH: storage.BOSH{
+				DirectorAddress: director.URL,
+				DirectorSSLCA:   certificatePEM(director),
+			},
+		}
+
+		checker = health.NewChecker(allProxyGetter, credhubGetter, fs, 5*time.Second)
+	})
+
+	AfterEach(func() {
+		jumpbox.Close() //nolint:errcheck
+		director.Close()
+		credhub.Close()
+	})
+
+	It("reaches the director and credhub through the jumpbox", func() {
+		results := checker.Check(state)
+
+		Expect(allProxyGetter.BoshAllProxyCall.Receives.JumpboxURL).To(Equal("jumpbox@" + jumpbox.Addr().String()))
+		Expect(allProxyGetter.BoshAllProxyCall.Receives.PrivateKey).To(Equal("/some/bosh_jumpbox_private.key"))
+		Expect(results).To(HaveLen(4))
+		Expect(results[0]).To(Equal(health.Result{Check: health.JumpboxCheck, Status: health.OK, Detail: "jumpbox@" + jumpbox.Addr().String()}))
+		Expect(results[1]).To(Equal(health.Result{Check: health.DirectorCheck, Status: health.OK, Detail: "some-director 277.1.0"}))
+		Expect(results[2].Check).To(Equal(health.DirectorCertificateCheck))
+		Expect(results[2].Status).To(Equal(health.OK))
+		Expect(results[2].Detail).To(HavePrefix("expires "))
+		Expect(results[3]).To(Equal(health.Result{Check: health.CredHubCheck, Status: health.OK, Detail: credhub.URL}))
+	})
+
+	Context("when the jumpbox refuses the key", func() {
+		BeforeEach(func() {
+			otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.WriteFile("/some/bosh_jumpbox_private.key", pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(otherKey),
+			}), 0600)).To(Succeed())
+		})
+
+		It("fails the jumpbox and skips the checks behind it", func() {
+			results := checker.Check(state)
+
+			Expect(results[0].Status).To(Equal(health.Failed))
+			Expect(results[0].Detail).To(ContainSubstring("unable to authenticate"))
+			for _, result := range results[1:] {
+				Expect(result.Status).To(Equal(health.Skipped))
+			}
+		})
+	})
+
+	Context("when the state has no jumpbox", func() {
+		It("fails the jumpbox check", func() {
+			state.Jumpbox.URL = ""
+
+			results := checker.Check(state)
+			Expect(results[0]).To(Equal(health.Result{Check: health.JumpboxCheck, Status: health.Failed, Detail: "there is no jumpbox in the state"}))
+			Expect(allProxyGetter.GeneratePrivateKeyCall.CallCount).To(Equal(0))
+		})
+	})
+
+	Context("when the jumpbox private key cannot be written", func() {
+		It("fails the jumpbox check", func() {
+			allProxyGetter.GeneratePrivateKeyCall.Returns.Error = fmt.Errorf("no vars store")
+
+			results := checker.Check(state)
+			Expect(results[0]).To(Equal(health.Result{Check: health.JumpboxCheck, Status: health.Failed, Detail: "Get jumpbox private key: no vars store"}))
+			Expect(results[1].Status).To(Equal(health.Skipped))
+		})
+	})
+
+	Context("when the director certificate is not signed by the CA in the state", func() {
+		BeforeEach(func() {
+			caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+			template := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "some-other-ca"},
+				NotBefore:             time.Now(),
+				NotAfter:              time.Now().Add(time.Hour),
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign,
+			}
+			ca, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			state.BOSH.DirectorSSLCA = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca}))
+		})
+
+		It("fails the certificate check but not the director check", func() {
+			results := checker.Check(state)
+
+			Expect(results[1].Status).To(Equal(health.OK))
+			Expect(results[2].Status).To(Equal(health.Failed))
+			Expect(results[2].Detail).To(ContainSubstring("certificate"))
+		})
+	})
+
+	Context("when the state has no director CA", func() {
+		It("fails the certificate check", func() {
+			state.BOSH.DirectorSSLCA = ""
+
+			results := checker.Check(state)
+			Expect(results[2]).To(Equal(health.Result{Check: health.DirectorCertificateCheck, Status: health.Failed, Detail: "there is no director CA certificate in the state"}))
+		})
+	})
+
+	Context("when the director is down", func() {
+		It("fails the director check", func() {
+			director.Close()
+
+			results := checker.Check(state)
+			Expect(results[1].Status).To(Equal(health.Failed))
+			Expect(results[2].Status).To(Equal(health.Failed))
+		})
+	})
+
+	Context("when credhub does not answer", func() {
+		BeforeEach(func() {
+			credhub.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+		})
+
+		It("fails the credhub check", func() {
+			results := checker.Check(state)
+			Expect(results[3].Status).To(Equal(health.Failed))
+			Expect(results[3].Detail).To(Equal(fmt.Sprintf("GET %s/info: 503 Service Unavailable", credhub.URL)))
+		})
+	})
+
+	Context("when the credhub server cannot be found", func() {
+		It("fails the credhub check", func() {
+			credhubGetter.GetServerCall.Returns.Error = fmt.Errorf("no vars file")
+
+			results := checker.Check(state)
+			Expect(results[3].Status).To(Equal(health.Failed))
+			Expect(results[3].Detail).To(Equal("Get credhub server: no vars file"))
+		})
+	})
+})
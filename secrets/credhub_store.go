@@ -0,0 +1,201 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// CredHubStore keeps secrets as CredHub value credentials under prefix. It
+// authenticates against the UAA named by CredHub's /info endpoint with the
+// client credentials grant, like `credhub login --client-name`.
+type CredHubStore struct {
+	address      string
+	prefix       string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+	accessToken  *string
+}
+
+// NewCredHubStore trusts caCert, which may be PEM or a path to a PEM file,
+// in addition to the system roots.
+func NewCredHubStore(address, prefix, clientID, clientSecret, caCert string) (CredHubStore, error) {
+	client := http.DefaultClient
+	if caCert != "" {
+		pem := []byte(caCert)
+		if !strings.Contains(caCert, "-----BEGIN") {
+			var err error
+			pem, err = os.ReadFile(caCert)
+			if err != nil {
+				return CredHubStore{}, fmt.Errorf("reading CREDHUB_CA_CERT: %s", err)
+			}
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return CredHubStore{}, errors.New("CREDHUB_CA_CERT does not contain a PEM certificate")
+		}
+		client = &http.Client{Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		}}
+	}
+
+	return CredHubStore{
+		address:      address,
+		prefix:       prefix,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       client,
+		accessToken:  new(string),
+	}, nil
+}
+
+func (c CredHubStore) Get(name string) (string, error) {
+	query := url.Values{"name": {c.name(name)}, "current": {"true"}}
+	response, err := c.do(http.MethodGet, fmt.Sprintf("/api/v1/data?%s", query.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", credhubError(response, "reading", name)
+	}
+
+	var credentials struct {
+		Data []struct {
+			Value string `json:"value"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&credentials)
+	if err != nil {
+		return "", fmt.Errorf("reading %s from credhub: %s", name, err)
+	}
+	if len(credentials.Data) == 0 {
+		return "", ErrNotFound
+	}
+
+	return credentials.Data[0].Value, nil
+}
+
+func (c CredHubStore) Put(name, value string) error {
+	body, err := json.Marshal(map[string]string{
+		"name":  c.name(name),
+		"type":  "value",
+		"value": value,
+	})
+	if err != nil {
+		return err // not tested
+	}
+
+	response, err := c.do(http.MethodPut, "/api/v1/data", body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return credhubError(response, "writing", name)
+	}
+
+	return nil
+}
+
+func (c CredHubStore) name(name string) string {
+	return "/" + path.Join(c.prefix, name)
+}
+
+func (c CredHubStore) do(method, uri string, body []byte) (*http.Response, error) {
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(method, c.address+uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, err // not tested
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to credhub: %s", err)
+	}
+	return response, nil
+}
+
+// token logs in once per bbl run.
+func (c CredHubStore) token() (string, error) {
+	if *c.accessToken != "" {
+		return *c.accessToken, nil
+	}
+
+	response, err := c.client.Get(c.address + "/info")
+	if err != nil {
+		return "", fmt.Errorf("connecting to credhub: %s", err)
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	var info struct {
+		AuthServer struct {
+			URL string `json:"url"`
+		} `json:"auth-server"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&info)
+	if err != nil || info.AuthServer.URL == "" {
+		return "", fmt.Errorf("finding the credhub auth server: %s", response.Status)
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}, "response_type": {"token"}}
+	request, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(info.AuthServer.URL, "/")+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err // not tested
+	}
+	request.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	tokenResponse, err := c.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("connecting to the credhub auth server: %s", err)
+	}
+	defer tokenResponse.Body.Close() //nolint:errcheck
+
+	if tokenResponse.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(tokenResponse.Body) //nolint:errcheck
+		return "", fmt.Errorf("logging in to credhub as %s: %s: %s", c.clientID, tokenResponse.Status, bytes.TrimSpace(body))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(tokenResponse.Body).Decode(&token)
+	if err != nil {
+		return "", fmt.Errorf("logging in to credhub: %s", err)
+	}
+
+	*c.accessToken = token.AccessToken
+	return token.AccessToken, nil
+}
+
+func credhubError(response *http.Response, action, name string) error {
+	body, _ := io.ReadAll(response.Body) //nolint:errcheck
+	return fmt.Errorf("%s %s in credhub: %s: %s", action, name, response.Status, bytes.TrimSpace(body))
+}
@@ -0,0 +1,107 @@
+package secrets_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/bosh-bootloader/secrets"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CredHubStore", func() {
+	var (
+		server *httptest.Server
+		data   map[string]string
+		logins int
+		store  secrets.CredHubStore
+	)
+
+	BeforeEach(func() {
+		data = map[string]string{}
+		logins = 0
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"auth-server":{"url":"%s/uaa"}}`, server.URL)
+		})
+		mux.HandleFunc("/uaa/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+			client, secret, _ := r.BasicAuth()
+			Expect(r.ParseForm()).To(Succeed())
+			if client != "some-client" || secret != "some-secret" || r.Form.Get("grant_type") != "client_credentials" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"unauthorized"}`)) //nolint:errcheck
+				return
+			}
+			logins++
+			w.Write([]byte(`{"access_token":"some-access-token"}`)) //nolint:errcheck
+		})
+		mux.HandleFunc("/api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer some-access-token"))
+
+			switch r.Method {
+			case http.MethodGet:
+				Expect(r.URL.Query().Get("current")).To(Equal("true"))
+				value, ok := data[r.URL.Query().Get("name")]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+					"data": []map[string]string{{"type": "value", "value": value}},
+				})
+			case http.MethodPut:
+				var body map[string]string
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				Expect(body["type"]).To(Equal("value"))
+				data[body["name"]] = body["value"]
+				json.NewEncoder(w).Encode(body) //nolint:errcheck
+			}
+		})
+		server = httptest.NewServer(mux)
+
+		var err error
+		store, err = secrets.NewCredHubStore(server.URL, "bbl/some-env", "some-client", "some-secret", "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("keeps secrets as value credentials under the prefix", func() {
+		Expect(store.Put("director-password", "some-password")).To(Succeed())
+		Expect(data).To(Equal(map[string]string{"/bbl/some-env/director-password": "some-password"}))
+
+		value, err := store.Get("director-password")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("some-password"))
+
+		Expect(logins).To(Equal(1))
+	})
+
+	It("returns ErrNotFound for a missing secret", func() {
+		_, err := store.Get("director-password")
+		Expect(err).To(MatchError(secrets.ErrNotFound))
+	})
+
+	Context("when the client credentials are rejected", func() {
+		It("returns an error", func() {
+			store, err := secrets.NewCredHubStore(server.URL, "bbl/some-env", "some-client", "some-other-secret", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = store.Get("director-password")
+			Expect(err).To(MatchError(`logging in to credhub as some-client: 401 Unauthorized: {"error":"unauthorized"}`))
+		})
+	})
+
+	Context("when the CA certificate is not PEM", func() {
+		It("returns an error", func() {
+			_, err := secrets.NewCredHubStore(server.URL, "bbl/some-env", "some-client", "some-secret", "-----BEGIN CERTIFICATE-----\nnope\n-----END CERTIFICATE-----\n")
+			Expect(err).To(MatchError("CREDHUB_CA_CERT does not contain a PEM certificate"))
+		})
+	})
+})
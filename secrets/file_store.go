@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// FileStore keeps secrets in a single encrypted JSON file, so that they can
+// live next to a plaintext bbl-state.json.
+type FileStore struct {
+	path      string
+	fs        fs
+	encryptor encryptor
+}
+
+func NewFileStore(path string, fs fs, encryptor encryptor) FileStore {
+	return FileStore{path: path, fs: fs, encryptor: encryptor}
+}
+
+func (f FileStore) Get(name string) (string, error) {
+	secrets, err := f.read()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f FileStore) Put(name, value string) error {
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	secrets[name] = value
+
+	contents, err := json.MarshalIndent(secrets, "", "\t")
+	if err != nil {
+		return err // not tested
+	}
+
+	contents, err = f.encryptor.Encrypt(contents)
+	if err != nil {
+		return fmt.Errorf("encrypting %s: %s", f.path, err)
+	}
+
+	err = f.fs.WriteFile(f.path, contents, 0600)
+	if err != nil {
+		return fmt.Errorf("writing %s: %s", f.path, err)
+	}
+
+	return nil
+}
+
+func (f FileStore) read() (map[string]string, error) {
+	secrets := map[string]string{}
+
+	contents, err := f.fs.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", f.path, err)
+	}
+
+	contents, err = f.encryptor.Decrypt(contents)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %s", f.path, err)
+	}
+
+	err = json.Unmarshal(contents, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", f.path, err)
+	}
+
+	return secrets, nil
+}
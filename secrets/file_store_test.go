@@ -0,0 +1,51 @@
+package secrets_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/secrets"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileStore", func() {
+	var (
+		fs    *afero.Afero
+		store secrets.FileStore
+	)
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		store = secrets.NewFileStore("/some/state-dir/bbl-secrets.json", fs, storage.NewEncryptor("some-passphrase"))
+	})
+
+	It("keeps secrets in an encrypted file", func() {
+		Expect(store.Put("director-password", "some-password")).To(Succeed())
+		Expect(store.Put("director-vars-store", "some-vars-store")).To(Succeed())
+
+		value, err := store.Get("director-password")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("some-password"))
+
+		contents, err := fs.ReadFile("/some/state-dir/bbl-secrets.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(storage.IsEncrypted(contents)).To(BeTrue())
+		Expect(string(contents)).NotTo(ContainSubstring("some-password"))
+	})
+
+	It("returns ErrNotFound for a missing secret", func() {
+		_, err := store.Get("director-password")
+		Expect(err).To(MatchError(secrets.ErrNotFound))
+	})
+
+	Context("when the file was encrypted with another key", func() {
+		It("returns an error", func() {
+			Expect(store.Put("director-password", "some-password")).To(Succeed())
+
+			store = secrets.NewFileStore("/some/state-dir/bbl-secrets.json", fs, storage.NewEncryptor("some-other-passphrase"))
+			_, err := store.Get("director-password")
+			Expect(err).To(MatchError(ContainSubstring("decrypting /some/state-dir/bbl-secrets.json")))
+		})
+	})
+})
@@ -0,0 +1,189 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	directorPassword      = "director-password"
+	directorSSLPrivateKey = "director-ssl-private-key"
+)
+
+// varsStores are the bosh create-env vars stores. They hold the director and
+// jumpbox CA keys, so they only exist in the vars dir while bbl runs.
+var varsStores = map[string]string{
+	"director-vars-store": "director-vars-store.yml",
+	"jumpbox-vars-store":  "jumpbox-vars-store.yml",
+}
+
+// Resolver moves secrets between the state and a Store. Without a Store it
+// leaves the state as it is and refuses to resolve references.
+type Resolver struct {
+	store   Store
+	fs      fs
+	fetched map[string]string
+}
+
+func NewResolver(store Store, fs fs) *Resolver {
+	return &Resolver{store: store, fs: fs}
+}
+
+// Resolve returns the secret a reference in bbl-state.json points to. Other
+// values are returned as they are.
+func (r *Resolver) Resolve(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+
+	if r.store == nil {
+		return "", fmt.Errorf("bbl-state.json refers to %s in a secret store, provide --secret-store or BBL_SECRET_STORE", nameOf(value))
+	}
+
+	secret, err := r.store.Get(nameOf(value))
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("%s is missing from the secret store", nameOf(value))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// Validate refuses to run a command that changes an environment whose
+// secrets are kept in a store that is not configured, since bosh would
+// otherwise generate new credentials for it.
+func (r *Resolver) Validate(state storage.State) error {
+	if r.store != nil {
+		return nil
+	}
+
+	for _, value := range []string{state.BOSH.DirectorPassword, state.BOSH.DirectorSSLPrivateKey, state.BOSH.VarsStore, state.Jumpbox.VarsStore} {
+		if IsReference(value) {
+			_, err := r.Resolve(value)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SealSecrets puts the director credentials into the store and replaces them
+// with references, and records references for the vars stores in varsDir.
+func (r *Resolver) SealSecrets(state storage.State, varsDir string) (storage.State, error) {
+	if r.store == nil {
+		return state, nil
+	}
+
+	var err error
+	state.BOSH.DirectorPassword, err = r.seal(directorPassword, state.BOSH.DirectorPassword)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	state.BOSH.DirectorSSLPrivateKey, err = r.seal(directorSSLPrivateKey, state.BOSH.DirectorSSLPrivateKey)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	if r.hasVarsStore(varsDir, "director-vars-store") {
+		state.BOSH.VarsStore = Reference("director-vars-store")
+	}
+	if r.hasVarsStore(varsDir, "jumpbox-vars-store") {
+		state.Jumpbox.VarsStore = Reference("jumpbox-vars-store")
+	}
+
+	return state, nil
+}
+
+func (r *Resolver) seal(name, value string) (string, error) {
+	if value == "" || IsReference(value) {
+		return value, nil
+	}
+
+	err := r.store.Put(name, value)
+	if err != nil {
+		return "", fmt.Errorf("storing %s: %s", name, err)
+	}
+
+	return Reference(name), nil
+}
+
+func (r *Resolver) hasVarsStore(varsDir, name string) bool {
+	_, err := r.fs.Stat(filepath.Join(varsDir, varsStores[name]))
+	return err == nil
+}
+
+// FetchVarsStores writes the vars stores from the store into varsDir the
+// first time they are needed. A vars store already in varsDir is newer than
+// the stored one, e.g. after an interrupted run, and is kept.
+func (r *Resolver) FetchVarsStores(varsDir string) error {
+	if r.store == nil || r.fetched != nil {
+		return nil
+	}
+
+	fetched := map[string]string{}
+	for name, file := range varsStores {
+		if r.hasVarsStore(varsDir, name) {
+			continue
+		}
+
+		contents, err := r.store.Get(name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("fetching %s: %s", name, err)
+		}
+
+		err = r.fs.WriteFile(filepath.Join(varsDir, file), []byte(contents), storage.StateMode)
+		if err != nil {
+			return fmt.Errorf("writing %s: %s", file, err)
+		}
+		fetched[name] = contents
+	}
+
+	r.fetched = fetched
+	return nil
+}
+
+// StoreVarsStores puts the vars stores in varsDir into the store and removes
+// them from disk. A vars store that is unchanged since it was fetched is not
+// written back, so that a read-only command cannot overwrite a newer one.
+func (r *Resolver) StoreVarsStores(varsDir string) error {
+	if r.store == nil {
+		return nil
+	}
+
+	for name, file := range varsStores {
+		path := filepath.Join(varsDir, file)
+		contents, err := r.fs.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %s", file, err)
+		}
+
+		fetched, ok := r.fetched[name]
+		if !ok || fetched != string(contents) {
+			err = r.store.Put(name, string(contents))
+			if err != nil {
+				return fmt.Errorf("storing %s: %s", name, err)
+			}
+		}
+
+		err = r.fs.Remove(path)
+		if err != nil {
+			return fmt.Errorf("removing %s: %s", file, err)
+		}
+	}
+
+	r.fetched = nil
+	return nil
+}
@@ -0,0 +1,149 @@
+package secrets_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/secrets"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resolver", func() {
+	var (
+		fs       *afero.Afero
+		store    secrets.FileStore
+		resolver *secrets.Resolver
+	)
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		Expect(fs.MkdirAll("/state/vars", 0700)).To(Succeed())
+		store = secrets.NewFileStore("/state/bbl-secrets.json", fs, storage.NewEncryptor("some-passphrase"))
+		resolver = secrets.NewResolver(store, fs)
+	})
+
+	Describe("SealSecrets", func() {
+		It("replaces the director credentials with references", func() {
+			Expect(fs.WriteFile("/state/vars/director-vars-store.yml", []byte("some-vars-store"), 0600)).To(Succeed())
+
+			state, err := resolver.SealSecrets(storage.State{
+				EnvID: "some-env",
+				BOSH: storage.BOSH{
+					DirectorUsername:      "admin",
+					DirectorPassword:      "some-password",
+					DirectorSSLPrivateKey: "some-private-key",
+				},
+			}, "/state/vars")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(state.BOSH).To(Equal(storage.BOSH{
+				DirectorUsername:      "admin",
+				DirectorPassword:      "secret:director-password",
+				DirectorSSLPrivateKey: "secret:director-ssl-private-key",
+				VarsStore:             "secret:director-vars-store",
+			}))
+			Expect(state.Jumpbox.VarsStore).To(BeEmpty())
+
+			Expect(store.Get("director-password")).To(Equal("some-password"))
+			Expect(store.Get("director-ssl-private-key")).To(Equal("some-private-key"))
+		})
+
+		It("leaves references alone", func() {
+			state, err := resolver.SealSecrets(storage.State{
+				BOSH: storage.BOSH{DirectorPassword: "secret:director-password"},
+			}, "/state/vars")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(state.BOSH.DirectorPassword).To(Equal("secret:director-password"))
+			Expect(fs.Exists("/state/bbl-secrets.json")).To(BeFalse())
+		})
+
+		Context("without a store", func() {
+			It("leaves the state as it is", func() {
+				state := storage.State{BOSH: storage.BOSH{DirectorPassword: "some-password"}}
+
+				sealed, err := secrets.NewResolver(nil, fs).SealSecrets(state, "/state/vars")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sealed).To(Equal(state))
+			})
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("returns the secret a reference points to", func() {
+			Expect(store.Put("director-password", "some-password")).To(Succeed())
+
+			Expect(resolver.Resolve("secret:director-password")).To(Equal("some-password"))
+			Expect(resolver.Resolve("some-plain-password")).To(Equal("some-plain-password"))
+		})
+
+		It("returns an error for a missing secret", func() {
+			_, err := resolver.Resolve("secret:director-password")
+			Expect(err).To(MatchError("director-password is missing from the secret store"))
+		})
+
+		Context("without a store", func() {
+			It("asks for one", func() {
+				_, err := secrets.NewResolver(nil, fs).Resolve("secret:director-password")
+				Expect(err).To(MatchError("bbl-state.json refers to director-password in a secret store, provide --secret-store or BBL_SECRET_STORE"))
+			})
+		})
+	})
+
+	Describe("Validate", func() {
+		It("refuses a state with references when there is no store", func() {
+			err := secrets.NewResolver(nil, fs).Validate(storage.State{
+				Jumpbox: storage.Jumpbox{VarsStore: "secret:jumpbox-vars-store"},
+			})
+			Expect(err).To(MatchError(ContainSubstring("provide --secret-store")))
+
+			Expect(secrets.NewResolver(nil, fs).Validate(storage.State{})).To(Succeed())
+			Expect(resolver.Validate(storage.State{BOSH: storage.BOSH{DirectorPassword: "secret:director-password"}})).To(Succeed())
+		})
+	})
+
+	Describe("vars stores", func() {
+		It("fetches them once and moves them back into the store", func() {
+			Expect(store.Put("jumpbox-vars-store", "some-jumpbox-vars-store")).To(Succeed())
+
+			Expect(resolver.FetchVarsStores("/state/vars")).To(Succeed())
+			contents, err := fs.ReadFile("/state/vars/jumpbox-vars-store.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-jumpbox-vars-store"))
+			Expect(fs.Exists("/state/vars/director-vars-store.yml")).To(BeFalse())
+
+			Expect(fs.WriteFile("/state/vars/jumpbox-vars-store.yml", []byte("some-new-vars-store"), 0600)).To(Succeed())
+			Expect(resolver.FetchVarsStores("/state/vars")).To(Succeed())
+			contents, err = fs.ReadFile("/state/vars/jumpbox-vars-store.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-new-vars-store"))
+
+			Expect(resolver.StoreVarsStores("/state/vars")).To(Succeed())
+			Expect(fs.Exists("/state/vars/jumpbox-vars-store.yml")).To(BeFalse())
+			Expect(store.Get("jumpbox-vars-store")).To(Equal("some-new-vars-store"))
+		})
+
+		It("does not write back a vars store that did not change", func() {
+			Expect(store.Put("jumpbox-vars-store", "some-jumpbox-vars-store")).To(Succeed())
+			Expect(resolver.FetchVarsStores("/state/vars")).To(Succeed())
+
+			Expect(store.Put("jumpbox-vars-store", "some-newer-vars-store")).To(Succeed())
+			Expect(resolver.StoreVarsStores("/state/vars")).To(Succeed())
+
+			Expect(fs.Exists("/state/vars/jumpbox-vars-store.yml")).To(BeFalse())
+			Expect(store.Get("jumpbox-vars-store")).To(Equal("some-newer-vars-store"))
+		})
+
+		It("keeps a vars store left in the vars dir by an interrupted run", func() {
+			Expect(store.Put("jumpbox-vars-store", "some-old-vars-store")).To(Succeed())
+			Expect(fs.WriteFile("/state/vars/jumpbox-vars-store.yml", []byte("some-new-vars-store"), 0600)).To(Succeed())
+
+			Expect(resolver.FetchVarsStores("/state/vars")).To(Succeed())
+
+			contents, err := fs.ReadFile("/state/vars/jumpbox-vars-store.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-new-vars-store"))
+		})
+	})
+})
@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const referencePrefix = "secret:"
+
+var ErrNotFound = errors.New("secret not found")
+
+// Store keeps the secrets bbl would otherwise write to the state directory.
+// Names are relative to the store, which is configured per environment.
+type Store interface {
+	// Get returns ErrNotFound when there is no secret called name.
+	Get(name string) (string, error)
+	Put(name, value string) error
+}
+
+type fs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.Remover
+	fileio.Stater
+}
+
+type encryptor interface {
+	Enabled() bool
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(contents []byte) ([]byte, error)
+}
+
+// NewStore returns the store for a --secret-store value, or nil when the
+// value is empty and secrets stay in the state directory:
+//
+//	file                             bbl-secrets.json in the state dir, encrypted with --state-encryption-key
+//	vault://host:8200/mount/path     Vault KV version 2, authenticated with VAULT_TOKEN
+//	vault+http://host:8200/mount/path
+//	credhub://host:8844/path         CredHub, authenticated with CREDHUB_CLIENT and CREDHUB_SECRET
+func NewStore(storeURL, stateDir string, fs fs, encryptor encryptor) (Store, error) {
+	if storeURL == "" {
+		return nil, nil
+	}
+
+	if storeURL == "file" {
+		if !encryptor.Enabled() {
+			return nil, errors.New("--secret-store file requires --state-encryption-key")
+		}
+		return NewFileStore(filepath.Join(stateDir, storage.SECRETS_FILE), fs, encryptor), nil
+	}
+
+	parsed, err := url.Parse(storeURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --secret-store: %s", err)
+	}
+
+	path := strings.Trim(parsed.Path, "/")
+	switch parsed.Scheme {
+	case "vault", "vault+http":
+		mount, prefix, _ := strings.Cut(path, "/")
+		if mount == "" {
+			return nil, fmt.Errorf("--secret-store %s needs the KV mount and a path for this environment, e.g. vault://%s/secret/bbl/some-env", storeURL, parsed.Host)
+		}
+		return NewVaultStore(address(parsed, "vault"), mount, prefix, os.Getenv("VAULT_TOKEN")), nil
+	case "credhub", "credhub+http":
+		if path == "" {
+			return nil, fmt.Errorf("--secret-store %s needs a path for this environment, e.g. credhub://%s/bbl/some-env", storeURL, parsed.Host)
+		}
+		return NewCredHubStore(address(parsed, "credhub"), path, os.Getenv("CREDHUB_CLIENT"), os.Getenv("CREDHUB_SECRET"), os.Getenv("CREDHUB_CA_CERT"))
+	default:
+		return nil, fmt.Errorf("--secret-store %q is unsupported, use file, vault://host/mount/path or credhub://host/path", storeURL)
+	}
+}
+
+// address is the https URL of the server, or http for the "+http" schemes.
+func address(parsed *url.URL, scheme string) string {
+	if parsed.Scheme == scheme+"+http" {
+		return fmt.Sprintf("http://%s", parsed.Host)
+	}
+	return fmt.Sprintf("https://%s", parsed.Host)
+}
+
+// Reference is what bbl-state.json holds in place of the secret called name.
+func Reference(name string) string {
+	return referencePrefix + name
+}
+
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, referencePrefix)
+}
+
+func nameOf(reference string) string {
+	return strings.TrimPrefix(reference, referencePrefix)
+}
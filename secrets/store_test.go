@@ -0,0 +1,61 @@
+package secrets_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/secrets"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewStore", func() {
+	var fs *afero.Afero
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+	})
+
+	It("keeps secrets in the state dir when no store is given", func() {
+		store, err := secrets.NewStore("", "/some/state-dir", fs, storage.NewEncryptor(""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store).To(BeNil())
+	})
+
+	It("returns an encrypted file store for file", func() {
+		store, err := secrets.NewStore("file", "/some/state-dir", fs, storage.NewEncryptor("some-passphrase"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store).To(Equal(secrets.NewFileStore(filepath.Join("/some/state-dir", "bbl-secrets.json"), fs, storage.NewEncryptor("some-passphrase"))))
+	})
+
+	It("requires a state encryption key for file", func() {
+		_, err := secrets.NewStore("file", "/some/state-dir", fs, storage.NewEncryptor(""))
+		Expect(err).To(MatchError("--secret-store file requires --state-encryption-key"))
+	})
+
+	It("returns a vault store for vault urls", func() {
+		os.Setenv("VAULT_TOKEN", "some-token") //nolint:errcheck
+		defer os.Unsetenv("VAULT_TOKEN")       //nolint:errcheck
+
+		store, err := secrets.NewStore("vault://vault.example.com:8200/secret/bbl/some-env", "/some/state-dir", fs, storage.NewEncryptor(""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store).To(Equal(secrets.NewVaultStore("https://vault.example.com:8200", "secret", "bbl/some-env", "some-token")))
+
+		store, err = secrets.NewStore("vault+http://127.0.0.1:8200/secret/some-env", "/some/state-dir", fs, storage.NewEncryptor(""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store).To(Equal(secrets.NewVaultStore("http://127.0.0.1:8200", "secret", "some-env", "some-token")))
+	})
+
+	DescribeTable("rejects incomplete or unknown stores",
+		func(storeURL, message string) {
+			_, err := secrets.NewStore(storeURL, "/some/state-dir", fs, storage.NewEncryptor(""))
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("a vault url without a mount", "vault://vault.example.com:8200", "needs the KV mount"),
+		Entry("a credhub url without a path", "credhub://credhub.example.com:8844", "needs a path"),
+		Entry("an unknown scheme", "consul://consul.example.com/bbl", "is unsupported"),
+	)
+})
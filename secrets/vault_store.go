@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+)
+
+// VaultStore keeps secrets in a Vault KV version 2 secrets engine, one
+// secret per name with the contents under the "value" key.
+type VaultStore struct {
+	address string
+	mount   string
+	prefix  string
+	token   string
+	client  *http.Client
+}
+
+func NewVaultStore(address, mount, prefix, token string) VaultStore {
+	return VaultStore{
+		address: address,
+		mount:   mount,
+		prefix:  prefix,
+		token:   token,
+		client:  http.DefaultClient,
+	}
+}
+
+type vaultSecret struct {
+	Data struct {
+		Data struct {
+			Value *string `json:"value"`
+		} `json:"data"`
+	} `json:"data"`
+}
+
+func (v VaultStore) Get(name string) (string, error) {
+	response, err := v.do(http.MethodGet, name, nil)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", vaultError(response, "reading", name)
+	}
+
+	var secret vaultSecret
+	err = json.NewDecoder(response.Body).Decode(&secret)
+	if err != nil {
+		return "", fmt.Errorf("reading %s from vault: %s", name, err)
+	}
+	if secret.Data.Data.Value == nil {
+		return "", ErrNotFound
+	}
+
+	return *secret.Data.Data.Value, nil
+}
+
+func (v VaultStore) Put(name, value string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"data": map[string]string{"value": value},
+	})
+	if err != nil {
+		return err // not tested
+	}
+
+	response, err := v.do(http.MethodPost, name, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNoContent {
+		return vaultError(response, "writing", name)
+	}
+
+	return nil
+}
+
+func (v VaultStore) do(method, name string, body []byte) (*http.Response, error) {
+	url := fmt.Sprintf("%s/v1/%s", v.address, path.Join(v.mount, "data", v.prefix, name))
+	request, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err // not tested
+	}
+	request.Header.Set("X-Vault-Token", v.token)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := v.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to vault: %s", err)
+	}
+	return response, nil
+}
+
+func vaultError(response *http.Response, action, name string) error {
+	body, _ := io.ReadAll(response.Body) //nolint:errcheck
+	return fmt.Errorf("%s %s in vault: %s: %s", action, name, response.Status, bytes.TrimSpace(body))
+}
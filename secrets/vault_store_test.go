@@ -0,0 +1,84 @@
+package secrets_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/secrets"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VaultStore", func() {
+	var (
+		server *httptest.Server
+		data   map[string]string
+		store  secrets.VaultStore
+	)
+
+	BeforeEach(func() {
+		data = map[string]string{}
+
+		// Enough of the KV version 2 API of `vault server -dev`.
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != "some-token" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"errors":["permission denied"]}`)) //nolint:errcheck
+				return
+			}
+
+			path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+			switch r.Method {
+			case http.MethodGet:
+				value, ok := data[path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"errors":[]}`)) //nolint:errcheck
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+					"data": map[string]interface{}{"data": map[string]string{"value": value}},
+				})
+			case http.MethodPost:
+				var body struct {
+					Data map[string]string `json:"data"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				data[path] = body.Data["value"]
+				w.Write([]byte(`{"data":{"version":1}}`)) //nolint:errcheck
+			}
+		}))
+
+		store = secrets.NewVaultStore(server.URL, "secret", "bbl/some-env", "some-token")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("keeps secrets under the prefix", func() {
+		Expect(store.Put("director-password", "some-password")).To(Succeed())
+		Expect(data).To(Equal(map[string]string{"bbl/some-env/director-password": "some-password"}))
+
+		value, err := store.Get("director-password")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("some-password"))
+	})
+
+	It("returns ErrNotFound for a missing secret", func() {
+		_, err := store.Get("director-password")
+		Expect(err).To(MatchError(secrets.ErrNotFound))
+	})
+
+	Context("when the token is rejected", func() {
+		It("returns an error", func() {
+			store = secrets.NewVaultStore(server.URL, "secret", "bbl/some-env", "some-other-token")
+
+			err := store.Put("director-password", "some-password")
+			Expect(err).To(MatchError(`writing director-password in vault: 403 Forbidden: {"errors":["permission denied"]}`))
+		})
+	})
+})
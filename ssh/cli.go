@@ -8,28 +8,40 @@ import (
 )
 
 type CLI struct {
-	in  io.Reader
-	out io.Writer
-	err io.Writer
+	command string
+	in      io.Reader
+	out     io.Writer
+	err     io.Writer
 }
 
 func NewCLI(in io.Reader, out, err io.Writer) CLI {
 	return CLI{
-		in:  in,
-		out: out,
-		err: err,
+		command: "ssh",
+		in:      in,
+		out:     out,
+		err:     err,
+	}
+}
+
+// NewSCPCLI runs scp, which takes the same options as ssh.
+func NewSCPCLI(in io.Reader, out, err io.Writer) CLI {
+	return CLI{
+		command: "scp",
+		in:      in,
+		out:     out,
+		err:     err,
 	}
 }
 
 // background execute
 func (c CLI) Start(args []string) (*exec.Cmd, error) {
-	fmt.Fprintf(c.out, "starting:\nssh %s\n", strings.Join(args, " ")) //nolint:errcheck
+	fmt.Fprintf(c.out, "starting:\n%s %s\n", c.command, strings.Join(args, " ")) //nolint:errcheck
 	return c.start(args)
 }
 
 // foreground execute
 func (c CLI) Run(args []string) error {
-	fmt.Fprintf(c.out, "running:\nssh %s\n", strings.Join(args, " ")) //nolint:errcheck
+	fmt.Fprintf(c.out, "running:\n%s %s\n", c.command, strings.Join(args, " ")) //nolint:errcheck
 	cmd, err := c.start(args)
 	if err != nil {
 		return err
@@ -38,7 +50,7 @@ func (c CLI) Run(args []string) error {
 }
 
 func (c CLI) start(args []string) (*exec.Cmd, error) {
-	command := exec.Command("ssh", args...)
+	command := exec.Command(c.command, args...)
 
 	command.Stdin = c.in
 	command.Stdout = c.out
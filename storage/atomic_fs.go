@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// AtomicFs writes the files bbl manages in the state dir through a temporary
+// file that is synced and then renamed into place, so that a crash or a full
+// disk never leaves one of them half written. Other files are written as usual.
+type AtomicFs struct {
+	*afero.Afero
+	dir string
+}
+
+func NewAtomicFs(fs *afero.Afero, dir string) AtomicFs {
+	return AtomicFs{Afero: fs, dir: dir}
+}
+
+func (a AtomicFs) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	relPath, err := filepath.Rel(a.dir, filename)
+	if err != nil || !isBBLManaged(relPath) {
+		return a.Afero.WriteFile(filename, data, perm)
+	}
+
+	return writeFileAtomically(a.Fs, filename, data, perm)
+}
+
+func writeFileAtomically(fs afero.Fs, filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+	temp, err := afero.TempFile(fs, dir, fmt.Sprintf(".%s-", filepath.Base(filename)))
+	if err != nil {
+		return err
+	}
+	defer fs.Remove(temp.Name()) //nolint:errcheck
+
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Write %s: %s", filename, err) //nolint:staticcheck
+	}
+
+	err = fs.Chmod(temp.Name(), perm)
+	if err != nil {
+		return err
+	}
+
+	err = fs.Rename(temp.Name(), filename)
+	if err != nil {
+		return err
+	}
+
+	// Sync the directory too so that the rename itself survives a crash.
+	if parent, err := fs.Open(dir); err == nil {
+		parent.Sync()  //nolint:errcheck
+		parent.Close() //nolint:errcheck
+	}
+
+	return nil
+}
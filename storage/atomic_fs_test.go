@@ -0,0 +1,71 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AtomicFs", func() {
+	var (
+		fs      storage.AtomicFs
+		tempDir string
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		fs = storage.NewAtomicFs(&afero.Afero{Fs: afero.NewOsFs()}, tempDir)
+
+		Expect(os.Mkdir(filepath.Join(tempDir, "vars"), os.ModePerm)).To(Succeed())
+	})
+
+	It("replaces managed files without leaving temporary files behind", func() {
+		path := filepath.Join(tempDir, "vars", "bosh-state.json")
+		Expect(os.WriteFile(path, []byte("some-old-contents"), 0644)).To(Succeed())
+
+		err := fs.WriteFile(path, []byte("some-contents"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-contents"))
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		entries, err := os.ReadDir(filepath.Join(tempDir, "vars"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("writes other files as usual", func() {
+		path := filepath.Join(tempDir, "vars", "user-ops.yml")
+
+		err := fs.WriteFile(path, []byte("some-contents"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-contents"))
+	})
+
+	DescribeTable("the files written atomically",
+		func(relPath string, managed bool) {
+			Expect(storage.IsBBLManaged(relPath)).To(Equal(managed))
+		},
+		Entry("bbl-state.json", "bbl-state.json", true),
+		Entry("a vars store", "vars/director-vars-store.yml", true),
+		Entry("a bosh-deployment manifest", "bosh-deployment/bosh.yml", true),
+		Entry("a nested bosh-deployment ops file", "bosh-deployment/aws/cpi.yml", true),
+		Entry("a deeply nested bosh-deployment ops file", "bosh-deployment/experimental/some-dir/ops.yml", true),
+		Entry("a nested jumpbox-deployment ops file", "jumpbox-deployment/aws/cpi.yml", true),
+		Entry("a user ops file", "vars/user-ops.yml", false),
+		Entry("a user terraform override", "terraform/override.tf", false),
+	)
+})
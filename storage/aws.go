@@ -3,6 +3,8 @@ package storage
 type AWS struct {
 	AccessKeyID     string `json:"-"`
 	SecretAccessKey string `json:"-"`
+	SessionToken    string `json:"-"`
+	Profile         string `json:"profile,omitempty"`
 	AssumeRoleArn   string `json:"assumeRole,omitempty"`
 	Region          string `json:"region,omitempty"`
 }
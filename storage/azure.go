@@ -1,9 +1,10 @@
 package storage
 
 type Azure struct {
-	ClientID       string `json:"-"`
-	ClientSecret   string `json:"-"`
-	Region         string `json:"region,omitempty"`
-	SubscriptionID string `json:"-"`
-	TenantID       string `json:"-"`
+	ClientID         string `json:"-"`
+	ClientSecret     string `json:"-"`
+	CredentialSource string `json:"credentialSource,omitempty"`
+	Region           string `json:"region,omitempty"`
+	SubscriptionID   string `json:"-"`
+	TenantID         string `json:"-"`
 }
@@ -1,27 +1,61 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
+	"time"
+
+	"github.com/spf13/afero"
 )
 
 type bootstrapLogger interface {
 	Println(message string)
 }
 
+type stateDecryptor interface {
+	Decrypt(contents []byte) ([]byte, error)
+}
+
+// CorruptStateError means bbl-state.json could not be trusted, typically
+// because a write was interrupted. Snapshot is the latest snapshot that can
+// replace it, if there is one. Edited means the file is still valid but no
+// longer matches its checksum.
+type CorruptStateError struct {
+	Reason   string
+	Snapshot *Snapshot
+	Edited   bool
+}
+
+func (e CorruptStateError) Error() string {
+	message := fmt.Sprintf("bbl-state.json is corrupted or was edited by hand: %s.", e.Reason)
+	if e.Edited {
+		message += "\nIf you edited it on purpose, run `bbl state accept-edit` to trust it again."
+	}
+	if e.Snapshot == nil {
+		return message
+	}
+
+	return fmt.Sprintf("%s\nThe last snapshot, %s, was taken before `bbl %s` at %s. Restore it with `bbl state rollback %s`.",
+		message, e.Snapshot.ID, e.Snapshot.Command, e.Snapshot.Created.Format(time.RFC3339), e.Snapshot.ID)
+}
+
 type StateBootstrap struct {
 	bootstrapLogger bootstrapLogger
 	bblVersion      string
+	decryptor       stateDecryptor
 }
 
-func NewStateBootstrap(bootstrapLogger bootstrapLogger, bblVersion string) StateBootstrap {
+func NewStateBootstrap(bootstrapLogger bootstrapLogger, bblVersion string, decryptor stateDecryptor) StateBootstrap {
 	return StateBootstrap{
 		bootstrapLogger: bootstrapLogger,
 		bblVersion:      bblVersion,
+		decryptor:       decryptor,
 	}
 }
 
@@ -31,7 +65,7 @@ func (b StateBootstrap) GetState(dir string) (State, error) {
 		return State{}, err
 	}
 
-	file, err := os.Open(filepath.Join(dir, STATE_FILE))
+	contents, err := os.ReadFile(filepath.Join(dir, STATE_FILE))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return State{}, nil
@@ -39,9 +73,24 @@ func (b StateBootstrap) GetState(dir string) (State, error) {
 		return State{}, err
 	}
 
+	contents, err = b.decryptor.Decrypt(contents)
+	if err != nil {
+		return State{}, err
+	}
+
+	if hasChecksum, valid := verifyChecksum(contents); hasChecksum && !valid {
+		corrupt := b.corruptStateError(dir, "its checksum does not match")
+		corrupt.Edited = json.Valid(removeChecksum(bytes.TrimSpace(contents)))
+		return State{}, corrupt
+	}
+
 	state := State{}
-	err = json.NewDecoder(file).Decode(&state)
+	err = json.NewDecoder(bytes.NewReader(contents)).Decode(&state)
 	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return State{}, b.corruptStateError(dir, err.Error())
+		}
 		return state, err
 	}
 
@@ -67,6 +116,18 @@ func (b StateBootstrap) GetState(dir string) (State, error) {
 	return state, nil
 }
 
+func (b StateBootstrap) corruptStateError(dir, reason string) CorruptStateError {
+	corrupt := CorruptStateError{Reason: reason}
+
+	// StateBootstrap reads the state dir with os, so the history does too.
+	snapshots, err := NewHistory(dir, &afero.Afero{Fs: afero.NewOsFs()}).List()
+	if err == nil && len(snapshots) > 0 {
+		corrupt.Snapshot = &snapshots[len(snapshots)-1]
+	}
+
+	return corrupt
+}
+
 // Get the earliest bbl version compatible with the given bbl state version.
 func (b StateBootstrap) getBBLVersion(stateSchema int) string {
 	stateToBBLVersion := map[int]string{
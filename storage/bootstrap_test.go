@@ -1,10 +1,16 @@
 package storage_test
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
 
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/secrets"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -23,7 +29,7 @@ var _ = Describe("StateBootstrap", func() {
 		BeforeEach(func() {
 			logger = &fakes.Logger{}
 			latestVersion = "latest"
-			bootstrap = storage.NewStateBootstrap(logger, latestVersion)
+			bootstrap = storage.NewStateBootstrap(logger, latestVersion, storage.NewEncryptor(""))
 
 			var err error
 			tempDir, err = os.MkdirTemp("", "")
@@ -103,6 +109,39 @@ var _ = Describe("StateBootstrap", func() {
 			})
 		})
 
+		Context("when the state file is encrypted", func() {
+			BeforeEach(func() {
+				contents, err := storage.NewEncryptor("some-passphrase").Encrypt([]byte(`{
+					"version": 14,
+					"bblVersion": "some-bbl-version",
+					"iaas": "gcp"
+				}`))
+				Expect(err).NotTo(HaveOccurred())
+
+				err = os.WriteFile(filepath.Join(tempDir, "bbl-state.json"), contents, storage.StateMode)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("decrypts the state with the encryption key", func() {
+				bootstrap = storage.NewStateBootstrap(logger, latestVersion, storage.NewEncryptor("some-passphrase"))
+
+				state, err := bootstrap.GetState(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state).To(Equal(storage.State{
+					Version:    14,
+					BBLVersion: "some-bbl-version",
+					IAAS:       "gcp",
+				}))
+			})
+
+			Context("when no encryption key is provided", func() {
+				It("returns an error", func() {
+					_, err := bootstrap.GetState(tempDir)
+					Expect(err).To(MatchError(storage.ErrMissingEncryptionKey))
+				})
+			})
+		})
+
 		Context("when there is a state file missing BBL version", func() {
 			BeforeEach(func() {
 				err := os.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte(`{
@@ -172,6 +211,72 @@ var _ = Describe("StateBootstrap", func() {
 			})
 		})
 
+		Context("when the state file carries a checksum", func() {
+			var stateFile string
+
+			BeforeEach(func() {
+				fs := &afero.Afero{Fs: afero.NewOsFs()}
+				store := storage.NewStore(tempDir, fs, storage.NewGarbageCollector(fs), storage.NewEncryptor(""), secrets.NewResolver(nil, nil))
+				Expect(store.Set(storage.State{IAAS: "gcp", EnvID: "some-env-id"})).To(Succeed())
+
+				stateFile = filepath.Join(tempDir, "bbl-state.json")
+			})
+
+			It("returns the state when the checksum matches", func() {
+				state, err := bootstrap.GetState(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.EnvID).To(Equal("some-env-id"))
+			})
+
+			Context("when the contents no longer match the checksum", func() {
+				BeforeEach(func() {
+					contents, err := os.ReadFile(stateFile)
+					Expect(err).NotTo(HaveOccurred())
+					contents = bytes.Replace(contents, []byte("some-env-id"), []byte("other-env-id"), 1)
+					Expect(os.WriteFile(stateFile, contents, storage.StateMode)).To(Succeed())
+				})
+
+				It("reports the corruption", func() {
+					_, err := bootstrap.GetState(tempDir)
+					Expect(err).To(MatchError("bbl-state.json is corrupted or was edited by hand: its checksum does not match.\nIf you edited it on purpose, run `bbl state accept-edit` to trust it again."))
+				})
+
+				It("trusts the edit once it is accepted", func() {
+					fs := &afero.Afero{Fs: afero.NewOsFs()}
+					store := storage.NewStore(tempDir, fs, storage.NewGarbageCollector(fs), storage.NewEncryptor(""), secrets.NewResolver(nil, nil))
+					Expect(store.AcceptEdit()).To(Succeed())
+
+					state, err := bootstrap.GetState(tempDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(state.EnvID).To(Equal("other-env-id"))
+				})
+			})
+
+			Context("when the file was truncated and there is a snapshot", func() {
+				BeforeEach(func() {
+					storage.SetTimeNow(func() time.Time { return time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC) })
+					Expect(storage.NewHistory(tempDir, &afero.Afero{Fs: afero.NewOsFs()}).Snapshot("up")).To(Succeed())
+
+					contents, err := os.ReadFile(stateFile)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(os.WriteFile(stateFile, contents[:len(contents)/2], storage.StateMode)).To(Succeed())
+				})
+
+				AfterEach(func() {
+					storage.ResetTimeNow()
+				})
+
+				It("offers the last snapshot", func() {
+					_, err := bootstrap.GetState(tempDir)
+
+					var corrupt storage.CorruptStateError
+					Expect(errors.As(err, &corrupt)).To(BeTrue())
+					Expect(corrupt.Snapshot.ID).To(Equal("20180102T030405Z"))
+					Expect(err.Error()).To(ContainSubstring("The last snapshot, 20180102T030405Z, was taken before `bbl up` at 2018-01-02T03:04:05Z. Restore it with `bbl state rollback 20180102T030405Z`."))
+				})
+			})
+		})
+
 		Context("failure cases", func() {
 			Context("when the directory does not exist", func() {
 				It("returns an error", func() {
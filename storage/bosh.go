@@ -11,6 +11,7 @@ type BOSH struct {
 	DirectorSSLCertificate string                 `json:"directorSSLCertificate"`
 	DirectorSSLPrivateKey  string                 `json:"directorSSLPrivateKey"`
 	Variables              string                 `json:"variables,omitempty"`
+	VarsStore              string                 `json:"varsStore,omitempty"`
 	State                  map[string]interface{} `json:"state,omitempty"`
 	Manifest               string                 `json:"manifest,omitempty"`
 }
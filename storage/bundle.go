@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/spf13/afero"
+)
+
+const (
+	BUNDLE_FORMAT = 1
+
+	bundleManifestFile = "bbl-bundle.json"
+)
+
+// BundleManifest describes a bundle written by `bbl state export`.
+type BundleManifest struct {
+	Format      int       `json:"format"`
+	BBLVersion  string    `json:"bblVersion"`
+	StateSchema int       `json:"stateSchema"`
+	EnvID       string    `json:"envID"`
+	IAAS        string    `json:"iaas"`
+	Encrypted   bool      `json:"encrypted"`
+	Created     time.Time `json:"created"`
+	Files       []string  `json:"files"`
+}
+
+// Bundler moves an environment between state directories. A bundle holds
+// bbl-state.json, the vars directory and the user's plan patches; everything
+// else is regenerated by `bbl plan` on import, so that paths and plugin
+// caches from the exporting machine are left behind. The vars directory is
+// sealed again on the way into the bundle, so that it never holds plaintext
+// credentials when the state is encrypted.
+type Bundler struct {
+	dir        string
+	bblVersion string
+	fs         bundlerFs
+	encryptor  encryptor
+}
+
+// bundlerFs is an afero.Fs so that every file the bundler writes can be
+// written atomically.
+type bundlerFs interface {
+	afero.Fs
+	fileio.FileReader
+}
+
+func NewBundler(dir, bblVersion string, fs bundlerFs, encryptor encryptor) Bundler {
+	return Bundler{dir: dir, bblVersion: bblVersion, fs: fs, encryptor: encryptor}
+}
+
+func (b Bundler) Export(bundlePath string, state State) error {
+	files, err := b.bundleFiles()
+	if err != nil {
+		return err
+	}
+
+	stateFile, err := b.fs.ReadFile(filepath.Join(b.dir, STATE_FILE))
+	if err != nil {
+		return fmt.Errorf("Read state file: %s", err) //nolint:staticcheck
+	}
+
+	manifest, err := json.MarshalIndent(BundleManifest{
+		Format:      BUNDLE_FORMAT,
+		BBLVersion:  b.bblVersion,
+		StateSchema: STATE_SCHEMA,
+		EnvID:       state.EnvID,
+		IAAS:        state.IAAS,
+		Encrypted:   IsEncrypted(stateFile),
+		Created:     timeNow().UTC(),
+		Files:       files,
+	}, "", "\t")
+	if err != nil {
+		return err // not tested
+	}
+
+	var bundle bytes.Buffer
+	gzipWriter := gzip.NewWriter(&bundle)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = writeTarEntry(tarWriter, bundleManifestFile, manifest, StateMode)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		fullPath := filepath.Join(b.dir, filepath.FromSlash(file))
+		info, err := b.fs.Stat(fullPath)
+		if err != nil {
+			return err
+		}
+
+		contents, err := b.fs.ReadFile(fullPath)
+		if err != nil {
+			return err
+		}
+
+		if path.Dir(file) == "vars" {
+			contents, err = b.encryptor.Encrypt(contents)
+			if err != nil {
+				return fmt.Errorf("%s: %s", file, err)
+			}
+		}
+
+		err = writeTarEntry(tarWriter, file, contents, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+	err = gzipWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(b.fs, bundlePath, bundle.Bytes(), StateMode)
+}
+
+// Import unpacks a bundle into the state directory. The whole bundle is
+// validated before anything is written, and an existing environment is never
+// overwritten.
+func (b Bundler) Import(bundlePath string) (BundleManifest, error) {
+	_, err := b.fs.Stat(filepath.Join(b.dir, STATE_FILE))
+	if err == nil {
+		return BundleManifest{}, fmt.Errorf("%s already contains a bbl environment, import into an empty state directory", b.dir)
+	}
+
+	manifest, files, err := b.readBundle(bundlePath)
+	if err != nil {
+		return BundleManifest{}, fmt.Errorf("Read bundle: %s", err) //nolint:staticcheck
+	}
+
+	if manifest.Format > BUNDLE_FORMAT || manifest.StateSchema > STATE_SCHEMA {
+		return BundleManifest{}, fmt.Errorf("The bundle was exported by bbl v%s with state schema %d. Please upgrade bbl to import it.", manifest.BBLVersion, manifest.StateSchema) //nolint:staticcheck
+	}
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file := files[name]
+		fullPath := filepath.Join(b.dir, filepath.FromSlash(name))
+
+		err = b.fs.MkdirAll(filepath.Dir(fullPath), StateMode)
+		if err != nil {
+			return BundleManifest{}, err
+		}
+
+		err = writeFileAtomically(b.fs, fullPath, file.contents, file.mode)
+		if err != nil {
+			return BundleManifest{}, err
+		}
+	}
+
+	return manifest, nil
+}
+
+func (b Bundler) bundleFiles() ([]string, error) {
+	files := []string{STATE_FILE}
+
+	err := afero.Walk(b.fs, b.dir, func(fullPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(b.dir, fullPath)
+		if err != nil {
+			return err // not tested
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			if relPath == ".terraform" || strings.HasPrefix(relPath, ".bbl") || strings.HasSuffix(relPath, "/.terraform") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if belongsInBundle(relPath) && relPath != STATE_FILE {
+			files = append(files, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Walk state dir: %s", err) //nolint:staticcheck
+	}
+
+	return files, nil
+}
+
+// belongsInBundle is true for the state file, the vars directory, the
+// secrets kept by `--secret-store file` and the files PatchDetector reports
+// as supplied by the user.
+func belongsInBundle(relPath string) bool {
+	if relPath == STATE_FILE || relPath == SECRETS_FILE || strings.HasPrefix(relPath, "vars/") {
+		return true
+	}
+	return isUserManaged(relPath) && !isBBLManaged(relPath)
+}
+
+type bundleFile struct {
+	contents []byte
+	mode     os.FileMode
+}
+
+func (b Bundler) readBundle(bundlePath string) (BundleManifest, map[string]bundleFile, error) {
+	bundle, err := b.fs.Open(bundlePath)
+	if err != nil {
+		return BundleManifest{}, nil, err
+	}
+	defer bundle.Close() //nolint:errcheck
+
+	gzipReader, err := gzip.NewReader(bundle)
+	if err != nil {
+		return BundleManifest{}, nil, err
+	}
+
+	var (
+		manifest      BundleManifest
+		foundManifest bool
+		files         = map[string]bundleFile{}
+		tarReader     = tar.NewReader(gzipReader)
+	)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return BundleManifest{}, nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			return BundleManifest{}, nil, fmt.Errorf("%s is not a regular file", header.Name)
+		}
+
+		contents, err := io.ReadAll(tarReader)
+		if err != nil {
+			return BundleManifest{}, nil, err
+		}
+
+		if header.Name == bundleManifestFile {
+			err = json.Unmarshal(contents, &manifest)
+			if err != nil {
+				return BundleManifest{}, nil, fmt.Errorf("%s: %s", bundleManifestFile, err)
+			}
+			foundManifest = true
+			continue
+		}
+
+		if path.Clean(header.Name) != header.Name || path.IsAbs(header.Name) || strings.HasPrefix(header.Name, "../") || !belongsInBundle(header.Name) {
+			return BundleManifest{}, nil, fmt.Errorf("%s does not belong in a bbl bundle", header.Name)
+		}
+
+		mode := os.FileMode(header.Mode).Perm()
+		if mode == 0 {
+			mode = StateMode
+		}
+		files[header.Name] = bundleFile{contents: contents, mode: mode}
+	}
+
+	if !foundManifest {
+		return BundleManifest{}, nil, fmt.Errorf("%s is missing, this is not a bbl bundle", bundleManifestFile)
+	}
+
+	if _, ok := files[STATE_FILE]; !ok {
+		return BundleManifest{}, nil, errors.New("the bundle does not contain bbl-state.json")
+	}
+
+	for _, name := range manifest.Files {
+		if _, ok := files[name]; !ok {
+			return BundleManifest{}, nil, fmt.Errorf("%s is listed in %s but missing from the bundle", name, bundleManifestFile)
+		}
+	}
+
+	return manifest, files, nil
+}
+
+func writeTarEntry(tarWriter *tar.Writer, name string, contents []byte, mode os.FileMode) error {
+	err := tarWriter.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     int64(mode),
+		Size:     int64(len(contents)),
+		Typeflag: tar.TypeReg,
+		ModTime:  timeNow(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tarWriter.Write(contents)
+	return err
+}
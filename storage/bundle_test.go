@@ -0,0 +1,226 @@
+package storage_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundler", func() {
+	var (
+		fs         *afero.Afero
+		exportDir  string
+		importDir  string
+		bundlePath string
+	)
+
+	exists := func(path string) bool {
+		exists, err := fs.Exists(path)
+		Expect(err).NotTo(HaveOccurred())
+		return exists
+	}
+
+	writeFile := func(dir, path, contents string) {
+		Expect(fs.MkdirAll(filepath.Dir(filepath.Join(dir, path)), os.ModePerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(dir, path), []byte(contents), storage.StateMode)).To(Succeed())
+	}
+
+	writeBundle := func(entries map[string]string) {
+		bundle, err := fs.Create(bundlePath)
+		Expect(err).NotTo(HaveOccurred())
+		gzipWriter := gzip.NewWriter(bundle)
+		tarWriter := tar.NewWriter(gzipWriter)
+		for name, contents := range entries {
+			Expect(tarWriter.WriteHeader(&tar.Header{
+				Name:     name,
+				Mode:     0600,
+				Size:     int64(len(contents)),
+				Typeflag: tar.TypeReg,
+			})).To(Succeed())
+			_, err = tarWriter.Write([]byte(contents))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(tarWriter.Close()).To(Succeed())
+		Expect(gzipWriter.Close()).To(Succeed())
+		Expect(bundle.Close()).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		exportDir = "/some/export-dir"
+		importDir = "/some/import-dir"
+		Expect(fs.MkdirAll(exportDir, os.ModePerm)).To(Succeed())
+		Expect(fs.MkdirAll(importDir, os.ModePerm)).To(Succeed())
+		bundlePath = filepath.Join(exportDir, "some-env.tgz")
+
+		storage.SetTimeNow(func() time.Time {
+			return time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+		})
+	})
+
+	AfterEach(func() {
+		storage.ResetTimeNow()
+	})
+
+	It("moves the state, vars and user files between state directories", func() {
+		writeFile(exportDir, "bbl-state.json", `{"envID": "some-env"}`)
+		writeFile(exportDir, "bbl-secrets.json", "some-encrypted-secrets")
+		writeFile(exportDir, "vars/terraform.tfstate", "some-tfstate")
+		writeFile(exportDir, "vars/director-vars-store.yml", "some-creds")
+		writeFile(exportDir, "terraform/my-patch.tf", "some-patch")
+		writeFile(exportDir, "create-director-override.sh", "some-override")
+		writeFile(exportDir, "terraform/bbl-template.tf", "some-template")
+		writeFile(exportDir, "create-director.sh", "some-script")
+		writeFile(exportDir, ".terraform/plugins/some-plugin", "some-plugin")
+		writeFile(exportDir, ".bbl/history/some-snapshot/bbl-state.json", "some-old-state")
+
+		bundler := storage.NewBundler(exportDir, "1.2.3", fs, storage.NewEncryptor(""))
+		err := bundler.Export(bundlePath, storage.State{EnvID: "some-env", IAAS: "aws"})
+		Expect(err).NotTo(HaveOccurred())
+
+		manifest, err := storage.NewBundler(importDir, "1.2.3", fs, storage.NewEncryptor("")).Import(bundlePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest).To(Equal(storage.BundleManifest{
+			Format:      storage.BUNDLE_FORMAT,
+			BBLVersion:  "1.2.3",
+			StateSchema: storage.STATE_SCHEMA,
+			EnvID:       "some-env",
+			IAAS:        "aws",
+			Created:     time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+			Files: []string{
+				"bbl-state.json",
+				"bbl-secrets.json",
+				"create-director-override.sh",
+				"terraform/my-patch.tf",
+				"vars/director-vars-store.yml",
+				"vars/terraform.tfstate",
+			},
+		}))
+
+		for _, file := range manifest.Files {
+			contents, err := fs.ReadFile(filepath.Join(importDir, file))
+			Expect(err).NotTo(HaveOccurred())
+			expected, err := fs.ReadFile(filepath.Join(exportDir, file))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(Equal(expected))
+		}
+
+		Expect(exists(filepath.Join(importDir, "create-director.sh"))).To(BeFalse())
+		Expect(exists(filepath.Join(importDir, "terraform", "bbl-template.tf"))).To(BeFalse())
+		Expect(exists(filepath.Join(importDir, ".terraform"))).To(BeFalse())
+		Expect(exists(filepath.Join(importDir, ".bbl"))).To(BeFalse())
+	})
+
+	Context("when the state is encrypted", func() {
+		It("exports the vars directory encrypted even if it was left unsealed", func() {
+			encryptor := storage.NewEncryptor("some-passphrase")
+			stateFile, err := encryptor.Encrypt([]byte(`{"envID": "some-env"}`))
+			Expect(err).NotTo(HaveOccurred())
+			writeFile(exportDir, "bbl-state.json", string(stateFile))
+			writeFile(exportDir, "vars/director-vars-store.yml", "some-creds")
+
+			err = storage.NewBundler(exportDir, "1.2.3", fs, encryptor).Export(bundlePath, storage.State{EnvID: "some-env"})
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := storage.NewBundler(importDir, "1.2.3", fs, encryptor).Import(bundlePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Encrypted).To(BeTrue())
+
+			contents, err := fs.ReadFile(filepath.Join(importDir, "vars", "director-vars-store.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storage.IsEncrypted(contents)).To(BeTrue())
+
+			plaintext, err := encryptor.Decrypt(contents)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(plaintext)).To(Equal("some-creds"))
+		})
+	})
+
+	Describe("Import", func() {
+		var bundler storage.Bundler
+
+		BeforeEach(func() {
+			bundler = storage.NewBundler(importDir, "1.2.3", fs, storage.NewEncryptor(""))
+		})
+
+		Context("when the state directory already has an environment", func() {
+			It("refuses to overwrite it", func() {
+				writeFile(importDir, "bbl-state.json", "some-state")
+				writeBundle(map[string]string{
+					"bbl-bundle.json": `{"format": 1}`,
+					"bbl-state.json":  "some-other-state",
+				})
+
+				_, err := bundler.Import(bundlePath)
+				Expect(err).To(MatchError(ContainSubstring("already contains a bbl environment")))
+
+				contents, err := fs.ReadFile(filepath.Join(importDir, "bbl-state.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("some-state"))
+			})
+		})
+
+		Context("when the bundle was written by a newer bbl", func() {
+			It("refuses to import it", func() {
+				writeBundle(map[string]string{
+					"bbl-bundle.json": `{"format": 1, "bblVersion": "99.0.0", "stateSchema": 99}`,
+					"bbl-state.json":  "some-state",
+				})
+
+				_, err := bundler.Import(bundlePath)
+				Expect(err).To(MatchError("The bundle was exported by bbl v99.0.0 with state schema 99. Please upgrade bbl to import it."))
+				Expect(exists(filepath.Join(importDir, "bbl-state.json"))).To(BeFalse())
+			})
+		})
+
+		Context("when the bundle has no manifest", func() {
+			It("returns an error", func() {
+				writeBundle(map[string]string{
+					"bbl-state.json": "some-state",
+				})
+
+				_, err := bundler.Import(bundlePath)
+				Expect(err).To(MatchError("Read bundle: bbl-bundle.json is missing, this is not a bbl bundle"))
+			})
+		})
+
+		Context("when a file listed in the manifest is missing", func() {
+			It("returns an error", func() {
+				writeBundle(map[string]string{
+					"bbl-bundle.json": `{"format": 1, "files": ["bbl-state.json", "vars/terraform.tfstate"]}`,
+					"bbl-state.json":  "some-state",
+				})
+
+				_, err := bundler.Import(bundlePath)
+				Expect(err).To(MatchError("Read bundle: vars/terraform.tfstate is listed in bbl-bundle.json but missing from the bundle"))
+				Expect(exists(filepath.Join(importDir, "bbl-state.json"))).To(BeFalse())
+			})
+		})
+
+		DescribeTable("when the bundle contains a file that does not belong in it",
+			func(name string) {
+				writeBundle(map[string]string{
+					"bbl-bundle.json": `{"format": 1}`,
+					"bbl-state.json":  "some-state",
+					name:              "some-contents",
+				})
+
+				_, err := bundler.Import(bundlePath)
+				Expect(err).To(MatchError(ContainSubstring("does not belong in a bbl bundle")))
+				Expect(exists(filepath.Join(importDir, "bbl-state.json"))).To(BeFalse())
+			},
+			Entry("a path outside the state directory", "../some-file"),
+			Entry("an absolute path", "/etc/some-file"),
+			Entry("an unclean path", "vars/../../some-file"),
+			Entry("a bbl managed file", "create-director.sh"),
+		)
+	})
+})
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// The checksum is the last field of bbl-state.json and covers the file as it
+// was before the field was added, so it can be checked without knowing the
+// schema of the state that was written.
+var checksumField = []byte(",\n\t\"checksum\": \"sha256:")
+
+func addChecksum(jsonData []byte) []byte {
+	body := bytes.TrimSuffix(jsonData, []byte("\n}"))
+
+	withChecksum := append([]byte{}, body...)
+	withChecksum = append(withChecksum, checksumField...)
+	withChecksum = append(withChecksum, checksumOf(jsonData)...)
+	return append(withChecksum, []byte("\"\n}")...)
+}
+
+// verifyChecksum reports whether contents carries a checksum, and whether
+// that checksum matches.
+func verifyChecksum(contents []byte) (bool, bool) {
+	contents = bytes.TrimSpace(contents)
+
+	i := bytes.LastIndex(contents, checksumField)
+	if i < 0 {
+		return false, false
+	}
+
+	sum := bytes.TrimSuffix(contents[i+len(checksumField):], []byte("\"\n}"))
+	return true, string(sum) == checksumOf(removeChecksum(contents))
+}
+
+func removeChecksum(contents []byte) []byte {
+	i := bytes.LastIndex(contents, checksumField)
+	if i < 0 {
+		return contents
+	}
+	return append(append([]byte{}, contents[:i]...), []byte("\n}")...)
+}
+
+func checksumOf(contents []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(contents))
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	encryptedStreamHeader = "bbl-encrypted-stream:v1\n"
+	encryptionChunkSize   = 64 * 1024
+
+	// lastChunk is set in the length of the chunk that ends a stream, so
+	// that a truncated stream does not decrypt.
+	lastChunk = uint32(1) << 31
+)
+
+// EncryptStream returns a writer that seals what is written to it into w in
+// chunks, for director backups that are too large to encrypt in memory.
+// Close seals the last chunk but does not close w. Without a passphrase it
+// writes plaintext through untouched.
+func (e Encryptor) EncryptStream(w io.Writer) (io.WriteCloser, error) {
+	if !e.Enabled() {
+		return nopWriteCloser{w}, nil
+	}
+
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %s", err)
+	}
+
+	gcm, err := e.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(append([]byte(encryptedStreamHeader), salt...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedStreamWriter{writer: w, gcm: gcm}, nil
+}
+
+// DecryptStream opens a stream written by EncryptStream, and passes any
+// other stream through untouched.
+func (e Encryptor) DecryptStream(r io.Reader) (io.Reader, error) {
+	reader := bufio.NewReader(r)
+	header, err := reader.Peek(len(encryptedStreamHeader))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(header, []byte(encryptedStreamHeader)) {
+		return reader, nil
+	}
+
+	if !e.Enabled() {
+		return nil, ErrMissingEncryptionKey
+	}
+
+	reader.Discard(len(encryptedStreamHeader)) //nolint:errcheck
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := io.ReadFull(reader, salt); err != nil {
+		return nil, errors.New("decode encrypted stream: the stream is truncated")
+	}
+
+	gcm, err := e.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedStreamReader{reader: reader, gcm: gcm}, nil
+}
+
+type encryptedStreamWriter struct {
+	writer  io.Writer
+	gcm     cipher.AEAD
+	buffer  []byte
+	counter uint64
+}
+
+func (s *encryptedStreamWriter) Write(p []byte) (int, error) {
+	s.buffer = append(s.buffer, p...)
+	for len(s.buffer) > encryptionChunkSize {
+		err := s.seal(s.buffer[:encryptionChunkSize], false)
+		if err != nil {
+			return 0, err
+		}
+		s.buffer = s.buffer[encryptionChunkSize:]
+	}
+	return len(p), nil
+}
+
+func (s *encryptedStreamWriter) Close() error {
+	return s.seal(s.buffer, true)
+}
+
+func (s *encryptedStreamWriter) seal(chunk []byte, last bool) error {
+	sealed := s.gcm.Seal(nil, chunkNonce(s.gcm, s.counter), chunk, chunkData(last))
+	s.counter++
+
+	length := uint32(len(sealed))
+	if last {
+		length |= lastChunk
+	}
+
+	err := binary.Write(s.writer, binary.BigEndian, length)
+	if err != nil {
+		return err
+	}
+	_, err = s.writer.Write(sealed)
+	return err
+}
+
+type encryptedStreamReader struct {
+	reader  io.Reader
+	gcm     cipher.AEAD
+	chunk   []byte
+	counter uint64
+	done    bool
+}
+
+func (s *encryptedStreamReader) Read(p []byte) (int, error) {
+	for len(s.chunk) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+
+		err := s.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, s.chunk)
+	s.chunk = s.chunk[n:]
+	return n, nil
+}
+
+func (s *encryptedStreamReader) open() error {
+	var length uint32
+	err := binary.Read(s.reader, binary.BigEndian, &length)
+	if err != nil {
+		return errors.New("decode encrypted stream: the stream is truncated")
+	}
+
+	last := length&lastChunk != 0
+	sealed := make([]byte, length&^lastChunk)
+	if _, err := io.ReadFull(s.reader, sealed); err != nil {
+		return errors.New("decode encrypted stream: the stream is truncated")
+	}
+
+	s.chunk, err = s.gcm.Open(nil, chunkNonce(s.gcm, s.counter), sealed, chunkData(last))
+	if err != nil {
+		return errors.New("decrypt stream: the state encryption key is incorrect or the stream is corrupted")
+	}
+	s.counter++
+	s.done = last
+
+	return nil
+}
+
+// chunkNonce numbers the chunks of a stream. Every stream derives its key
+// from a fresh salt, so the numbers are never reused with the same key.
+func chunkNonce(gcm cipher.AEAD, counter uint64) []byte {
+	nonce := make([]byte, gcm.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], counter)
+	return nonce
+}
+
+func chunkData(last bool) []byte {
+	if last {
+		return []byte(encryptedStreamHeader + "last")
+	}
+	return []byte(encryptedStreamHeader)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
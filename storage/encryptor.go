@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+const (
+	encryptedHeader     = "bbl-encrypted:v1\n"
+	encryptionSaltSize  = 16
+	encryptionKeySize   = 32
+	encryptionKDFRounds = 100000
+)
+
+var ErrMissingEncryptionKey = errors.New("bbl state is encrypted: provide --state-encryption-key or BBL_STATE_ENCRYPTION_KEY")
+
+// Encryptor seals bbl-state.json and the files in vars/ with AES-256-GCM
+// using a key derived from the --state-encryption-key passphrase. Without a
+// passphrase it passes plaintext through untouched.
+type Encryptor struct {
+	passphrase string
+}
+
+func NewEncryptor(passphrase string) Encryptor {
+	return Encryptor{passphrase: passphrase}
+}
+
+func IsEncrypted(contents []byte) bool {
+	return bytes.HasPrefix(contents, []byte(encryptedHeader))
+}
+
+func (e Encryptor) Enabled() bool {
+	return e.passphrase != ""
+}
+
+func (e Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	if !e.Enabled() || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %s", err)
+	}
+
+	gcm, err := e.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %s", err)
+	}
+
+	payload := append(salt, nonce...)
+	payload = gcm.Seal(payload, nonce, plaintext, []byte(encryptedHeader))
+
+	return []byte(encryptedHeader + base64.StdEncoding.EncodeToString(payload) + "\n"), nil
+}
+
+func (e Encryptor) Decrypt(contents []byte) ([]byte, error) {
+	if !IsEncrypted(contents) {
+		return contents, nil
+	}
+
+	if !e.Enabled() {
+		return nil, ErrMissingEncryptionKey
+	}
+
+	encoded := bytes.TrimSpace(contents[len(encryptedHeader):])
+	payload, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decode encrypted contents: %s", err)
+	}
+
+	if len(payload) < encryptionSaltSize {
+		return nil, errors.New("decode encrypted contents: payload is truncated")
+	}
+
+	gcm, err := e.cipher(payload[:encryptionSaltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	payload = payload[encryptionSaltSize:]
+	if len(payload) < gcm.NonceSize() {
+		return nil, errors.New("decode encrypted contents: payload is truncated")
+	}
+
+	plaintext, err := gcm.Open(nil, payload[:gcm.NonceSize()], payload[gcm.NonceSize():], []byte(encryptedHeader))
+	if err != nil {
+		return nil, errors.New("decrypt contents: the state encryption key is incorrect or the file is corrupted")
+	}
+
+	return plaintext, nil
+}
+
+// dirFs is the part of the store's fs that EncryptDir and DecryptDir use.
+type dirFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.DirReader
+}
+
+// EncryptDir encrypts every regular file directly inside dir in place.
+func (e Encryptor) EncryptDir(fs dirFs, dir string) error {
+	if !e.Enabled() {
+		return nil
+	}
+	return e.transformDir(fs, dir, e.Encrypt)
+}
+
+// DecryptDir decrypts every encrypted file directly inside dir in place so
+// that terraform and the bosh cli can read them.
+func (e Encryptor) DecryptDir(fs dirFs, dir string) error {
+	return e.transformDir(fs, dir, e.Decrypt)
+}
+
+func (e Encryptor) transformDir(fs dirFs, dir string, transform func([]byte) ([]byte, error)) error {
+	entries, err := fs.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		contents, err := fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		transformed, err := transform(contents)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+
+		if bytes.Equal(transformed, contents) {
+			continue
+		}
+
+		err = fs.WriteFile(path, transformed, entry.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e Encryptor) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, e.passphrase, salt, encryptionKDFRounds, encryptionKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive encryption key: %s", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
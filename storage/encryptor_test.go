@@ -0,0 +1,191 @@
+package storage_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encryptor", func() {
+	var encryptor storage.Encryptor
+
+	BeforeEach(func() {
+		encryptor = storage.NewEncryptor("some-passphrase")
+	})
+
+	Describe("Encrypt", func() {
+		It("produces contents that decrypt back to the plaintext", func() {
+			encrypted, err := encryptor.Encrypt([]byte("some-secret"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(encrypted)).NotTo(ContainSubstring("some-secret"))
+			Expect(storage.IsEncrypted(encrypted)).To(BeTrue())
+
+			decrypted, err := encryptor.Decrypt(encrypted)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(decrypted)).To(Equal("some-secret"))
+		})
+
+		It("does not encrypt contents twice", func() {
+			encrypted, err := encryptor.Encrypt([]byte("some-secret"))
+			Expect(err).NotTo(HaveOccurred())
+
+			again, err := encryptor.Encrypt(encrypted)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(encrypted))
+		})
+
+		Context("when no passphrase is provided", func() {
+			It("returns the plaintext", func() {
+				contents, err := storage.NewEncryptor("").Encrypt([]byte("some-secret"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("some-secret"))
+			})
+		})
+	})
+
+	Describe("Decrypt", func() {
+		It("passes plaintext through", func() {
+			contents, err := encryptor.Decrypt([]byte(`{"iaas": "aws"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(`{"iaas": "aws"}`))
+		})
+
+		Context("when the passphrase is wrong", func() {
+			It("returns an error", func() {
+				encrypted, err := encryptor.Encrypt([]byte("some-secret"))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = storage.NewEncryptor("wrong-passphrase").Decrypt(encrypted)
+				Expect(err).To(MatchError(ContainSubstring("the state encryption key is incorrect")))
+			})
+		})
+
+		Context("when no passphrase is provided", func() {
+			It("returns an error", func() {
+				encrypted, err := encryptor.Encrypt([]byte("some-secret"))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = storage.NewEncryptor("").Decrypt(encrypted)
+				Expect(err).To(MatchError(storage.ErrMissingEncryptionKey))
+			})
+		})
+	})
+
+	Describe("EncryptStream and DecryptStream", func() {
+		seal := func(encryptor storage.Encryptor, plaintext []byte) []byte {
+			var sealed bytes.Buffer
+			writer, err := encryptor.EncryptStream(&sealed)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = writer.Write(plaintext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writer.Close()).To(Succeed())
+			return sealed.Bytes()
+		}
+
+		open := func(encryptor storage.Encryptor, sealed []byte) ([]byte, error) {
+			reader, err := encryptor.DecryptStream(bytes.NewReader(sealed))
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(reader)
+		}
+
+		It("round trips a stream of several chunks", func() {
+			plaintext := bytes.Repeat([]byte("some-backup "), 20000)
+
+			sealed := seal(encryptor, plaintext)
+			Expect(sealed).NotTo(ContainSubstring("some-backup"))
+
+			opened, err := open(encryptor, sealed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opened).To(Equal(plaintext))
+		})
+
+		It("round trips an empty stream", func() {
+			opened, err := open(encryptor, seal(encryptor, nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opened).To(BeEmpty())
+		})
+
+		It("passes plaintext streams through", func() {
+			sealed := seal(storage.NewEncryptor(""), []byte("some-backup"))
+			Expect(string(sealed)).To(Equal("some-backup"))
+
+			opened, err := open(encryptor, sealed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(opened)).To(Equal("some-backup"))
+		})
+
+		Context("when the stream is truncated", func() {
+			It("returns an error", func() {
+				sealed := seal(encryptor, bytes.Repeat([]byte("some-backup "), 20000))
+
+				_, err := open(encryptor, sealed[:len(sealed)/2])
+				Expect(err).To(MatchError(ContainSubstring("the stream is truncated")))
+			})
+		})
+
+		Context("when the passphrase is wrong", func() {
+			It("returns an error", func() {
+				_, err := open(storage.NewEncryptor("wrong-passphrase"), seal(encryptor, []byte("some-backup")))
+				Expect(err).To(MatchError(ContainSubstring("the state encryption key is incorrect")))
+			})
+		})
+
+		Context("when no passphrase is provided", func() {
+			It("returns an error", func() {
+				_, err := open(storage.NewEncryptor(""), seal(encryptor, []byte("some-backup")))
+				Expect(err).To(MatchError(storage.ErrMissingEncryptionKey))
+			})
+		})
+	})
+
+	Describe("EncryptDir and DecryptDir", func() {
+		var (
+			fs      *afero.Afero
+			varsDir string
+		)
+
+		BeforeEach(func() {
+			fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+			varsDir = "/some/state-dir/vars"
+
+			err := fs.WriteFile(filepath.Join(varsDir, "director-vars-store.yml"), []byte("admin_password: some-password"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("encrypts and decrypts every file in place, preserving permissions", func() {
+			path := filepath.Join(varsDir, "director-vars-store.yml")
+
+			err := encryptor.EncryptDir(fs, varsDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storage.IsEncrypted(contents)).To(BeTrue())
+
+			info, err := fs.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			err = encryptor.DecryptDir(fs, varsDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err = fs.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("admin_password: some-password"))
+		})
+
+		It("ignores a missing directory", func() {
+			Expect(encryptor.EncryptDir(fs, filepath.Join(varsDir, "missing"))).To(Succeed())
+			Expect(encryptor.DecryptDir(fs, filepath.Join(varsDir, "missing"))).To(Succeed())
+		})
+	})
+})
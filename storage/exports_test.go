@@ -2,6 +2,8 @@ package storage
 
 import (
 	"encoding/json"
+	"os"
+	"time"
 
 	uuid "github.com/nu7hatch/gouuid"
 )
@@ -21,3 +23,35 @@ func SetUUIDNewV4(f func() (uuid *uuid.UUID, err error)) {
 func ResetUUIDNewV4() {
 	uuidNewV4 = uuid.NewV4
 }
+
+func SetOsHostname(f func() (string, error)) {
+	osHostname = f
+}
+
+func ResetOsHostname() {
+	osHostname = os.Hostname
+}
+
+func SetOsGetpid(f func() int) {
+	osGetpid = f
+}
+
+func ResetOsGetpid() {
+	osGetpid = os.Getpid
+}
+
+func SetTimeNow(f func() time.Time) {
+	timeNow = f
+}
+
+func ResetTimeNow() {
+	timeNow = time.Now
+}
+
+func RemoveChecksum(contents []byte) []byte {
+	return removeChecksum(contents)
+}
+
+func IsBBLManaged(relPath string) bool {
+	return isBBLManaged(relPath)
+}
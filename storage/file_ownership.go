@@ -5,6 +5,7 @@ import "path/filepath"
 // tested and exercised via PatchDetector and GarbageCollector
 var bblManaged = []string{
 	"bbl-state.json",
+	"bbl-secrets.json",
 	"create-jumpbox.sh",
 	"create-director.sh",
 	"delete-jumpbox.sh",
@@ -38,6 +39,14 @@ var bblManaged = []string{
 	"jumpbox-deployment",
 	"bosh-deployment",
 	"bbl-ops-files",
+
+	// the files bbl copies into those directories
+	"jumpbox-deployment/*",
+	"jumpbox-deployment/*/*",
+	"jumpbox-deployment/*/*/*",
+	"bosh-deployment/*",
+	"bosh-deployment/*/*",
+	"bosh-deployment/*/*/*",
 }
 
 var bblManagedDirsWhichMayContainUserFiles = []string{
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Fingerprinter hashes the files in the state dir that a phase of bbl up
+// reads, so that an unchanged phase can be skipped on a rerun.
+type Fingerprinter struct {
+	fs  fs
+	dir string
+}
+
+func NewFingerprinter(fs fs, dir string) Fingerprinter {
+	return Fingerprinter{
+		fs:  fs,
+		dir: dir,
+	}
+}
+
+// Fingerprint hashes the names and contents of the files matching patterns,
+// which are slash separated paths relative to the state dir that may end in a
+// glob, along with any extra inputs. Directories are read recursively, and
+// missing paths and hidden files such as terraform's plugin cache are left out.
+func (f Fingerprinter) Fingerprint(patterns []string, extra ...string) (string, error) {
+	files := map[string][]byte{}
+	for _, pattern := range patterns {
+		err := f.add(pattern, files)
+		if err != nil {
+			return "", fmt.Errorf("Fingerprint %s: %s", pattern, err) //nolint:staticcheck
+		}
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(files[name]))
+		hash.Write(files[name]) //nolint:errcheck
+	}
+	for _, input := range extra {
+		fmt.Fprintf(hash, "\x00%d\x00%s", len(input), input)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (f Fingerprinter) add(pattern string, files map[string][]byte) error {
+	dir, base := path.Split(pattern)
+	if !strings.ContainsAny(base, "*?[") {
+		return f.addPath(pattern, files)
+	}
+
+	entries, err := f.fs.ReadDir(filepath.Join(f.dir, filepath.FromSlash(dir)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if matched, _ := path.Match(base, entry.Name()); matched {
+			err = f.addPath(path.Join(dir, entry.Name()), files)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f Fingerprinter) addPath(name string, files map[string][]byte) error {
+	if strings.HasPrefix(path.Base(name), ".") {
+		return nil
+	}
+
+	fullPath := filepath.Join(f.dir, filepath.FromSlash(name))
+	info, err := f.fs.Stat(fullPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		contents, err := f.fs.ReadFile(fullPath)
+		if err != nil {
+			return err
+		}
+		files[name] = contents
+		return nil
+	}
+
+	entries, err := f.fs.ReadDir(fullPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err = f.addPath(path.Join(name, entry.Name()), files)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fingerprinter", func() {
+	var (
+		fingerprinter storage.Fingerprinter
+		tempDir       string
+	)
+
+	writeFile := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(tempDir, path)), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(tempDir, path), []byte(contents), storage.StateMode)).To(Succeed())
+	}
+
+	fingerprint := func(patterns []string, extra ...string) string {
+		fingerprint, err := fingerprinter.Fingerprint(patterns, extra...)
+		Expect(err).NotTo(HaveOccurred())
+		return fingerprint
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		fingerprinter = storage.NewFingerprinter(storage.NewAtomicFs(&afero.Afero{Fs: afero.NewOsFs()}, tempDir), tempDir)
+
+		writeFile("terraform/bbl-template.tf", "some-template")
+		writeFile("vars/bbl.tfvars", "some-vars")
+		writeFile("vars/terraform.tfstate", "some-tfstate")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir) //nolint:errcheck
+	})
+
+	It("changes when a matching file changes", func() {
+		before := fingerprint([]string{"terraform", "vars/*.tfvars"})
+
+		writeFile("vars/bbl.tfvars", "other-vars")
+		Expect(fingerprint([]string{"terraform", "vars/*.tfvars"})).NotTo(Equal(before))
+	})
+
+	It("changes when a file is added to a directory", func() {
+		before := fingerprint([]string{"terraform"})
+
+		writeFile("terraform/my_override.tf", "some-override")
+		Expect(fingerprint([]string{"terraform"})).NotTo(Equal(before))
+	})
+
+	It("changes with the extra inputs", func() {
+		Expect(fingerprint([]string{"terraform"}, "some-vars")).NotTo(Equal(fingerprint([]string{"terraform"}, "other-vars")))
+	})
+
+	It("ignores files that do not match, hidden files and missing paths", func() {
+		before := fingerprint([]string{"terraform", "vars/*.tfvars", "create-jumpbox-override.sh"})
+
+		writeFile("vars/terraform.tfstate", "other-tfstate")
+		writeFile("terraform/.terraform/providers/some-provider", "some-provider")
+		writeFile("terraform/.terraform.lock.hcl", "some-lock")
+		Expect(fingerprint([]string{"terraform", "vars/*.tfvars", "create-jumpbox-override.sh"})).To(Equal(before))
+	})
+})
@@ -44,6 +44,15 @@ var _ = Describe("garbage collector", func() {
 			))
 		})
 
+		It("leaves the state lock to the locker that holds it", func() {
+			err := gc.Remove("some-dir")
+			Expect(err).NotTo(HaveOccurred())
+
+			lockFile := filepath.Join("some-dir", storage.LOCK_FILE)
+			Expect(fileIO.RemoveCall.Receives).NotTo(ContainElement(fakes.RemoveReceive{Name: lockFile}))
+			Expect(fileIO.RemoveAllCall.Receives).NotTo(ContainElement(fakes.RemoveAllReceive{Path: lockFile}))
+		})
+
 		It("removes bosh *-env scripts", func() {
 			createDirector := filepath.Join("some-dir", "create-director.sh")
 			createJumpbox := filepath.Join("some-dir", "create-jumpbox.sh")
@@ -3,6 +3,8 @@ package storage
 type GCP struct {
 	ServiceAccountKey     string   `json:"-"`
 	ServiceAccountKeyPath string   `json:"-"`
+	AccessToken           string   `json:"-"`
+	CredentialSource      string   `json:"credentialSource,omitempty"`
 	ProjectID             string   `json:"-"`
 	Zone                  string   `json:"zone,omitempty"`
 	Region                string   `json:"region,omitempty"`
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+const (
+	HISTORY_DIR  = ".bbl/history"
+	HISTORY_SIZE = 10
+
+	snapshotInfoFile = "snapshot.json"
+)
+
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Command string    `json:"command"`
+	Created time.Time `json:"created"`
+}
+
+// History keeps the last HISTORY_SIZE copies of bbl-state.json and the vars
+// directory so that a bad `bbl up` can be rolled back.
+type History struct {
+	dir string
+	fs  historyFs
+}
+
+type historyFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.Stater
+	fileio.DirReader
+	fileio.AllMkdirer
+	fileio.AllRemover
+}
+
+func NewHistory(dir string, fs historyFs) History {
+	return History{dir: dir, fs: fs}
+}
+
+// Snapshot copies the current state into the history before command runs.
+// It does nothing when there is no state yet.
+func (h History) Snapshot(command string) error {
+	_, err := h.fs.Stat(filepath.Join(h.dir, STATE_FILE))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("Stat state file: %s", err) //nolint:staticcheck
+	}
+
+	created := timeNow().UTC()
+	id := created.Format("20060102T150405Z")
+	for i := 2; ; i++ {
+		_, err := h.fs.Stat(h.path(id))
+		if os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", created.Format("20060102T150405Z"), i)
+	}
+
+	snapshotDir := h.path(id)
+	err = h.fs.MkdirAll(snapshotDir, StateMode)
+	if err != nil {
+		return fmt.Errorf("Create snapshot dir: %s", err) //nolint:staticcheck
+	}
+
+	err = h.copyStateFiles(h.dir, snapshotDir)
+	if err != nil {
+		h.fs.RemoveAll(snapshotDir)                  //nolint:errcheck
+		return fmt.Errorf("Snapshot state: %s", err) //nolint:staticcheck
+	}
+
+	info, err := json.MarshalIndent(Snapshot{ID: id, Command: command, Created: created}, "", "\t")
+	if err != nil {
+		return err // not tested
+	}
+
+	err = h.fs.WriteFile(filepath.Join(snapshotDir, snapshotInfoFile), info, StateMode)
+	if err != nil {
+		return fmt.Errorf("Write snapshot info: %s", err) //nolint:staticcheck
+	}
+
+	return h.prune()
+}
+
+// List returns the snapshots from oldest to newest.
+func (h History) List() ([]Snapshot, error) {
+	entries, err := h.fs.ReadDir(filepath.Join(h.dir, HISTORY_DIR))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Read history dir: %s", err) //nolint:staticcheck
+	}
+
+	snapshots := []Snapshot{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		contents, err := h.fs.ReadFile(filepath.Join(h.path(entry.Name()), snapshotInfoFile))
+		if os.IsNotExist(err) {
+			// A snapshot that was interrupted before it was finished.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Read snapshot %s: %s", entry.Name(), err) //nolint:staticcheck
+		}
+
+		var snapshot Snapshot
+		err = json.Unmarshal(contents, &snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("Read snapshot %s: %s", entry.Name(), err) //nolint:staticcheck
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+
+	return snapshots, nil
+}
+
+// Restore replaces bbl-state.json and the vars directory with the copies in
+// snapshot id. The state it replaces is not snapshotted here: bbl snapshots
+// it before the vars directory is decrypted, as it does for every command
+// that modifies the state, so a rollback can itself be rolled back.
+func (h History) Restore(id string) error {
+	snapshotDir := h.path(id)
+	_, err := h.fs.Stat(filepath.Join(snapshotDir, snapshotInfoFile))
+	if err != nil || filepath.Base(id) != id {
+		if err == nil || os.IsNotExist(err) {
+			return fmt.Errorf("There is no snapshot %q, run `bbl state history` to list them", id) //nolint:staticcheck
+		}
+		return fmt.Errorf("Stat snapshot: %s", err) //nolint:staticcheck
+	}
+
+	err = h.fs.RemoveAll(filepath.Join(h.dir, "vars"))
+	if err != nil {
+		return fmt.Errorf("Remove vars dir: %s", err) //nolint:staticcheck
+	}
+
+	err = h.copyStateFiles(snapshotDir, h.dir)
+	if err != nil {
+		return fmt.Errorf("Restore snapshot: %s", err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func (h History) prune() error {
+	snapshots, err := h.List()
+	if err != nil {
+		return err
+	}
+
+	for len(snapshots) > HISTORY_SIZE {
+		err = h.fs.RemoveAll(h.path(snapshots[0].ID))
+		if err != nil {
+			return fmt.Errorf("Remove snapshot %s: %s", snapshots[0].ID, err) //nolint:staticcheck
+		}
+		snapshots = snapshots[1:]
+	}
+
+	return nil
+}
+
+func (h History) path(id string) string {
+	return filepath.Join(h.dir, HISTORY_DIR, id)
+}
+
+// copyStateFiles copies bbl-state.json, the vars directory and the secrets
+// kept by `--secret-store file` from one directory to another.
+func (h History) copyStateFiles(from, to string) error {
+	err := h.copyFile(filepath.Join(from, STATE_FILE), filepath.Join(to, STATE_FILE))
+	if err != nil {
+		return err
+	}
+
+	err = h.copyFile(filepath.Join(from, SECRETS_FILE), filepath.Join(to, SECRETS_FILE))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return h.copyDir(filepath.Join(from, "vars"), filepath.Join(to, "vars"))
+}
+
+func (h History) copyDir(from, to string) error {
+	entries, err := h.fs.ReadDir(from)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	err = h.fs.MkdirAll(to, StateMode)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir():
+			err = h.copyDir(filepath.Join(from, entry.Name()), filepath.Join(to, entry.Name()))
+		case entry.Mode().IsRegular():
+			err = h.copyFile(filepath.Join(from, entry.Name()), filepath.Join(to, entry.Name()))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h History) copyFile(from, to string) error {
+	info, err := h.fs.Stat(from)
+	if err != nil {
+		return err
+	}
+
+	contents, err := h.fs.ReadFile(from)
+	if err != nil {
+		return err
+	}
+
+	return h.fs.WriteFile(to, contents, info.Mode().Perm())
+}
@@ -0,0 +1,156 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var (
+		history storage.History
+		fs      *afero.Afero
+		tempDir string
+		now     time.Time
+	)
+
+	writeState := func(state, tfstate string) {
+		Expect(fs.MkdirAll(filepath.Join(tempDir, "vars"), os.ModePerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte(state), storage.StateMode)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(tempDir, "vars", "terraform.tfstate"), []byte(tfstate), storage.StateMode)).To(Succeed())
+	}
+
+	readFile := func(path string) string {
+		contents, err := fs.ReadFile(filepath.Join(tempDir, path))
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		tempDir = "/some/state-dir"
+
+		now = time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+		storage.SetTimeNow(func() time.Time {
+			now = now.Add(time.Minute)
+			return now
+		})
+
+		history = storage.NewHistory(tempDir, fs)
+	})
+
+	AfterEach(func() {
+		storage.ResetTimeNow()
+	})
+
+	Describe("Snapshot", func() {
+		It("records the state and the command", func() {
+			writeState("some-state", "some-tfstate")
+
+			Expect(history.Snapshot("up")).To(Succeed())
+
+			snapshots, err := history.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(Equal([]storage.Snapshot{{
+				ID:      "20180102T030505Z",
+				Command: "up",
+				Created: time.Date(2018, 1, 2, 3, 5, 5, 0, time.UTC),
+			}}))
+			Expect(readFile(".bbl/history/20180102T030505Z/bbl-state.json")).To(Equal("some-state"))
+			Expect(readFile(".bbl/history/20180102T030505Z/vars/terraform.tfstate")).To(Equal("some-tfstate"))
+		})
+
+		It("keeps the latest snapshots only", func() {
+			writeState("some-state", "some-tfstate")
+
+			for i := 0; i < storage.HISTORY_SIZE+2; i++ {
+				Expect(history.Snapshot("up")).To(Succeed())
+			}
+
+			snapshots, err := history.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(HaveLen(storage.HISTORY_SIZE))
+			Expect(snapshots[0].ID).To(Equal("20180102T030705Z"))
+		})
+
+		Context("when there is no state yet", func() {
+			It("does nothing", func() {
+				Expect(history.Snapshot("up")).To(Succeed())
+
+				snapshots, err := history.List()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(snapshots).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Restore", func() {
+		BeforeEach(func() {
+			writeState("old-state", "old-tfstate")
+			Expect(history.Snapshot("up")).To(Succeed())
+
+			writeState("new-state", "new-tfstate")
+			Expect(fs.WriteFile(filepath.Join(tempDir, "vars", "extra-file"), []byte("extra"), storage.StateMode)).To(Succeed())
+		})
+
+		It("puts back the state and vars dir of the snapshot", func() {
+			Expect(history.Restore("20180102T030505Z")).To(Succeed())
+
+			Expect(readFile("bbl-state.json")).To(Equal("old-state"))
+			Expect(readFile("vars/terraform.tfstate")).To(Equal("old-tfstate"))
+			Expect(filepath.Join(tempDir, "vars", "extra-file")).NotTo(BeAnExistingFile())
+		})
+
+		It("leaves the snapshot of the replaced state to the caller", func() {
+			Expect(history.Restore("20180102T030505Z")).To(Succeed())
+
+			snapshots, err := history.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+		})
+
+		Context("when the state dir is encrypted", func() {
+			BeforeEach(func() {
+				encryptor := storage.NewEncryptor("some-passphrase")
+				Expect(encryptor.EncryptDir(fs, filepath.Join(tempDir, "vars"))).To(Succeed())
+
+				// bbl snapshots the state before it decrypts the vars dir.
+				Expect(history.Snapshot("state rollback")).To(Succeed())
+				Expect(encryptor.DecryptDir(fs, filepath.Join(tempDir, "vars"))).To(Succeed())
+			})
+
+			It("does not copy the decrypted vars into the history", func() {
+				Expect(history.Restore("20180102T030505Z")).To(Succeed())
+
+				err := fs.Walk(filepath.Join(tempDir, ".bbl", "history"), func(path string, info os.FileInfo, err error) error {
+					Expect(err).NotTo(HaveOccurred())
+					if info.IsDir() {
+						return nil
+					}
+
+					contents, err := fs.ReadFile(path)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(contents)).NotTo(ContainSubstring("new-tfstate"), path)
+					Expect(string(contents)).NotTo(ContainSubstring("extra"), path)
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when the snapshot does not exist", func() {
+			It("returns an error and leaves the state alone", func() {
+				err := history.Restore("../..")
+				Expect(err).To(MatchError(`There is no snapshot "../..", run ` + "`bbl state history`" + ` to list them`))
+
+				Expect(readFile("bbl-state.json")).To(Equal("new-state"))
+			})
+		})
+	})
+})
@@ -9,6 +9,7 @@ import (
 type Jumpbox struct {
 	URL       string                 `json:"url"`
 	Variables string                 `json:"variables,omitempty"`
+	VarsStore string                 `json:"varsStore,omitempty"`
 	Manifest  string                 `json:"manifest,omitempty"`
 	State     map[string]interface{} `json:"state,omitempty"`
 }
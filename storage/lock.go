@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+const LOCK_FILE = "bbl-state.lock"
+
+var (
+	osHostname = os.Hostname
+	osGetpid   = os.Getpid
+	timeNow    = time.Now
+)
+
+type LockInfo struct {
+	PID      int       `json:"pid"`
+	Hostname string    `json:"hostname"`
+	Command  string    `json:"command"`
+	Started  time.Time `json:"started"`
+}
+
+type LockHeldError struct {
+	Info LockInfo
+}
+
+func (e LockHeldError) Error() string {
+	return fmt.Sprintf("The bbl state directory is locked by `bbl %s` (pid %d on %s, started %s).\nWait for it to finish, or run `bbl state unlock --force` if that process is gone.",
+		e.Info.Command, e.Info.PID, e.Info.Hostname, e.Info.Started.Format(time.RFC3339))
+}
+
+// Locker holds an advisory lock on the state directory so that two mutating
+// bbl commands do not write terraform or bosh state at the same time.
+type Locker struct {
+	dir string
+	fs  lockFs
+}
+
+type lockFs interface {
+	fileio.FileOpener
+	fileio.FileReader
+	fileio.Stater
+	fileio.Remover
+	fileio.AllMkdirer
+}
+
+func NewLocker(dir string, fs lockFs) Locker {
+	return Locker{
+		dir: dir,
+		fs:  fs,
+	}
+}
+
+func (l Locker) Lock(command string) error {
+	hostname, err := osHostname()
+	if err != nil {
+		return fmt.Errorf("Get hostname: %s", err) //nolint:staticcheck
+	}
+
+	contents, err := json.MarshalIndent(LockInfo{
+		PID:      osGetpid(),
+		Hostname: hostname,
+		Command:  command,
+		Started:  timeNow().UTC(),
+	}, "", "\t")
+	if err != nil {
+		return err // not tested
+	}
+
+	// The lock is taken before the remote state is downloaded into the dir.
+	err = l.fs.MkdirAll(l.dir, StateMode)
+	if err != nil {
+		return fmt.Errorf("Lock state dir: %s", err) //nolint:staticcheck
+	}
+
+	file, err := l.fs.OpenFile(l.path(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, StateMode)
+	if err != nil {
+		if os.IsExist(err) {
+			info, _, readErr := l.Info()
+			if readErr != nil {
+				return readErr
+			}
+			return LockHeldError{Info: info}
+		}
+		return fmt.Errorf("Lock state dir: %s", err) //nolint:staticcheck
+	}
+	defer file.Close() //nolint:errcheck
+
+	_, err = file.Write(contents)
+	if err != nil {
+		return fmt.Errorf("Write lock file: %s", err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+// Unlock releases a lock held by this process. Locks held by other
+// processes are left alone.
+func (l Locker) Unlock() error {
+	info, locked, err := l.Info()
+	if err != nil || !locked {
+		return err
+	}
+
+	hostname, err := osHostname()
+	if err != nil {
+		return fmt.Errorf("Get hostname: %s", err) //nolint:staticcheck
+	}
+
+	if info.PID != osGetpid() || info.Hostname != hostname {
+		return nil
+	}
+
+	return l.ForceUnlock()
+}
+
+// ForceUnlock removes the lock file regardless of its holder.
+func (l Locker) ForceUnlock() error {
+	err := l.fs.Remove(l.path())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Remove lock file: %s", err) //nolint:staticcheck
+	}
+	return nil
+}
+
+// Info returns the holder of the lock, and whether the lock is held.
+func (l Locker) Info() (LockInfo, bool, error) {
+	contents, err := l.fs.ReadFile(l.path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return LockInfo{}, false, nil
+		}
+		return LockInfo{}, false, fmt.Errorf("Read lock file: %s", err) //nolint:staticcheck
+	}
+
+	var info LockInfo
+	err = json.Unmarshal(contents, &info)
+	if err != nil {
+		return LockInfo{}, true, errors.New("The lock file is unreadable, run `bbl state unlock --force` to remove it") //nolint:staticcheck
+	}
+
+	return info, true, nil
+}
+
+func (l Locker) IsLocked() (bool, error) {
+	_, err := l.fs.Stat(l.path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("Stat lock file: %s", err) //nolint:staticcheck
+	}
+	return true, nil
+}
+
+func (l Locker) path() string {
+	return filepath.Join(l.dir, LOCK_FILE)
+}
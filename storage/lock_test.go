@@ -0,0 +1,166 @@
+package storage_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locker", func() {
+	var (
+		locker  storage.Locker
+		fs      *afero.Afero
+		started time.Time
+	)
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+
+		started = time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+		storage.SetOsHostname(func() (string, error) { return "some-host", nil })
+		storage.SetOsGetpid(func() int { return 1234 })
+		storage.SetTimeNow(func() time.Time { return started })
+
+		locker = storage.NewLocker("some-state-dir", fs)
+	})
+
+	AfterEach(func() {
+		storage.ResetOsHostname()
+		storage.ResetOsGetpid()
+		storage.ResetTimeNow()
+	})
+
+	Describe("Lock", func() {
+		It("writes the holder to the lock file", func() {
+			err := locker.Lock("up")
+			Expect(err).NotTo(HaveOccurred())
+
+			info, locked, err := locker.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locked).To(BeTrue())
+			Expect(info).To(Equal(storage.LockInfo{
+				PID:      1234,
+				Hostname: "some-host",
+				Command:  "up",
+				Started:  started,
+			}))
+		})
+
+		Context("when the lock is already held", func() {
+			var fileIO *fakes.FileIO
+
+			BeforeEach(func() {
+				Expect(locker.Lock("up")).To(Succeed())
+				held, err := fs.ReadFile(filepath.Join("some-state-dir", storage.LOCK_FILE))
+				Expect(err).NotTo(HaveOccurred())
+
+				fileIO = &fakes.FileIO{}
+				fileIO.OpenFileCall.Returns.Error = &os.PathError{Op: "open", Path: storage.LOCK_FILE, Err: os.ErrExist}
+				fileIO.ReadFileCall.Fake = func(string) ([]byte, error) { return held, nil }
+			})
+
+			It("returns an error describing the holder", func() {
+				err := storage.NewLocker("some-state-dir", fileIO).Lock("destroy")
+				Expect(fileIO.OpenFileCall.Receives.Flag & os.O_EXCL).NotTo(BeZero())
+				Expect(err).To(BeAssignableToTypeOf(storage.LockHeldError{}))
+				Expect(err.Error()).To(ContainSubstring("locked by `bbl up` (pid 1234 on some-host, started 2018-01-02T03:04:05Z)"))
+				Expect(err.Error()).To(ContainSubstring("bbl state unlock --force"))
+			})
+		})
+
+		Context("when the state dir does not exist", func() {
+			It("creates it, since the remote state is downloaded after locking", func() {
+				err := storage.NewLocker("missing", fs).Lock("up")
+				Expect(err).NotTo(HaveOccurred())
+
+				exists, err := fs.Exists(filepath.Join("missing", storage.LOCK_FILE))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(BeTrue())
+			})
+		})
+
+		Context("when the state dir cannot be created", func() {
+			It("returns an error", func() {
+				fileIO := &fakes.FileIO{}
+				fileIO.MkdirAllCall.Returns.Error = errors.New("permission denied")
+
+				err := storage.NewLocker("some-state-dir", fileIO).Lock("up")
+				Expect(err).To(MatchError("Lock state dir: permission denied"))
+			})
+		})
+	})
+
+	Describe("Unlock", func() {
+		It("removes a lock held by this process", func() {
+			Expect(locker.Lock("up")).To(Succeed())
+
+			Expect(locker.Unlock()).To(Succeed())
+
+			locked, err := locker.IsLocked()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locked).To(BeFalse())
+		})
+
+		It("leaves a lock held by another process", func() {
+			Expect(locker.Lock("up")).To(Succeed())
+			storage.SetOsGetpid(func() int { return 5678 })
+
+			Expect(locker.Unlock()).To(Succeed())
+
+			locked, err := locker.IsLocked()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locked).To(BeTrue())
+		})
+
+		It("does nothing when there is no lock", func() {
+			Expect(locker.Unlock()).To(Succeed())
+		})
+	})
+
+	Describe("ForceUnlock", func() {
+		It("removes a lock held by another process", func() {
+			Expect(locker.Lock("up")).To(Succeed())
+			storage.SetOsGetpid(func() int { return 5678 })
+
+			Expect(locker.ForceUnlock()).To(Succeed())
+
+			locked, err := locker.IsLocked()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locked).To(BeFalse())
+		})
+	})
+
+	Describe("Info", func() {
+		Context("when the lock file is unreadable", func() {
+			It("returns an error", func() {
+				err := fs.WriteFile(filepath.Join("some-state-dir", storage.LOCK_FILE), []byte("%%%"), storage.StateMode)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, locked, err := locker.Info()
+				Expect(locked).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("bbl state unlock --force")))
+			})
+		})
+
+		Context("when the lock file cannot be read", func() {
+			It("returns an error", func() {
+				fileIO := &fakes.FileIO{}
+				fileIO.ReadFileCall.Fake = func(string) ([]byte, error) {
+					return nil, errors.New("permission denied")
+				}
+
+				_, locked, err := storage.NewLocker("some-state-dir", fileIO).Info()
+				Expect(locked).To(BeFalse())
+				Expect(err).To(MatchError("Read lock file: permission denied"))
+			})
+		})
+	})
+})
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"path/filepath"
 	"reflect"
+	"sort"
 
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
 )
 
 type store interface {
 	Set(state State) error
+	GetStateDir() string
 	GetVarsDir() (string, error)
 	GetTerraformDir() (string, error)
 	GetOldBblDir() string
@@ -36,82 +38,260 @@ func NewMigrator(store store, fs migratorFs) Migrator {
 	return Migrator{store: store, fs: fs}
 }
 
+// migrationDirs are the directories the migrations work in.
+type migrationDirs struct {
+	vars        string
+	terraform   string
+	oldBbl      string
+	cloudConfig string
+}
+
+// migration is a single step of Migrate. plan describes what apply would do
+// to the state directory without touching it, and returns nil when there is
+// nothing to migrate.
+type migration struct {
+	name  string
+	plan  func(m Migrator, state State) (*MigrationStep, error)
+	apply func(m Migrator, state State, dirs migrationDirs) (State, error)
+}
+
+var migrations = []migration{
+	{
+		name: "MigrateTerraformState",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			if state.TFState == "" {
+				return nil, nil
+			}
+			return &MigrationStep{Creates: []string{"vars/terraform.tfstate"}, Updates: []string{STATE_FILE}}, nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return m.MigrateTerraformState(state, dirs.vars)
+		},
+	},
+	{
+		name: "MigrateTerraformTemplate",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			return m.renameStep("terraform/template.tf", "terraform/bbl-template.tf"), nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return state, m.MigrateTerraformTemplate(dirs.terraform)
+		},
+	},
+	{
+		name: "MigrateDirectorState",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			if len(state.BOSH.State) == 0 {
+				return nil, nil
+			}
+			return &MigrationStep{Creates: []string{"vars/bosh-state.json"}, Updates: []string{STATE_FILE}}, nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return m.MigrateDirectorState(state, dirs.vars)
+		},
+	},
+	{
+		name: "MigrateJumpboxState",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			if len(state.Jumpbox.State) == 0 {
+				return nil, nil
+			}
+			return &MigrationStep{Creates: []string{"vars/jumpbox-state.json"}, Updates: []string{STATE_FILE}}, nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return m.MigrateJumpboxState(state, dirs.vars)
+		},
+	},
+	{
+		name: "MigrateCloudConfigDir",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			if !m.exists(".bbl/cloudconfig") {
+				return nil, nil
+			}
+
+			files, err := m.fs.ReadDir(filepath.Join(m.store.GetStateDir(), ".bbl", "cloudconfig"))
+			if err != nil {
+				return nil, fmt.Errorf("reading legacy .bbl dir contents: %s", err)
+			}
+
+			step := &MigrationStep{Deletes: []string{".bbl/cloudconfig"}}
+			for _, file := range files {
+				step.Creates = append(step.Creates, fmt.Sprintf("cloud-config/%s", file.Name()))
+			}
+			sort.Strings(step.Creates)
+			return step, nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return state, m.MigrateCloudConfigDir(dirs.oldBbl, dirs.cloudConfig)
+		},
+	},
+	{
+		name: "MigrateTerraformVars",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			return m.renameStep("vars/terraform.tfvars", "vars/bbl.tfvars"), nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return state, m.MigrateTerraformVars(dirs.vars)
+		},
+	},
+	{
+		name: "MigrateDirectorVars",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			return m.varsStoreStep("director", state.BOSH.Variables), nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return m.MigrateDirectorVars(state, dirs.vars)
+		},
+	},
+	{
+		name: "MigrateDirectorVarsFile",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			return m.renameStep("vars/director-deployment-vars.yml", "vars/director-vars-file.yml"), nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return state, m.MigrateDirectorVarsFile(dirs.vars)
+		},
+	},
+	{
+		name: "MigrateJumpboxVars",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			return m.varsStoreStep("jumpbox", state.Jumpbox.Variables), nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return m.MigrateJumpboxVars(state, dirs.vars)
+		},
+	},
+	{
+		name: "MigrateJumpboxVarsFile",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			return m.renameStep("vars/jumpbox-deployment-vars.yml", "vars/jumpbox-vars-file.yml"), nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return state, m.MigrateJumpboxVarsFile(dirs.vars)
+		},
+	},
+	{
+		// Store.Set writes the current schema version once every
+		// migration has been applied.
+		name: "UpdateStateSchema",
+		plan: func(m Migrator, state State) (*MigrationStep, error) {
+			if state.Version >= STATE_SCHEMA {
+				return nil, nil
+			}
+			return &MigrationStep{Updates: []string{STATE_FILE}}, nil
+		},
+		apply: func(m Migrator, state State, dirs migrationDirs) (State, error) {
+			return state, nil
+		},
+	},
+}
+
 func (m Migrator) Migrate(state State) (State, error) {
 	if reflect.DeepEqual(state, State{}) {
 		return state, nil
 	}
 
-	varsDir, err := m.store.GetVarsDir()
-	if err != nil {
-		return State{}, fmt.Errorf("migrating state: %s", err)
-	}
-
-	state, err = m.MigrateTerraformState(state, varsDir)
+	dirs, err := m.migrationDirs()
 	if err != nil {
 		return State{}, err
 	}
 
-	terraformDir, err := m.store.GetTerraformDir()
-	if err != nil {
-		return State{}, fmt.Errorf("migrating terraform: %s", err)
+	for _, migration := range migrations {
+		state, err = migration.apply(m, state, dirs)
+		if err != nil {
+			return State{}, err
+		}
 	}
 
-	err = m.MigrateTerraformTemplate(terraformDir)
+	err = m.store.Set(state)
 	if err != nil {
-		return State{}, err
+		return State{}, fmt.Errorf("saving migrated state: %s", err)
 	}
 
-	state, err = m.MigrateDirectorState(state, varsDir)
+	return state, nil
+}
+
+func (m Migrator) migrationDirs() (migrationDirs, error) {
+	varsDir, err := m.store.GetVarsDir()
 	if err != nil {
-		return State{}, err
+		return migrationDirs{}, fmt.Errorf("migrating state: %s", err)
 	}
 
-	state, err = m.MigrateJumpboxState(state, varsDir)
+	terraformDir, err := m.store.GetTerraformDir()
 	if err != nil {
-		return State{}, err
+		return migrationDirs{}, fmt.Errorf("migrating terraform: %s", err)
 	}
 
-	bblDir := m.store.GetOldBblDir()
 	cloudConfigDir, err := m.store.GetCloudConfigDir()
 	if err != nil {
-		return State{}, fmt.Errorf("getting cloud-config dir: %s", err)
-	}
-	err = m.MigrateCloudConfigDir(bblDir, cloudConfigDir)
-	if err != nil {
-		return State{}, err
+		return migrationDirs{}, fmt.Errorf("getting cloud-config dir: %s", err)
 	}
 
-	err = m.MigrateTerraformVars(varsDir)
-	if err != nil {
-		return State{}, err
-	}
+	return migrationDirs{
+		vars:        varsDir,
+		terraform:   terraformDir,
+		oldBbl:      m.store.GetOldBblDir(),
+		cloudConfig: cloudConfigDir,
+	}, nil
+}
 
-	state, err = m.MigrateDirectorVars(state, varsDir)
-	if err != nil {
-		return State{}, err
-	}
+// MigrationStep is a migration that Migrate would apply, with the files it
+// would create, update and delete relative to the state directory.
+type MigrationStep struct {
+	Name    string
+	Creates []string
+	Updates []string
+	Deletes []string
+}
 
-	err = m.MigrateDirectorVarsFile(varsDir)
-	if err != nil {
-		return State{}, err
+// Plan lists the steps Migrate would apply to state without changing
+// anything on disk.
+func (m Migrator) Plan(state State) ([]MigrationStep, error) {
+	if reflect.DeepEqual(state, State{}) {
+		return nil, nil
 	}
 
-	state, err = m.MigrateJumpboxVars(state, varsDir)
-	if err != nil {
-		return State{}, err
+	var steps []MigrationStep
+	for _, migration := range migrations {
+		step, err := migration.plan(m, state)
+		if err != nil {
+			return nil, err
+		}
+		if step != nil {
+			step.Name = migration.name
+			steps = append(steps, *step)
+		}
 	}
 
-	err = m.MigrateJumpboxVarsFile(varsDir)
-	if err != nil {
-		return State{}, err
+	return steps, nil
+}
+
+func (m Migrator) exists(relPath string) bool {
+	_, err := m.fs.Stat(filepath.Join(m.store.GetStateDir(), relPath))
+	return err == nil
+}
+
+func (m Migrator) renameStep(oldPath, newPath string) *MigrationStep {
+	if !m.exists(oldPath) {
+		return nil
 	}
+	return &MigrationStep{Creates: []string{newPath}, Deletes: []string{oldPath}}
+}
 
-	err = m.store.Set(state)
-	if err != nil {
-		return State{}, fmt.Errorf("saving migrated state: %s", err)
+func (m Migrator) varsStoreStep(deployment, variables string) *MigrationStep {
+	legacyVarsStore := fmt.Sprintf("vars/%s-variables.yml", deployment)
+	hasLegacyVarsStore := m.exists(legacyVarsStore)
+	if variables == "" && !hasLegacyVarsStore {
+		return nil
 	}
 
-	return state, nil
+	step := &MigrationStep{Creates: []string{fmt.Sprintf("vars/%s-vars-store.yml", deployment)}}
+	if variables != "" {
+		step.Updates = []string{STATE_FILE}
+	}
+	if hasLegacyVarsStore {
+		step.Deletes = []string{legacyVarsStore}
+	}
+	return step
 }
 
 func (m Migrator) MigrateTerraformState(state State, varsDir string) (State, error) {
@@ -170,8 +350,8 @@ func (m Migrator) MigrateJumpboxState(state State, varsDir string) (State, error
 }
 
 func (m Migrator) MigrateCloudConfigDir(bblDir, cloudConfigDir string) error {
-	if _, err := m.fs.Stat(bblDir); err == nil {
-		oldCloudConfigDir := filepath.Join(bblDir, "cloudconfig")
+	oldCloudConfigDir := filepath.Join(bblDir, "cloudconfig")
+	if _, err := m.fs.Stat(oldCloudConfigDir); err == nil {
 		files, err := m.fs.ReadDir(oldCloudConfigDir)
 		if err != nil {
 			return fmt.Errorf("reading legacy .bbl dir contents: %s", err)
@@ -191,10 +371,13 @@ func (m Migrator) MigrateCloudConfigDir(bblDir, cloudConfigDir string) error {
 			}
 		}
 
-		err = m.fs.RemoveAll(m.store.GetOldBblDir())
+		err = m.fs.RemoveAll(oldCloudConfigDir)
 		if err != nil {
 			return fmt.Errorf("removing legacy .bbl dir: %s", err)
 		}
+
+		// .bbl also holds the state history, so it is only removed when empty.
+		m.fs.Remove(bblDir) //nolint:errcheck
 	}
 	return nil
 }
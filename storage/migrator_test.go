@@ -9,6 +9,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
 )
 
 var _ = Describe("Migrator", func() {
@@ -267,7 +268,8 @@ var _ = Describe("Migrator", func() {
 
 				Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(filepath.Join(cloudConfigDir, "some-config-file")))
 				Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(Equal("some-cloud-config"))
-				Expect(fileIO.RemoveAllCall.Receives[0].Path).To(Equal(oldBblDir))
+				Expect(fileIO.RemoveAllCall.Receives[0].Path).To(Equal(oldCloudConfigDir))
+				Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: oldBblDir}))
 			})
 
 			Context("when the contents of the old .bbl dir cannot be read", func() {
@@ -507,4 +509,93 @@ var _ = Describe("Migrator", func() {
 			})
 		})
 	})
+
+	Describe("Plan", func() {
+		writeFile := func(path string) {
+			Expect(os.MkdirAll(filepath.Dir(filepath.Join(stateDir, path)), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(stateDir, path), []byte("some-contents"), storage.StateMode)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			store.GetStateDirCall.Returns.Directory = stateDir
+			migrator = storage.NewMigrator(store, &afero.Afero{Fs: afero.NewOsFs()})
+		})
+
+		It("lists the migrations that apply and the files they touch", func() {
+			writeFile("terraform/template.tf")
+			writeFile(".bbl/cloudconfig/ops.yml")
+			writeFile(".bbl/cloudconfig/cloud-config.yml")
+			writeFile("vars/director-variables.yml")
+			writeFile("vars/jumpbox-deployment-vars.yml")
+
+			steps, err := migrator.Plan(storage.State{
+				Version: storage.STATE_SCHEMA,
+				EnvID:   "some-env-id",
+				TFState: "some-tf-state",
+				Jumpbox: storage.Jumpbox{Variables: "some-jumpbox-vars"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(steps).To(Equal([]storage.MigrationStep{
+				{
+					Name:    "MigrateTerraformState",
+					Creates: []string{"vars/terraform.tfstate"},
+					Updates: []string{"bbl-state.json"},
+				},
+				{
+					Name:    "MigrateTerraformTemplate",
+					Creates: []string{"terraform/bbl-template.tf"},
+					Deletes: []string{"terraform/template.tf"},
+				},
+				{
+					Name:    "MigrateCloudConfigDir",
+					Creates: []string{"cloud-config/cloud-config.yml", "cloud-config/ops.yml"},
+					Deletes: []string{".bbl/cloudconfig"},
+				},
+				{
+					Name:    "MigrateDirectorVars",
+					Creates: []string{"vars/director-vars-store.yml"},
+					Deletes: []string{"vars/director-variables.yml"},
+				},
+				{
+					Name:    "MigrateJumpboxVars",
+					Creates: []string{"vars/jumpbox-vars-store.yml"},
+					Updates: []string{"bbl-state.json"},
+				},
+				{
+					Name:    "MigrateJumpboxVarsFile",
+					Creates: []string{"vars/jumpbox-vars-file.yml"},
+					Deletes: []string{"vars/jumpbox-deployment-vars.yml"},
+				},
+			}))
+
+			Expect(filepath.Join(stateDir, "terraform", "template.tf")).To(BeAnExistingFile())
+			Expect(filepath.Join(stateDir, "vars", "terraform.tfstate")).NotTo(BeAnExistingFile())
+			Expect(store.SetCall.CallCount).To(Equal(0))
+		})
+
+		Context("when the state was written by an older schema", func() {
+			It("updates the state file", func() {
+				Expect(os.RemoveAll(oldCloudConfigDir)).To(Succeed())
+
+				steps, err := migrator.Plan(storage.State{Version: 13, EnvID: "some-env-id"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(steps).To(Equal([]storage.MigrationStep{{
+					Name:    "UpdateStateSchema",
+					Updates: []string{"bbl-state.json"},
+				}}))
+			})
+		})
+
+		Context("when the state is already migrated", func() {
+			It("returns no steps", func() {
+				Expect(os.RemoveAll(oldCloudConfigDir)).To(Succeed())
+
+				steps, err := migrator.Plan(storage.State{Version: storage.STATE_SCHEMA, EnvID: "some-env-id"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(steps).To(BeEmpty())
+			})
+		})
+	})
 })
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// notRendered are the paths in the state dir that bbl plan does not render
+// from templates, so bbl plan --diff leaves them out. vars/ holds credentials.
+var notRendered = map[string]struct{}{
+	STATE_FILE:             {},
+	LOCK_FILE:              {},
+	SECRETS_FILE:           {},
+	".bbl":                 {},
+	"vars":                 {},
+	"terraform/.terraform": {},
+}
+
+// CopyStateDir copies the state dir to a new temporary directory, leaving out
+// the terraform plugin cache, so that bbl plan --diff can render into the
+// copy without writing to the state dir.
+func CopyStateDir(dir string) (string, error) {
+	scratchDir, err := os.MkdirTemp("", "bbl-plan-diff")
+	if err != nil {
+		return "", fmt.Errorf("Create scratch dir: %s", err) //nolint:staticcheck
+	}
+
+	err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == filepath.Join("terraform", ".terraform") {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(scratchDir, rel)
+
+		if entry.IsDir() {
+			return os.MkdirAll(target, StateMode)
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		return copyFile(path, target)
+	})
+	if err != nil {
+		os.RemoveAll(scratchDir)                         //nolint:errcheck
+		return "", fmt.Errorf("Copy state dir: %s", err) //nolint:staticcheck
+	}
+
+	return scratchDir, nil
+}
+
+func copyFile(from, to string) error {
+	info, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+
+	contents, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(to, contents, info.Mode().Perm())
+}
+
+// Differ remembers the rendered files in the state dir so that it can show
+// how rendering them again changed them.
+type Differ struct {
+	fs     fs
+	dir    string
+	before map[string]string
+}
+
+func NewDiffer(fs fs, dir string) *Differ {
+	return &Differ{
+		fs:  fs,
+		dir: dir,
+	}
+}
+
+func (d *Differ) Snapshot() error {
+	files, err := d.read()
+	if err != nil {
+		return err
+	}
+
+	d.before = files
+	return nil
+}
+
+// Diff returns a unified diff from the snapshot to the files in the state
+// dir now, or an empty string when nothing changed.
+func (d *Differ) Diff() (string, error) {
+	after, err := d.read()
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for name := range d.before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := d.before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diff strings.Builder
+	for _, name := range names {
+		before, existed := d.before[name]
+		now, exists := after[name]
+		if existed && exists && before == now {
+			continue
+		}
+
+		unified := difflib.UnifiedDiff{
+			A:        splitLines(before),
+			B:        splitLines(now),
+			FromFile: "a/" + name,
+			ToFile:   "b/" + name,
+			Context:  3,
+		}
+		if !existed {
+			unified.A = nil
+			unified.FromFile = "/dev/null"
+		}
+		if !exists {
+			unified.B = nil
+			unified.ToFile = "/dev/null"
+		}
+
+		err = difflib.WriteUnifiedDiff(&diff, unified)
+		if err != nil {
+			return "", fmt.Errorf("Diff %s: %s", name, err) //nolint:staticcheck
+		}
+	}
+
+	return diff.String(), nil
+}
+
+// splitLines keeps the line endings, unlike difflib.SplitLines which adds an
+// empty last line to files that end in a newline.
+func splitLines(contents string) []string {
+	lines := strings.SplitAfter(contents, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+func (d *Differ) read() (map[string]string, error) {
+	files := map[string]string{}
+	err := d.readDir("", files)
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (d *Differ) readDir(rel string, files map[string]string) error {
+	entries, err := d.fs.ReadDir(filepath.Join(d.dir, rel))
+	if err != nil {
+		if os.IsNotExist(err) && rel == "" {
+			return nil
+		}
+		return fmt.Errorf("Read state dir: %s", err) //nolint:staticcheck
+	}
+
+	for _, entry := range entries {
+		name := filepath.ToSlash(filepath.Join(rel, entry.Name()))
+		if _, ok := notRendered[name]; ok {
+			continue
+		}
+		// bbl.log and its rotated copies, which bbl plan --diff itself logs to.
+		if strings.HasPrefix(name, LOG_FILE) {
+			continue
+		}
+
+		if entry.IsDir() {
+			err = d.readDir(name, files)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		contents, err := d.fs.ReadFile(filepath.Join(d.dir, name))
+		if err != nil {
+			return fmt.Errorf("Read state dir: %s", err) //nolint:staticcheck
+		}
+		files[name] = string(contents)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Preview", func() {
+	var tempDir string
+
+	writeFile := func(dir, path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, path), []byte(contents), storage.StateMode)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir) //nolint:errcheck
+	})
+
+	Describe("CopyStateDir", func() {
+		It("copies the state dir without the terraform plugin cache", func() {
+			writeFile(tempDir, "bbl-state.json", "some-state")
+			writeFile(tempDir, "vars/bbl.tfvars", "some-vars")
+			writeFile(tempDir, "terraform/bbl-template.tf", "some-template")
+			writeFile(tempDir, "terraform/.terraform/providers/some-provider", "some-provider")
+			writeFile(tempDir, "bbl.log", "some-log")
+			writeFile(tempDir, "bbl.log.1", "some-rotated-log")
+
+			scratchDir, err := storage.CopyStateDir(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(scratchDir) //nolint:errcheck
+
+			Expect(filepath.Join(scratchDir, "bbl-state.json")).To(BeAnExistingFile())
+			Expect(filepath.Join(scratchDir, "vars", "bbl.tfvars")).To(BeAnExistingFile())
+			Expect(filepath.Join(scratchDir, "terraform", "bbl-template.tf")).To(BeAnExistingFile())
+			Expect(filepath.Join(scratchDir, "terraform", ".terraform")).NotTo(BeAnExistingFile())
+		})
+
+		Context("when the state dir does not exist", func() {
+			It("returns an empty scratch dir", func() {
+				scratchDir, err := storage.CopyStateDir(filepath.Join(tempDir, "missing"))
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(scratchDir) //nolint:errcheck
+
+				entries, err := os.ReadDir(scratchDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Differ", func() {
+		var differ *storage.Differ
+
+		BeforeEach(func() {
+			differ = storage.NewDiffer(storage.NewAtomicFs(&afero.Afero{Fs: afero.NewOsFs()}, tempDir), tempDir)
+		})
+
+		It("returns a unified diff of the rendered files", func() {
+			writeFile(tempDir, "create-director.sh", "bosh create-env\n  -o old-ops.yml\n")
+			writeFile(tempDir, "cloud-config/ops.yml", "old\n")
+			Expect(differ.Snapshot()).To(Succeed())
+
+			writeFile(tempDir, "create-director.sh", "bosh create-env\n  -o new-ops.yml\n")
+			Expect(os.Remove(filepath.Join(tempDir, "cloud-config", "ops.yml"))).To(Succeed())
+			writeFile(tempDir, "runtime-config/runtime-config.yml", "new\n")
+
+			diff, err := differ.Diff()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff).To(Equal(`--- a/cloud-config/ops.yml
++++ /dev/null
+@@ -1 +0,0 @@
+-old
+--- a/create-director.sh
++++ b/create-director.sh
+@@ -1,2 +1,2 @@
+ bosh create-env
+-  -o old-ops.yml
++  -o new-ops.yml
+--- /dev/null
++++ b/runtime-config/runtime-config.yml
+@@ -0,0 +1 @@
++new
+`))
+		})
+
+		It("leaves out the files bbl plan does not render", func() {
+			Expect(differ.Snapshot()).To(Succeed())
+
+			writeFile(tempDir, "bbl-state.json", "some-state")
+			writeFile(tempDir, "vars/director-vars-store.yml", "admin_password: some-password")
+			writeFile(tempDir, "terraform/.terraform/providers/some-provider", "some-provider")
+			writeFile(tempDir, "bbl.log", "some-log")
+			writeFile(tempDir, "bbl.log.1", "some-rotated-log")
+
+			diff, err := differ.Diff()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff).To(BeEmpty())
+		})
+	})
+})
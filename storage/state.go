@@ -18,4 +18,31 @@ type State struct {
 	LB             LB         `json:"lb"`
 	LatestTFOutput string     `json:"latestTFOutput"`
 	StorageBucket  string     `json:"storageBucket,omitempty"`
+
+	// Checkpoints are the phases of bbl up that finished, in order, so that
+	// a rerun can resume after them.
+	Checkpoints []Checkpoint `json:"checkpoints,omitempty"`
+
+	// CARotation is the stage that bbl rotate --certs last reached, or empty
+	// when no CA rotation is in progress.
+	CARotation string `json:"caRotation,omitempty"`
+
+	// DirectorBackups adds the BOSH Backup and Restore scripts to the
+	// director so that bbl backup-director can back it up.
+	DirectorBackups bool `json:"directorBackups,omitempty"`
+}
+
+const (
+	// CARotationNewCATrusted means the new CAs are trusted next to the old
+	// ones, which still sign the director certificates.
+	CARotationNewCATrusted = "new-ca-trusted"
+	// CARotationNewCASigning means the new CAs sign the director
+	// certificates and the old ones are still trusted.
+	CARotationNewCASigning = "new-ca-signing"
+)
+
+// Checkpoint is a finished phase of bbl up and a fingerprint of its inputs.
+type Checkpoint struct {
+	Phase       string `json:"phase"`
+	Fingerprint string `json:"fingerprint"`
 }
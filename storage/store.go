@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,16 +22,23 @@ var (
 const (
 	STATE_SCHEMA = 14
 	STATE_FILE   = "bbl-state.json"
+	SECRETS_FILE = "bbl-secrets.json"
+	LOG_FILE     = "bbl.log"
 )
 
 type Store struct {
 	dir              string
 	fs               fs
 	garbageCollector garbageCollector
+	encryptor        encryptor
+	secretStore      secretStore
 	stateSchema      int
+	varsDir          string
+	redactor         redactor
 }
 
 type fs interface {
+	fileio.FileReader
 	fileio.FileWriter
 	fileio.Remover
 	fileio.AllRemover
@@ -38,19 +47,50 @@ type fs interface {
 	fileio.DirReader
 }
 
+// secretStore keeps the director credentials and vars stores outside the
+// state directory when --secret-store is set.
+type secretStore interface {
+	SealSecrets(state State, varsDir string) (State, error)
+	FetchVarsStores(varsDir string) error
+	StoreVarsStores(varsDir string) error
+}
+
+// redactor learns the secrets that a command creates while it runs, such as
+// the vars stores written by bosh create-env or rotated credentials.
+type redactor interface {
+	AddState(state State)
+	AddVarsStore(contents string)
+}
+
 type garbageCollector interface {
 	Remove(d string) error
 }
 
-func NewStore(dir string, fs fs, garbageCollector garbageCollector) Store {
+type encryptor interface {
+	Enabled() bool
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(contents []byte) ([]byte, error)
+	EncryptDir(fs dirFs, dir string) error
+	DecryptDir(fs dirFs, dir string) error
+}
+
+func NewStore(dir string, fs fs, garbageCollector garbageCollector, encryptor encryptor, secretStore secretStore) Store {
 	return Store{
 		dir:              dir,
 		fs:               fs,
 		garbageCollector: garbageCollector,
+		encryptor:        encryptor,
+		secretStore:      secretStore,
 		stateSchema:      STATE_SCHEMA,
 	}
 }
 
+// RedactWith tells redactor about the secrets in every state that is saved,
+// so that they are redacted from the rest of the output.
+func (s *Store) RedactWith(redactor redactor) {
+	s.redactor = redactor
+}
+
 func (s Store) Set(state State) error {
 	_, err := s.fs.Stat(s.dir)
 	if err != nil {
@@ -75,11 +115,23 @@ func (s Store) Set(state State) error {
 		state.ID = uuid.String()
 	}
 
+	s.redact(state)
+
+	state, err = s.secretStore.SealSecrets(state, filepath.Join(s.dir, "vars"))
+	if err != nil {
+		return fmt.Errorf("Store secrets: %s", err) //nolint:staticcheck
+	}
+
 	jsonData, err := marshalIndent(state, "", "\t")
 	if err != nil {
 		return err
 	}
 
+	jsonData, err = s.encryptor.Encrypt(addChecksum(jsonData))
+	if err != nil {
+		return fmt.Errorf("Encrypt state: %s", err) //nolint:staticcheck
+	}
+
 	stateFile := filepath.Join(s.dir, STATE_FILE)
 	err = s.fs.WriteFile(stateFile, jsonData, os.FileMode(0644))
 	if err != nil {
@@ -89,6 +141,206 @@ func (s Store) Set(state State) error {
 	return nil
 }
 
+// redact reads the vars stores before they are sealed, since bosh
+// create-env writes them outside the state.
+func (s Store) redact(state State) {
+	if s.redactor == nil {
+		return
+	}
+
+	s.redactor.AddState(state)
+	for _, varsStore := range []string{"jumpbox-vars-store.yml", "director-vars-store.yml"} {
+		contents, err := s.fs.ReadFile(filepath.Join(s.dir, "vars", varsStore))
+		if err != nil {
+			continue
+		}
+		contents, err = s.encryptor.Decrypt(contents)
+		if err == nil {
+			s.redactor.AddVarsStore(string(contents))
+		}
+	}
+}
+
+// EncryptStateDir converts an existing state directory so that bbl-state.json
+// and the files in vars/ are encrypted at rest.
+func (s Store) EncryptStateDir() error {
+	if !s.encryptor.Enabled() {
+		return errors.New("--state-encryption-key is required to encrypt the state directory")
+	}
+
+	err := s.transformStateFile(s.encryptor.Encrypt)
+	if err != nil {
+		return fmt.Errorf("Encrypt state: %s", err) //nolint:staticcheck
+	}
+
+	err = s.encryptor.EncryptDir(s.fs, filepath.Join(s.dir, "vars"))
+	if err != nil {
+		return fmt.Errorf("Encrypt vars dir: %s", err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+// DecryptStateDir writes bbl-state.json and the files in vars/ back as plaintext.
+func (s Store) DecryptStateDir() error {
+	err := s.transformStateFile(s.encryptor.Decrypt)
+	if err != nil {
+		return fmt.Errorf("Decrypt state: %s", err) //nolint:staticcheck
+	}
+
+	err = s.encryptor.DecryptDir(s.fs, filepath.Join(s.dir, "vars"))
+	if err != nil {
+		return fmt.Errorf("Decrypt vars dir: %s", err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+// AcceptEdit gives a bbl-state.json that was edited by hand a new checksum,
+// so that bbl trusts it again. It keeps the file encrypted if it was.
+func (s Store) AcceptEdit() error {
+	return s.transformStateFile(func(contents []byte) ([]byte, error) {
+		plaintext, err := s.encryptor.Decrypt(contents)
+		if err != nil {
+			return nil, err
+		}
+
+		var state State
+		err = json.Unmarshal(removeChecksum(bytes.TrimSpace(plaintext)), &state)
+		if err != nil {
+			return nil, fmt.Errorf("bbl-state.json is not valid JSON: %s", err)
+		}
+
+		jsonData, err := marshalIndent(state, "", "\t")
+		if err != nil {
+			return nil, err
+		}
+
+		jsonData = addChecksum(jsonData)
+		if IsEncrypted(contents) {
+			return s.encryptor.Encrypt(jsonData)
+		}
+		return jsonData, nil
+	})
+}
+
+// UnsealVarsDir decrypts the vars directory in place for the duration of a
+// command so that terraform and the bosh cli can read it.
+func (s Store) UnsealVarsDir() error {
+	if !s.encryptor.Enabled() {
+		return nil
+	}
+
+	err := s.encryptor.DecryptDir(s.fs, filepath.Join(s.dir, "vars"))
+	if err != nil {
+		return fmt.Errorf("Decrypt vars dir: %s", err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+// UnsealVarsCopy decrypts a temporary copy of the vars directory for
+// commands that do not hold the state lock, so that the files at rest are
+// never left in plaintext by them. The returned store reads vars from the
+// copy, and the returned directory is empty when there is nothing to decrypt.
+func (s Store) UnsealVarsCopy() (Store, string, error) {
+	if !s.encryptor.Enabled() {
+		return s, "", nil
+	}
+
+	copyDir, err := os.MkdirTemp("", "bbl-vars")
+	if err != nil {
+		return s, "", fmt.Errorf("Create vars copy: %s", err) //nolint:staticcheck
+	}
+
+	err = s.copyVarsDir(copyDir)
+	if err == nil {
+		err = s.encryptor.DecryptDir(s.fs, copyDir)
+	}
+	if err != nil {
+		os.RemoveAll(copyDir)                                  //nolint:errcheck
+		return s, "", fmt.Errorf("Decrypt vars copy: %s", err) //nolint:staticcheck
+	}
+
+	s.varsDir = copyDir
+	return s, copyDir, nil
+}
+
+func (s Store) copyVarsDir(copyDir string) error {
+	varsDir := filepath.Join(s.dir, "vars")
+	entries, err := s.fs.ReadDir(varsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+		contents, err := s.fs.ReadFile(filepath.Join(varsDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		err = s.fs.WriteFile(filepath.Join(copyDir, entry.Name()), contents, StateMode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SealVarsDir moves the vars stores to the secret store and re-encrypts the
+// vars directory after a command when bbl-state.json is encrypted at rest.
+func (s Store) SealVarsDir() error {
+	err := s.secretStore.StoreVarsStores(s.varsPath())
+	if err != nil {
+		return fmt.Errorf("Store vars stores: %s", err) //nolint:staticcheck
+	}
+
+	// A decrypted copy is thrown away rather than sealed.
+	if !s.encryptor.Enabled() || s.varsDir != "" {
+		return nil
+	}
+
+	contents, err := s.fs.ReadFile(filepath.Join(s.dir, STATE_FILE))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !IsEncrypted(contents) {
+		return nil
+	}
+
+	err = s.encryptor.EncryptDir(s.fs, filepath.Join(s.dir, "vars"))
+	if err != nil {
+		return fmt.Errorf("Encrypt vars dir: %s", err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func (s Store) transformStateFile(transform func([]byte) ([]byte, error)) error {
+	stateFile := filepath.Join(s.dir, STATE_FILE)
+	contents, err := s.fs.ReadFile(stateFile)
+	if err != nil {
+		return err
+	}
+
+	transformed, err := transform(contents)
+	if err != nil {
+		return err
+	}
+
+	return s.fs.WriteFile(stateFile, transformed, os.FileMode(0644))
+}
+
 func (s Store) GetStateDir() string {
 	return s.dir
 }
@@ -105,8 +357,31 @@ func (s Store) GetTerraformDir() (string, error) {
 	return s.getDir("terraform", os.ModePerm)
 }
 
+// GetVarsDir also fetches the vars stores from the secret store, so that
+// they are only read from it by the commands that need them.
 func (s Store) GetVarsDir() (string, error) {
-	return s.getDir("vars", StateMode)
+	dir := s.varsDir
+	if dir == "" {
+		var err error
+		dir, err = s.getDir("vars", StateMode)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err := s.secretStore.FetchVarsStores(dir)
+	if err != nil {
+		return "", fmt.Errorf("Fetch vars stores: %s", err) //nolint:staticcheck
+	}
+
+	return dir, nil
+}
+
+func (s Store) varsPath() string {
+	if s.varsDir != "" {
+		return s.varsDir
+	}
+	return filepath.Join(s.dir, "vars")
 }
 
 func (s Store) GetDirectorDeploymentDir() (string, error) {
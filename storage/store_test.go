@@ -6,8 +6,10 @@ import (
 	"path/filepath"
 
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/secrets"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	uuid "github.com/nu7hatch/gouuid"
+	"github.com/spf13/afero"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -28,7 +30,7 @@ var _ = Describe("Store", func() {
 		fileIO = &fakes.FileIO{}
 		garbageCollector = &fakes.GarbageCollector{}
 
-		store = storage.NewStore(tempDir, fileIO, garbageCollector)
+		store = storage.NewStore(tempDir, fileIO, garbageCollector, storage.NewEncryptor(""), secrets.NewResolver(nil, nil))
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -127,7 +129,8 @@ var _ = Describe("Store", func() {
 
 				Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(filepath.Join(tempDir, "bbl-state.json")))
 				Expect(fileIO.WriteFileCall.Receives[0].Mode).To(Equal(os.FileMode(0644)))
-				Expect(fileIO.WriteFileCall.Receives[0].Contents).To(MatchJSON(`{
+				Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(MatchRegexp(`,\n\t"checksum": "sha256:[0-9a-f]{64}"\n}$`))
+				Expect(storage.RemoveChecksum(fileIO.WriteFileCall.Receives[0].Contents)).To(MatchJSON(`{
 				"version": 14,
 				"bblVersion": "5.3.0",
 				"iaas": "aws",
@@ -182,6 +185,62 @@ var _ = Describe("Store", func() {
 			})
 		})
 
+		Context("when a state encryption key is provided", func() {
+			It("encrypts the state file", func() {
+				encryptor := storage.NewEncryptor("some-passphrase")
+				store = storage.NewStore(tempDir, fileIO, garbageCollector, encryptor, secrets.NewResolver(nil, nil))
+
+				err := store.Set(storage.State{EnvID: "some-env-id"})
+				Expect(err).NotTo(HaveOccurred())
+
+				contents := fileIO.WriteFileCall.Receives[0].Contents
+				Expect(storage.IsEncrypted(contents)).To(BeTrue())
+				Expect(string(contents)).NotTo(ContainSubstring("some-env-id"))
+
+				decrypted, err := encryptor.Decrypt(contents)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(decrypted)).To(ContainSubstring(`"envID": "some-env-id"`))
+			})
+		})
+
+		Context("when a secret store is configured", func() {
+			var secretStore *fakes.SecretStore
+
+			BeforeEach(func() {
+				secretStore = &fakes.SecretStore{}
+				secretStore.SealSecretsCall.Returns.State = storage.State{
+					EnvID: "some-env-id",
+					BOSH:  storage.BOSH{DirectorPassword: "secret:director-password"},
+				}
+				store = storage.NewStore(tempDir, fileIO, garbageCollector, storage.NewEncryptor(""), secretStore)
+			})
+
+			It("writes references in place of the secrets", func() {
+				err := store.Set(storage.State{
+					EnvID: "some-env-id",
+					BOSH:  storage.BOSH{DirectorPassword: "some-password"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(secretStore.SealSecretsCall.Receives.State.BOSH.DirectorPassword).To(Equal("some-password"))
+				Expect(secretStore.SealSecretsCall.Receives.VarsDir).To(Equal(filepath.Join(tempDir, "vars")))
+
+				contents := string(fileIO.WriteFileCall.Receives[0].Contents)
+				Expect(contents).To(ContainSubstring(`"directorPassword": "secret:director-password"`))
+				Expect(contents).NotTo(ContainSubstring("some-password"))
+			})
+
+			Context("when storing the secrets fails", func() {
+				It("returns an error without writing the state", func() {
+					secretStore.SealSecretsCall.Returns.Error = errors.New("vault is sealed")
+
+					err := store.Set(storage.State{EnvID: "some-env-id"})
+					Expect(err).To(MatchError("Store secrets: vault is sealed"))
+					Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
 		Context("when the state is empty", func() {
 			It("calls the garbage collector", func() {
 				err := store.Set(storage.State{})
@@ -236,7 +295,7 @@ var _ = Describe("Store", func() {
 				})
 
 				It("returns an error", func() {
-					store = storage.NewStore("non-valid-dir", fileIO, garbageCollector)
+					store = storage.NewStore("non-valid-dir", fileIO, garbageCollector, storage.NewEncryptor(""), secrets.NewResolver(nil, nil))
 					err := store.Set(storage.State{})
 					Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
 				})
@@ -253,6 +312,259 @@ var _ = Describe("Store", func() {
 				})
 			})
 		})
+
+		Context("when it redacts with a redactor", func() {
+			var redactor *fakes.Redactor
+
+			BeforeEach(func() {
+				redactor = &fakes.Redactor{}
+				store.RedactWith(redactor)
+
+				fileIO.ReadFileCall.Fake = func(filename string) ([]byte, error) {
+					if filename == filepath.Join(tempDir, "vars", "director-vars-store.yml") {
+						return []byte("admin_password: some-new-password"), nil
+					}
+					return nil, os.ErrNotExist
+				}
+			})
+
+			It("adds the secrets of the saved state and the vars stores", func() {
+				state := storage.State{
+					EnvID: "some-env-id",
+					BOSH:  storage.BOSH{DirectorPassword: "some-rotated-password"},
+				}
+
+				err := store.Set(state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(redactor.AddStateCall.Receives.State.BOSH.DirectorPassword).To(Equal("some-rotated-password"))
+				Expect(redactor.AddVarsStoreCall.Receives.Contents).To(Equal([]string{"admin_password: some-new-password"}))
+			})
+		})
+	})
+
+	Describe("EncryptStateDir and DecryptStateDir", func() {
+		var (
+			encryptor storage.Encryptor
+			fs        *afero.Afero
+			stateFile string
+			varsFile  string
+		)
+
+		BeforeEach(func() {
+			encryptor = storage.NewEncryptor("some-passphrase")
+			fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+			store = storage.NewStore(tempDir, fs, garbageCollector, encryptor, secrets.NewResolver(nil, nil))
+
+			stateFile = filepath.Join(tempDir, "bbl-state.json")
+			err := fs.WriteFile(stateFile, []byte(`{"envID": "some-env-id"}`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+			varsFile = filepath.Join(tempDir, "vars", "director-vars-store.yml")
+			err = fs.WriteFile(varsFile, []byte("admin_password: some-password"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("encrypts the state file and the vars dir", func() {
+			err := store.EncryptStateDir()
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFile(stateFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storage.IsEncrypted(contents)).To(BeTrue())
+
+			contents, err = fs.ReadFile(varsFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storage.IsEncrypted(contents)).To(BeTrue())
+		})
+
+		It("decrypts the state file and the vars dir", func() {
+			Expect(store.EncryptStateDir()).To(Succeed())
+
+			err := store.DecryptStateDir()
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFile(stateFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(`{"envID": "some-env-id"}`))
+
+			contents, err = fs.ReadFile(varsFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("admin_password: some-password"))
+		})
+
+		Context("when no encryption key is provided", func() {
+			It("returns an error", func() {
+				store = storage.NewStore(tempDir, fs, garbageCollector, storage.NewEncryptor(""), secrets.NewResolver(nil, nil))
+
+				err := store.EncryptStateDir()
+				Expect(err).To(MatchError(ContainSubstring("--state-encryption-key is required")))
+			})
+		})
+	})
+
+	Describe("UnsealVarsDir and SealVarsDir", func() {
+		var (
+			fs       *afero.Afero
+			varsFile string
+		)
+
+		BeforeEach(func() {
+			encryptor := storage.NewEncryptor("some-passphrase")
+			fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+			store = storage.NewStore(tempDir, fs, garbageCollector, encryptor, secrets.NewResolver(nil, nil))
+
+			varsFile = filepath.Join(tempDir, "vars", "jumpbox-vars-store.yml")
+			err := fs.WriteFile(varsFile, []byte("jumpbox_ssh: some-key"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when the state file is encrypted", func() {
+			BeforeEach(func() {
+				contents, err := storage.NewEncryptor("some-passphrase").Encrypt([]byte("{}"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fs.WriteFile(filepath.Join(tempDir, "bbl-state.json"), contents, 0644)).To(Succeed())
+			})
+
+			It("encrypts the vars dir and decrypts it again", func() {
+				Expect(store.SealVarsDir()).To(Succeed())
+
+				contents, err := fs.ReadFile(varsFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(storage.IsEncrypted(contents)).To(BeTrue())
+
+				Expect(store.UnsealVarsDir()).To(Succeed())
+
+				contents, err = fs.ReadFile(varsFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("jumpbox_ssh: some-key"))
+			})
+		})
+
+		Context("when the state file is plaintext", func() {
+			BeforeEach(func() {
+				Expect(fs.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte("{}"), 0644)).To(Succeed())
+			})
+
+			It("leaves the vars dir alone", func() {
+				Expect(store.SealVarsDir()).To(Succeed())
+
+				contents, err := fs.ReadFile(varsFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("jumpbox_ssh: some-key"))
+			})
+		})
+	})
+
+	Describe("AcceptEdit", func() {
+		It("rewrites the state file with a new checksum", func() {
+			fileIO.ReadFileCall.Returns.Contents = []byte("{\n\t\"envID\": \"some-env-id\",\n\t\"checksum\": \"sha256:some-old-checksum\"\n}\n")
+
+			err := store.AcceptEdit()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(filepath.Join(tempDir, "bbl-state.json")))
+			contents := string(fileIO.WriteFileCall.Receives[0].Contents)
+			Expect(contents).To(ContainSubstring(`"envID": "some-env-id"`))
+			Expect(contents).NotTo(ContainSubstring("some-old-checksum"))
+			Expect(contents).To(MatchRegexp(`,\n\t"checksum": "sha256:[0-9a-f]{64}"\n}$`))
+		})
+
+		Context("when the state file is encrypted", func() {
+			It("keeps it encrypted", func() {
+				encryptor := storage.NewEncryptor("some-passphrase")
+				store = storage.NewStore(tempDir, fileIO, garbageCollector, encryptor, secrets.NewResolver(nil, nil))
+				contents, err := encryptor.Encrypt([]byte(`{"envID": "some-env-id"}`))
+				Expect(err).NotTo(HaveOccurred())
+				fileIO.ReadFileCall.Returns.Contents = contents
+
+				Expect(store.AcceptEdit()).To(Succeed())
+
+				Expect(storage.IsEncrypted(fileIO.WriteFileCall.Receives[0].Contents)).To(BeTrue())
+			})
+		})
+
+		Context("when the edit left invalid JSON", func() {
+			It("returns an error without writing the state file", func() {
+				fileIO.ReadFileCall.Returns.Contents = []byte(`{"envID": `)
+
+				err := store.AcceptEdit()
+				Expect(err).To(MatchError(ContainSubstring("bbl-state.json is not valid JSON")))
+				Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("UnsealVarsCopy", func() {
+		var (
+			encryptor storage.Encryptor
+			varsFile  string
+		)
+
+		BeforeEach(func() {
+			encryptor = storage.NewEncryptor("some-passphrase")
+			store = storage.NewStore(tempDir, &afero.Afero{Fs: afero.NewOsFs()}, garbageCollector, encryptor, secrets.NewResolver(nil, nil))
+
+			err := os.MkdirAll(filepath.Join(tempDir, "vars"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+			varsFile = filepath.Join(tempDir, "vars", "jumpbox-vars-store.yml")
+			err = os.WriteFile(varsFile, []byte("jumpbox_ssh: some-key"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encryptor.EncryptDir(&afero.Afero{Fs: afero.NewOsFs()}, filepath.Join(tempDir, "vars"))).To(Succeed())
+		})
+
+		It("decrypts a copy of the vars dir and leaves the vars dir encrypted", func() {
+			unsealed, copyDir, err := store.UnsealVarsCopy()
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(copyDir)
+
+			varsDir, err := unsealed.GetVarsDir()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(varsDir).To(Equal(copyDir))
+
+			contents, err := os.ReadFile(filepath.Join(copyDir, "jumpbox-vars-store.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("jumpbox_ssh: some-key"))
+
+			Expect(unsealed.SealVarsDir()).To(Succeed())
+
+			contents, err = os.ReadFile(varsFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storage.IsEncrypted(contents)).To(BeTrue())
+		})
+
+		Context("when no encryption key is provided", func() {
+			It("reads the vars dir itself", func() {
+				store = storage.NewStore(tempDir, &afero.Afero{Fs: afero.NewOsFs()}, garbageCollector, storage.NewEncryptor(""), secrets.NewResolver(nil, nil))
+
+				unsealed, copyDir, err := store.UnsealVarsCopy()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(copyDir).To(BeEmpty())
+
+				varsDir, err := unsealed.GetVarsDir()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(varsDir).To(Equal(filepath.Join(tempDir, "vars")))
+			})
+		})
+	})
+
+	Describe("SealVarsDir with a secret store", func() {
+		It("moves the vars stores to the secret store", func() {
+			secretStore := &fakes.SecretStore{}
+			store = storage.NewStore(tempDir, fileIO, garbageCollector, storage.NewEncryptor(""), secretStore)
+
+			Expect(store.SealVarsDir()).To(Succeed())
+			Expect(secretStore.StoreVarsStoresCall.Receives.VarsDir).To(Equal(filepath.Join(tempDir, "vars")))
+		})
+
+		Context("when storing the vars stores fails", func() {
+			It("returns an error", func() {
+				secretStore := &fakes.SecretStore{}
+				secretStore.StoreVarsStoresCall.Returns.Error = errors.New("vault is sealed")
+				store = storage.NewStore(tempDir, fileIO, garbageCollector, storage.NewEncryptor(""), secretStore)
+
+				Expect(store.SealVarsDir()).To(MatchError("Store vars stores: vault is sealed"))
+			})
+		})
 	})
 
 	DescribeTable("get dirs returns the path to an existing directory",
@@ -360,5 +672,30 @@ var _ = Describe("Store", func() {
 			})
 		})
 
+		Context("when a secret store is configured", func() {
+			var secretStore *fakes.SecretStore
+
+			BeforeEach(func() {
+				secretStore = &fakes.SecretStore{}
+				store = storage.NewStore(tempDir, fileIO, garbageCollector, storage.NewEncryptor(""), secretStore)
+			})
+
+			It("fetches the vars stores into the vars dir", func() {
+				varsDir, err := store.GetVarsDir()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(secretStore.FetchVarsStoresCall.Receives.VarsDir).To(Equal(varsDir))
+			})
+
+			Context("when fetching the vars stores fails", func() {
+				It("returns an error", func() {
+					secretStore.FetchVarsStoresCall.Returns.Error = errors.New("vault is sealed")
+
+					_, err := store.GetVarsDir()
+					Expect(err).To(MatchError("Fetch vars stores: vault is sealed"))
+				})
+			})
+		})
+
 	})
 })
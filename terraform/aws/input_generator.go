@@ -64,8 +64,9 @@ func (i InputGenerator) Generate(state storage.State) (map[string]interface{}, e
 
 func (i InputGenerator) Credentials(state storage.State) map[string]string {
 	return map[string]string{
-		"access_key": state.AWS.AccessKeyID,
-		"secret_key": state.AWS.SecretAccessKey,
-		"role_arn":   state.AWS.AssumeRoleArn,
+		"access_key":    state.AWS.AccessKeyID,
+		"secret_key":    state.AWS.SecretAccessKey,
+		"session_token": state.AWS.SessionToken,
+		"role_arn":      state.AWS.AssumeRoleArn,
 	}
 }
@@ -159,10 +159,24 @@ var _ = Describe("InputGenerator", func() {
 			credentials := inputGenerator.Credentials(state)
 
 			Expect(credentials).To(Equal(map[string]string{
-				"access_key": "some-access-key-id",
-				"secret_key": "some-secret-access-key",
-				"role_arn":   "some-assume-role-arn",
+				"access_key":    "some-access-key-id",
+				"secret_key":    "some-secret-access-key",
+				"session_token": "",
+				"role_arn":      "some-assume-role-arn",
 			}))
 		})
+
+		It("returns the session token of credentials resolved from a profile", func() {
+			credentials := inputGenerator.Credentials(storage.State{
+				AWS: storage.AWS{
+					AccessKeyID:     "some-access-key-id",
+					SecretAccessKey: "some-secret-access-key",
+					SessionToken:    "some-session-token",
+					Profile:         "some-profile",
+				},
+			})
+
+			Expect(credentials["session_token"]).To(Equal("some-session-token"))
+		})
 	})
 })
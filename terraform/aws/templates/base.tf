@@ -14,6 +14,7 @@ terraform {
 provider "aws" {
   access_key = var.access_key
   secret_key = var.secret_key
+  token      = var.session_token
   region     = var.region
   assume_role {
     role_arn = var.role_arn
@@ -28,6 +29,11 @@ variable "secret_key" {
   type = string
 }
 
+variable "session_token" {
+  type    = string
+  default = ""
+}
+
 variable "region" {
   type = string
 }
@@ -1,6 +1,7 @@
 package azure
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -42,5 +43,7 @@ func (i InputGenerator) Credentials(state storage.State) map[string]string {
 		"tenant_id":       state.Azure.TenantID,
 		"client_id":       state.Azure.ClientID,
 		"client_secret":   state.Azure.ClientSecret,
+		"use_cli":         strconv.FormatBool(state.Azure.CredentialSource == "cli"),
+		"use_msi":         strconv.FormatBool(state.Azure.CredentialSource == "managed-identity"),
 	}
 }
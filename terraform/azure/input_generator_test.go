@@ -106,7 +106,17 @@ var _ = Describe("InputGenerator", func() {
 				"client_secret":   "client-secret",
 				"subscription_id": "subscription-id",
 				"tenant_id":       "tenant-id",
+				"use_cli":         "false",
+				"use_msi":         "false",
 			}))
 		})
+
+		It("tells terraform to use the Azure CLI or managed identity", func() {
+			state.Azure.CredentialSource = "cli"
+			Expect(inputGenerator.Credentials(state)).To(HaveKeyWithValue("use_cli", "true"))
+
+			state.Azure.CredentialSource = "managed-identity"
+			Expect(inputGenerator.Credentials(state)).To(HaveKeyWithValue("use_msi", "true"))
+		})
 	})
 })
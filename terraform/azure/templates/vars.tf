@@ -12,6 +12,14 @@ variable "client_id" {}
 
 variable "client_secret" {}
 
+variable "use_cli" {
+  default = false
+}
+
+variable "use_msi" {
+  default = false
+}
+
 variable "network_cidr" {
   default = "10.0.0.0/16"
 }
@@ -25,6 +33,8 @@ provider "azurerm" {
   tenant_id       = "${var.tenant_id}"
   client_id       = "${var.client_id}"
   client_secret   = "${var.client_secret}"
+  use_cli         = var.use_cli
+  use_msi         = var.use_msi
   features {}
 }
 
@@ -1,7 +1,6 @@
 package terraform
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -47,11 +46,13 @@ func (c CLI) RunWithEnv(stdout io.Writer, workingDirectory string, args []string
 
 	err = command.Run()
 	if err != nil {
-		_, isBuffer := c.errorBuffer.(*bytes.Buffer)
+		// A buffer, which may redact secrets, holds the output that was not
+		// printed.
+		_, isBuffer := c.errorBuffer.(fmt.Stringer)
 		if !isBuffer {
-			return fmt.Errorf("command execution failed got: %s", err)
+			return fmt.Errorf("command execution failed got: %w", err)
 		}
-		return fmt.Errorf("command execution failed got: %s stderr:\n %s", err, c.errorBuffer)
+		return fmt.Errorf("command execution failed got: %w stderr:\n %s", err, c.errorBuffer)
 	}
 
 	return nil
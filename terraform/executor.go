@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
@@ -24,6 +25,13 @@ type Executor struct {
 	fs           fs
 	debug        bool
 	out          io.Writer
+	redactor     redactor
+}
+
+// redactor learns the values of sensitive terraform outputs, such as
+// generated credentials, so that they are redacted from the output.
+type redactor interface {
+	Add(secrets ...string)
 }
 
 type tfOutput struct {
@@ -58,6 +66,11 @@ func NewExecutor(cli terraformCLI, bufferingCLI terraformCLI, stateStore stateSt
 	}
 }
 
+// RedactWith tells redactor about the sensitive outputs that are read.
+func (e *Executor) RedactWith(redactor redactor) {
+	e.redactor = redactor
+}
+
 func (e Executor) Setup(template string, input map[string]interface{}) error {
 	terraformDir, err := e.stateStore.GetTerraformDir()
 	if err != nil {
@@ -93,8 +106,16 @@ func (e Executor) Setup(template string, input map[string]interface{}) error {
 }
 
 func formatVars(inputs map[string]interface{}) string {
+	// Sorted, so that the same inputs always write the same file.
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	formattedVars := ""
-	for name, value := range inputs {
+	for _, name := range names {
+		value := inputs[name]
 		if vString, ok := value.(string); ok {
 			vString = fmt.Sprintf(`"%s"`, vString)
 			if strings.Contains(vString, "\n") { //nolint:staticcheck
@@ -114,6 +135,10 @@ func (e Executor) runTFCommand(args []string) error {
 }
 
 func (e Executor) runTFCommandWithEnvs(args, envs []string) error {
+	return e.runTFCommandWithOutput(e.out, args, envs)
+}
+
+func (e Executor) runTFCommandWithOutput(stdout io.Writer, args, envs []string) error {
 	varsDir, err := e.stateStore.GetVarsDir()
 	if err != nil {
 		return err
@@ -153,17 +178,27 @@ func (e Executor) runTFCommandWithEnvs(args, envs []string) error {
 		}
 	}
 
-	err = e.cli.RunWithEnv(e.out, terraformDir, args, envs)
+	err = e.cli.RunWithEnv(stdout, terraformDir, args, envs)
 	if err != nil {
 		if e.debug {
 			return err
 		}
-		return fmt.Errorf("%s", redactedError)
+		return redactedErr{err: err}
 	}
 
 	return nil
 }
 
+// redactedErr hides terraform's output, which can contain credentials, while
+// keeping the failure available to errors.As.
+type redactedErr struct {
+	err error
+}
+
+func (r redactedErr) Error() string { return redactedError }
+
+func (r redactedErr) Unwrap() error { return r.err }
+
 func (e Executor) Init() error {
 	terraformDir, err := e.stateStore.GetTerraformDir()
 	if err != nil {
@@ -256,6 +291,31 @@ func (e Executor) Destroy(credentials map[string]string) error {
 	return e.runTFCommandWithEnvs(args, []string{"TF_WARN_OUTPUT_ERRORS=1"})
 }
 
+// Plan runs terraform plan and summarises what applying the templates would
+// change, including changes made to the infrastructure outside of bbl.
+func (e Executor) Plan(credentials map[string]string) (PlanSummary, error) {
+	args := []string{"plan", "-json", "-detailed-exitcode", "-input=false", "-lock=false"}
+	for key, value := range credentials {
+		arg := fmt.Sprintf("%s=%s", key, value)
+		args = append(args, "-var", arg)
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	err := e.runTFCommandWithOutput(io.MultiWriter(output, e.out), args, []string{})
+	summary := readPlanSummary(output)
+	if err != nil {
+		// With -detailed-exitcode, terraform plan exits 2 when there are changes.
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+			summary.HasChanges = true
+			return summary, nil
+		}
+		return PlanSummary{}, err
+	}
+
+	return summary, nil
+}
+
 func (e Executor) Version() (string, error) {
 	buffer := bytes.NewBuffer([]byte{})
 	err := e.bufferingCLI.Run(buffer, "/tmp", []string{"version"})
@@ -347,6 +407,10 @@ func (e Executor) Outputs() (map[string]interface{}, error) {
 	outputs := map[string]interface{}{}
 	for tfKey, tfValue := range tfOutputs {
 		outputs[tfKey] = tfValue.Value
+
+		if secret, ok := tfValue.Value.(string); ok && tfValue.Sensitive && e.redactor != nil {
+			e.redactor.Add(secret)
+		}
 	}
 
 	return outputs, nil
@@ -483,6 +483,79 @@ var _ = Describe("Executor", func() {
 		})
 	})
 
+	Describe("Plan", func() {
+		var credentials map[string]string
+
+		BeforeEach(func() {
+			credentials = map[string]string{"some-cert": "some-cert-value"}
+			fileIO.ReadDirCall.Returns.FileInfos = []os.FileInfo{
+				fakes.FileInfo{
+					FileName: "bbl.tfvars",
+				},
+			}
+		})
+
+		It("runs terraform plan without changing the state", func() {
+			summary, err := executor.Plan(credentials)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(summary.HasChanges).To(BeFalse())
+
+			Expect(cli.RunCall.Receives.WorkingDirectory).To(Equal(terraformDir))
+			Expect(cli.RunCall.Receives.Args).To(ConsistOf([]string{
+				"plan",
+				"-json",
+				"-detailed-exitcode",
+				"-input=false",
+				"-lock=false",
+				"-var", "some-cert=some-cert-value",
+				"-state", relativeStatePath,
+				"-var-file", relativeVarsPath,
+			}))
+		})
+
+		Context("when terraform plan exits 2", func() {
+			BeforeEach(func() {
+				cli.RunCall.Stub = func(stdout io.Writer) {
+					fmt.Fprintln(stdout, `{"@level":"info","@message":"Terraform 1.5.7","type":"version"}`)
+					fmt.Fprintln(stdout, `{"@level":"info","type":"resource_drift","change":{"resource":{"addr":"aws_security_group.internal_security_group"},"action":"update"}}`)
+					fmt.Fprintln(stdout, `{"@level":"info","type":"planned_change","change":{"resource":{"addr":"aws_security_group.internal_security_group"},"action":"update"}}`)
+					fmt.Fprintln(stdout, `{"@level":"info","type":"planned_change","change":{"resource":{"addr":"aws_eip.jumpbox_eip"},"action":"create"}}`)
+					fmt.Fprintln(stdout, `Warning: some provider warning`)
+					fmt.Fprintln(stdout, `{"@level":"info","type":"change_summary","changes":{"add":1,"change":1,"import":0,"remove":0,"operation":"plan"}}`)
+				}
+				cli.RunCall.Returns.Errors = []error{fmt.Errorf("command execution failed got: %w", exitCodeError(2))}
+			})
+
+			It("summarises the changes", func() {
+				summary, err := debugFalse.Plan(credentials)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(summary).To(Equal(terraform.PlanSummary{
+					HasChanges: true,
+					Add:        1,
+					Change:     1,
+					Changes: []terraform.ResourceChange{
+						{Address: "aws_security_group.internal_security_group", Action: "update"},
+						{Address: "aws_eip.jumpbox_eip", Action: "create"},
+					},
+					Drifted: []terraform.ResourceChange{
+						{Address: "aws_security_group.internal_security_group", Action: "update"},
+					},
+				}))
+			})
+		})
+
+		Context("when terraform plan fails", func() {
+			BeforeEach(func() {
+				cli.RunCall.Returns.Errors = []error{fmt.Errorf("command execution failed got: %w", exitCodeError(1))}
+			})
+
+			It("returns a redacted error", func() {
+				_, err := debugFalse.Plan(credentials)
+				Expect(err).To(MatchError("Some output has been redacted, use `bbl latest-error` to see it or run again with --debug for additional debug output"))
+			})
+		})
+	})
+
 	Describe("Version", func() {
 		BeforeEach(func() {
 			bufferingCLI.RunCall.Stub = func(stdout io.Writer) {
@@ -630,6 +703,38 @@ var _ = Describe("Executor", func() {
 			}))
 		})
 
+		When("an output is sensitive", func() {
+			var redactor *fakes.Redactor
+
+			BeforeEach(func() {
+				redactor = &fakes.Redactor{}
+				executor.RedactWith(redactor)
+
+				bufferingCLI.RunCall.Stub = func(stdout io.Writer) {
+					fmt.Fprintf(stdout, //nolint:errcheck
+						`{
+						"external_ip": {
+							"sensitive": false,
+							"type": "string",
+							"value": "some-external-ip"
+						},
+						"client_secret": {
+							"sensitive": true,
+							"type": "string",
+							"value": "some-client-secret"
+						}
+					}`)
+				}
+			})
+
+			It("redacts its value", func() {
+				_, err := executor.Outputs()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(redactor.AddCall.Receives.Secrets).To(Equal([]string{"some-client-secret"}))
+			})
+		})
+
 		When("the .terraform diretory is present", func() {
 			It("does not run an unnecessary terraform init", func() {
 				_, err := executor.Outputs()
@@ -768,3 +873,9 @@ var _ = Describe("Executor", func() {
 		})
 	})
 })
+
+type exitCodeError int
+
+func (e exitCodeError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+
+func (e exitCodeError) ExitCode() int { return int(e) }
@@ -30,6 +30,7 @@ func (i InputGenerator) Generate(state storage.State) (map[string]interface{}, e
 
 func (i InputGenerator) Credentials(state storage.State) map[string]string {
 	return map[string]string{
-		"credentials": state.GCP.ServiceAccountKeyPath,
+		"credentials":  state.GCP.ServiceAccountKeyPath,
+		"access_token": state.GCP.AccessToken,
 	}
 }
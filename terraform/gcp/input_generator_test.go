@@ -79,7 +79,19 @@ var _ = Describe("InputGenerator", func() {
 			credentials := inputGenerator.Credentials(state)
 
 			Expect(credentials).To(Equal(map[string]string{
-				"credentials": "/some/service/account/key",
+				"credentials":  "/some/service/account/key",
+				"access_token": "",
+			}))
+		})
+
+		It("returns the access token of application default credentials", func() {
+			credentials := inputGenerator.Credentials(storage.State{
+				GCP: storage.GCP{AccessToken: "some-access-token", CredentialSource: "application-default"},
+			})
+
+			Expect(credentials).To(Equal(map[string]string{
+				"credentials":  "",
+				"access_token": "some-access-token",
 			}))
 		})
 	})
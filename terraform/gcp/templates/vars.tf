@@ -18,10 +18,16 @@ variable "credentials" {
   type = string
 }
 
+variable "access_token" {
+  type    = string
+  default = ""
+}
+
 provider "google" {
-  credentials = "${file("${var.credentials}")}"
-  project     = "${var.project_id}"
-  region      = "${var.region}"
+  credentials  = var.access_token == "" ? file(var.credentials) : null
+  access_token = var.access_token == "" ? null : var.access_token
+  project      = "${var.project_id}"
+  region       = "${var.region}"
 }
 
 terraform {
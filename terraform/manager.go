@@ -24,6 +24,7 @@ type executor interface {
 	Init() error
 	Apply(credentials map[string]string) error
 	Validate(credentials map[string]string) error
+	Plan(credentials map[string]string) (PlanSummary, error)
 	Destroy(credentials map[string]string) error
 	Outputs() (map[string]interface{}, error)
 	Output(string) (string, error)
@@ -81,6 +82,16 @@ func (m Manager) ValidateVersion() error {
 }
 
 func (m Manager) Setup(bblState storage.State) error {
+	if err := m.Generate(bblState); err != nil {
+		return err
+	}
+
+	return m.Init(bblState)
+}
+
+// Generate writes the terraform template and variables without running
+// terraform init.
+func (m Manager) Generate(bblState storage.State) error {
 	m.logger.Step("generating terraform template")
 	template := m.templateGenerator.Generate(bblState)
 
@@ -94,7 +105,7 @@ func (m Manager) Setup(bblState storage.State) error {
 		return fmt.Errorf("Executor setup: %s", err) //nolint:staticcheck
 	}
 
-	return m.Init(bblState)
+	return nil
 }
 
 func (m Manager) Init(bblState storage.State) error {
@@ -150,6 +161,20 @@ func (m Manager) Validate(bblState storage.State) (storage.State, error) {
 	return bblState, nil
 }
 
+// Plan reports what terraform would change if bbl applied its templates now.
+func (m Manager) Plan(bblState storage.State) (PlanSummary, error) {
+	if err := m.executor.Init(); err != nil {
+		return PlanSummary{}, fmt.Errorf("Executor init: %s", err) //nolint:staticcheck
+	}
+
+	summary, err := m.executor.Plan(m.inputGenerator.Credentials(bblState))
+	if err != nil {
+		return PlanSummary{}, fmt.Errorf("Executor plan: %s", err) //nolint:staticcheck
+	}
+
+	return summary, nil
+}
+
 func (m Manager) GetOutputs() (Outputs, error) {
 	tfOutputs, err := m.executor.Outputs()
 	if err != nil {
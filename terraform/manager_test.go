@@ -134,6 +134,23 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("Generate", func() {
+		BeforeEach(func() {
+			templateGenerator.GenerateCall.Returns.Template = "some-terraform-template"
+			inputGenerator.GenerateCall.Returns.Inputs = map[string]interface{}{"env_id": "some-env-id"}
+		})
+
+		It("writes the template and variables without running terraform init", func() {
+			err := manager.Generate(storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.SetupCall.Receives.Template).To(Equal("some-terraform-template"))
+			Expect(executor.SetupCall.Receives.Inputs).To(Equal(map[string]interface{}{"env_id": "some-env-id"}))
+			Expect(executor.InitCall.CallCount).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"generating terraform template", "generating terraform variables"}))
+		})
+	})
+
 	Describe("Apply", func() {
 		var (
 			incomingState storage.State
@@ -279,6 +296,51 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("Plan", func() {
+		var credentials map[string]string
+
+		BeforeEach(func() {
+			credentials = map[string]string{
+				"some-credential": "some-credential-value",
+			}
+			inputGenerator.CredentialsCall.Returns.Credentials = credentials
+			executor.PlanCall.Returns.Summary = terraform.PlanSummary{HasChanges: true, Change: 1}
+		})
+
+		It("initializes terraform and plans with the state's credentials", func() {
+			summary, err := manager.Plan(storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(summary).To(Equal(terraform.PlanSummary{HasChanges: true, Change: 1}))
+
+			Expect(executor.InitCall.CallCount).To(Equal(1))
+			Expect(executor.PlanCall.Receives.Credentials).To(Equal(credentials))
+			Expect(logger.StepCall.CallCount).To(Equal(0))
+		})
+
+		Context("when executor init fails", func() {
+			BeforeEach(func() {
+				executor.InitCall.Returns.Error = errors.New("lemon")
+			})
+
+			It("returns the error", func() {
+				_, err := manager.Plan(storage.State{})
+				Expect(err).To(MatchError("Executor init: lemon"))
+				Expect(executor.PlanCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when executor plan fails", func() {
+			BeforeEach(func() {
+				executor.PlanCall.Returns.Error = errors.New("lime")
+			})
+
+			It("returns the error", func() {
+				_, err := manager.Plan(storage.State{})
+				Expect(err).To(MatchError("Executor plan: lime"))
+			})
+		})
+	})
+
 	Describe("GetOutputs", func() {
 		BeforeEach(func() {
 			executor.OutputsCall.Returns.Outputs = map[string]interface{}{"external_ip": "some-external-ip"}
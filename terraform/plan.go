@@ -0,0 +1,74 @@
+package terraform
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// PlanSummary is what terraform plan would do to the infrastructure, read
+// from the messages of terraform plan -json.
+type PlanSummary struct {
+	// HasChanges is set when terraform plan -detailed-exitcode exits 2,
+	// which also covers changes to outputs alone.
+	HasChanges bool
+
+	Add     int
+	Change  int
+	Destroy int
+
+	// Changes are the resource changes terraform would make.
+	Changes []ResourceChange
+	// Drifted are the resources that changed outside of terraform since
+	// they were last applied.
+	Drifted []ResourceChange
+}
+
+type ResourceChange struct {
+	Address string `json:"address"`
+	Action  string `json:"action"`
+}
+
+type planMessage struct {
+	Type   string `json:"type"`
+	Change struct {
+		Resource struct {
+			Addr string `json:"addr"`
+		} `json:"resource"`
+		Action string `json:"action"`
+	} `json:"change"`
+	Changes struct {
+		Add    int `json:"add"`
+		Change int `json:"change"`
+		Remove int `json:"remove"`
+	} `json:"changes"`
+}
+
+// readPlanSummary reads terraform's machine readable UI, skipping lines that
+// are not JSON such as provider warnings.
+func readPlanSummary(output io.Reader) PlanSummary {
+	summary := PlanSummary{}
+
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var message planMessage
+		if json.Unmarshal(scanner.Bytes(), &message) != nil {
+			continue
+		}
+
+		change := ResourceChange{Address: message.Change.Resource.Addr, Action: message.Change.Action}
+		switch message.Type {
+		case "planned_change":
+			summary.Changes = append(summary.Changes, change)
+		case "resource_drift":
+			summary.Drifted = append(summary.Drifted, change)
+		case "change_summary":
+			summary.Add = message.Changes.Add
+			summary.Change = message.Changes.Change
+			summary.Destroy = message.Changes.Remove
+		}
+	}
+
+	return summary
+}